@@ -0,0 +1,27 @@
+package admin
+
+import (
+    "github.com/edulinq/autograder/api/core"
+    "github.com/edulinq/autograder/docker"
+)
+
+type FetchBuildLogsRequest struct {
+    core.APIRequestAssignmentContext
+    core.MinRoleAdmin
+    core.MinTokenScopeRead
+}
+
+type FetchBuildLogsResponse struct {
+    // Most recent first.
+    BuildLogs []*docker.BuildLog `json:"build-logs"`
+}
+
+func HandleFetchBuildLogs(request *FetchBuildLogsRequest) (*FetchBuildLogsResponse, *core.APIError) {
+    buildLogs, err := docker.GetBuildLogs(request.Assignment);
+    if (err != nil) {
+        return nil, core.NewInternalError("-207", &request.APIRequestCourseUserContext,
+                "Failed to get build logs.").Add("assignment", request.Assignment.GetID()).Err(err);
+    }
+
+    return &FetchBuildLogsResponse{buildLogs}, nil;
+}
@@ -0,0 +1,84 @@
+package admin
+
+import (
+    "fmt"
+    "reflect"
+    "testing"
+
+    "github.com/edulinq/autograder/api/core"
+    "github.com/edulinq/autograder/common"
+    "github.com/edulinq/autograder/db"
+    "github.com/edulinq/autograder/docker"
+    "github.com/edulinq/autograder/model"
+    "github.com/edulinq/autograder/util"
+)
+
+func TestFetchBuildLogs(test *testing.T) {
+    assignment := db.MustGetTestAssignment();
+
+    util.RemoveDirent(assignment.GetBuildLogPath());
+    defer util.RemoveDirent(assignment.GetBuildLogPath());
+
+    // No builds.
+    buildLogs := fetchBuildLogs(test, model.RoleAdmin);
+    if (len(buildLogs) != 0) {
+        test.Fatalf("Unexpected build logs before any builds: '%s'.", util.MustToJSONIndent(buildLogs));
+    }
+
+    expected := make([]*docker.BuildLog, 0);
+    for i := 0; i < (docker.MAX_BUILD_LOGS + 2); i++ {
+        buildLog := &docker.BuildLog{
+            ImageName: assignment.ImageName(),
+            BaseImage: "edulinq/grader.python",
+            StartTime: common.NowTimestamp(),
+            DurationMS: int64(i),
+            Success: ((i % 2) == 0),
+            Output: fmt.Sprintf("Build %d", i),
+        };
+
+        err := docker.RecordBuildLog(assignment, buildLog);
+        if (err != nil) {
+            test.Fatalf("Failed to record build log: '%v'.", err);
+        }
+
+        expected = append([]*docker.BuildLog{buildLog}, expected...);
+    }
+
+    expected = expected[:docker.MAX_BUILD_LOGS];
+
+    buildLogs = fetchBuildLogs(test, model.RoleAdmin);
+    if (!reflect.DeepEqual(expected, buildLogs)) {
+        test.Fatalf("Unexpected build logs. Expected: '%s', Actual: '%s'.",
+                util.MustToJSONIndent(expected), util.MustToJSONIndent(buildLogs));
+    }
+
+    // Non-admins cannot see build logs.
+    fields := map[string]any{
+        "assignment-id": "hw0",
+    };
+
+    response := core.SendTestAPIRequestFull(test, core.NewEndpoint(`admin/build-logs/fetch`), fields, nil, model.RoleGrader);
+    if (response.Success) {
+        test.Fatalf("Response is a success when it should not be: '%v'.", response);
+    }
+
+    if (response.Locator != "-020") {
+        test.Fatalf("Unexpected error locator. Expected: '-020', Actual: '%s'.", response.Locator);
+    }
+}
+
+func fetchBuildLogs(test *testing.T, role model.UserRole) []*docker.BuildLog {
+    fields := map[string]any{
+        "assignment-id": "hw0",
+    };
+
+    response := core.SendTestAPIRequestFull(test, core.NewEndpoint(`admin/build-logs/fetch`), fields, nil, role);
+    if (!response.Success) {
+        test.Fatalf("Response is not a success when it should be: '%v'.", response);
+    }
+
+    var responseContent FetchBuildLogsResponse;
+    util.MustJSONFromString(util.MustToJSON(response.Content), &responseContent);
+
+    return responseContent.BuildLogs;
+}
@@ -12,6 +12,7 @@ import (
 type FetchLogsRequest struct {
     core.APIRequestCourseUserContext
     core.MinRoleAdmin
+    core.MinTokenScopeRead
 
     common.RawLogQuery
 }
@@ -7,6 +7,7 @@ import (
 )
 
 var routes []*core.Route = []*core.Route{
+    core.NewAPIRoute(core.NewEndpoint(`admin/build-logs/fetch`), HandleFetchBuildLogs),
     core.NewAPIRoute(core.NewEndpoint(`admin/logs/fetch`), HandleFetchLogs),
     core.NewAPIRoute(core.NewEndpoint(`admin/update/course`), HandleUpdateCourse),
 };
@@ -1,6 +1,9 @@
 package core
 
 import (
+    "time"
+
+    "github.com/edulinq/autograder/common"
     "github.com/edulinq/autograder/config"
     "github.com/edulinq/autograder/db"
     "github.com/edulinq/autograder/log"
@@ -10,24 +13,243 @@ import (
 // Return a user only in the case that the authentication is successful.
 // If any error is retuturned, then the request should end and the response sent based on the error.
 // This assumes basic validation has already been done on the request.
+// If the request was authenticated with an API token, then this.Token will also be set.
+// If the request was authenticated with a session token, then this.Session will also be set.
+// If a second factor was used (directly, or by the session/token), then this.SecondFactor will be true.
+// Failed passwords, tokens, and second factors count towards locking out the account and client IP (see lockout.go).
 func (this *APIRequestCourseUserContext) Auth() (*model.User, *APIError) {
-    user, err := db.GetUser(this.Course, this.UserEmail);
+    this.Token = nil;
+    this.Session = nil;
+    this.SecondFactor = false;
+
+    lockedUntil, locked := checkLockout(this.UserEmail, this.ClientIP, time.Now());
+    if (locked) {
+        return nil, NewAuthBadRequestError("-057", this, "Locked Out").Add("locked-until", common.TimestampFromTime(lockedUntil));
+    }
+
+    serverUser, err := db.GetServerUser(this.UserEmail);
     if (err != nil) {
         return nil, NewAuthBadRequestError("-012", this, "Cannot Get User").Err(err);
     }
 
+    if (serverUser == nil) {
+        recordAuthFailure("", this.ClientIP, time.Now());
+        return nil, NewAuthBadRequestError("-013", this, "Unknown User");
+    }
+
+    user := serverUser.ToCourseUser(this.Course.GetID());
+
+    // Server admins act as owners in every course (even ones they are not enrolled in).
+    if (serverUser.IsAdmin()) {
+        if (user == nil) {
+            user = serverUser.ToUser();
+        }
+
+        user.Role = model.RoleOwner;
+        user.ServerAdmin = true;
+    }
+
     if (user == nil) {
+        recordAuthFailure("", this.ClientIP, time.Now());
         return nil, NewAuthBadRequestError("-013", this, "Unknown User");
     }
 
     if (config.NO_AUTH.Get()) {
         log.Debug("Authentication Disabled.", this.Course, log.NewUserAttr(this.UserEmail));
+        this.SecondFactor = true;
         return user, nil;
     }
 
-    if (!user.CheckPassword(this.UserPass)) {
-        return nil, NewAuthBadRequestError("-014", this, "Bad Password");
+    if (this.UserSession != "") {
+        // A session is checked instead of the password (if one is provided).
+        keys, err := db.GetSessionKeys();
+        if (err != nil) {
+            return nil, NewInternalError("-043", this, "Failed to get session keys.").Err(err);
+        }
+
+        claims, err := model.ParseSessionToken(this.UserSession, model.SESSION_TOKEN_TYPE_SESSION, keys);
+        if (err != nil) {
+            return nil, NewAuthBadRequestError("-044", this, "Bad Session").Err(err);
+        }
+
+        if (!claims.Matches(this.Course.GetID(), user)) {
+            return nil, NewAuthBadRequestError("-044", this, "Session Does Not Match User");
+        }
+
+        this.Session = claims;
+        this.SecondFactor = claims.SecondFactor;
+    } else if (this.UserToken != "") {
+        // A token is checked instead of the password (if one is provided).
+        this.Token = user.CheckToken(this.UserToken);
+        if (this.Token == nil) {
+            recordAuthFailure(this.UserEmail, this.ClientIP, time.Now());
+            return nil, NewAuthBadRequestError("-041", this, "Bad or Expired Token");
+        }
+
+        this.SecondFactor = this.Token.SecondFactor;
+    } else {
+        if (this.Course.DisablePasswordLogin) {
+            return nil, NewAuthBadRequestError("-045", this, "Password Login Disabled");
+        }
+
+        if (!user.CheckPassword(this.UserPass)) {
+            recordAuthFailure(this.UserEmail, this.ClientIP, time.Now());
+            return nil, NewAuthBadRequestError("-014", this, "Bad Password");
+        }
+
+        upgradePasswordHash(serverUser, user, this.UserPass);
+    }
+
+    if (this.UserTOTP != "") {
+        ok, err := checkSecondFactor(serverUser, this.UserTOTP, this.ClientIP);
+        if (err != nil) {
+            return nil, NewInternalError("-059", this, "Failed to save user after checking second factor.").Err(err);
+        }
+
+        if (!ok) {
+            return nil, NewAuthBadRequestError("-060", this, "Bad Second Factor");
+        }
+
+        this.SecondFactor = true;
+    }
+
+    if (this.Session == nil) {
+        recordAuthSuccess(this.UserEmail);
     }
 
     return user, nil;
 }
+
+// Authenticate a server-level request.
+// Works the same as APIRequestCourseUserContext.Auth(), except that sessions from any course are accepted.
+func (this *APIRequestServerUserContext) Auth() (*model.ServerUser, *APIError) {
+    this.Token = nil;
+    this.Session = nil;
+    this.SecondFactor = false;
+
+    lockedUntil, locked := checkLockout(this.UserEmail, this.ClientIP, time.Now());
+    if (locked) {
+        return nil, NewServerAuthBadRequestError("-058", this, "Locked Out").Add("locked-until", common.TimestampFromTime(lockedUntil));
+    }
+
+    serverUser, err := db.GetServerUser(this.UserEmail);
+    if (err != nil) {
+        return nil, NewServerAuthBadRequestError("-051", this, "Cannot Get User").Err(err);
+    }
+
+    if (serverUser == nil) {
+        recordAuthFailure("", this.ClientIP, time.Now());
+        return nil, NewServerAuthBadRequestError("-052", this, "Unknown User");
+    }
+
+    if (config.NO_AUTH.Get()) {
+        log.Debug("Authentication Disabled.", log.NewUserAttr(this.UserEmail));
+        this.SecondFactor = true;
+        return serverUser, nil;
+    }
+
+    user := serverUser.ToUser();
+
+    if (this.UserSession != "") {
+        keys, err := db.GetSessionKeys();
+        if (err != nil) {
+            return nil, NewServerInternalError("-053", this, "Failed to get session keys.").Err(err);
+        }
+
+        claims, err := model.ParseSessionToken(this.UserSession, model.SESSION_TOKEN_TYPE_SESSION, keys);
+        if (err != nil) {
+            return nil, NewServerAuthBadRequestError("-054", this, "Bad Session").Err(err);
+        }
+
+        if (!claims.MatchesUser(user)) {
+            return nil, NewServerAuthBadRequestError("-054", this, "Session Does Not Match User");
+        }
+
+        this.Session = claims;
+        this.SecondFactor = claims.SecondFactor;
+    } else if (this.UserToken != "") {
+        this.Token = user.CheckToken(this.UserToken);
+        if (this.Token == nil) {
+            recordAuthFailure(this.UserEmail, this.ClientIP, time.Now());
+            return nil, NewServerAuthBadRequestError("-055", this, "Bad or Expired Token");
+        }
+
+        this.SecondFactor = this.Token.SecondFactor;
+    } else {
+        if (!user.CheckPassword(this.UserPass)) {
+            recordAuthFailure(this.UserEmail, this.ClientIP, time.Now());
+            return nil, NewServerAuthBadRequestError("-056", this, "Bad Password");
+        }
+
+        upgradePasswordHash(serverUser, user, this.UserPass);
+    }
+
+    if (this.UserTOTP != "") {
+        ok, err := checkSecondFactor(serverUser, this.UserTOTP, this.ClientIP);
+        if (err != nil) {
+            return nil, NewServerInternalError("-061", this, "Failed to save user after checking second factor.").Err(err);
+        }
+
+        if (!ok) {
+            return nil, NewServerAuthBadRequestError("-062", this, "Bad Second Factor");
+        }
+
+        this.SecondFactor = true;
+    }
+
+    if (this.Session == nil) {
+        recordAuthSuccess(this.UserEmail);
+    }
+
+    return serverUser, nil;
+}
+
+// Check (and use up) a second factor.
+// Since a used code cannot be used again, the user is saved on success.
+// Bad codes count towards a lockout.
+func checkSecondFactor(serverUser *model.ServerUser, code string, clientIP string) (bool, error) {
+    now := time.Now();
+
+    if (!serverUser.CheckSecondFactor(code, now)) {
+        recordAuthFailure(serverUser.Email, clientIP, now);
+        return false, nil;
+    }
+
+    err := db.SaveServerUser(serverUser);
+    if (err != nil) {
+        return false, err;
+    }
+
+    return true, nil;
+}
+
+// Rehash a (correct) password that was hashed with old parameters (see model.User.PasswordNeedsRehash()).
+// |user| is a view of |serverUser| (e.g., in a course), and will be updated to match.
+// Failures are only logged, since the old hash still works.
+func upgradePasswordHash(serverUser *model.ServerUser, user *model.User, hashPass string) {
+    if (!user.PasswordNeedsRehash()) {
+        return;
+    }
+
+    oldParams := user.PassParams;
+
+    err := serverUser.RehashPassword(hashPass);
+    if (err != nil) {
+        log.Warn("Failed to rehash password.", err, serverUser);
+        return;
+    }
+
+    err = db.SaveServerUser(serverUser);
+    if (err != nil) {
+        log.Warn("Failed to save user after rehashing password.", err, serverUser);
+        return;
+    }
+
+    user.Pass = serverUser.Pass;
+    user.Salt = serverUser.Salt;
+    user.PassParams = serverUser.PassParams;
+    user.PassVersion = serverUser.PassVersion;
+
+    log.Info("Rehashed password with new parameters.", serverUser,
+            log.NewAttr("old-params", oldParams), log.NewAttr("new-params", serverUser.PassParams));
+}
@@ -4,6 +4,8 @@ import (
     "testing"
 
     "github.com/edulinq/autograder/config"
+    "github.com/edulinq/autograder/db"
+    "github.com/edulinq/autograder/model"
     "github.com/edulinq/autograder/util"
 )
 
@@ -70,3 +72,288 @@ func TestAuth(test *testing.T) {
         }
     }
 }
+
+func TestAuthToken(test *testing.T) {
+    defer db.ResetForTesting();
+    db.ResetForTesting();
+
+    type readAPIRequest struct {
+        APIRequestCourseUserContext
+        MinRoleOther
+        MinTokenScopeRead
+    }
+
+    type submitAPIRequest struct {
+        APIRequestCourseUserContext
+        MinRoleOther
+        MinTokenScopeSubmit
+    }
+
+    type adminAPIRequest struct {
+        APIRequestCourseUserContext
+        MinRoleOther
+    }
+
+    course := db.MustGetCourse("course101");
+    user, err := db.GetUser(course, "student@test.com");
+    if (err != nil) {
+        test.Fatalf("Failed to get user: '%v'.", err);
+    }
+
+    tokens := make(map[model.TokenScope]string);
+    for _, scope := range []model.TokenScope{model.TokenScopeRead, model.TokenScopeSubmit, model.TokenScopeAdmin} {
+        token, cleartext, err := model.NewAPIToken(string(scope), scope, "");
+        if (err != nil) {
+            test.Fatalf("Failed to create token: '%v'.", err);
+        }
+
+        user.AddToken(token);
+        tokens[scope] = cleartext;
+    }
+
+    err = db.SaveUser(course, user);
+    if (err != nil) {
+        test.Fatalf("Failed to save user: '%v'.", err);
+    }
+
+    testCases := []struct{scope model.TokenScope; token string; pass string; locator string}{
+        {model.TokenScopeRead, tokens[model.TokenScopeRead], "", ""},
+        {model.TokenScopeRead, tokens[model.TokenScopeSubmit], "", ""},
+        {model.TokenScopeRead, tokens[model.TokenScopeAdmin], "", ""},
+
+        {model.TokenScopeSubmit, tokens[model.TokenScopeRead], "", "-042"},
+        {model.TokenScopeSubmit, tokens[model.TokenScopeSubmit], "", ""},
+        {model.TokenScopeSubmit, tokens[model.TokenScopeAdmin], "", ""},
+
+        {model.TokenScopeAdmin, tokens[model.TokenScopeRead], "", "-042"},
+        {model.TokenScopeAdmin, tokens[model.TokenScopeSubmit], "", "-042"},
+        {model.TokenScopeAdmin, tokens[model.TokenScopeAdmin], "", ""},
+
+        // A token is checked instead of a password.
+        {model.TokenScopeRead, "ZZZ", "", "-041"},
+        {model.TokenScopeRead, "ZZZ", "student", "-041"},
+
+        // Passwords are not limited by scopes.
+        {model.TokenScopeAdmin, "", "student", ""},
+
+        {model.TokenScopeRead, "", "", "-017"},
+    };
+
+    for i, testCase := range testCases {
+        courseUserContext := APIRequestCourseUserContext{
+            CourseID: "course101",
+            UserEmail: "student@test.com",
+            UserToken: testCase.token,
+        };
+
+        if (testCase.pass != "") {
+            courseUserContext.UserPass = util.Sha256HexFromString(testCase.pass);
+        }
+
+        var request any;
+        switch testCase.scope {
+            case model.TokenScopeRead:
+                request = &readAPIRequest{APIRequestCourseUserContext: courseUserContext};
+            case model.TokenScopeSubmit:
+                request = &submitAPIRequest{APIRequestCourseUserContext: courseUserContext};
+            default:
+                request = &adminAPIRequest{APIRequestCourseUserContext: courseUserContext};
+        }
+
+        apiErr := ValidateAPIRequest(nil, request, "");
+
+        if ((apiErr == nil) && (testCase.locator != "")) {
+            test.Errorf("Case %d: Expecting error '%s', but got no error.", i, testCase.locator);
+        } else if ((apiErr != nil) && (testCase.locator == "")) {
+            test.Errorf("Case %d: Expecting no error, but got '%s': '%v'.", i, apiErr.Locator, apiErr);
+        } else if ((apiErr != nil) && (testCase.locator != "") && (apiErr.Locator != testCase.locator)) {
+            test.Errorf("Case %d: Got a different error than expected. Expected: '%s', actual: '%s' -- '%v'.",
+                    i, testCase.locator, apiErr.Locator, apiErr);
+        }
+    }
+}
+
+func TestAuthServerAdmin(test *testing.T) {
+    defer db.ResetForTesting();
+    db.ResetForTesting();
+
+    type ownerAPIRequest struct {
+        APIRequestCourseUserContext
+        MinRoleOwner
+    }
+
+    testCases := []struct{email string; pass string; serverAdmin bool; locator string}{
+        {db.TEST_SERVER_ADMIN_EMAIL, db.TEST_SERVER_ADMIN_PASS, true, ""},
+        {"owner@test.com", "owner", false, ""},
+        {"admin@test.com", "admin", false, "-020"},
+        {db.TEST_SERVER_ADMIN_EMAIL, "ZZZ", true, "-014"},
+    };
+
+    for i, testCase := range testCases {
+        request := ownerAPIRequest{
+            APIRequestCourseUserContext: APIRequestCourseUserContext{
+                CourseID: "course101",
+                UserEmail: testCase.email,
+                UserPass: util.Sha256HexFromString(testCase.pass),
+            },
+        };
+
+        apiErr := ValidateAPIRequest(nil, &request, "");
+        if (testCase.locator != "") {
+            if ((apiErr == nil) || (apiErr.Locator != testCase.locator)) {
+                test.Errorf("Case %d: Unexpected error. Expected: '%s', actual: '%v'.", i, testCase.locator, apiErr);
+            }
+
+            continue;
+        }
+
+        if (apiErr != nil) {
+            test.Errorf("Case %d: Expecting no error, but got '%s': '%v'.", i, apiErr.Locator, apiErr);
+            continue;
+        }
+
+        if ((request.User.Role != model.RoleOwner) || (request.User.ServerAdmin != testCase.serverAdmin)) {
+            test.Errorf("Case %d: Unexpected user. Role: '%s', Server Admin: %v.", i, request.User.Role, request.User.ServerAdmin);
+            continue;
+        }
+
+        // Saving a server admin (e.g., after creating a token) should not enroll them.
+        err := db.SaveUser(request.Course, request.User);
+        if (err != nil) {
+            test.Errorf("Case %d: Failed to save user: '%v'.", i, err);
+            continue;
+        }
+
+        user, err := db.GetUser(request.Course, testCase.email);
+        if (err != nil) {
+            test.Errorf("Case %d: Failed to get user: '%v'.", i, err);
+            continue;
+        }
+
+        if (testCase.serverAdmin != (user == nil)) {
+            test.Errorf("Case %d: Unexpected enrollment after save: '%v'.", i, user);
+            continue;
+        }
+    }
+}
+
+func TestAuthServerUserContext(test *testing.T) {
+    type serverAdminAPIRequest struct {
+        APIRequestServerUserContext
+        MinServerRoleAdmin
+    }
+
+    type serverUserAPIRequest struct {
+        APIRequestServerUserContext
+        MinServerRoleUser
+    }
+
+    type noRoleAPIRequest struct {
+        APIRequestServerUserContext
+    }
+
+    testCases := []struct{email string; pass string; adminRequest bool; locator string}{
+        {db.TEST_SERVER_ADMIN_EMAIL, db.TEST_SERVER_ADMIN_PASS, true, ""},
+        {db.TEST_SERVER_ADMIN_EMAIL, db.TEST_SERVER_ADMIN_PASS, false, ""},
+        {"owner@test.com", "owner", false, ""},
+        {"student@test.com", "student", false, ""},
+
+        {"owner@test.com", "owner", true, "-049"},
+        {"student@test.com", "student", true, "-049"},
+
+        {"", "student", false, "-046"},
+        {"Z", "student", false, "-052"},
+        {"student@test.com", "ZZZ", false, "-056"},
+    };
+
+    for i, testCase := range testCases {
+        context := APIRequestServerUserContext{
+            UserEmail: testCase.email,
+            UserPass: util.Sha256HexFromString(testCase.pass),
+        };
+
+        var request any = &serverUserAPIRequest{APIRequestServerUserContext: context};
+        if (testCase.adminRequest) {
+            request = &serverAdminAPIRequest{APIRequestServerUserContext: context};
+        }
+
+        apiErr := ValidateAPIRequest(nil, request, "");
+
+        if ((apiErr == nil) && (testCase.locator != "")) {
+            test.Errorf("Case %d: Expecting error '%s', but got no error.", i, testCase.locator);
+        } else if ((apiErr != nil) && (testCase.locator == "")) {
+            test.Errorf("Case %d: Expecting no error, but got '%s': '%v'.", i, apiErr.Locator, apiErr);
+        } else if ((apiErr != nil) && (testCase.locator != "") && (apiErr.Locator != testCase.locator)) {
+            test.Errorf("Case %d: Got a different error than expected. Expected: '%s', actual: '%s' -- '%v'.",
+                    i, testCase.locator, apiErr.Locator, apiErr);
+        }
+    }
+
+    request := noRoleAPIRequest{
+        APIRequestServerUserContext: APIRequestServerUserContext{
+            UserEmail: db.TEST_SERVER_ADMIN_EMAIL,
+            UserPass: util.Sha256HexFromString(db.TEST_SERVER_ADMIN_PASS),
+        },
+    };
+
+    apiErr := ValidateAPIRequest(nil, &request, "");
+    if ((apiErr == nil) || (apiErr.Locator != "-048")) {
+        test.Fatalf("Request without a server role did not fail correctly: '%v'.", apiErr);
+    }
+}
+
+func TestAuthPasswordRehash(test *testing.T) {
+    db.ResetForTesting();
+    defer db.ResetForTesting();
+
+    oldTime := config.PASSWORD_HASH_TIME.Get();
+    defer config.PASSWORD_HASH_TIME.Set(oldTime);
+
+    type baseAPIRequest struct {
+        APIRequestCourseUserContext
+        MinRoleOther
+    }
+
+    serverUser, err := db.GetServerUser("student@test.com");
+    if (err != nil) {
+        test.Fatalf("Failed to get user: '%v'.", err);
+    }
+
+    // Rehashing should not end existing sessions.
+    passCheck := model.GetSessionPassCheck(serverUser.ToUser());
+
+    config.PASSWORD_HASH_TIME.Set(oldTime + 1);
+
+    // Authenticate twice, once to rehash and once with the new hash.
+    for i := 0; i < 2; i++ {
+        request := baseAPIRequest{
+            APIRequestCourseUserContext: APIRequestCourseUserContext{
+                CourseID: "course101",
+                UserEmail: "student@test.com",
+                UserPass: util.Sha256HexFromString("student"),
+            },
+        };
+
+        apiErr := ValidateAPIRequest(nil, &request, "");
+        if (apiErr != nil) {
+            test.Fatalf("Attempt %d: Failed to auth: '%v'.", i, apiErr);
+        }
+
+        user, err := db.GetServerUser("student@test.com");
+        if (err != nil) {
+            test.Fatalf("Attempt %d: Failed to get user: '%v'.", i, err);
+        }
+
+        if (!user.PassParams.Equals(model.GetTargetPasswordHashParams())) {
+            test.Fatalf("Attempt %d: Password was not rehashed: '%+v'.", i, user.PassParams);
+        }
+
+        if (!request.User.PassParams.Equals(user.PassParams) || (request.User.Pass != user.Pass)) {
+            test.Fatalf("Attempt %d: Request user does not match the rehashed account.", i);
+        }
+
+        if (model.GetSessionPassCheck(user.ToUser()) != passCheck) {
+            test.Fatalf("Attempt %d: Rehash changed the session pass check.", i);
+        }
+    }
+}
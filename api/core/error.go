@@ -3,7 +3,9 @@ package core
 import (
     "errors"
     "fmt"
+    "math"
     "net/http"
+    "time"
 
     "github.com/edulinq/autograder/common"
     "github.com/edulinq/autograder/log"
@@ -31,6 +33,9 @@ const (
     // The users role is not high enough for the specific operation.
     // Can happen at the validation or handling phases.
     HTTP_PERMISSIONS_ERROR = http.StatusForbidden;
+    // The client has made too many requests (see ratelimit.go).
+    // These responses include a Retry-After header.
+    HTTP_STATUS_TOO_MANY_REQUESTS = http.StatusTooManyRequests;
 )
 
 // This is technically an error,
@@ -50,6 +55,9 @@ type APIError struct {
     AssignmentID string
     UserEmail string
 
+    // If positive, the client is told (via a Retry-After header) to wait this long before trying again.
+    RetryAfterSecs int
+
     AdditionalDetails map[string]any
 }
 
@@ -248,6 +256,112 @@ func NewInternalError(locator string, request *APIRequestCourseUserContext, inte
     return err;
 }
 
+func NewServerAuthBadRequestError(locator string, request *APIRequestServerUserContext, internalMessage string) *APIError {
+    err := &APIError{
+        RequestID: request.RequestID,
+        Locator: locator,
+        Endpoint: request.Endpoint,
+        Timestamp: request.Timestamp,
+        LogLevel: log.LevelInfo,
+        HTTPStatus: HTTP_STATUS_AUTH_ERROR,
+        InternalText: fmt.Sprintf("Authentication failure: '%s'.", internalMessage),
+        ResponseText: "Authentication failure, check email and password.",
+        UserEmail: request.UserEmail,
+    };
+
+    return err;
+}
+
+// The user authenticated, but the request also needs a second factor.
+// Unlike other auth errors, the response says what is needed (so clients know to ask for a code).
+func NewSecondFactorError(locator string, request *APIRequest, email string, responseMessage string) *APIError {
+    err := &APIError{
+        RequestID: request.RequestID,
+        Locator: locator,
+        Endpoint: request.Endpoint,
+        Timestamp: request.Timestamp,
+        LogLevel: log.LevelInfo,
+        HTTPStatus: HTTP_PERMISSIONS_ERROR,
+        InternalText: "Second factor required.",
+        ResponseText: responseMessage,
+        UserEmail: email,
+    };
+
+    return err;
+}
+
+// The client has hit a rate limit (before the request was parsed, see ratelimit.go).
+func NewBareRateLimitError(locator string, endpoint string, retryAfter time.Duration) *APIError {
+    retryAfterSecs := getRetryAfterSecs(retryAfter);
+
+    return &APIError{
+        RequestID: locator,
+        Locator: locator,
+        Endpoint: endpoint,
+        Timestamp: common.NowTimestamp(),
+        LogLevel: log.LevelInfo,
+        HTTPStatus: HTTP_STATUS_TOO_MANY_REQUESTS,
+        InternalText: "Rate limited.",
+        ResponseText: fmt.Sprintf("Too many requests, try again in %d seconds.", retryAfterSecs),
+        RetryAfterSecs: retryAfterSecs,
+    };
+}
+
+// The user has hit a rate limit (see ratelimit.go).
+func NewRateLimitError(locator string, request *APIRequest, email string, retryAfter time.Duration) *APIError {
+    retryAfterSecs := getRetryAfterSecs(retryAfter);
+
+    err := &APIError{
+        RequestID: request.RequestID,
+        Locator: locator,
+        Endpoint: request.Endpoint,
+        Timestamp: request.Timestamp,
+        LogLevel: log.LevelInfo,
+        HTTPStatus: HTTP_STATUS_TOO_MANY_REQUESTS,
+        InternalText: "Rate limited.",
+        ResponseText: fmt.Sprintf("Too many requests, try again in %d seconds.", retryAfterSecs),
+        UserEmail: email,
+        RetryAfterSecs: retryAfterSecs,
+    };
+
+    return err;
+}
+
+func NewServerBadPermissionsError(locator string, request *APIRequestServerUserContext, minRole model.ServerRole, internalMessage string) *APIError {
+    err := &APIError{
+        RequestID: request.RequestID,
+        Locator: locator,
+        Endpoint: request.Endpoint,
+        Timestamp: request.Timestamp,
+        LogLevel: log.LevelInfo,
+        HTTPStatus: HTTP_PERMISSIONS_ERROR,
+        InternalText: fmt.Sprintf("Insufficient Permissions: '%s'.", internalMessage),
+        ResponseText: "You have insufficient permissions for the requested operation.",
+        UserEmail: request.UserEmail,
+    };
+
+    err.Add("actual-server-role", request.ServerUser.Role);
+    err.Add("min-required-server-role", minRole);
+
+    return err;
+}
+
+func NewServerInternalError(locator string, request *APIRequestServerUserContext, internalMessage string) *APIError {
+    err := &APIError{
+        RequestID: request.RequestID,
+        Locator: locator,
+        Endpoint: request.Endpoint,
+        Timestamp: request.Timestamp,
+        LogLevel: log.LevelError,
+        HTTPStatus: HTTP_STATUS_SERVER_ERROR,
+        InternalText: internalMessage,
+        ResponseText: fmt.Sprintf("The server failed to process your request. Please contact an adimistrator with this ID '%s'.", request.RequestID),
+        UserEmail: request.UserEmail,
+    };
+
+    return err;
+}
+
 // Very rare errors can occur so early that there is not even a request id.
 func NewBareInternalError(locator string, endpoint string, internalMessage string) *APIError {
     err := &APIError{
@@ -263,3 +377,8 @@ func NewBareInternalError(locator string, endpoint string, internalMessage strin
 
     return err;
 }
+
+// Round up to whole seconds (the unit of the Retry-After header), and always wait at least a second.
+func getRetryAfterSecs(retryAfter time.Duration) int {
+    return max(1, int(math.Ceil(retryAfter.Seconds())));
+}
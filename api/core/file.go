@@ -0,0 +1,106 @@
+package core
+
+// Support for API endpoints that respond with a raw file instead of a JSON API response
+// (e.g. so that a browser can directly display an HTML report).
+// Any error is still sent as a normal (JSON) API response.
+
+import (
+    "fmt"
+    "mime"
+    "net/http"
+    "path/filepath"
+    "reflect"
+    "strconv"
+
+    "github.com/edulinq/autograder/log"
+)
+
+const DEFAULT_FILE_CONTENT_TYPE = "application/octet-stream";
+
+// A file to send back to the client.
+type APIFile struct {
+    // The base name of the file (used in the Content-Disposition header).
+    Name string
+    // If empty, the content type will be guessed (see GuessContentType()).
+    ContentType string
+    Data []byte
+}
+
+// Guess the content type of a file from its name, and then its contents.
+func GuessContentType(name string, data []byte) string {
+    contentType := mime.TypeByExtension(filepath.Ext(name));
+    if (contentType != "") {
+        return contentType;
+    }
+
+    if (len(data) > 0) {
+        return http.DetectContentType(data);
+    }
+
+    return DEFAULT_FILE_CONTENT_TYPE;
+}
+
+func handleAPIFileEndpoint(response http.ResponseWriter, request *http.Request, apiHandler any) error {
+    validAPIHandler, apiErr := validateAPIFileHandler(request.URL.Path, apiHandler);
+    if (apiErr != nil) {
+        return sendAPIResponse(nil, response, nil, apiErr, false);
+    }
+
+    apiRequest, apiErr := createAPIRequest(request, validAPIHandler);
+    if (apiErr != nil) {
+        return sendAPIResponse(nil, response, nil, apiErr, false);
+    }
+    defer CleanupAPIrequest(apiRequest);
+
+    content, apiErr := callHandler(validAPIHandler, apiRequest);
+    if (apiErr != nil) {
+        return sendAPIResponse(apiRequest, response, nil, apiErr, false);
+    }
+
+    file := content.(*APIFile);
+    if (file == nil) {
+        apiErr = NewBareInternalError("-039", request.URL.Path, "API file handler returned neither a file nor an error.");
+        return sendAPIResponse(apiRequest, response, nil, apiErr, false);
+    }
+
+    contentType := file.ContentType;
+    if (contentType == "") {
+        contentType = GuessContentType(file.Name, file.Data);
+    }
+
+    header := response.Header();
+    header.Set("Content-Type", contentType);
+    header.Set("Content-Length", strconv.Itoa(len(file.Data)));
+    header.Set("Content-Disposition", mime.FormatMediaType("inline", map[string]string{"filename": filepath.Base(file.Name)}));
+    header.Set("X-Content-Type-Options", "nosniff");
+    // Files may come from untrusted sources (e.g. graders or students),
+    // so any scripts in them should not run with access to the autograder's origin.
+    header.Set("Content-Security-Policy", "sandbox");
+
+    response.WriteHeader(HTTP_STATUS_GOOD);
+
+    _, err := response.Write(file.Data);
+    if (err != nil) {
+        log.Error("Failed to write API file.", err, log.NewAttr("endpoint", request.URL.Path), log.NewAttr("file", file.Name));
+        return fmt.Errorf("Could not write API file '%s': '%w'.", file.Name, err);
+    }
+
+    return nil;
+}
+
+// File handlers look like normal API handlers, but return an *APIFile.
+func validateAPIFileHandler(endpoint string, apiHandler any) (ValidAPIHandler, *APIError) {
+    validAPIHandler, apiErr := validateAPIHandler(endpoint, apiHandler);
+    if (apiErr != nil) {
+        return nil, apiErr;
+    }
+
+    reflectType := reflect.TypeOf(apiHandler);
+    if (reflectType.Out(0) != reflect.TypeOf((*APIFile)(nil))) {
+        return nil, NewBareInternalError("-040", endpoint, "API file handler's first return value is not a *APIFile.").
+                Add("type", reflectType.Out(0).String()).
+                Add("function-info", getFuncInfo(apiHandler));
+    }
+
+    return validAPIHandler, nil;
+}
@@ -0,0 +1,236 @@
+package core
+
+// Failed authentication attempts are tracked per account (email) and per client IP.
+// Once an account or IP has too many recent failures,
+// it is locked out for a time that doubles with each further failure (up to a maximum).
+// Lockouts are only kept in memory, so they are cleared when the server restarts.
+
+import (
+    "net"
+    "net/http"
+    "slices"
+    "strings"
+    "sync"
+    "time"
+
+    "github.com/edulinq/autograder/common"
+    "github.com/edulinq/autograder/config"
+    "github.com/edulinq/autograder/log"
+)
+
+type LockoutKind string;
+
+const (
+    LockoutKindUser LockoutKind = "user"
+    LockoutKindIP LockoutKind = "ip"
+)
+
+// Keep the backoff from overflowing.
+const MAX_LOCKOUT_DOUBLINGS = 30;
+
+type LockoutInfo struct {
+    Kind LockoutKind `json:"kind"`
+    // The email or IP.
+    Key string `json:"key"`
+    FailedAttempts int `json:"failed-attempts"`
+    LastFailureTime common.Timestamp `json:"last-failure-time"`
+    // Empty if not currently locked out.
+    LockedUntil common.Timestamp `json:"locked-until"`
+}
+
+type lockoutEntry struct {
+    failedAttempts int
+    lastFailure time.Time
+    lockedUntil time.Time
+}
+
+var lockoutLock sync.Mutex;
+var lockouts map[LockoutKind]map[string]*lockoutEntry = map[LockoutKind]map[string]*lockoutEntry{
+    LockoutKindUser: make(map[string]*lockoutEntry),
+    LockoutKindIP: make(map[string]*lockoutEntry),
+};
+
+// Check if an account or IP is locked out.
+// Returns the time the (longest) lockout ends, and if there is a lockout.
+// Empty keys are ignored.
+func checkLockout(email string, address string, now time.Time) (time.Time, bool) {
+    lockoutLock.Lock();
+    defer lockoutLock.Unlock();
+
+    var lockedUntil time.Time;
+
+    for _, entry := range []*lockoutEntry{getLockoutEntryLock(LockoutKindUser, email, now), getLockoutEntryLock(LockoutKindIP, address, now)} {
+        if ((entry != nil) && entry.lockedUntil.After(lockedUntil)) {
+            lockedUntil = entry.lockedUntil;
+        }
+    }
+
+    return lockedUntil, now.Before(lockedUntil);
+}
+
+// Record a failed authentication attempt.
+// An empty email (e.g., for an unknown user) only counts against the IP.
+func recordAuthFailure(email string, address string, now time.Time) {
+    lockoutLock.Lock();
+    defer lockoutLock.Unlock();
+
+    recordAuthFailureLock(LockoutKindUser, email, config.LOCKOUT_USER_ATTEMPTS.Get(), now);
+    recordAuthFailureLock(LockoutKindIP, address, config.LOCKOUT_IP_ATTEMPTS.Get(), now);
+}
+
+// A successful authentication clears the account's failures (but not the IP's).
+func recordAuthSuccess(email string) {
+    lockoutLock.Lock();
+    defer lockoutLock.Unlock();
+
+    delete(lockouts[LockoutKindUser], email);
+}
+
+// Get all the accounts and IPs with recent failures, sorted by kind and key.
+func GetLockouts() []*LockoutInfo {
+    lockoutLock.Lock();
+    defer lockoutLock.Unlock();
+
+    now := time.Now();
+    infos := make([]*LockoutInfo, 0);
+
+    for kind, entries := range lockouts {
+        for key, _ := range entries {
+            entry := getLockoutEntryLock(kind, key, now);
+            if (entry == nil) {
+                continue;
+            }
+
+            info := &LockoutInfo{
+                Kind: kind,
+                Key: key,
+                FailedAttempts: entry.failedAttempts,
+                LastFailureTime: common.TimestampFromTime(entry.lastFailure),
+            };
+
+            if (now.Before(entry.lockedUntil)) {
+                info.LockedUntil = common.TimestampFromTime(entry.lockedUntil);
+            }
+
+            infos = append(infos, info);
+        }
+    }
+
+    slices.SortFunc(infos, func(a *LockoutInfo, b *LockoutInfo) int {
+        if (a.Kind != b.Kind) {
+            return strings.Compare(string(a.Kind), string(b.Kind));
+        }
+
+        return strings.Compare(a.Key, b.Key);
+    });
+
+    return infos;
+}
+
+// Clear the failures (and any lockout) for an account or IP.
+// Returns true if there was anything to clear.
+func ClearLockout(kind LockoutKind, key string) bool {
+    lockoutLock.Lock();
+    defer lockoutLock.Unlock();
+
+    entries, ok := lockouts[kind];
+    if (!ok) {
+        return false;
+    }
+
+    _, ok = entries[key];
+    delete(entries, key);
+
+    return ok;
+}
+
+func ClearAllLockouts() {
+    lockoutLock.Lock();
+    defer lockoutLock.Unlock();
+
+    for _, entries := range lockouts {
+        clear(entries);
+    }
+}
+
+// Get an entry, forgetting it if its last failure is old enough.
+// Returns nil if there is no (current) entry.
+func getLockoutEntryLock(kind LockoutKind, key string, now time.Time) *lockoutEntry {
+    if (key == "") {
+        return nil;
+    }
+
+    entry := lockouts[kind][key];
+    if (entry == nil) {
+        return nil;
+    }
+
+    resetDuration := time.Duration(config.LOCKOUT_RESET_MINS.Get()) * time.Minute;
+    if (now.Before(entry.lockedUntil) || (now.Sub(entry.lastFailure) < resetDuration)) {
+        return entry;
+    }
+
+    delete(lockouts[kind], key);
+    return nil;
+}
+
+func recordAuthFailureLock(kind LockoutKind, key string, maxAttempts int, now time.Time) {
+    if (key == "") {
+        return;
+    }
+
+    entry := getLockoutEntryLock(kind, key, now);
+    if (entry == nil) {
+        entry = &lockoutEntry{};
+        lockouts[kind][key] = entry;
+    }
+
+    entry.failedAttempts++;
+    entry.lastFailure = now;
+
+    duration := getLockoutDuration(entry.failedAttempts, maxAttempts);
+    if (duration == 0) {
+        return;
+    }
+
+    entry.lockedUntil = now.Add(duration);
+
+    log.Warn("Authentication locked out.",
+            log.NewAttr("lockout-kind", kind), log.NewAttr("lockout-key", key),
+            log.NewAttr("failed-attempts", entry.failedAttempts), log.NewAttr("locked-until", common.TimestampFromTime(entry.lockedUntil)));
+}
+
+// Get how long to lock out for after some number of failures.
+// The first lockout (once |maxAttempts| is reached) uses the base duration, and each further failure doubles it.
+// A non-positive |maxAttempts| disables lockouts.
+func getLockoutDuration(failedAttempts int, maxAttempts int) time.Duration {
+    if ((maxAttempts <= 0) || (failedAttempts < maxAttempts)) {
+        return 0;
+    }
+
+    doublings := min(failedAttempts - maxAttempts, MAX_LOCKOUT_DOUBLINGS);
+
+    duration := time.Duration(config.LOCKOUT_BASE_SECS.Get()) * time.Second * (1 << doublings);
+    maxDuration := time.Duration(config.LOCKOUT_MAX_MINS.Get()) * time.Minute;
+
+    if ((duration <= 0) || (duration > maxDuration)) {
+        duration = maxDuration;
+    }
+
+    return duration;
+}
+
+// Get the IP of the client making a request.
+// Returns an empty string if the request is not available.
+func getClientIP(request *http.Request) string {
+    if (request == nil) {
+        return "";
+    }
+
+    host, _, err := net.SplitHostPort(request.RemoteAddr);
+    if (err != nil) {
+        return request.RemoteAddr;
+    }
+
+    return host;
+}
@@ -0,0 +1,122 @@
+package core
+
+import (
+    "testing"
+    "time"
+
+    "github.com/edulinq/autograder/config"
+    "github.com/edulinq/autograder/util"
+)
+
+func TestGetLockoutDuration(test *testing.T) {
+    base := time.Duration(config.LOCKOUT_BASE_SECS.Get()) * time.Second;
+    max := time.Duration(config.LOCKOUT_MAX_MINS.Get()) * time.Minute;
+
+    testCases := []struct{failedAttempts int; maxAttempts int; expected time.Duration}{
+        {0, 5, 0},
+        {4, 5, 0},
+        {5, 5, base},
+        {6, 5, 2 * base},
+        {7, 5, 4 * base},
+        {1000, 5, max},
+
+        {1000, 0, 0},
+        {1000, -1, 0},
+    };
+
+    for i, testCase := range testCases {
+        actual := getLockoutDuration(testCase.failedAttempts, testCase.maxAttempts);
+        if (testCase.expected != actual) {
+            test.Errorf("Case %d: Unexpected lockout duration. Expected: '%v', Actual: '%v'.", i, testCase.expected, actual);
+        }
+    }
+}
+
+func TestLockoutUser(test *testing.T) {
+    ClearAllLockouts();
+    defer ClearAllLockouts();
+
+    type baseAPIRequest struct {
+        APIRequestCourseUserContext
+        MinRoleOther
+    }
+
+    send := func(pass string) *APIError {
+        request := baseAPIRequest{
+            APIRequestCourseUserContext: APIRequestCourseUserContext{
+                CourseID: "course101",
+                UserEmail: "student@test.com",
+                UserPass: util.Sha256HexFromString(pass),
+            },
+        };
+
+        return ValidateAPIRequest(nil, &request, "");
+    };
+
+    for i := 0; i < config.LOCKOUT_USER_ATTEMPTS.Get(); i++ {
+        apiErr := send("ZZZ");
+        if ((apiErr == nil) || (apiErr.Locator != "-014")) {
+            test.Fatalf("Attempt %d: Unexpected error. Expected: '-014', Actual: '%v'.", i, apiErr);
+        }
+    }
+
+    // Even the correct password is rejected while locked out.
+    apiErr := send("student");
+    if ((apiErr == nil) || (apiErr.Locator != "-057")) {
+        test.Fatalf("Unexpected error when locked out. Expected: '-057', Actual: '%v'.", apiErr);
+    }
+
+    lockouts := GetLockouts();
+    if ((len(lockouts) != 1) || (lockouts[0].Key != "student@test.com") || (lockouts[0].LockedUntil == "")) {
+        test.Fatalf("Unexpected lockouts: '%s'.", util.MustToJSONIndent(lockouts));
+    }
+
+    if (!ClearLockout(LockoutKindUser, "student@test.com")) {
+        test.Fatalf("Lockout was not found when clearing.");
+    }
+
+    apiErr = send("student");
+    if (apiErr != nil) {
+        test.Fatalf("Failed to auth after clearing lockout: '%v'.", apiErr);
+    }
+}
+
+func TestLockoutIP(test *testing.T) {
+    ClearAllLockouts();
+    defer ClearAllLockouts();
+
+    now := time.Now();
+    address := "1.2.3.4";
+
+    // Failures across different accounts all count against the IP.
+    for i := 0; i < config.LOCKOUT_IP_ATTEMPTS.Get(); i++ {
+        _, locked := checkLockout("", address, now);
+        if (locked) {
+            test.Fatalf("Attempt %d: IP locked out too early.", i);
+        }
+
+        recordAuthFailure("", address, now);
+    }
+
+    _, locked := checkLockout("student@test.com", address, now);
+    if (!locked) {
+        test.Fatalf("IP was not locked out.");
+    }
+
+    // Another IP is not affected.
+    _, locked = checkLockout("student@test.com", "5.6.7.8", now);
+    if (locked) {
+        test.Fatalf("Other IP was locked out.");
+    }
+
+    // Once the lockout (and reset period) has passed, the IP is forgotten.
+    later := now.Add(time.Duration(config.LOCKOUT_MAX_MINS.Get() + config.LOCKOUT_RESET_MINS.Get()) * time.Minute);
+    _, locked = checkLockout("", address, later);
+    if (locked) {
+        test.Fatalf("IP is still locked out after the lockout has passed.");
+    }
+
+    if (len(GetLockouts()) != 0) {
+        test.Fatalf("IP was not forgotten: '%s'.", util.MustToJSONIndent(GetLockouts()));
+    }
+}
@@ -0,0 +1,260 @@
+package core
+
+// Requests are rate limited with token buckets, kept per endpoint group (the first part of the endpoint, e.g., 'submission').
+// Each user (after authentication) and each client IP (before the request is parsed) gets their own buckets.
+// A bucket holds up to a burst of tokens, refills at a steady rate, and each request takes one token.
+// Rates and bursts can be configured per group (and for users, per role), see getRateLimitConfig().
+// Buckets are only kept in memory, so they are cleared when the server restarts.
+
+import (
+    "fmt"
+    "math"
+    "slices"
+    "strings"
+    "sync"
+    "time"
+
+    "github.com/edulinq/autograder/common"
+    "github.com/edulinq/autograder/config"
+)
+
+type RateLimitKind string;
+
+const (
+    RateLimitKindUser RateLimitKind = "user"
+    RateLimitKindIP RateLimitKind = "ip"
+)
+
+// Sweep out full (idle) buckets after this many requests.
+const RATE_LIMIT_SWEEP_INTERVAL = 1024;
+
+type RateLimitInfo struct {
+    Kind RateLimitKind `json:"kind"`
+    // The email or IP.
+    Key string `json:"key"`
+    Group string `json:"group"`
+    Rate float64 `json:"rate"`
+    Burst int `json:"burst"`
+    // The tokens currently available (requests that can be made right now).
+    Tokens float64 `json:"tokens"`
+    // The number of requests that have been limited since this bucket was created.
+    LimitedCount int `json:"limited-count"`
+    LastRequestTime common.Timestamp `json:"last-request-time"`
+}
+
+type rateLimitBucketKey struct {
+    kind RateLimitKind
+    key string
+    group string
+}
+
+type rateLimitBucket struct {
+    rate float64
+    burst int
+    tokens float64
+    lastUpdate time.Time
+    lastRequest time.Time
+    limitedCount int
+}
+
+var rateLimitLock sync.Mutex;
+var rateLimitBuckets map[rateLimitBucketKey]*rateLimitBucket = make(map[rateLimitBucketKey]*rateLimitBucket);
+var rateLimitRequestCount int = 0;
+
+// Check the IP rate limit for an endpoint.
+// Returns nil if the request can proceed.
+func checkIPRateLimit(address string, endpoint string) *APIError {
+    group := getEndpointGroup(endpoint);
+
+    rate, burst := getRateLimitConfig(RateLimitKindIP, group, "");
+    retryAfter, ok := takeRateLimitToken(RateLimitKindIP, address, group, rate, burst, time.Now());
+    if (ok) {
+        return nil;
+    }
+
+    return NewBareRateLimitError("-066", endpoint, retryAfter).Add("client-ip", address);
+}
+
+// Check the user rate limit for an endpoint.
+// The role is the user's course role for course requests, and their server role for server requests.
+// Returns the amount of time until the next request is allowed, and if this request can proceed.
+func checkUserRateLimit(email string, role string, endpoint string) (time.Duration, bool) {
+    group := getEndpointGroup(endpoint);
+
+    rate, burst := getRateLimitConfig(RateLimitKindUser, group, role);
+    return takeRateLimitToken(RateLimitKindUser, email, group, rate, burst, time.Now());
+}
+
+// Get the rate and burst for a kind of limit, endpoint group, and role (ignored when empty).
+// The most specific of these keys that is set is used:
+//  - web.ratelimit.<kind>.<group>.<role>.rate
+//  - web.ratelimit.<kind>.<group>.rate
+//  - web.ratelimit.<kind>.rate
+// (And the same for burst.)
+func getRateLimitConfig(kind RateLimitKind, group string, role string) (float64, int) {
+    var rate float64;
+    var burst int;
+
+    switch (kind) {
+        case RateLimitKindUser:
+            rate = config.RATE_LIMIT_USER_RATE.Get();
+            burst = config.RATE_LIMIT_USER_BURST.Get();
+        case RateLimitKindIP:
+            rate = config.RATE_LIMIT_IP_RATE.Get();
+            burst = config.RATE_LIMIT_IP_BURST.Get();
+        default:
+            return 0, 0;
+    }
+
+    prefixes := []string{fmt.Sprintf("web.ratelimit.%s.%s", kind, group)};
+    if (role != "") {
+        prefixes = append(prefixes, fmt.Sprintf("web.ratelimit.%s.%s.%s", kind, group, role));
+    }
+
+    for _, prefix := range prefixes {
+        rate = config.GetFloatDefault(prefix + ".rate", rate);
+        burst = config.GetIntDefault(prefix + ".burst", burst);
+    }
+
+    return rate, burst;
+}
+
+// Take a token from a bucket.
+// Returns the amount of time until a token is available, and if a token was taken.
+// A non-positive rate (or disabled rate limits) always allows the request.
+func takeRateLimitToken(kind RateLimitKind, key string, group string, rate float64, burst int, now time.Time) (time.Duration, bool) {
+    if (config.RATE_LIMIT_DISABLE.Get() || (rate <= 0) || (key == "")) {
+        return 0, true;
+    }
+
+    burst = max(1, burst);
+
+    rateLimitLock.Lock();
+    defer rateLimitLock.Unlock();
+
+    rateLimitRequestCount++;
+    if (rateLimitRequestCount >= RATE_LIMIT_SWEEP_INTERVAL) {
+        rateLimitRequestCount = 0;
+        sweepRateLimitBucketsLock(now);
+    }
+
+    bucketKey := rateLimitBucketKey{kind, key, group};
+
+    bucket := rateLimitBuckets[bucketKey];
+    if (bucket == nil) {
+        bucket = &rateLimitBucket{
+            tokens: float64(burst),
+            lastUpdate: now,
+        };
+        rateLimitBuckets[bucketKey] = bucket;
+    }
+
+    // The config may have changed since the last request.
+    bucket.rate = rate;
+    bucket.burst = burst;
+    bucket.refill(now);
+
+    bucket.lastRequest = now;
+
+    if (bucket.tokens >= 1.0) {
+        bucket.tokens -= 1.0;
+        return 0, true;
+    }
+
+    bucket.limitedCount++;
+
+    retryAfter := time.Duration(((1.0 - bucket.tokens) / bucket.rate) * float64(time.Second));
+    return retryAfter, false;
+}
+
+// Get all the current buckets, sorted by kind, key, and group.
+func GetRateLimits() []*RateLimitInfo {
+    rateLimitLock.Lock();
+    defer rateLimitLock.Unlock();
+
+    now := time.Now();
+    sweepRateLimitBucketsLock(now);
+
+    infos := make([]*RateLimitInfo, 0, len(rateLimitBuckets));
+    for bucketKey, bucket := range rateLimitBuckets {
+        infos = append(infos, &RateLimitInfo{
+            Kind: bucketKey.kind,
+            Key: bucketKey.key,
+            Group: bucketKey.group,
+            Rate: bucket.rate,
+            Burst: bucket.burst,
+            Tokens: bucket.tokens,
+            LimitedCount: bucket.limitedCount,
+            LastRequestTime: common.TimestampFromTime(bucket.lastRequest),
+        });
+    }
+
+    slices.SortFunc(infos, func(a *RateLimitInfo, b *RateLimitInfo) int {
+        if (a.Kind != b.Kind) {
+            return strings.Compare(string(a.Kind), string(b.Kind));
+        }
+
+        if (a.Key != b.Key) {
+            return strings.Compare(a.Key, b.Key);
+        }
+
+        return strings.Compare(a.Group, b.Group);
+    });
+
+    return infos;
+}
+
+// Clear all the buckets (in every group) for a user or IP.
+// Returns the number of buckets cleared.
+func ClearRateLimit(kind RateLimitKind, key string) int {
+    rateLimitLock.Lock();
+    defer rateLimitLock.Unlock();
+
+    count := 0;
+    for bucketKey, _ := range rateLimitBuckets {
+        if ((bucketKey.kind == kind) && (bucketKey.key == key)) {
+            delete(rateLimitBuckets, bucketKey);
+            count++;
+        }
+    }
+
+    return count;
+}
+
+// Returns the number of buckets cleared.
+func ClearAllRateLimits() int {
+    rateLimitLock.Lock();
+    defer rateLimitLock.Unlock();
+
+    count := len(rateLimitBuckets);
+    clear(rateLimitBuckets);
+
+    return count;
+}
+
+// Refill and forget any full buckets (a full bucket is the same as no bucket).
+func sweepRateLimitBucketsLock(now time.Time) {
+    for bucketKey, bucket := range rateLimitBuckets {
+        bucket.refill(now);
+        if (bucket.tokens >= float64(bucket.burst)) {
+            delete(rateLimitBuckets, bucketKey);
+        }
+    }
+}
+
+func (this *rateLimitBucket) refill(now time.Time) {
+    elapsed := now.Sub(this.lastUpdate).Seconds();
+    if (elapsed > 0) {
+        this.tokens = math.Min(float64(this.burst), this.tokens + (elapsed * this.rate));
+        this.lastUpdate = now;
+    }
+}
+
+// Get the group for an endpoint, e.g., '/api/v02/submission/submit' -> 'submission'.
+func getEndpointGroup(endpoint string) string {
+    group := strings.TrimPrefix(endpoint, CURRENT_PREFIX);
+    group = strings.TrimPrefix(group, "/");
+
+    group, _, _ = strings.Cut(group, "/");
+    return group;
+}
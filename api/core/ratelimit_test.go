@@ -0,0 +1,194 @@
+package core
+
+import (
+    "testing"
+    "time"
+
+    "github.com/edulinq/autograder/config"
+    "github.com/edulinq/autograder/model"
+    "github.com/edulinq/autograder/util"
+)
+
+func TestGetEndpointGroup(test *testing.T) {
+    testCases := []struct{endpoint string; expected string}{
+        {NewEndpoint(`submission/submit`), "submission"},
+        {NewEndpoint(`submission/history`), "submission"},
+        {NewEndpoint(`server/lockouts/list`), "server"},
+        {NewEndpoint(`zzz`), "zzz"},
+        {`/test/api/panic`, "test"},
+        {"", ""},
+    };
+
+    for i, testCase := range testCases {
+        actual := getEndpointGroup(testCase.endpoint);
+        if (testCase.expected != actual) {
+            test.Errorf("Case %d: Unexpected group for '%s'. Expected: '%s', Actual: '%s'.", i, testCase.endpoint, testCase.expected, actual);
+        }
+    }
+}
+
+func TestGetRateLimitConfig(test *testing.T) {
+    // Use groups that no other test uses.
+    config.Set("web.ratelimit.user.ratelimitconfig.rate", 2.0);
+    config.Set("web.ratelimit.user.ratelimitconfig.student.rate", 0.5);
+    config.Set("web.ratelimit.user.ratelimitconfig.student.burst", 3);
+    config.Set("web.ratelimit.ip.ratelimitconfig.burst", 7);
+
+    userRate := config.RATE_LIMIT_USER_RATE.Get();
+    userBurst := config.RATE_LIMIT_USER_BURST.Get();
+    ipRate := config.RATE_LIMIT_IP_RATE.Get();
+
+    testCases := []struct{kind RateLimitKind; group string; role string; expectedRate float64; expectedBurst int}{
+        {RateLimitKindUser, "zzz", "", userRate, userBurst},
+        {RateLimitKindUser, "zzz", "student", userRate, userBurst},
+        {RateLimitKindUser, "ratelimitconfig", "", 2.0, userBurst},
+        {RateLimitKindUser, "ratelimitconfig", "grader", 2.0, userBurst},
+        {RateLimitKindUser, "ratelimitconfig", "student", 0.5, 3},
+        {RateLimitKindIP, "ratelimitconfig", "", ipRate, 7},
+        {RateLimitKindIP, "ratelimitconfig", "student", ipRate, 7},
+        {RateLimitKind("zzz"), "ratelimitconfig", "", 0, 0},
+    };
+
+    for i, testCase := range testCases {
+        rate, burst := getRateLimitConfig(testCase.kind, testCase.group, testCase.role);
+        if ((testCase.expectedRate != rate) || (testCase.expectedBurst != burst)) {
+            test.Errorf("Case %d: Unexpected config. Expected: (%v, %d), Actual: (%v, %d).",
+                    i, testCase.expectedRate, testCase.expectedBurst, rate, burst);
+        }
+    }
+}
+
+func TestTakeRateLimitToken(test *testing.T) {
+    config.RATE_LIMIT_DISABLE.Set(false);
+    defer config.RATE_LIMIT_DISABLE.Set(true);
+
+    ClearAllRateLimits();
+    defer ClearAllRateLimits();
+
+    now := time.Now();
+
+    testCases := []struct{offset time.Duration; rate float64; burst int; expectedOK bool; expectedRetryAfter time.Duration}{
+        // Use up the burst.
+        {0, 1.0, 2, true, 0},
+        {0, 1.0, 2, true, 0},
+        {0, 1.0, 2, false, time.Second},
+
+        // Partially refilled.
+        {500 * time.Millisecond, 1.0, 2, false, 500 * time.Millisecond},
+
+        // Refilled enough for one request.
+        {time.Second, 1.0, 2, true, 0},
+        {time.Second, 1.0, 2, false, time.Second},
+
+        // The rate changed.
+        {time.Second, 2.0, 2, false, 500 * time.Millisecond},
+        {2 * time.Second, 2.0, 2, true, 0},
+
+        // Disabled.
+        {2 * time.Second, 0.0, 2, true, 0},
+    };
+
+    for i, testCase := range testCases {
+        retryAfter, ok := takeRateLimitToken(RateLimitKindUser, "student@test.com", "test", testCase.rate, testCase.burst, now.Add(testCase.offset));
+        if ((testCase.expectedOK != ok) || (testCase.expectedRetryAfter != retryAfter)) {
+            test.Fatalf("Case %d: Unexpected result. Expected: (%v, %v), Actual: (%v, %v).",
+                    i, testCase.expectedRetryAfter, testCase.expectedOK, retryAfter, ok);
+        }
+    }
+
+    infos := GetRateLimits();
+    if (len(infos) != 1) {
+        test.Fatalf("Unexpected number of rate limits. Expected: 1, Actual: %d.", len(infos));
+    }
+
+    if ((infos[0].Key != "student@test.com") || (infos[0].Group != "test") || (infos[0].LimitedCount != 4)) {
+        test.Fatalf("Unexpected rate limit: '%s'.", util.MustToJSONIndent(infos[0]));
+    }
+
+    count := ClearRateLimit(RateLimitKindUser, "student@test.com");
+    if (count != 1) {
+        test.Fatalf("Unexpected number of cleared rate limits. Expected: 1, Actual: %d.", count);
+    }
+
+    if (len(GetRateLimits()) != 0) {
+        test.Fatalf("Found rate limits after clearing: '%s'.", util.MustToJSONIndent(GetRateLimits()));
+    }
+}
+
+func TestRateLimitUser(test *testing.T) {
+    config.RATE_LIMIT_DISABLE.Set(false);
+    defer config.RATE_LIMIT_DISABLE.Set(true);
+
+    ClearAllRateLimits();
+    defer ClearAllRateLimits();
+
+    endpoint := `/ratelimituser/test`;
+
+    handler := func(request *BaseTestRequest) (*any, *APIError) {
+        return nil, nil;
+    }
+
+    routes = append(routes, NewAPIRoute(endpoint, handler));
+
+    // Only limit students.
+    config.Set("web.ratelimit.user.ratelimituser.student.rate", 0.001);
+    config.Set("web.ratelimit.user.ratelimituser.student.burst", 2);
+
+    for i := 0; i < 2; i++ {
+        response := SendTestAPIRequestFull(test, endpoint, nil, nil, model.RoleStudent);
+        if (!response.Success) {
+            test.Fatalf("Request %d: Unexpected failure: '%v'.", i, response);
+        }
+    }
+
+    // Other users are not affected.
+    response := SendTestAPIRequestFull(test, endpoint, nil, nil, model.RoleGrader);
+    if (!response.Success) {
+        test.Fatalf("Unexpected failure for a grader: '%v'.", response);
+    }
+
+    responseText, headers := sendTestAPIRequestWithHeaders(test, endpoint, nil, model.RoleStudent);
+    checkRateLimitedResponse(test, responseText, headers, "-067");
+}
+
+func TestRateLimitIP(test *testing.T) {
+    config.RATE_LIMIT_DISABLE.Set(false);
+    defer config.RATE_LIMIT_DISABLE.Set(true);
+
+    ClearAllRateLimits();
+    defer ClearAllRateLimits();
+
+    endpoint := `/ratelimitip/test`;
+
+    handler := func(request *BaseTestRequest) (*any, *APIError) {
+        return nil, nil;
+    }
+
+    routes = append(routes, NewAPIRoute(endpoint, handler));
+
+    config.Set("web.ratelimit.ip.ratelimitip.rate", 0.001);
+    config.Set("web.ratelimit.ip.ratelimitip.burst", 1);
+
+    response := SendTestAPIRequestFull(test, endpoint, nil, nil, model.RoleStudent);
+    if (!response.Success) {
+        test.Fatalf("Unexpected failure: '%v'.", response);
+    }
+
+    // The IP is limited before authentication, so even a different user is limited.
+    responseText, headers := sendTestAPIRequestWithHeaders(test, endpoint, nil, model.RoleGrader);
+    checkRateLimitedResponse(test, responseText, headers, "-066");
+}
+
+func checkRateLimitedResponse(test *testing.T, responseText string, headers map[string][]string, expectedLocator string) {
+    var response APIResponse;
+    util.MustJSONFromString(responseText, &response);
+
+    if ((response.HTTPStatus != HTTP_STATUS_TOO_MANY_REQUESTS) || (response.Locator != expectedLocator)) {
+        test.Fatalf("Unexpected response. Expected: (%d, '%s'), Actual: '%v'.", HTTP_STATUS_TOO_MANY_REQUESTS, expectedLocator, &response);
+    }
+
+    retryAfter := headers["Retry-After"];
+    if ((len(retryAfter) != 1) || (retryAfter[0] == "") || (retryAfter[0] == "0")) {
+        test.Fatalf("Unexpected Retry-After header: '%v'.", retryAfter);
+    }
+}
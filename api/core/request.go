@@ -21,6 +21,8 @@ type APIRequest struct {
     RequestID string `json:"-"`
     Endpoint string `json:"-"`
     Timestamp common.Timestamp `json:"-"`
+    // The IP of the client that made the request (empty if unknown).
+    ClientIP string `json:"-"`
 
     // This request is being used as part of a test.
     TestingMode bool `json:"-"`
@@ -33,11 +35,41 @@ type APIRequestCourseUserContext struct {
     CourseID string `json:"course-id"`
     UserEmail string `json:"user-email"`
     UserPass string `json:"user-pass"`
+    // An API token can be used instead of a password.
+    UserToken string `json:"user-token"`
+    // A session token (from logging in) can also be used instead of a password.
+    UserSession string `json:"user-session"`
+    // A TOTP code (or recovery code) for users with two-factor authentication.
+    UserTOTP string `json:"user-totp"`
 
     // These fields are filled out as the request is parsed,
     // before being sent to the handler.
     Course *model.Course
     User *model.User
+    // The token used to authenticate (nil if the request was not authenticated with a token).
+    Token *model.APIToken
+    // The session used to authenticate (nil if the request was not authenticated with a session token).
+    Session *model.SessionClaims
+    // A second factor was used to authenticate (directly, or by the session/token used).
+    SecondFactor bool `json:"-"`
+}
+
+// Context for a request that has a user, but no course (requests about the server as a whole).
+type APIRequestServerUserContext struct {
+    APIRequest
+
+    UserEmail string `json:"user-email"`
+    UserPass string `json:"user-pass"`
+    UserToken string `json:"user-token"`
+    UserSession string `json:"user-session"`
+    UserTOTP string `json:"user-totp"`
+
+    // These fields are filled out as the request is parsed,
+    // before being sent to the handler.
+    ServerUser *model.ServerUser
+    Token *model.APIToken
+    Session *model.SessionClaims
+    SecondFactor bool `json:"-"`
 }
 
 //Context for requests that need an assignment on top of a user/course.
@@ -78,8 +110,8 @@ func (this *APIRequestCourseUserContext) Validate(request any, endpoint string)
         return NewBadRequestError("-016", &this.APIRequest, "No user email specified.");
     }
 
-    if (this.UserPass == "") {
-        return NewBadRequestError("-017", &this.APIRequest, "No user password specified.");
+    if ((this.UserPass == "") && (this.UserToken == "") && (this.UserSession == "")) {
+        return NewBadRequestError("-017", &this.APIRequest, "No user password, token, or session specified.");
     }
 
     var err error;
@@ -98,6 +130,11 @@ func (this *APIRequestCourseUserContext) Validate(request any, endpoint string)
         return apiErr;
     }
 
+    retryAfter, ok := checkUserRateLimit(this.UserEmail, this.User.Role.String(), this.Endpoint);
+    if (!ok) {
+        return NewRateLimitError("-067", &this.APIRequest, this.UserEmail, retryAfter).Course(this.CourseID);
+    }
+
     minRole, foundRole := getMaxRole(request);
     if (!foundRole) {
         return NewInternalError("-019", this, "No role found for request. All request structs require a minimum role.");
@@ -107,6 +144,100 @@ func (this *APIRequestCourseUserContext) Validate(request any, endpoint string)
         return NewBadPermissionsError("-020", this, minRole, "Base API Request");
     }
 
+    // Privileged endpoints always need a second factor.
+    if (minRole >= model.RoleGrader) {
+        apiErr = this.RequireSecondFactor();
+        if (apiErr != nil) {
+            return apiErr;
+        }
+    }
+
+    if (this.Token != nil) {
+        minScope := getMinTokenScope(request);
+        if (!this.Token.Scope.Allows(minScope)) {
+            return NewBadPermissionsError("-042", this, minRole, "API token scope is insufficient.").
+                    Add("token-scope", this.Token.Scope).Add("min-required-scope", minScope);
+        }
+    }
+
+    return nil;
+}
+
+// Require a second factor from users that have one (or are required to by the course).
+// Validate() already does this for privileged endpoints,
+// handlers should call it before performing privileged actions on less privileged endpoints
+// (e.g., acting on another user's account).
+func (this *APIRequestCourseUserContext) RequireSecondFactor() *APIError {
+    if (this.SecondFactor) {
+        return nil;
+    }
+
+    if (this.User.TOTPEnabled) {
+        return NewSecondFactorError("-063", &this.APIRequest, this.UserEmail,
+                "This operation requires two-factor authentication, include a code in 'user-totp'.").Course(this.CourseID);
+    }
+
+    if (this.Course.RequireTOTP) {
+        return NewSecondFactorError("-064", &this.APIRequest, this.UserEmail,
+                "This course requires staff to use two-factor authentication, enroll with 'user/totp/enroll'.").Course(this.CourseID);
+    }
+
+    return nil;
+}
+
+// Validate and authenticate a server-level request.
+// See APIRequestCourseUserContext.Validate().
+func (this *APIRequestServerUserContext) Validate(request any, endpoint string) *APIError {
+    apiErr := this.APIRequest.Validate(request, endpoint);
+    if (apiErr != nil) {
+        return apiErr;
+    }
+
+    if (this.UserEmail == "") {
+        return NewBadRequestError("-046", &this.APIRequest, "No user email specified.");
+    }
+
+    if ((this.UserPass == "") && (this.UserToken == "") && (this.UserSession == "")) {
+        return NewBadRequestError("-047", &this.APIRequest, "No user password, token, or session specified.");
+    }
+
+    this.ServerUser, apiErr = this.Auth();
+    if (apiErr != nil) {
+        return apiErr;
+    }
+
+    serverRole := model.ServerRoleUser;
+    if (this.ServerUser.IsAdmin()) {
+        serverRole = model.ServerRoleAdmin;
+    }
+
+    retryAfter, ok := checkUserRateLimit(this.UserEmail, string(serverRole), this.Endpoint);
+    if (!ok) {
+        return NewRateLimitError("-068", &this.APIRequest, this.UserEmail, retryAfter);
+    }
+
+    minRole, foundRole := getMinServerRole(request);
+    if (!foundRole) {
+        return NewServerInternalError("-048", this, "No server role found for request. All server request structs require a minimum server role.");
+    }
+
+    if ((minRole == model.ServerRoleAdmin) && !this.ServerUser.IsAdmin()) {
+        return NewServerBadPermissionsError("-049", this, minRole, "Base API Request");
+    }
+
+    if ((minRole == model.ServerRoleAdmin) && this.ServerUser.HasTOTP() && !this.SecondFactor) {
+        return NewSecondFactorError("-065", &this.APIRequest, this.UserEmail,
+                "This operation requires two-factor authentication, include a code in 'user-totp'.");
+    }
+
+    if (this.Token != nil) {
+        minScope := getMinTokenScope(request);
+        if (!this.Token.Scope.Allows(minScope)) {
+            return NewServerBadPermissionsError("-050", this, minRole, "API token scope is insufficient.").
+                    Add("token-scope", this.Token.Scope).Add("min-required-scope", minScope);
+        }
+    }
+
     return nil;
 }
 
@@ -148,7 +279,7 @@ func ValidateAPIRequest(request *http.Request, apiRequest any, endpoint string)
     }
 
     // Ensure the request has an request type embedded, and validate it.
-    foundRequestStruct, apiErr := validateRequestStruct(apiRequest, endpoint);
+    foundRequestStruct, apiErr := validateRequestStruct(request, apiRequest, endpoint);
     if (apiErr != nil) {
         return apiErr;
     }
@@ -187,10 +318,12 @@ func CleanupAPIrequest(apiRequest ValidAPIRequest) error {
     return nil;
 }
 
-func validateRequestStruct(request any, endpoint string) (bool, *APIError) {
+func validateRequestStruct(httpRequest *http.Request, request any, endpoint string) (bool, *APIError) {
     // Check all the fields (including embedded ones) for structures that we recognize as requests.
     foundRequestStruct := false;
 
+    clientIP := getClientIP(httpRequest);
+
     reflectValue := reflect.ValueOf(request).Elem();
     if (reflectValue.Kind() != reflect.Struct) {
         return false, NewBareInternalError("-031", endpoint, "Request's type must be a struct.").
@@ -205,6 +338,7 @@ func validateRequestStruct(request any, endpoint string) (bool, *APIError) {
             apiRequest := fieldValue.Interface().(APIRequest);
             foundRequestStruct = true;
 
+            apiRequest.ClientIP = clientIP;
             apiErr := apiRequest.Validate(request, endpoint);
             if (apiErr != nil) {
                 return false, apiErr;
@@ -216,17 +350,31 @@ func validateRequestStruct(request any, endpoint string) (bool, *APIError) {
             courseUserRequest := fieldValue.Interface().(APIRequestCourseUserContext);
             foundRequestStruct = true;
 
+            courseUserRequest.ClientIP = clientIP;
             apiErr := courseUserRequest.Validate(request, endpoint);
             if (apiErr != nil) {
                 return false, apiErr;
             }
 
             fieldValue.Set(reflect.ValueOf(courseUserRequest));
+        } else if (fieldValue.Type() == reflect.TypeOf((*APIRequestServerUserContext)(nil)).Elem()) {
+            // APIRequestServerUserContext
+            serverUserRequest := fieldValue.Interface().(APIRequestServerUserContext);
+            foundRequestStruct = true;
+
+            serverUserRequest.ClientIP = clientIP;
+            apiErr := serverUserRequest.Validate(request, endpoint);
+            if (apiErr != nil) {
+                return false, apiErr;
+            }
+
+            fieldValue.Set(reflect.ValueOf(serverUserRequest));
         } else if (fieldValue.Type() == reflect.TypeOf((*APIRequestAssignmentContext)(nil)).Elem()) {
             // APIRequestAssignmentContext
             assignmentRequest := fieldValue.Interface().(APIRequestAssignmentContext);
             foundRequestStruct = true;
 
+            assignmentRequest.ClientIP = clientIP;
             apiErr := assignmentRequest.Validate(request, endpoint);
             if (apiErr != nil) {
                 return false, apiErr;
@@ -287,3 +435,63 @@ func getMaxRole(request any) (model.UserRole, bool) {
 
     return role, foundRole;
 }
+
+// Take a request (or any object),
+// go through all the fields and look for fields typed as the encoded MinServerRole* fields.
+// Return the maximum amongst the found roles.
+// Return: (role, found role).
+func getMinServerRole(request any) (model.ServerRole, bool) {
+    reflectValue := reflect.ValueOf(request);
+
+    // Dereference any pointer.
+    if (reflectValue.Kind() == reflect.Pointer) {
+        reflectValue = reflectValue.Elem();
+    }
+
+    foundRole := false;
+    role := model.ServerRoleUser;
+
+    for i := 0; i < reflectValue.NumField(); i++ {
+        fieldValue := reflectValue.Field(i);
+
+        if (fieldValue.Type() == reflect.TypeOf((*MinServerRoleAdmin)(nil)).Elem()) {
+            foundRole = true;
+            role = model.ServerRoleAdmin;
+        } else if (fieldValue.Type() == reflect.TypeOf((*MinServerRoleUser)(nil)).Elem()) {
+            foundRole = true;
+        }
+    }
+
+    return role, foundRole;
+}
+
+// Take a request (or any object),
+// go through all the fields and look for fields typed as the encoded MinTokenScope* fields.
+// Return the maximum amongst the found scopes.
+// Requests that do not declare a scope require the admin scope.
+func getMinTokenScope(request any) model.TokenScope {
+    reflectValue := reflect.ValueOf(request);
+
+    // Dereference any pointer.
+    if (reflectValue.Kind() == reflect.Pointer) {
+        reflectValue = reflectValue.Elem();
+    }
+
+    scope := model.TokenScope("");
+
+    for i := 0; i < reflectValue.NumField(); i++ {
+        fieldValue := reflectValue.Field(i);
+
+        if (fieldValue.Type() == reflect.TypeOf((*MinTokenScopeSubmit)(nil)).Elem()) {
+            scope = model.TokenScopeSubmit;
+        } else if ((fieldValue.Type() == reflect.TypeOf((*MinTokenScopeRead)(nil)).Elem()) && (scope == "")) {
+            scope = model.TokenScopeRead;
+        }
+    }
+
+    if (scope == "") {
+        scope = model.TokenScopeAdmin;
+    }
+
+    return scope;
+}
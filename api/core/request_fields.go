@@ -25,6 +25,16 @@ type MinRoleGrader bool;
 type MinRoleStudent bool;
 type MinRoleOther bool;
 
+// The minimum server role required, encoded as a type so it can be embedded into a request struct.
+// Requests with an APIRequestServerUserContext must have one of these.
+type MinServerRoleUser bool;
+type MinServerRoleAdmin bool;
+
+// The minimum API token scope required, encoded as a type so it can be embedded into a request struct.
+// Requests without one of these require a token with the admin scope (requests authenticated with a password are not affected).
+type MinTokenScopeRead bool;
+type MinTokenScopeSubmit bool;
+
 // A request having a field of this type indicates that the users for the course should be automatically fetched.
 // The existence of this type in a struct also indicates that the request is at least a APIRequestCourseUserContext.
 type CourseUsers map[string]*model.User;
@@ -9,6 +9,7 @@ import (
     "reflect"
     "regexp"
     "runtime"
+    "strconv"
     "strings"
 
     "github.com/edulinq/autograder/log"
@@ -98,6 +99,23 @@ func NewRedirect(method string, pattern string, target string) *Route {
 }
 
 func NewAPIRoute(pattern string, apiHandler any) *Route {
+    return newAPIRoute(pattern, apiHandler, handleAPIEndpoint);
+}
+
+// Create a route for an API endpoint that streams events back to the client (see APIStream).
+// The handler should look like an APIHandler, but also take an *APIStream as a second argument.
+func NewAPIStreamRoute(pattern string, apiHandler any) *Route {
+    return newAPIRoute(pattern, apiHandler, handleAPIStreamEndpoint);
+}
+
+// Create a route for an API endpoint that responds with a raw file (see APIFile).
+// The handler should look like an APIHandler, but return an *APIFile.
+func NewAPIFileRoute(pattern string, apiHandler any) *Route {
+    return newAPIRoute(pattern, apiHandler, handleAPIFileEndpoint);
+}
+
+func newAPIRoute(pattern string, apiHandler any,
+        endpointHandler func(http.ResponseWriter, *http.Request, any) error) *Route {
     handler := func(response http.ResponseWriter, request *http.Request) (err error) {
         // Recover from any panic.
         defer func() {
@@ -114,9 +132,7 @@ func NewAPIRoute(pattern string, apiHandler any) *Route {
             err = sendAPIResponse(nil, response, nil, apiErr, false);
         }();
 
-        err = handleAPIEndpoint(response, request, apiHandler);
-
-        return err;
+        return endpointHandler(response, request, apiHandler);
     }
 
     return &Route{"POST", regexp.MustCompile("^" + pattern + "$"), handler};
@@ -180,6 +196,10 @@ func sendAPIResponse(apiRequest ValidAPIRequest, response http.ResponseWriter,
         }
     }
 
+    if ((apiErr != nil) && (apiErr.RetryAfterSecs > 0)) {
+        response.Header().Set("Retry-After", strconv.Itoa(apiErr.RetryAfterSecs));
+    }
+
     response.WriteHeader(apiResponse.HTTPStatus);
 
     _, err = fmt.Fprint(response, payload);
@@ -196,6 +216,12 @@ func sendAPIResponse(apiRequest ValidAPIRequest, response http.ResponseWriter,
 func createAPIRequest(request *http.Request, apiHandler ValidAPIHandler) (ValidAPIRequest, *APIError) {
     endpoint := request.URL.Path;
 
+    // Check the IP's rate limit before doing any real work (the user's limit is checked after authentication).
+    apiErr := checkIPRateLimit(getClientIP(request), endpoint);
+    if (apiErr != nil) {
+        return nil, apiErr;
+    }
+
     // Allocate memory for the request.
     apiRequest, apiErr := allocateAPIRequest(endpoint, apiHandler);
     if (apiErr != nil) {
@@ -259,6 +285,16 @@ func allocateAPIRequest(endpoint string, apiHandler ValidAPIHandler) (any, *APIE
 // Reflexively ensure that the api handler is of the correct type/format (e.g. looks like APIHandler).
 // Once you have a ValidAPIHandler, there is no need to check before doing reflection operations.
 func validateAPIHandler(endpoint string, apiHandler any) (ValidAPIHandler, *APIError) {
+    return validateAPIHandlerFull(endpoint, apiHandler, false);
+}
+
+// Stream handlers take an *APIStream as an additional (second) argument.
+func validateAPIHandlerFull(endpoint string, apiHandler any, stream bool) (ValidAPIHandler, *APIError) {
+    numArgs := 1;
+    if (stream) {
+        numArgs = 2;
+    }
+
     reflectValue := reflect.ValueOf(apiHandler);
     reflectType := reflect.TypeOf(apiHandler);
 
@@ -269,13 +305,19 @@ func validateAPIHandler(endpoint string, apiHandler any) (ValidAPIHandler, *APIE
 
     funcInfo := getFuncInfo(apiHandler);
 
-    if (reflectType.NumIn() != 1) {
-        return nil, NewBareInternalError("-007", endpoint, "API handler does not have exactly 1 argument.").
+    if (reflectType.NumIn() != numArgs) {
+        return nil, NewBareInternalError("-007", endpoint, fmt.Sprintf("API handler does not have exactly %d argument(s).", numArgs)).
                 Add("num-in", reflectType.NumIn()).
                 Add("function-info", funcInfo);
     }
     argumentType := reflectType.In(0);
 
+    if (stream && (reflectType.In(1) != reflect.TypeOf((*APIStream)(nil)))) {
+        return nil, NewBareInternalError("-037", endpoint, "API stream handler's second argument is not a *APIStream.").
+                Add("type", reflectType.In(1).String()).
+                Add("function-info", funcInfo);
+    }
+
     if (argumentType.Kind() != reflect.Pointer) {
         return nil, NewBareInternalError("-008", endpoint, "API handler's argument is not a pointer.").
                 Add("kind", argumentType.Kind().String()).
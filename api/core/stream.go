@@ -0,0 +1,183 @@
+package core
+
+// Support for API endpoints that stream events back to the client as they happen
+// (using Server-Sent Events: https://html.spec.whatwg.org/multipage/server-sent-events.html).
+// Errors that happen before the handler is called (e.g. bad auth) are sent as normal (non-streaming) API responses.
+// Once the handler is called, the response will be an event stream that always ends with a STREAM_EVENT_RESULT event
+// holding the standard APIResponse (the same response a non-streaming endpoint would have sent).
+
+import (
+    "fmt"
+    "net/http"
+    "reflect"
+    "strings"
+    "sync"
+
+    "github.com/edulinq/autograder/log"
+    "github.com/edulinq/autograder/util"
+)
+
+const STREAM_EVENT_RESULT = "result";
+
+const STREAM_CONTENT_TYPE = "text/event-stream";
+
+// A stream that handlers can send events to while they work.
+// Safe to use from multiple goroutines.
+type APIStream struct {
+    response http.ResponseWriter
+    started bool
+    lock sync.Mutex
+}
+
+// An event parsed from a stream response.
+type StreamEvent struct {
+    Type string
+    Data string
+}
+
+func newAPIStream(response http.ResponseWriter) *APIStream {
+    return &APIStream{
+        response: response,
+    };
+}
+
+// Send an event to the client.
+// The data will be encoded as JSON.
+func (this *APIStream) Send(eventType string, data any) error {
+    payload, err := util.ToJSON(data);
+    if (err != nil) {
+        return fmt.Errorf("Could not serialize stream event '%s': '%w'.", eventType, err);
+    }
+
+    this.lock.Lock();
+    defer this.lock.Unlock();
+
+    return this.write(eventType, payload);
+}
+
+// Assumes the lock is held.
+func (this *APIStream) write(eventType string, payload string) error {
+    if (!this.started) {
+        header := this.response.Header();
+        header.Set("Content-Type", STREAM_CONTENT_TYPE);
+        header.Set("Cache-Control", "no-cache");
+        // Ask proxies (e.g. nginx) not to buffer the stream.
+        header.Set("X-Accel-Buffering", "no");
+
+        this.response.WriteHeader(HTTP_STATUS_GOOD);
+        this.started = true;
+    }
+
+    _, err := fmt.Fprintf(this.response, "event: %s\ndata: %s\n\n", eventType, payload);
+    if (err != nil) {
+        return fmt.Errorf("Could not write stream event '%s': '%w'.", eventType, err);
+    }
+
+    flusher, ok := this.response.(http.Flusher);
+    if (ok) {
+        flusher.Flush();
+    }
+
+    return nil;
+}
+
+func (this *APIStream) isStarted() bool {
+    this.lock.Lock();
+    defer this.lock.Unlock();
+
+    return this.started;
+}
+
+// Send the final API response as the last event in the stream.
+func (this *APIStream) sendResult(apiRequest ValidAPIRequest, content any, apiErr *APIError) error {
+    var apiResponse *APIResponse = nil;
+
+    if (apiErr != nil) {
+        apiResponse = apiErr.ToResponse();
+        apiErr.Log();
+    } else {
+        apiResponse = NewAPIResponse(apiRequest, content);
+    }
+
+    payload, err := util.ToJSON(apiResponse);
+    if (err != nil) {
+        apiErr = NewBareInternalError("-038", "", "Could not serialize API stream response.").Err(err);
+        apiErr.Log();
+
+        payload, _ = util.ToJSON(apiErr.ToResponse());
+    }
+
+    this.lock.Lock();
+    defer this.lock.Unlock();
+
+    return this.write(STREAM_EVENT_RESULT, payload);
+}
+
+func handleAPIStreamEndpoint(response http.ResponseWriter, request *http.Request, apiHandler any) error {
+    validAPIHandler, apiErr := validateAPIHandlerFull(request.URL.Path, apiHandler, true);
+    if (apiErr != nil) {
+        return sendAPIResponse(nil, response, nil, apiErr, false);
+    }
+
+    apiRequest, apiErr := createAPIRequest(request, validAPIHandler);
+    if (apiErr != nil) {
+        return sendAPIResponse(nil, response, nil, apiErr, false);
+    }
+    defer CleanupAPIrequest(apiRequest);
+
+    stream := newAPIStream(response);
+
+    apiResponse, apiErr := callStreamHandler(apiHandler, apiRequest, stream);
+
+    // If nothing was streamed and there was an error, then just send a normal response.
+    if ((apiErr != nil) && !stream.isStarted()) {
+        return sendAPIResponse(apiRequest, response, nil, apiErr, false);
+    }
+
+    err := stream.sendResult(apiRequest, apiResponse, apiErr);
+    if (err != nil) {
+        log.Error("Failed to write final stream result.", err, log.NewAttr("endpoint", request.URL.Path));
+    }
+
+    // The response has already started, so there is nothing else we can send.
+    return nil;
+}
+
+// Reflexively call the API stream handler with the request and stream.
+func callStreamHandler(apiHandler ValidAPIHandler, apiRequest ValidAPIRequest, stream *APIStream) (any, *APIError) {
+    input := []reflect.Value{reflect.ValueOf(apiRequest), reflect.ValueOf(stream)};
+    output := reflect.ValueOf(apiHandler).Call(input);
+
+    response := output[0].Interface();
+    apiErr := output[1].Interface().(*APIError);
+
+    return response, apiErr;
+}
+
+// Parse the text of a stream response into events.
+// Only the "event" and "data" fields are used.
+func ParseStreamEvents(text string) []*StreamEvent {
+    events := make([]*StreamEvent, 0);
+
+    for _, block := range strings.Split(strings.ReplaceAll(text, "\r\n", "\n"), "\n\n") {
+        if (strings.TrimSpace(block) == "") {
+            continue;
+        }
+
+        event := StreamEvent{Type: "message"};
+        dataLines := make([]string, 0, 1);
+
+        for _, line := range strings.Split(block, "\n") {
+            if (strings.HasPrefix(line, "event:")) {
+                event.Type = strings.TrimSpace(strings.TrimPrefix(line, "event:"));
+            } else if (strings.HasPrefix(line, "data:")) {
+                dataLines = append(dataLines, strings.TrimPrefix(strings.TrimPrefix(line, "data:"), " "));
+            }
+        }
+
+        event.Data = strings.Join(dataLines, "\n");
+        events = append(events, &event);
+    }
+
+    return events;
+}
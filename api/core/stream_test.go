@@ -0,0 +1,133 @@
+package core
+
+import (
+    "fmt"
+    "reflect"
+    "testing"
+
+    "github.com/edulinq/autograder/model"
+    "github.com/edulinq/autograder/util"
+)
+
+func TestAPIStreamBase(test *testing.T) {
+    endpoint := `/test/api/stream/base`;
+
+    type responseType struct {
+        Count int `json:"count"`
+    }
+
+    handler := func(request *BaseTestRequest, stream *APIStream) (*responseType, *APIError) {
+        for i := 0; i < 3; i++ {
+            err := stream.Send("count", map[string]int{"value": i});
+            if (err != nil) {
+                return nil, NewInternalError("-900", &request.APIRequestCourseUserContext, "Failed to send.").Err(err);
+            }
+        }
+
+        return &responseType{Count: 3}, nil;
+    }
+
+    routes = append(routes, NewAPIStreamRoute(endpoint, handler));
+
+    events, response := SendTestAPIStreamRequestFull(test, endpoint, nil, nil, model.RoleStudent);
+    if (!response.Success) {
+        test.Fatalf("Response is not a success when it should be: '%v'.", response);
+    }
+
+    if (len(events) != 3) {
+        test.Fatalf("Unexpected number of events. Expected: 3, Actual: %d.", len(events));
+    }
+
+    for i, event := range events {
+        expected := &StreamEvent{Type: "count", Data: fmt.Sprintf(`{"value":%d}`, i)};
+        if (!reflect.DeepEqual(expected, event)) {
+            test.Errorf("Case %d: Unexpected event. Expected: '%v', Actual: '%v'.", i, expected, event);
+        }
+    }
+
+    var content responseType;
+    util.MustJSONFromString(util.MustToJSON(response.Content), &content);
+
+    if (content.Count != 3) {
+        test.Fatalf("Unexpected response content: '%v'.", content);
+    }
+}
+
+func TestAPIStreamErrors(test *testing.T) {
+    testCases := []struct{stream bool; role model.UserRole; locator string; numEvents int}{
+        // Error before the stream starts, get a normal response.
+        {false, model.RoleStudent, "-901", 0},
+        // Error after the stream starts, get the error as the result.
+        {true, model.RoleStudent, "-901", 1},
+        // Error before the handler is called (auth errors have no locator).
+        {false, model.RoleUnknown, "", 0},
+    };
+
+    for i, testCase := range testCases {
+        endpoint := fmt.Sprintf("/test/api/stream/errors/%d", i);
+
+        sendEvent := testCase.stream;
+        handler := func(request *BaseTestRequest, stream *APIStream) (*any, *APIError) {
+            if (sendEvent) {
+                stream.Send("test", "foo");
+            }
+
+            return nil, NewBadCourseRequestError("-901", &request.APIRequestCourseUserContext, "Test error.");
+        }
+
+        routes = append(routes, NewAPIStreamRoute(endpoint, handler));
+
+        events, response := SendTestAPIStreamRequestFull(test, endpoint, nil, nil, testCase.role);
+        if (response.Success) {
+            test.Errorf("Case %d: Response is a success when it should not be: '%v'.", i, response);
+            continue;
+        }
+
+        if (response.Locator != testCase.locator) {
+            test.Errorf("Case %d: Unexpected locator. Expected: '%s', Actual: '%s'.", i, testCase.locator, response.Locator);
+            continue;
+        }
+
+        if (len(events) != testCase.numEvents) {
+            test.Errorf("Case %d: Unexpected number of events. Expected: %d, Actual: %d.", i, testCase.numEvents, len(events));
+            continue;
+        }
+    }
+}
+
+func TestMalformedStreamHandlers(test *testing.T) {
+    testCases := []struct{handler any; locator string}{
+        {func(request *BaseTestRequest) (*any, *APIError) { return nil, nil }, "-007"},
+        {func(request *BaseTestRequest, stream int) (*any, *APIError) { return nil, nil }, "-037"},
+        {func(request *BaseTestRequest, stream *int) (*any, *APIError) { return nil, nil }, "-037"},
+        {func(request BaseTestRequest, stream *APIStream) (*any, *APIError) { return nil, nil }, "-008"},
+    };
+
+    for i, testCase := range testCases {
+        endpoint := fmt.Sprintf("/test/api/stream/malformed/handler/%d", i);
+        routes = append(routes, NewAPIStreamRoute(endpoint, testCase.handler));
+
+        _, response := SendTestAPIStreamRequestFull(test, endpoint, nil, nil, model.RoleAdmin);
+        if (response.Locator != testCase.locator) {
+            test.Errorf("Case %d -- Expected response locator of '%s', found response locator of '%s'. Response: [%v]", i, testCase.locator, response.Locator, response);
+        }
+    }
+}
+
+func TestParseStreamEvents(test *testing.T) {
+    testCases := []struct{text string; expected []*StreamEvent}{
+        {"", []*StreamEvent{}},
+        {"event: a\ndata: 1\n\n", []*StreamEvent{&StreamEvent{"a", "1"}}},
+        {"event: a\ndata: 1\n\nevent: b\ndata: 2\n\n", []*StreamEvent{&StreamEvent{"a", "1"}, &StreamEvent{"b", "2"}}},
+        {"data: 1\ndata: 2\n\n", []*StreamEvent{&StreamEvent{"message", "1\n2"}}},
+        {"event: a\r\ndata: 1\r\n\r\n", []*StreamEvent{&StreamEvent{"a", "1"}}},
+        {": comment\nevent: a\ndata:1", []*StreamEvent{&StreamEvent{"a", "1"}}},
+    };
+
+    for i, testCase := range testCases {
+        actual := ParseStreamEvents(testCase.text);
+        if (!reflect.DeepEqual(testCase.expected, actual)) {
+            test.Errorf("Case %d: Unexpected events. Expected: '%s', Actual: '%s'.", i, util.MustToJSON(testCase.expected), util.MustToJSON(actual));
+        }
+    }
+}
@@ -1,8 +1,10 @@
 package core
 
 import (
+    "net/http"
     "net/http/httptest"
     "os"
+    "strings"
     "testing"
 
     "github.com/edulinq/autograder/common"
@@ -60,25 +62,98 @@ func SendTestAPIRequest(test *testing.T, endpoint string, fields map[string]any)
 // Provided fields will override base fields.
 // The given role will choose the user (the test course has one user per role).
 func SendTestAPIRequestFull(test *testing.T, endpoint string, fields map[string]any, paths []string, role model.UserRole) *APIResponse {
-    url := serverURL + endpoint;
+    responseText := sendTestAPIRequestText(test, endpoint, fields, paths, role);
 
-    email := model.GetRoleString(role) + "@test.com";
-    pass := util.Sha256HexFromString(model.GetRoleString(role));
+    var response APIResponse;
+    err := util.JSONFromString(responseText, &response);
+    if (err != nil) {
+        test.Fatalf("Could not unmarshal JSON response '%s': '%v'.", responseText, err);
+    }
+
+    return &response;
+}
 
+// Make a request as the test server admin (see db.TEST_SERVER_ADMIN_EMAIL).
+func SendTestServerAdminAPIRequest(test *testing.T, endpoint string, fields map[string]any) *APIResponse {
     content := map[string]any{
-        "course-id": "course101",
-        "assignment-id": "hw0",
-        "user-email": email,
-        "user-pass": pass,
+        "user-email": db.TEST_SERVER_ADMIN_EMAIL,
+        "user-pass": util.Sha256HexFromString(db.TEST_SERVER_ADMIN_PASS),
     };
 
     for key, value := range fields {
         content[key] = value;
     }
 
-    form := map[string]string{
-        API_REQUEST_CONTENT_KEY: util.MustToJSON(content),
-    };
+    return SendTestAPIRequestFull(test, endpoint, content, nil, model.RoleOther);
+}
+
+// Make a request to a streaming endpoint (see NewAPIStreamRoute()).
+// Returns all the events that came before the final result, and the final API response.
+// If the request failed before streaming started, then there will be no events.
+func SendTestAPIStreamRequestFull(test *testing.T, endpoint string, fields map[string]any, paths []string, role model.UserRole) ([]*StreamEvent, *APIResponse) {
+    responseText := sendTestAPIRequestText(test, endpoint, fields, paths, role);
+
+    var response APIResponse;
+
+    // A non-streamed (error) response.
+    if (strings.HasPrefix(strings.TrimSpace(responseText), "{")) {
+        err := util.JSONFromString(responseText, &response);
+        if (err != nil) {
+            test.Fatalf("Could not unmarshal JSON response '%s': '%v'.", responseText, err);
+        }
+
+        return []*StreamEvent{}, &response;
+    }
+
+    events := ParseStreamEvents(responseText);
+    if (len(events) == 0) {
+        test.Fatalf("Stream response has no events: '%s'.", responseText);
+    }
+
+    lastEvent := events[len(events) - 1];
+    if (lastEvent.Type != STREAM_EVENT_RESULT) {
+        test.Fatalf("Last stream event is not a result (found '%s'): '%s'.", lastEvent.Type, responseText);
+    }
+
+    err := util.JSONFromString(lastEvent.Data, &response);
+    if (err != nil) {
+        test.Fatalf("Could not unmarshal stream result '%s': '%v'.", lastEvent.Data, err);
+    }
+
+    return events[0:len(events) - 1], &response;
+}
+
+// Make a request to a file endpoint (see NewAPIFileRoute()).
+// Returns the file's response headers and body, or a non-nil API response if a (JSON) API response was sent instead.
+func SendTestAPIFileRequestFull(test *testing.T, endpoint string, fields map[string]any, role model.UserRole) (map[string][]string, string, *APIResponse) {
+    responseText, headers := sendTestAPIRequestWithHeaders(test, endpoint, fields, role);
+
+    // Only files have a content disposition.
+    if (len(http.Header(headers).Get("Content-Disposition")) > 0) {
+        return headers, responseText, nil;
+    }
+
+    var response APIResponse;
+    err := util.JSONFromString(responseText, &response);
+    if (err != nil) {
+        test.Fatalf("Could not unmarshal JSON response '%s': '%v'.", responseText, err);
+    }
+
+    return headers, "", &response;
+}
+
+func sendTestAPIRequestWithHeaders(test *testing.T, endpoint string, fields map[string]any, role model.UserRole) (string, map[string][]string) {
+    responseText, headers, err := common.PostWithHeadersNoCheck(serverURL + endpoint, getTestAPIRequestForm(fields, role), make(map[string][]string));
+    if (err != nil) {
+        test.Fatalf("API POST returned an error: '%v'.", err);
+    }
+
+    return responseText, headers;
+}
+
+func sendTestAPIRequestText(test *testing.T, endpoint string, fields map[string]any, paths []string, role model.UserRole) string {
+    url := serverURL + endpoint;
+    form := getTestAPIRequestForm(fields, role);
 
     var responseText string;
     var err error;
@@ -93,11 +168,25 @@ func SendTestAPIRequestFull(test *testing.T, endpoint string, fields map[string]
         test.Fatalf("API POST returned an error: '%v'.", err);
     }
 
-    var response APIResponse;
-    err = util.JSONFromString(responseText, &response);
-    if (err != nil) {
-        test.Fatalf("Could not unmarshal JSON response '%s': '%v'.", responseText, err);
+    return responseText;
+}
+
+func getTestAPIRequestForm(fields map[string]any, role model.UserRole) map[string]string {
+    email := model.GetRoleString(role) + "@test.com";
+    pass := util.Sha256HexFromString(model.GetRoleString(role));
+
+    content := map[string]any{
+        "course-id": "course101",
+        "assignment-id": "hw0",
+        "user-email": email,
+        "user-pass": pass,
+    };
+
+    for key, value := range fields {
+        content[key] = value;
     }
 
-    return &response;
+    return map[string]string{
+        API_REQUEST_CONTENT_KEY: util.MustToJSON(content),
+    };
 }
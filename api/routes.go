@@ -6,6 +6,7 @@ import (
     "github.com/edulinq/autograder/api/admin"
     "github.com/edulinq/autograder/api/core"
     "github.com/edulinq/autograder/api/lms"
+    "github.com/edulinq/autograder/api/server"
     "github.com/edulinq/autograder/api/submission"
     "github.com/edulinq/autograder/api/user"
 )
@@ -27,6 +28,7 @@ func GetRoutes() *[]*core.Route {
     routes = append(routes, *(user.GetRoutes())...);
     routes = append(routes, *(submission.GetRoutes())...);
     routes = append(routes, *(admin.GetRoutes())...);
+    routes = append(routes, *(server.GetRoutes())...);
 
     return &routes;
 }
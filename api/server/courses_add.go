@@ -0,0 +1,40 @@
+package server
+
+import (
+    "github.com/edulinq/autograder/api/core"
+    "github.com/edulinq/autograder/common"
+    "github.com/edulinq/autograder/procedures"
+)
+
+type CoursesAddRequest struct {
+    core.APIRequestServerUserContext
+    core.MinServerRoleAdmin
+
+    // A FileSpec for the course's source.
+    Source core.NonEmptyString `json:"source"`
+}
+
+type CoursesAddResponse struct {
+    CourseIDs []string `json:"course-ids"`
+}
+
+func HandleCoursesAdd(request *CoursesAddRequest) (*CoursesAddResponse, *core.APIError) {
+    spec, err := common.ParseFileSpec(string(request.Source));
+    if (err != nil) {
+        return nil, core.NewBadRequestError("-302", &request.APIRequest, "Source FileSpec is not formatted properly.").Err(err);
+    }
+
+    courses, err := procedures.AddCourses(spec, true);
+
+    response := CoursesAddResponse{CourseIDs: make([]string, 0, len(courses))};
+    for _, course := range courses {
+        response.CourseIDs = append(response.CourseIDs, course.GetID());
+    }
+
+    if (err != nil) {
+        return nil, core.NewServerInternalError("-303", &request.APIRequestServerUserContext, "Failed to add courses.").
+                Err(err).Add("added-courses", response.CourseIDs);
+    }
+
+    return &response, nil;
+}
@@ -0,0 +1,47 @@
+package server
+
+import (
+    "slices"
+    "strings"
+
+    "github.com/edulinq/autograder/api/core"
+    "github.com/edulinq/autograder/db"
+)
+
+type CoursesListRequest struct {
+    core.APIRequestServerUserContext
+    core.MinServerRoleAdmin
+    core.MinTokenScopeRead
+}
+
+type CoursesListResponse struct {
+    Courses []*CourseInfo `json:"courses"`
+}
+
+type CourseInfo struct {
+    ID string `json:"id"`
+    Name string `json:"name"`
+    NumAssignments int `json:"num-assignments"`
+}
+
+func HandleCoursesList(request *CoursesListRequest) (*CoursesListResponse, *core.APIError) {
+    courses, err := db.GetCourses();
+    if (err != nil) {
+        return nil, core.NewServerInternalError("-301", &request.APIRequestServerUserContext, "Failed to get courses.").Err(err);
+    }
+
+    response := CoursesListResponse{Courses: make([]*CourseInfo, 0, len(courses))};
+    for _, course := range courses {
+        response.Courses = append(response.Courses, &CourseInfo{
+            ID: course.GetID(),
+            Name: course.GetDisplayName(),
+            NumAssignments: len(course.Assignments),
+        });
+    }
+
+    slices.SortFunc(response.Courses, func(a *CourseInfo, b *CourseInfo) int {
+        return strings.Compare(a.ID, b.ID);
+    });
+
+    return &response, nil;
+}
@@ -0,0 +1,40 @@
+package server
+
+import (
+    "github.com/edulinq/autograder/api/core"
+    "github.com/edulinq/autograder/db"
+    "github.com/edulinq/autograder/procedures"
+)
+
+type CoursesRemoveRequest struct {
+    core.APIRequestServerUserContext
+    core.MinServerRoleAdmin
+
+    TargetCourseID core.NonEmptyString `json:"target-course-id"`
+}
+
+type CoursesRemoveResponse struct {
+    FoundCourse bool `json:"found-course"`
+}
+
+// Remove a course and all of its data.
+// Users enrolled in the course keep their accounts.
+func HandleCoursesRemove(request *CoursesRemoveRequest) (*CoursesRemoveResponse, *core.APIError) {
+    course, err := db.GetCourse(string(request.TargetCourseID));
+    if (err != nil) {
+        return nil, core.NewServerInternalError("-310", &request.APIRequestServerUserContext, "Failed to get course.").
+                Err(err).Course(string(request.TargetCourseID));
+    }
+
+    if (course == nil) {
+        return &CoursesRemoveResponse{false}, nil;
+    }
+
+    err = procedures.RemoveCourse(course);
+    if (err != nil) {
+        return nil, core.NewServerInternalError("-311", &request.APIRequestServerUserContext, "Failed to remove course.").
+                Err(err).Course(course.GetID());
+    }
+
+    return &CoursesRemoveResponse{true}, nil;
+}
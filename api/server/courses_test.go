@@ -0,0 +1,224 @@
+package server
+
+import (
+    "path/filepath"
+    "reflect"
+    "testing"
+    "time"
+
+    "github.com/edulinq/autograder/api/core"
+    "github.com/edulinq/autograder/config"
+    "github.com/edulinq/autograder/db"
+    "github.com/edulinq/autograder/docker"
+    "github.com/edulinq/autograder/model"
+    "github.com/edulinq/autograder/util"
+)
+
+func TestCoursesList(test *testing.T) {
+    response := core.SendTestServerAdminAPIRequest(test, core.NewEndpoint(`server/courses/list`), nil);
+    if (!response.Success) {
+        test.Fatalf("Response is not a success when it should be: '%v'.", response);
+    }
+
+    var responseContent CoursesListResponse;
+    util.MustJSONFromString(util.MustToJSON(response.Content), &responseContent);
+
+    actual := make([]string, 0, len(responseContent.Courses));
+    for _, course := range responseContent.Courses {
+        actual = append(actual, course.ID);
+    }
+
+    expected := []string{"course-languages", "course-with-lms", "course-without-source", "course101", "course101-with-zero-limit"};
+    if (!reflect.DeepEqual(expected, actual)) {
+        test.Fatalf("Unexpected courses. Expected: '%v', actual: '%v'.", expected, actual);
+    }
+}
+
+func TestCoursesNotServerAdmin(test *testing.T) {
+    endpoints := []string{`server/courses/add`, `server/courses/list`, `server/courses/remove`, `server/courses/update`};
+
+    fields := map[string]any{
+        "source": "ZZZ",
+        "target-course-id": "course101",
+    };
+
+    for _, endpoint := range endpoints {
+        // Course owners are not server admins.
+        response := core.SendTestAPIRequestFull(test, core.NewEndpoint(endpoint), fields, nil, model.RoleOwner);
+        if (response.Success) {
+            test.Errorf("Endpoint '%s': Response is a success when it should not be.", endpoint);
+            continue;
+        }
+
+        if (response.Locator != "-049") {
+            test.Errorf("Endpoint '%s': Incorrect error returned. Expcted '%s', found '%s'.", endpoint, "-049", response.Locator);
+            continue;
+        }
+    }
+}
+
+func TestCoursesRemove(test *testing.T) {
+    defer db.ResetForTesting();
+
+    testCases := []struct{ target string; expected CoursesRemoveResponse }{
+        {"course-languages", CoursesRemoveResponse{true}},
+        {"ZZZ", CoursesRemoveResponse{false}},
+    };
+
+    for i, testCase := range testCases {
+        db.ResetForTesting();
+
+        fields := map[string]any{
+            "target-course-id": testCase.target,
+        };
+
+        response := core.SendTestServerAdminAPIRequest(test, core.NewEndpoint(`server/courses/remove`), fields);
+        if (!response.Success) {
+            test.Errorf("Case %d: Response is not a success when it should be: '%v'.", i, response);
+            continue;
+        }
+
+        var responseContent CoursesRemoveResponse;
+        util.MustJSONFromString(util.MustToJSON(response.Content), &responseContent);
+
+        if (testCase.expected != responseContent) {
+            test.Errorf("Case %d: Unexpected result. Expected: '%+v', actual: '%+v'.", i, testCase.expected, responseContent);
+            continue;
+        }
+
+        course, err := db.GetCourse(testCase.target);
+        if (err != nil) {
+            test.Errorf("Case %d: Failed to get course: '%v'.", i, err);
+            continue;
+        }
+
+        if (course != nil) {
+            test.Errorf("Case %d: Course still exists.", i);
+            continue;
+        }
+
+        // Users keep their accounts (and other courses).
+        user, err := db.GetServerUser("student@test.com");
+        if (err != nil) {
+            test.Errorf("Case %d: Failed to get user: '%v'.", i, err);
+            continue;
+        }
+
+        if ((user == nil) || user.IsEnrolled(testCase.target) || !user.IsEnrolled("course101")) {
+            test.Errorf("Case %d: Unexpected user after removal: '%s'.", i, util.MustToJSON(user));
+            continue;
+        }
+    }
+}
+
+func TestCoursesAddExisting(test *testing.T) {
+    defer db.ResetForTesting();
+
+    fields := map[string]any{
+        "source": filepath.Join(config.GetCourseImportDir(), config.TESTS_DIRNAME, "COURSE101"),
+    };
+
+    response := core.SendTestServerAdminAPIRequest(test, core.NewEndpoint(`server/courses/add`), fields);
+    if (response.Success) {
+        test.Fatalf("Adding an existing course did not fail.");
+    }
+
+    if (response.Locator != "-303") {
+        test.Fatalf("Incorrect error returned. Expcted '%s', found '%s'.", "-303", response.Locator);
+    }
+}
+
+func TestCoursesAdd(test *testing.T) {
+    if (config.DOCKER_DISABLE.Get()) {
+        test.Skip("Docker is disabled, skipping test.");
+    }
+
+    if (!docker.CanAccessDocker()) {
+        test.Skip("Could not access docker, skipping test.");
+    }
+
+    defer db.ResetForTesting();
+
+    course := db.MustGetCourse("course-languages");
+    _, err := db.RemoveUser(course, "student@test.com");
+    if (err != nil) {
+        test.Fatalf("Failed to remove user: '%v'.", err);
+    }
+
+    response := core.SendTestServerAdminAPIRequest(test, core.NewEndpoint(`server/courses/remove`), map[string]any{"target-course-id": "course-languages"});
+    if (!response.Success) {
+        test.Fatalf("Failed to remove course: '%v'.", response);
+    }
+
+    fields := map[string]any{
+        "source": filepath.Join(config.GetCourseImportDir(), config.TESTS_DIRNAME, "course-languages"),
+    };
+
+    response = core.SendTestServerAdminAPIRequest(test, core.NewEndpoint(`server/courses/add`), fields);
+    if (!response.Success) {
+        test.Fatalf("Response is not a success when it should be: '%v'.", response);
+    }
+
+    var responseContent CoursesAddResponse;
+    util.MustJSONFromString(util.MustToJSON(response.Content), &responseContent);
+
+    if (!reflect.DeepEqual([]string{"course-languages"}, responseContent.CourseIDs)) {
+        test.Fatalf("Unexpected added courses: '%v'.", responseContent.CourseIDs);
+    }
+
+    user, err := db.GetUser(db.MustGetCourse("course-languages"), "student@test.com");
+    if (err != nil) {
+        test.Fatalf("Failed to get user: '%v'.", err);
+    }
+
+    if (user == nil) {
+        test.Fatalf("User from the course source was not enrolled.");
+    }
+}
+
+func TestCoursesAdminSecondFactor(test *testing.T) {
+    db.ResetForTesting();
+    defer db.ResetForTesting();
+
+    admin, err := db.GetServerUser(db.TEST_SERVER_ADMIN_EMAIL);
+    if (err != nil) {
+        test.Fatalf("Failed to get server admin: '%v'.", err);
+    }
+
+    now := time.Now();
+
+    secret, err := admin.StartTOTPEnrollment();
+    if (err != nil) {
+        test.Fatalf("Failed to start enrollment: '%v'.", err);
+    }
+
+    code, err := model.GetTOTPCode(secret, model.GetTOTPStep(now));
+    if (err != nil) {
+        test.Fatalf("Failed to get code: '%v'.", err);
+    }
+
+    _, err = admin.ConfirmTOTP(code, now);
+    if (err != nil) {
+        test.Fatalf("Failed to confirm enrollment: '%v'.", err);
+    }
+
+    err = db.SaveServerUser(admin);
+    if (err != nil) {
+        test.Fatalf("Failed to save server admin: '%v'.", err);
+    }
+
+    response := core.SendTestServerAdminAPIRequest(test, core.NewEndpoint(`server/courses/list`), nil);
+    if (response.Locator != "-065") {
+        test.Fatalf("Unexpected response without a second factor. Expected '-065', found: '%v'.", response);
+    }
+
+    code, err = model.GetTOTPCode(secret, model.GetTOTPStep(now) + 1);
+    if (err != nil) {
+        test.Fatalf("Failed to get code: '%v'.", err);
+    }
+
+    response = core.SendTestServerAdminAPIRequest(test, core.NewEndpoint(`server/courses/list`), map[string]any{"user-totp": code});
+    if (!response.Success) {
+        test.Fatalf("Request with a second factor was not successful: '%v'.", response);
+    }
+}
@@ -0,0 +1,69 @@
+package server
+
+import (
+    "fmt"
+
+    "github.com/edulinq/autograder/api/core"
+    "github.com/edulinq/autograder/common"
+    "github.com/edulinq/autograder/db"
+    "github.com/edulinq/autograder/procedures"
+)
+
+// The same as admin/update/course, but for any course.
+type CoursesUpdateRequest struct {
+    core.APIRequestServerUserContext
+    core.MinServerRoleAdmin
+
+    TargetCourseID core.NonEmptyString `json:"target-course-id"`
+    Source string `json:"source"`
+    Clear bool `json:"clear"`
+}
+
+type CoursesUpdateResponse struct {
+    CourseUpdated bool `json:"course-updated"`
+}
+
+func HandleCoursesUpdate(request *CoursesUpdateRequest) (*CoursesUpdateResponse, *core.APIError) {
+    course, err := db.GetCourse(string(request.TargetCourseID));
+    if (err != nil) {
+        return nil, core.NewServerInternalError("-304", &request.APIRequestServerUserContext, "Failed to get course.").
+                Err(err).Course(string(request.TargetCourseID));
+    }
+
+    if (course == nil) {
+        return nil, core.NewBadRequestError("-305", &request.APIRequest,
+                fmt.Sprintf("Could not find course: '%s'.", request.TargetCourseID)).Course(string(request.TargetCourseID));
+    }
+
+    if (request.Clear) {
+        err = db.ClearCourse(course);
+        if (err != nil) {
+            return nil, core.NewServerInternalError("-306", &request.APIRequestServerUserContext, "Failed to clear course.").
+                    Err(err).Course(course.GetID());
+        }
+    }
+
+    if (request.Source != "") {
+        spec, err := common.ParseFileSpec(request.Source);
+        if (err != nil) {
+            return nil, core.NewBadRequestError("-307", &request.APIRequest, "Source FileSpec is not formatted properly.").
+                    Err(err).Course(course.GetID());
+        }
+
+        course.Source = spec;
+
+        err = db.SaveCourse(course);
+        if (err != nil) {
+            return nil, core.NewServerInternalError("-308", &request.APIRequestServerUserContext, "Failed to save course.").
+                    Err(err).Course(course.GetID());
+        }
+    }
+
+    updated, err := procedures.UpdateCourse(course, true);
+    if (err != nil) {
+        return nil, core.NewServerInternalError("-309", &request.APIRequestServerUserContext, "Failed to update course.").
+                Err(err).Course(course.GetID());
+    }
+
+    return &CoursesUpdateResponse{updated}, nil;
+}
@@ -0,0 +1,43 @@
+package server
+
+import (
+    "fmt"
+
+    "github.com/edulinq/autograder/api/core"
+    "github.com/edulinq/autograder/log"
+)
+
+type LockoutsClearRequest struct {
+    core.APIRequestServerUserContext
+    core.MinServerRoleAdmin
+
+    // If neither a kind nor key is given, then all lockouts are cleared.
+    Kind core.LockoutKind `json:"kind"`
+    Key string `json:"key"`
+}
+
+type LockoutsClearResponse struct {
+    Found bool `json:"found"`
+}
+
+// Clear the failed attempts (and any lockout) for an account or IP.
+func HandleLockoutsClear(request *LockoutsClearRequest) (*LockoutsClearResponse, *core.APIError) {
+    if ((request.Kind == "") && (request.Key == "")) {
+        core.ClearAllLockouts();
+        log.Info("Cleared all lockouts.", request.ServerUser);
+        return &LockoutsClearResponse{true}, nil;
+    }
+
+    if ((request.Kind != core.LockoutKindUser) && (request.Kind != core.LockoutKindIP)) {
+        return nil, core.NewBadRequestError("-312", &request.APIRequest,
+                fmt.Sprintf("Unknown lockout kind: '%s'.", request.Kind));
+    }
+
+    found := core.ClearLockout(request.Kind, request.Key);
+    if (found) {
+        log.Info("Cleared lockout.", request.ServerUser,
+                log.NewAttr("lockout-kind", request.Kind), log.NewAttr("lockout-key", request.Key));
+    }
+
+    return &LockoutsClearResponse{found}, nil;
+}
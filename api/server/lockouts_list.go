@@ -0,0 +1,20 @@
+package server
+
+import (
+    "github.com/edulinq/autograder/api/core"
+)
+
+type LockoutsListRequest struct {
+    core.APIRequestServerUserContext
+    core.MinServerRoleAdmin
+    core.MinTokenScopeRead
+}
+
+type LockoutsListResponse struct {
+    Lockouts []*core.LockoutInfo `json:"lockouts"`
+}
+
+// List the accounts and IPs with recent failed authentication attempts (including any that are locked out).
+func HandleLockoutsList(request *LockoutsListRequest) (*LockoutsListResponse, *core.APIError) {
+    return &LockoutsListResponse{core.GetLockouts()}, nil;
+}
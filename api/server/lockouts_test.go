@@ -0,0 +1,98 @@
+package server
+
+import (
+    "testing"
+
+    "github.com/edulinq/autograder/api/core"
+    "github.com/edulinq/autograder/model"
+    "github.com/edulinq/autograder/util"
+)
+
+func TestLockoutsBase(test *testing.T) {
+    core.ClearAllLockouts();
+    defer core.ClearAllLockouts();
+
+    // A bad password.
+    fields := map[string]any{
+        "user-pass": util.Sha256HexFromString("ZZZ"),
+    };
+
+    response := core.SendTestAPIRequestFull(test, core.NewEndpoint(`server/lockouts/list`), fields, nil, model.RoleStudent);
+    if (response.HTTPStatus != core.HTTP_STATUS_AUTH_ERROR) {
+        test.Fatalf("Unexpected response for a bad password: '%v'.", response);
+    }
+
+    lockouts := listLockouts(test);
+    if (len(lockouts) != 2) {
+        test.Fatalf("Unexpected number of lockouts. Expected: 2, Actual: %d.", len(lockouts));
+    }
+
+    // Sorted by kind.
+    if ((lockouts[0].Kind != core.LockoutKindIP) || (lockouts[1].Kind != core.LockoutKindUser) || (lockouts[1].Key != "student@test.com")) {
+        test.Fatalf("Unexpected lockouts: '%s'.", util.MustToJSONIndent(lockouts));
+    }
+
+    if ((lockouts[1].FailedAttempts != 1) || (lockouts[1].LockedUntil != "")) {
+        test.Fatalf("Unexpected user lockout: '%s'.", util.MustToJSONIndent(lockouts[1]));
+    }
+
+    fields = map[string]any{
+        "kind": core.LockoutKindUser,
+        "key": "student@test.com",
+    };
+
+    response = core.SendTestServerAdminAPIRequest(test, core.NewEndpoint(`server/lockouts/clear`), fields);
+    if (!response.Success) {
+        test.Fatalf("Failed to clear lockout: '%v'.", response);
+    }
+
+    var clearResponse LockoutsClearResponse;
+    util.MustJSONFromString(util.MustToJSON(response.Content), &clearResponse);
+    if (!clearResponse.Found) {
+        test.Fatalf("Lockout was not found when clearing.");
+    }
+
+    lockouts = listLockouts(test);
+    if ((len(lockouts) != 1) || (lockouts[0].Kind != core.LockoutKindIP)) {
+        test.Fatalf("Unexpected lockouts after clearing user: '%s'.", util.MustToJSONIndent(lockouts));
+    }
+
+    // Clear everything.
+    response = core.SendTestServerAdminAPIRequest(test, core.NewEndpoint(`server/lockouts/clear`), nil);
+    if (!response.Success) {
+        test.Fatalf("Failed to clear all lockouts: '%v'.", response);
+    }
+
+    lockouts = listLockouts(test);
+    if (len(lockouts) != 0) {
+        test.Fatalf("Unexpected lockouts after clearing all: '%s'.", util.MustToJSONIndent(lockouts));
+    }
+}
+
+func TestLockoutsClearBadKind(test *testing.T) {
+    fields := map[string]any{
+        "kind": "ZZZ",
+        "key": "student@test.com",
+    };
+
+    response := core.SendTestServerAdminAPIRequest(test, core.NewEndpoint(`server/lockouts/clear`), fields);
+    if (response.Success) {
+        test.Fatalf("Clearing a bad kind was successful.");
+    }
+
+    if (response.Locator != "-312") {
+        test.Fatalf("Unexpected locator. Expected: '-312', Actual: '%s'.", response.Locator);
+    }
+}
+
+func listLockouts(test *testing.T) []*core.LockoutInfo {
+    response := core.SendTestServerAdminAPIRequest(test, core.NewEndpoint(`server/lockouts/list`), nil);
+    if (!response.Success) {
+        test.Fatalf("Failed to list lockouts: '%v'.", response);
+    }
+
+    var responseContent LockoutsListResponse;
+    util.MustJSONFromString(util.MustToJSON(response.Content), &responseContent);
+
+    return responseContent.Lockouts;
+}
@@ -0,0 +1,43 @@
+package server
+
+import (
+    "fmt"
+
+    "github.com/edulinq/autograder/api/core"
+    "github.com/edulinq/autograder/log"
+)
+
+type RateLimitsClearRequest struct {
+    core.APIRequestServerUserContext
+    core.MinServerRoleAdmin
+
+    // If neither a kind nor key is given, then all rate limits are cleared.
+    Kind core.RateLimitKind `json:"kind"`
+    Key string `json:"key"`
+}
+
+type RateLimitsClearResponse struct {
+    Count int `json:"count"`
+}
+
+// Clear (refill) the rate limits for a user or IP.
+func HandleRateLimitsClear(request *RateLimitsClearRequest) (*RateLimitsClearResponse, *core.APIError) {
+    if ((request.Kind == "") && (request.Key == "")) {
+        count := core.ClearAllRateLimits();
+        log.Info("Cleared all rate limits.", request.ServerUser);
+        return &RateLimitsClearResponse{count}, nil;
+    }
+
+    if ((request.Kind != core.RateLimitKindUser) && (request.Kind != core.RateLimitKindIP)) {
+        return nil, core.NewBadRequestError("-313", &request.APIRequest,
+                fmt.Sprintf("Unknown rate limit kind: '%s'.", request.Kind));
+    }
+
+    count := core.ClearRateLimit(request.Kind, request.Key);
+    if (count > 0) {
+        log.Info("Cleared rate limit.", request.ServerUser,
+                log.NewAttr("rate-limit-kind", request.Kind), log.NewAttr("rate-limit-key", request.Key));
+    }
+
+    return &RateLimitsClearResponse{count}, nil;
+}
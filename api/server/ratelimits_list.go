@@ -0,0 +1,20 @@
+package server
+
+import (
+    "github.com/edulinq/autograder/api/core"
+)
+
+type RateLimitsListRequest struct {
+    core.APIRequestServerUserContext
+    core.MinServerRoleAdmin
+    core.MinTokenScopeRead
+}
+
+type RateLimitsListResponse struct {
+    RateLimits []*core.RateLimitInfo `json:"rate-limits"`
+}
+
+// List the users and IPs that have recently used up some of their rate limits.
+func HandleRateLimitsList(request *RateLimitsListRequest) (*RateLimitsListResponse, *core.APIError) {
+    return &RateLimitsListResponse{core.GetRateLimits()}, nil;
+}
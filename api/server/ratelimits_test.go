@@ -0,0 +1,114 @@
+package server
+
+import (
+    "slices"
+    "testing"
+
+    "github.com/edulinq/autograder/api/core"
+    "github.com/edulinq/autograder/config"
+    "github.com/edulinq/autograder/db"
+    "github.com/edulinq/autograder/model"
+    "github.com/edulinq/autograder/util"
+)
+
+func TestRateLimitsBase(test *testing.T) {
+    config.RATE_LIMIT_DISABLE.Set(false);
+    defer config.RATE_LIMIT_DISABLE.Set(true);
+
+    core.ClearAllRateLimits();
+    defer core.ClearAllRateLimits();
+
+    // Refill slowly enough that buckets will not be full again by the time they are listed.
+    config.Set("web.ratelimit.user.server.rate", 0.001);
+    defer config.Set("web.ratelimit.user.server.rate", config.RATE_LIMIT_USER_RATE.Get());
+    config.Set("web.ratelimit.ip.server.rate", 0.001);
+    defer config.Set("web.ratelimit.ip.server.rate", config.RATE_LIMIT_IP_RATE.Get());
+
+    // Requests that fail after authentication still count.
+    response := core.SendTestAPIRequestFull(test, core.NewEndpoint(`server/ratelimits/list`), nil, nil, model.RoleStudent);
+    if (response.HTTPStatus != core.HTTP_PERMISSIONS_ERROR) {
+        test.Fatalf("Unexpected response for a non-admin: '%v'.", response);
+    }
+
+    rateLimits := listRateLimits(test);
+
+    expectedKeys := map[core.RateLimitKind][]string{
+        core.RateLimitKindIP: []string{"127.0.0.1"},
+        core.RateLimitKindUser: []string{db.TEST_SERVER_ADMIN_EMAIL, "student@test.com"},
+    };
+
+    if (len(rateLimits) != 3) {
+        test.Fatalf("Unexpected number of rate limits. Expected: 3, Actual: %d.", len(rateLimits));
+    }
+
+    for _, rateLimit := range rateLimits {
+        if ((rateLimit.Group != "server") || !slices.Contains(expectedKeys[rateLimit.Kind], rateLimit.Key)) {
+            test.Fatalf("Unexpected rate limit: '%s'.", util.MustToJSONIndent(rateLimit));
+        }
+
+        if (rateLimit.Tokens >= float64(rateLimit.Burst)) {
+            test.Fatalf("Rate limit has a full bucket: '%s'.", util.MustToJSONIndent(rateLimit));
+        }
+    }
+
+    fields := map[string]any{
+        "kind": core.RateLimitKindUser,
+        "key": "student@test.com",
+    };
+
+    response = core.SendTestServerAdminAPIRequest(test, core.NewEndpoint(`server/ratelimits/clear`), fields);
+    if (!response.Success) {
+        test.Fatalf("Failed to clear rate limit: '%v'.", response);
+    }
+
+    var clearResponse RateLimitsClearResponse;
+    util.MustJSONFromString(util.MustToJSON(response.Content), &clearResponse);
+    if (clearResponse.Count != 1) {
+        test.Fatalf("Unexpected number of cleared rate limits. Expected: 1, Actual: %d.", clearResponse.Count);
+    }
+
+    for _, rateLimit := range listRateLimits(test) {
+        if (rateLimit.Key == "student@test.com") {
+            test.Fatalf("Found rate limit after clearing: '%s'.", util.MustToJSONIndent(rateLimit));
+        }
+    }
+
+    // Clear everything.
+    response = core.SendTestServerAdminAPIRequest(test, core.NewEndpoint(`server/ratelimits/clear`), nil);
+    if (!response.Success) {
+        test.Fatalf("Failed to clear all rate limits: '%v'.", response);
+    }
+
+    util.MustJSONFromString(util.MustToJSON(response.Content), &clearResponse);
+    if (clearResponse.Count != 2) {
+        test.Fatalf("Unexpected number of cleared rate limits. Expected: 2, Actual: %d.", clearResponse.Count);
+    }
+}
+
+func TestRateLimitsClearBadKind(test *testing.T) {
+    fields := map[string]any{
+        "kind": "ZZZ",
+        "key": "student@test.com",
+    };
+
+    response := core.SendTestServerAdminAPIRequest(test, core.NewEndpoint(`server/ratelimits/clear`), fields);
+    if (response.Success) {
+        test.Fatalf("Clearing a bad kind was successful.");
+    }
+
+    if (response.Locator != "-313") {
+        test.Fatalf("Unexpected locator. Expected: '-313', Actual: '%s'.", response.Locator);
+    }
+}
+
+func listRateLimits(test *testing.T) []*core.RateLimitInfo {
+    response := core.SendTestServerAdminAPIRequest(test, core.NewEndpoint(`server/ratelimits/list`), nil);
+    if (!response.Success) {
+        test.Fatalf("Failed to list rate limits: '%v'.", response);
+    }
+
+    var responseContent RateLimitsListResponse;
+    util.MustJSONFromString(util.MustToJSON(response.Content), &responseContent);
+
+    return responseContent.RateLimits;
+}
@@ -0,0 +1,24 @@
+package server
+
+// All the API endpoints handled by this package.
+// These endpoints are about the server as a whole (not a single course),
+// and are only available to server admins.
+
+import (
+    "github.com/edulinq/autograder/api/core"
+)
+
+var routes []*core.Route = []*core.Route{
+    core.NewAPIRoute(core.NewEndpoint(`server/courses/add`), HandleCoursesAdd),
+    core.NewAPIRoute(core.NewEndpoint(`server/courses/list`), HandleCoursesList),
+    core.NewAPIRoute(core.NewEndpoint(`server/courses/remove`), HandleCoursesRemove),
+    core.NewAPIRoute(core.NewEndpoint(`server/courses/update`), HandleCoursesUpdate),
+    core.NewAPIRoute(core.NewEndpoint(`server/lockouts/clear`), HandleLockoutsClear),
+    core.NewAPIRoute(core.NewEndpoint(`server/lockouts/list`), HandleLockoutsList),
+    core.NewAPIRoute(core.NewEndpoint(`server/ratelimits/clear`), HandleRateLimitsClear),
+    core.NewAPIRoute(core.NewEndpoint(`server/ratelimits/list`), HandleRateLimitsList),
+};
+
+func GetRoutes() *[]*core.Route {
+    return &routes;
+}
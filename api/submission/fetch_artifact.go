@@ -0,0 +1,61 @@
+package submission
+
+import (
+    "path/filepath"
+
+    "github.com/edulinq/autograder/api/core"
+    "github.com/edulinq/autograder/db"
+    "github.com/edulinq/autograder/util"
+)
+
+type FetchArtifactRequest struct {
+    core.APIRequestAssignmentContext
+    core.MinRoleStudent
+    core.MinTokenScopeRead
+
+    TargetUser core.TargetUserSelfOrGrader `json:"target-email"`
+    TargetSubmission string `json:"target-submission"`
+
+    // Relative to the grader's output dir (see submission/fetch/artifacts).
+    Path string `json:"path"`
+}
+
+// Responds with the raw artifact (not a standard API response) so that it can be viewed directly (e.g. in a browser).
+func HandleFetchArtifact(request *FetchArtifactRequest) (*core.APIFile, *core.APIError) {
+    if (!request.TargetUser.Found) {
+        return nil, core.NewBadCourseRequestError("-612", &request.APIRequestCourseUserContext, "Could not find target user.").
+                Add("target-user", request.TargetUser.Email);
+    }
+
+    gradingResult, err := db.GetSubmissionContents(request.Assignment, request.TargetUser.Email, request.TargetSubmission);
+    if (err != nil) {
+        return nil, core.NewInternalError("-613", &request.APIRequestCourseUserContext, "Failed to get submission contents.").
+                Err(err).Assignment(request.Assignment.GetID()).
+                Add("target-user", request.TargetUser.Email).Add("submission", request.TargetSubmission);
+    }
+
+    if (gradingResult == nil) {
+        return nil, core.NewBadCourseRequestError("-614", &request.APIRequestCourseUserContext, "Could not find submission.").
+                Add("target-user", request.TargetUser.Email).Add("submission", request.TargetSubmission);
+    }
+
+    path := filepath.Clean(request.Path);
+
+    gzipData, ok := getVisibleArtifacts(request.Assignment, request.User.Role, gradingResult)[path];
+    if (!ok) {
+        return nil, core.NewBadCourseRequestError("-615", &request.APIRequestCourseUserContext, "Could not find artifact.").
+                Add("target-user", request.TargetUser.Email).Add("submission", request.TargetSubmission).Add("path", request.Path);
+    }
+
+    data, err := util.GunzipBytes(gzipData);
+    if (err != nil) {
+        return nil, core.NewInternalError("-616", &request.APIRequestCourseUserContext, "Failed to decompress artifact.").
+                Err(err).Assignment(request.Assignment.GetID()).
+                Add("target-user", request.TargetUser.Email).Add("submission", request.TargetSubmission).Add("path", path);
+    }
+
+    return &core.APIFile{
+        Name: filepath.Base(path),
+        Data: data,
+    }, nil;
+}
@@ -0,0 +1,68 @@
+package submission
+
+import (
+    "net/http"
+    "testing"
+
+    "github.com/edulinq/autograder/api/core"
+    "github.com/edulinq/autograder/db"
+    "github.com/edulinq/autograder/model"
+)
+
+func TestFetchArtifact(test *testing.T) {
+    defer db.ResetForTesting();
+    prepArtifactsTest(test);
+
+    testCases := []struct{
+            role model.UserRole
+            targetEmail string
+            targetSubmission string
+            path string
+            locator string
+            contentType string
+            body string
+    }{
+        {model.RoleStudent, "", "", "report/index.html", "", "text/html; charset=utf-8", "<html><body>Report</body></html>"},
+        {model.RoleStudent, "", "", "./report/../report/index.html", "", "text/html; charset=utf-8", "<html><body>Report</body></html>"},
+        {model.RoleStudent, "", "1697406272", "plot.png", "", "image/png", "not really a png"},
+        {model.RoleGrader, "student@test.com", "", "secret.txt", "", "text/plain; charset=utf-8", "Only for graders."},
+
+        {model.RoleStudent, "", "", "secret.txt", "-615", "", ""},
+        {model.RoleStudent, "", "", "ZZZ", "-615", "", ""},
+        {model.RoleStudent, "", "ZZZ", "plot.png", "-614", "", ""},
+        {model.RoleStudent, "grader@test.com", "", "plot.png", "-033", "", ""},
+    };
+
+    for i, testCase := range testCases {
+        fields := map[string]any{
+            "target-email": testCase.targetEmail,
+            "target-submission": testCase.targetSubmission,
+            "path": testCase.path,
+        };
+
+        headers, body, response := core.SendTestAPIFileRequestFull(test, core.NewEndpoint(`submission/fetch/artifact`), fields, testCase.role);
+        if (response != nil) {
+            if (testCase.locator == "") {
+                test.Errorf("Case %d: Got an unexpected API response: '%v'.", i, response);
+            } else if (testCase.locator != response.Locator) {
+                test.Errorf("Case %d: Unexpected error locator. Expected: '%s', Actual: '%s'.", i, testCase.locator, response.Locator);
+            }
+
+            continue;
+        }
+
+        if (testCase.locator != "") {
+            test.Errorf("Case %d: Did not get an expected error ('%s').", i, testCase.locator);
+            continue;
+        }
+
+        contentType := http.Header(headers).Get("Content-Type");
+        if (testCase.contentType != contentType) {
+            test.Errorf("Case %d: Unexpected content type. Expected: '%s', Actual: '%s'.", i, testCase.contentType, contentType);
+        }
+
+        if (testCase.body != body) {
+            test.Errorf("Case %d: Unexpected body. Expected: '%s', Actual: '%s'.", i, testCase.body, body);
+        }
+    }
+}
@@ -0,0 +1,100 @@
+package submission
+
+import (
+    "sort"
+
+    "github.com/edulinq/autograder/api/core"
+    "github.com/edulinq/autograder/common"
+    "github.com/edulinq/autograder/db"
+    "github.com/edulinq/autograder/model"
+    "github.com/edulinq/autograder/util"
+)
+
+type FetchArtifactsRequest struct {
+    core.APIRequestAssignmentContext
+    core.MinRoleStudent
+    core.MinTokenScopeRead
+
+    TargetUser core.TargetUserSelfOrGrader `json:"target-email"`
+    TargetSubmission string `json:"target-submission"`
+}
+
+type FetchArtifactsResponse struct {
+    FoundUser bool `json:"found-user"`
+    FoundSubmission bool `json:"found-submission"`
+    Artifacts []*ArtifactInfo `json:"artifacts"`
+}
+
+type ArtifactInfo struct {
+    // Relative to the grader's output dir.
+    Path string `json:"path"`
+    SizeBytes int `json:"size-bytes"`
+    ContentType string `json:"content-type"`
+}
+
+func HandleFetchArtifacts(request *FetchArtifactsRequest) (*FetchArtifactsResponse, *core.APIError) {
+    response := FetchArtifactsResponse{};
+
+    if (!request.TargetUser.Found) {
+        return &response, nil;
+    }
+
+    response.FoundUser = true;
+
+    gradingResult, err := db.GetSubmissionContents(request.Assignment, request.TargetUser.Email, request.TargetSubmission);
+    if (err != nil) {
+        return nil, core.NewInternalError("-610", &request.APIRequestCourseUserContext, "Failed to get submission contents.").
+                Err(err).Assignment(request.Assignment.GetID()).
+                Add("target-user", request.TargetUser.Email).Add("submission", request.TargetSubmission);
+    }
+
+    if (gradingResult == nil) {
+        return &response, nil;
+    }
+
+    response.FoundSubmission = true;
+    response.Artifacts = make([]*ArtifactInfo, 0);
+
+    for path, gzipData := range getVisibleArtifacts(request.Assignment, request.User.Role, gradingResult) {
+        data, err := util.GunzipBytes(gzipData);
+        if (err != nil) {
+            return nil, core.NewInternalError("-611", &request.APIRequestCourseUserContext, "Failed to decompress artifact.").
+                    Err(err).Assignment(request.Assignment.GetID()).
+                    Add("target-user", request.TargetUser.Email).Add("submission", request.TargetSubmission).Add("path", path);
+        }
+
+        response.Artifacts = append(response.Artifacts, &ArtifactInfo{
+            Path: path,
+            SizeBytes: len(data),
+            ContentType: core.GuessContentType(path, data),
+        });
+    }
+
+    sort.Slice(response.Artifacts, func(i int, j int) bool {
+        return response.Artifacts[i].Path < response.Artifacts[j].Path;
+    });
+
+    return &response, nil;
+}
+
+// Get the output files (path: gzipped contents) from a submission that a user with the given role can see.
+func getVisibleArtifacts(assignment *model.Assignment, role model.UserRole, gradingResult *model.GradingResult) map[string][]byte {
+    artifacts := make(map[string][]byte);
+
+    // The raw grader output contains the hidden questions, so it cannot be shown until they are released.
+    hideResult := ((gradingResult.Info != nil) && gradingResult.Info.HasHiddenQuestions() && !assignment.CanSeeHiddenQuestions(role));
+
+    for path, gzipData := range gradingResult.OutputFilesGZip {
+        if (hideResult && (path == common.GRADER_OUTPUT_RESULT_FILENAME)) {
+            continue;
+        }
+
+        if (!assignment.CanSeeArtifact(role, path)) {
+            continue;
+        }
+
+        artifacts[path] = gzipData;
+    }
+
+    return artifacts;
+}
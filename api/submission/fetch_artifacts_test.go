@@ -0,0 +1,142 @@
+package submission
+
+import (
+    "os"
+    "path/filepath"
+    "reflect"
+    "testing"
+
+    "github.com/edulinq/autograder/api/core"
+    "github.com/edulinq/autograder/db"
+    "github.com/edulinq/autograder/model"
+    "github.com/edulinq/autograder/util"
+)
+
+func TestFetchArtifacts(test *testing.T) {
+    defer db.ResetForTesting();
+    prepArtifactsTest(test);
+
+    testCases := []struct{
+            role model.UserRole
+            targetEmail string
+            targetSubmission string
+            permError bool
+            foundSubmission bool
+            expectedPaths []string
+    }{
+        {model.RoleStudent, "", "", false, true, []string{"plot.png", "report/index.html"}},
+        {model.RoleStudent, "student@test.com", "1697406272", false, true, []string{"plot.png", "report/index.html"}},
+        {model.RoleGrader, "student@test.com", "", false, true, []string{"plot.png", "report/index.html", "result.json", "secret.txt"}},
+        {model.RoleStudent, "", "ZZZ", false, false, nil},
+        {model.RoleStudent, "grader@test.com", "", true, false, nil},
+    };
+
+    for i, testCase := range testCases {
+        fields := map[string]any{
+            "target-email": testCase.targetEmail,
+            "target-submission": testCase.targetSubmission,
+        };
+
+        response := core.SendTestAPIRequestFull(test, core.NewEndpoint(`submission/fetch/artifacts`), fields, nil, testCase.role);
+        if (!response.Success) {
+            if (testCase.permError) {
+                expectedLocator := "-033";
+                if (response.Locator != expectedLocator) {
+                    test.Errorf("Case %d: Incorrect error returned. Expcted '%s', found '%s'.", i, expectedLocator, response.Locator);
+                }
+            } else {
+                test.Errorf("Case %d: Response is not a success when it should be: '%v'.", i, response);
+            }
+
+            continue;
+        }
+
+        if (testCase.permError) {
+            test.Errorf("Case %d: Did not get an expected permissions error.", i);
+            continue;
+        }
+
+        var responseContent FetchArtifactsResponse;
+        util.MustJSONFromString(util.MustToJSON(response.Content), &responseContent);
+
+        if (testCase.foundSubmission != responseContent.FoundSubmission) {
+            test.Errorf("Case %d: Found submission does not match. Expected: '%v', Actual: '%v'.", i, testCase.foundSubmission, responseContent.FoundSubmission);
+            continue;
+        }
+
+        if (!testCase.foundSubmission) {
+            continue;
+        }
+
+        paths := make([]string, 0, len(responseContent.Artifacts));
+        for _, artifact := range responseContent.Artifacts {
+            paths = append(paths, artifact.Path);
+        }
+
+        if (!reflect.DeepEqual(testCase.expectedPaths, paths)) {
+            test.Errorf("Case %d: Unexpected artifacts. Expected: '%v', Actual: '%v'.", i, testCase.expectedPaths, paths);
+        }
+
+        for _, artifact := range responseContent.Artifacts {
+            if ((artifact.Path == "report/index.html") && (artifact.ContentType != "text/html; charset=utf-8")) {
+                test.Errorf("Case %d: Unexpected content type for '%s': '%s'.", i, artifact.Path, artifact.ContentType);
+            }
+        }
+    }
+}
+
+// Add some output files to the most recent test submission and make some of them visible to students.
+// The caller should reset the db when done.
+func prepArtifactsTest(test *testing.T) {
+    db.ResetForTesting();
+
+    assignment := db.MustGetTestAssignment();
+
+    result, err := db.GetSubmissionContents(assignment, "student@test.com", "1697406272");
+    if (err != nil) {
+        test.Fatalf("Failed to get submission contents: '%v'.", err);
+    }
+
+    files := map[string]string{
+        "report/index.html": "<html><body>Report</body></html>",
+        "plot.png": "not really a png",
+        "secret.txt": "Only for graders.",
+    };
+
+    tempDir, err := util.MkDirTemp("autograder-artifacts-test-");
+    if (err != nil) {
+        test.Fatalf("Failed to make temp dir: '%v'.", err);
+    }
+    defer os.RemoveAll(tempDir);
+
+    for path, contents := range files {
+        path = filepath.Join(tempDir, path);
+        util.MkDir(filepath.Dir(path));
+
+        err = util.WriteFile(contents, path);
+        if (err != nil) {
+            test.Fatalf("Failed to write test artifact '%s': '%v'.", path, err);
+        }
+    }
+
+    artifacts, err := util.GzipDirectoryToBytes(tempDir);
+    if (err != nil) {
+        test.Fatalf("Failed to gzip test artifacts: '%v'.", err);
+    }
+
+    for path, data := range artifacts {
+        result.OutputFilesGZip[path] = data;
+    }
+
+    err = db.SaveSubmission(assignment, result);
+    if (err != nil) {
+        test.Fatalf("Failed to save submission: '%v'.", err);
+    }
+
+    assignment.StudentArtifacts = []string{"report/*", "*.png"};
+
+    err = db.SaveCourse(assignment.GetCourse());
+    if (err != nil) {
+        test.Fatalf("Failed to save course: '%v'.", err);
+    }
+}
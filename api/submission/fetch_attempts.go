@@ -9,6 +9,7 @@ import (
 type FetchAttemptsRequest struct {
     core.APIRequestAssignmentContext
     core.MinRoleGrader
+    core.MinTokenScopeRead
 
     TargetUser core.TargetUserSelfOrGrader `json:"target-email"`
 }
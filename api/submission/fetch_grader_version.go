@@ -0,0 +1,37 @@
+package submission
+
+import (
+    "github.com/edulinq/autograder/api/core"
+    "github.com/edulinq/autograder/db"
+    "github.com/edulinq/autograder/model"
+)
+
+type FetchByGraderVersionRequest struct {
+    core.APIRequestAssignmentContext
+    core.MinRoleGrader
+    core.MinTokenScopeRead
+
+    FilterRole model.UserRole `json:"filter-role"`
+
+    // Empty fields match any version.
+    model.GraderVersion
+}
+
+type FetchByGraderVersionResponse struct {
+    Submissions []*model.SubmissionHistoryItem `json:"submissions"`
+}
+
+func HandleFetchByGraderVersion(request *FetchByGraderVersionRequest) (*FetchByGraderVersionResponse, *core.APIError) {
+    if (request.GraderVersion.IsEmpty()) {
+        return nil, core.NewBadCourseRequestError("-608", &request.APIRequestCourseUserContext,
+                "At least one grader version field (image-id, commit-hash, config-hash) must be provided.");
+    }
+
+    submissions, err := db.GetSubmissionsByGraderVersion(request.Assignment, &request.GraderVersion, request.FilterRole);
+    if (err != nil) {
+        return nil, core.NewInternalError("-609", &request.APIRequestCourseUserContext, "Failed to get submissions by grader version.").
+                Err(err).Assignment(request.Assignment.GetID());
+    }
+
+    return &FetchByGraderVersionResponse{submissions}, nil;
+}
@@ -0,0 +1,72 @@
+package submission
+
+import (
+    "reflect"
+    "testing"
+
+    "github.com/edulinq/autograder/api/core"
+    "github.com/edulinq/autograder/db"
+    "github.com/edulinq/autograder/model"
+    "github.com/edulinq/autograder/util"
+)
+
+func TestFetchByGraderVersion(test *testing.T) {
+    defer db.ResetForTesting();
+    db.ResetForTesting();
+
+    assignment := db.MustGetTestAssignment();
+
+    result, err := db.GetSubmissionContents(assignment, "student@test.com", "1697406272");
+    if (err != nil) {
+        test.Fatalf("Failed to get submission contents: '%v'.", err);
+    }
+
+    result.Info.GraderVersion = &model.GraderVersion{ImageID: "sha256:1234", ConfigHash: "abcd"};
+
+    err = db.SaveSubmission(assignment, result);
+    if (err != nil) {
+        test.Fatalf("Failed to save submission: '%v'.", err);
+    }
+
+    testCases := []struct{
+            role model.UserRole
+            fields map[string]any
+            locator string
+            expectedIDs []string
+    }{
+        {model.RoleGrader, map[string]any{"image-id": "sha256:1234"}, "", []string{result.Info.ID}},
+        {model.RoleAdmin, map[string]any{"image-id": "sha256:1234", "config-hash": "abcd"}, "", []string{result.Info.ID}},
+        {model.RoleAdmin, map[string]any{"config-hash": "abcd", "filter-role": "grader"}, "", []string{}},
+        {model.RoleGrader, map[string]any{"image-id": "ZZZ"}, "", []string{}},
+        {model.RoleGrader, map[string]any{}, "-608", nil},
+        {model.RoleStudent, map[string]any{"image-id": "sha256:1234"}, "-020", nil},
+    };
+
+    for i, testCase := range testCases {
+        response := core.SendTestAPIRequestFull(test, core.NewEndpoint(`submission/fetch/grader-version`), testCase.fields, nil, testCase.role);
+        if (!response.Success) {
+            if (testCase.locator != response.Locator) {
+                test.Errorf("Case %d: Unexpected error locator. Expected: '%s', Actual: '%s'.", i, testCase.locator, response.Locator);
+            }
+
+            continue;
+        }
+
+        if (testCase.locator != "") {
+            test.Errorf("Case %d: Response is a success when it should not be: '%v'.", i, response);
+            continue;
+        }
+
+        var responseContent FetchByGraderVersionResponse;
+        util.MustJSONFromString(util.MustToJSON(response.Content), &responseContent);
+
+        ids := make([]string, 0, len(responseContent.Submissions));
+        for _, submission := range responseContent.Submissions {
+            ids = append(ids, submission.ID);
+        }
+
+        if (!reflect.DeepEqual(testCase.expectedIDs, ids)) {
+            test.Errorf("Case %d: Unexpected submissions. Expected: '%v', Actual: '%v'.", i, testCase.expectedIDs, ids);
+        }
+    }
+}
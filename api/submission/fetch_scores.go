@@ -9,6 +9,7 @@ import (
 type FetchScoresRequest struct {
     core.APIRequestAssignmentContext
     core.MinRoleGrader
+    core.MinTokenScopeRead
 
     // Filter results to only users with this role.
     FilterRole model.UserRole `json:"filter-role"`
@@ -9,6 +9,7 @@ import (
 type FetchSubmissionRequest struct {
     core.APIRequestAssignmentContext
     core.MinRoleStudent
+    core.MinTokenScopeRead
 
     TargetUser core.TargetUserSelfOrGrader `json:"target-email"`
     TargetSubmission string `json:"target-submission"`
@@ -40,6 +41,22 @@ func HandleFetchSubmission(request *FetchSubmissionRequest) (*FetchSubmissionRes
         return &response, nil;
     }
 
+    // Only send the output files that this user can see (this also handles the raw grader output).
+    gradingResult.OutputFilesGZip = getVisibleArtifacts(request.Assignment, request.User.Role, gradingResult);
+
+    // Raw output may reveal information about hidden questions (like the raw grader output file).
+    canSeeHidden := request.Assignment.CanSeeHiddenQuestions(request.User.Role);
+    hasHidden := ((gradingResult.Info != nil) && gradingResult.Info.HasHiddenQuestions());
+    if (request.Assignment.ShouldHideGraderOutput(request.User.Role) || (hasHidden && !canSeeHidden)) {
+        gradingResult.Stdout = "";
+        gradingResult.Stderr = "";
+        gradingResult.SidecarLogs = nil;
+    }
+
+    if ((gradingResult.Info != nil) && !canSeeHidden) {
+        gradingResult.Info = gradingResult.Info.ToVisible();
+    }
+
     response.FoundSubmission = true;
     response.GradingResult = gradingResult;
 
@@ -3,10 +3,14 @@ package submission
 import (
     "path/filepath"
     "reflect"
+    "slices"
     "testing"
+    "time"
 
     "github.com/edulinq/autograder/api/core"
+    "github.com/edulinq/autograder/common"
     "github.com/edulinq/autograder/config"
+    "github.com/edulinq/autograder/db"
     "github.com/edulinq/autograder/model"
     "github.com/edulinq/autograder/util"
 )
@@ -19,6 +23,10 @@ func TestFetchSubmission(test *testing.T) {
         "1697406272": model.MustLoadGradingResult(getTestSubmissionResultPath("1697406272")),
     };
 
+    // Students cannot see any of the output files (none are student artifacts).
+    studentGradingResult := *studentGradingResults["1697406272"];
+    studentGradingResult.OutputFilesGZip = map[string][]byte{};
+
     testCases := []struct{
             role model.UserRole
             targetEmail string
@@ -56,8 +64,8 @@ func TestFetchSubmission(test *testing.T) {
         {model.RoleGrader, "ZZZ@test.com", "", false, false, false, nil},
 
         // Student, self, recent.
-        {model.RoleStudent, "",                 "", true, true, false, studentGradingResults["1697406272"]},
-        {model.RoleStudent, "student@test.com", "", true, true, false, studentGradingResults["1697406272"]},
+        {model.RoleStudent, "",                 "", true, true, false, &studentGradingResult},
+        {model.RoleStudent, "student@test.com", "", true, true, false, &studentGradingResult},
 
         // Student, self, missing.
         {model.RoleStudent, "",                 "ZZZ", true, false, false, nil},
@@ -116,6 +124,152 @@ func TestFetchSubmission(test *testing.T) {
     }
 }
 
+func TestFetchSubmissionArtifacts(test *testing.T) {
+    defer db.ResetForTesting();
+    prepArtifactsTest(test);
+
+    testCases := []struct{role model.UserRole; expectedPaths []string}{
+        {model.RoleStudent, []string{"plot.png", "report/index.html"}},
+        {model.RoleGrader, []string{"plot.png", "report/index.html", "result.json", "secret.txt"}},
+    };
+
+    for i, testCase := range testCases {
+        fields := map[string]any{
+            "target-email": "student@test.com",
+        };
+
+        response := core.SendTestAPIRequestFull(test, core.NewEndpoint(`submission/fetch/submission`), fields, nil, testCase.role);
+        if (!response.Success) {
+            test.Errorf("Case %d: Response is not a success when it should be: '%v'.", i, response);
+            continue;
+        }
+
+        var responseContent FetchSubmissionResponse;
+        util.MustJSONFromString(util.MustToJSON(response.Content), &responseContent);
+
+        if (!responseContent.FoundSubmission) {
+            test.Errorf("Case %d: Submission not found.", i);
+            continue;
+        }
+
+        paths := make([]string, 0, len(responseContent.GradingResult.OutputFilesGZip));
+        for path, _ := range responseContent.GradingResult.OutputFilesGZip {
+            paths = append(paths, path);
+        }
+        slices.Sort(paths);
+
+        if (!reflect.DeepEqual(testCase.expectedPaths, paths)) {
+            test.Errorf("Case %d: Unexpected output files. Expected: '%v', Actual: '%v'.", i, testCase.expectedPaths, paths);
+        }
+    }
+}
+
+func TestFetchSubmissionHiddenQuestions(test *testing.T) {
+    defer db.ResetForTesting();
+
+    testCases := []struct{role model.UserRole; released bool; expectedQuestions []string; score float64; maxPoints float64; hideOutput bool}{
+        {model.RoleStudent, false, []string{"Q1", "Style"}, 1.0, 1.0, true},
+        {model.RoleStudent, true, []string{"Q1", "Q2", "Style"}, 2.0, 2.0, false},
+        {model.RoleGrader, false, []string{"Q1", "Q2", "Style"}, 2.0, 2.0, false},
+    };
+
+    for i, testCase := range testCases {
+        prepHiddenQuestionsTest(test, testCase.released);
+
+        fields := map[string]any{
+            "target-email": "student@test.com",
+        };
+
+        response := core.SendTestAPIRequestFull(test, core.NewEndpoint(`submission/fetch/submission`), fields, nil, testCase.role);
+        if (!response.Success) {
+            test.Errorf("Case %d: Response is not a success when it should be: '%v'.", i, response);
+            continue;
+        }
+
+        var responseContent FetchSubmissionResponse;
+        util.MustJSONFromString(util.MustToJSON(response.Content), &responseContent);
+
+        if (!responseContent.FoundSubmission) {
+            test.Errorf("Case %d: Submission not found.", i);
+            continue;
+        }
+
+        checkHiddenQuestions(test, i, responseContent.GradingResult.Info, testCase.expectedQuestions, testCase.score, testCase.maxPoints);
+
+        result := responseContent.GradingResult;
+        hasOutput := ((result.Stdout != "") || (result.Stderr != "") || (len(result.SidecarLogs) > 0));
+        if (testCase.hideOutput && hasOutput) {
+            test.Errorf("Case %d: Raw output was not hidden: '%s', '%s', '%v'.", i, result.Stdout, result.Stderr, result.SidecarLogs);
+        }
+
+        // Only the sidecar logs are stored with the test submissions.
+        if (!testCase.hideOutput && (len(result.SidecarLogs) == 0)) {
+            test.Errorf("Case %d: Raw output is missing: '%v'.", i, result.SidecarLogs);
+        }
+    }
+}
+
 func getTestSubmissionResultPath(shortID string) string {
     return filepath.Join(config.GetCourseImportDir(), "_tests", "COURSE101", "submissions", "HW0", "student@test.com", shortID, "submission-result.json");
 }
+
+// Mark Q2 of the test assignment as hidden (and mark it in all the existing test submissions).
+// The test submissions will also have sidecar logs.
+// If released is true, then the hidden questions will already be released to students.
+// The caller should reset the db when done.
+func prepHiddenQuestionsTest(test *testing.T, released bool) {
+    db.ResetForTesting();
+
+    assignment := db.MustGetTestAssignment();
+    assignment.HiddenQuestions = []string{"Q2"};
+
+    if (released) {
+        assignment.HiddenReleaseTime = common.TimestampFromTime(time.Now().Add(-time.Hour));
+    }
+
+    err := db.SaveCourse(assignment.GetCourse());
+    if (err != nil) {
+        test.Fatalf("Failed to save course: '%v'.", err);
+    }
+
+    for _, shortID := range []string{"1697406256", "1697406265", "1697406272"} {
+        result, err := db.GetSubmissionContents(assignment, "student@test.com", shortID);
+        if (err != nil) {
+            test.Fatalf("Failed to get submission contents for '%s': '%v'.", shortID, err);
+        }
+
+        result.SidecarLogs = map[string]string{"db": "Only for graders."};
+
+        // Recompute the points (like the grader does).
+        result.Info.MaxPoints = 0.0;
+        result.Info.Score = 0.0;
+        assignment.MarkHiddenQuestions(result.Info);
+        result.Info.ComputePoints();
+
+        err = db.SaveSubmission(assignment, result);
+        if (err != nil) {
+            test.Fatalf("Failed to save submission '%s': '%v'.", shortID, err);
+        }
+    }
+}
+
+func checkHiddenQuestions(test *testing.T, i int, gradingInfo *model.GradingInfo, expectedQuestions []string, score float64, maxPoints float64) {
+    if (gradingInfo == nil) {
+        test.Errorf("Case %d: Grading info is nil.", i);
+        return;
+    }
+
+    questions := make([]string, 0, len(gradingInfo.Questions));
+    for _, question := range gradingInfo.Questions {
+        questions = append(questions, question.Name);
+    }
+
+    if (!reflect.DeepEqual(expectedQuestions, questions)) {
+        test.Errorf("Case %d: Unexpected questions. Expected: '%v', Actual: '%v'.", i, expectedQuestions, questions);
+    }
+
+    if (!util.IsClose(score, gradingInfo.Score) || !util.IsClose(maxPoints, gradingInfo.MaxPoints)) {
+        test.Errorf("Case %d: Unexpected points. Expected: %s / %s, Actual: %s / %s.", i,
+                util.FloatToStr(score), util.FloatToStr(maxPoints), util.FloatToStr(gradingInfo.Score), util.FloatToStr(gradingInfo.MaxPoints));
+    }
+}
@@ -9,6 +9,7 @@ import (
 type FetchSubmissionsRequest struct {
     core.APIRequestAssignmentContext
     core.MinRoleGrader
+    core.MinTokenScopeRead
 
     FilterRole model.UserRole `json:"filter-role"`
 }
@@ -9,6 +9,7 @@ import (
 type HistoryRequest struct {
     core.APIRequestAssignmentContext
     core.MinRoleStudent
+    core.MinTokenScopeRead
 
     TargetUser core.TargetUserSelfOrGrader `json:"target-email"`
 }
@@ -37,6 +38,12 @@ func HandleHistory(request *HistoryRequest) (*HistoryResponse, *core.APIError) {
                 Add("target-user", request.TargetUser.Email);
     }
 
+    if (!request.Assignment.CanSeeHiddenQuestions(request.User.Role)) {
+        for i, item := range history {
+            history[i] = item.ToVisible();
+        }
+    }
+
     response.History = history;
 
     return &response, nil;
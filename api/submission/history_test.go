@@ -6,6 +6,7 @@ import (
 
     "github.com/edulinq/autograder/api/core"
     "github.com/edulinq/autograder/common"
+    "github.com/edulinq/autograder/db"
     "github.com/edulinq/autograder/model"
     "github.com/edulinq/autograder/util"
 )
@@ -66,6 +67,55 @@ func TestHistory(test *testing.T) {
     }
 }
 
+func TestHistoryHiddenQuestions(test *testing.T) {
+    defer db.ResetForTesting();
+
+    // Q2 is worth one point in all the submissions.
+    testCases := []struct{role model.UserRole; released bool; hidden bool}{
+        {model.RoleStudent, false, true},
+        {model.RoleStudent, true, false},
+        {model.RoleGrader, false, false},
+    };
+
+    for i, testCase := range testCases {
+        prepHiddenQuestionsTest(test, testCase.released);
+
+        fields := map[string]any{
+            "target-email": "student@test.com",
+        };
+
+        response := core.SendTestAPIRequestFull(test, core.NewEndpoint(`submission/history`), fields, nil, testCase.role);
+        if (!response.Success) {
+            test.Errorf("Case %d: Response is not a success when it should be: '%v'.", i, response);
+            continue;
+        }
+
+        var responseContent HistoryResponse;
+        util.MustJSONFromString(util.MustToJSON(response.Content), &responseContent);
+
+        if (len(studentHist) != len(responseContent.History)) {
+            test.Errorf("Case %d: Unexpected history length. Expected: %d, Actual: %d.", i, len(studentHist), len(responseContent.History));
+            continue;
+        }
+
+        for j, item := range responseContent.History {
+            expectedMaxPoints := studentHist[j].MaxPoints;
+            if (testCase.hidden) {
+                expectedMaxPoints -= 1.0;
+            }
+
+            if (!util.IsClose(expectedMaxPoints, item.MaxPoints)) {
+                test.Errorf("Case %d, Item %d: Unexpected max points. Expected: %s, Actual: %s.", i, j,
+                        util.FloatToStr(expectedMaxPoints), util.FloatToStr(item.MaxPoints));
+            }
+
+            if (testCase.hidden && (!util.IsZero(item.HiddenMaxPoints) || !util.IsZero(item.HiddenScore))) {
+                test.Errorf("Case %d, Item %d: Hidden points were not removed: '%+v'.", i, j, item);
+            }
+        }
+    }
+}
+
 var studentHist []*model.SubmissionHistoryItem = []*model.SubmissionHistoryItem{
     &model.SubmissionHistoryItem{
         ID: "course101::hw0::student@test.com::1697406256",
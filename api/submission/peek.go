@@ -9,6 +9,7 @@ import (
 type PeekRequest struct {
     core.APIRequestAssignmentContext
     core.MinRoleStudent
+    core.MinTokenScopeRead
 
     TargetUser core.TargetUserSelfOrGrader `json:"target-email"`
     TargetSubmission string `json:"target-submission"`
@@ -43,5 +44,9 @@ func HandlePeek(request *PeekRequest) (*PeekResponse, *core.APIError) {
     response.FoundSubmission = true;
     response.GradingInfo = submissionResult;
 
+    if (!request.Assignment.CanSeeHiddenQuestions(request.User.Role)) {
+        response.GradingInfo = submissionResult.ToVisible();
+    }
+
     return &response, nil;
 }
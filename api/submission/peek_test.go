@@ -4,6 +4,7 @@ import (
     "testing"
 
     "github.com/edulinq/autograder/api/core"
+    "github.com/edulinq/autograder/db"
     "github.com/edulinq/autograder/model"
     "github.com/edulinq/autograder/util"
 )
@@ -98,3 +99,37 @@ func TestPeek(test *testing.T) {
         }
     }
 }
+
+func TestPeekHiddenQuestions(test *testing.T) {
+    defer db.ResetForTesting();
+
+    testCases := []struct{role model.UserRole; released bool; expectedQuestions []string; score float64; maxPoints float64}{
+        {model.RoleStudent, false, []string{"Q1", "Style"}, 1.0, 1.0},
+        {model.RoleStudent, true, []string{"Q1", "Q2", "Style"}, 2.0, 2.0},
+        {model.RoleGrader, false, []string{"Q1", "Q2", "Style"}, 2.0, 2.0},
+    };
+
+    for i, testCase := range testCases {
+        prepHiddenQuestionsTest(test, testCase.released);
+
+        fields := map[string]any{
+            "target-email": "student@test.com",
+        };
+
+        response := core.SendTestAPIRequestFull(test, core.NewEndpoint(`submission/peek`), fields, nil, testCase.role);
+        if (!response.Success) {
+            test.Errorf("Case %d: Response is not a success when it should be: '%v'.", i, response);
+            continue;
+        }
+
+        var responseContent PeekResponse;
+        util.MustJSONFromString(util.MustToJSON(response.Content), &responseContent);
+
+        if (!responseContent.FoundSubmission) {
+            test.Errorf("Case %d: Submission not found.", i);
+            continue;
+        }
+
+        checkHiddenQuestions(test, i, responseContent.GradingInfo, testCase.expectedQuestions, testCase.score, testCase.maxPoints);
+    }
+}
@@ -9,11 +9,15 @@ import (
 var routes []*core.Route = []*core.Route{
     core.NewAPIRoute(core.NewEndpoint(`submission/history`), HandleHistory),
     core.NewAPIRoute(core.NewEndpoint(`submission/peek`), HandlePeek),
+    core.NewAPIFileRoute(core.NewEndpoint(`submission/fetch/artifact`), HandleFetchArtifact),
+    core.NewAPIRoute(core.NewEndpoint(`submission/fetch/artifacts`), HandleFetchArtifacts),
     core.NewAPIRoute(core.NewEndpoint(`submission/fetch/attempts`), HandleFetchAttempts),
+    core.NewAPIRoute(core.NewEndpoint(`submission/fetch/grader-version`), HandleFetchByGraderVersion),
     core.NewAPIRoute(core.NewEndpoint(`submission/fetch/scores`), HandleFetchScores),
     core.NewAPIRoute(core.NewEndpoint(`submission/fetch/submission`), HandleFetchSubmission),
     core.NewAPIRoute(core.NewEndpoint(`submission/fetch/submissions`), HandleFetchSubmissions),
     core.NewAPIRoute(core.NewEndpoint(`submission/submit`), HandleSubmit),
+    core.NewAPIStreamRoute(core.NewEndpoint(`submission/submit/stream`), HandleSubmitStream),
     core.NewAPIRoute(core.NewEndpoint(`submission/remove`), HandleRemoveSubmission),
 };
 
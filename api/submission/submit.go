@@ -1,6 +1,8 @@
 package submission
 
 import (
+    "errors"
+
     "github.com/edulinq/autograder/api/core"
     "github.com/edulinq/autograder/grader"
     "github.com/edulinq/autograder/log"
@@ -10,6 +12,7 @@ import (
 type SubmitRequest struct {
     core.APIRequestAssignmentContext
     core.MinRoleStudent
+    core.MinTokenScopeSubmit
     Files core.POSTFiles
 
     Message string `json:"message"`
@@ -21,12 +24,22 @@ type SubmitResponse struct {
 
     GradingSucess bool `json:"grading-success"`
     GradingInfo *model.GradingInfo `json:"result"`
+
+    // Set if grading failed because the grader exceeded a resource limit.
+    LimitViolation *grader.ResourceLimitError `json:"limit-violation,omitempty"`
+
+    // Set if the submission was rejected because it failed the assignment's pre-check.
+    PreCheck *grader.PreCheckResult `json:"pre-check,omitempty"`
 }
 
 func HandleSubmit(request *SubmitRequest) (*SubmitResponse, *core.APIError) {
+    return submit(request, grader.GetDefaultGradeOptions());
+}
+
+func submit(request *SubmitRequest, options grader.GradeOptions) (*SubmitResponse, *core.APIError) {
     response := SubmitResponse{};
 
-    result, reject, err := grader.GradeDefault(request.Assignment, request.Files.TempDir, request.User.Email, request.Message);
+    result, reject, err := grader.Grade(request.Assignment, request.Files.TempDir, request.User.Email, request.Message, true, options);
     if (err != nil) {
         stdout := "";
         stderr := "";
@@ -38,6 +51,12 @@ func HandleSubmit(request *SubmitRequest) (*SubmitResponse, *core.APIError) {
 
         log.Info("Submission grading failed.", err, request.Assignment, log.NewAttr("stdout", stdout), log.NewAttr("stderr", stderr), request.User);
 
+        var limitErr *grader.ResourceLimitError;
+        if (errors.As(err, &limitErr)) {
+            response.LimitViolation = limitErr;
+            response.Message = limitErr.Error();
+        }
+
         return &response, nil;
     }
 
@@ -46,11 +65,21 @@ func HandleSubmit(request *SubmitRequest) (*SubmitResponse, *core.APIError) {
 
         response.Rejected = true;
         response.Message = reject.String();
+
+        preCheckReject, ok := reject.(*grader.RejectPreCheckFailed);
+        if (ok) {
+            response.PreCheck = preCheckReject.Result;
+        }
+
         return &response, nil;
     }
 
     response.GradingSucess = true;
     response.GradingInfo = result.Info;
 
+    if (!request.Assignment.CanSeeHiddenQuestions(request.User.Role)) {
+        response.GradingInfo = result.Info.ToVisible();
+    }
+
     return &response, nil;
 }
@@ -0,0 +1,38 @@
+package submission
+
+import (
+    "github.com/edulinq/autograder/api/core"
+    "github.com/edulinq/autograder/grader"
+    "github.com/edulinq/autograder/log"
+    "github.com/edulinq/autograder/model"
+)
+
+// Submit and stream grading events (see grader.GradingEvent) back as they happen.
+// The final event will be the same response that the non-streaming submit endpoint returns.
+func HandleSubmitStream(request *SubmitRequest, stream *core.APIStream) (*SubmitResponse, *core.APIError) {
+    options := grader.GetDefaultGradeOptions();
+    options.Listener = func(event *grader.GradingEvent) {
+        if (!shouldSendGradingEvent(request.Assignment, request.User.Role, event)) {
+            return;
+        }
+
+        err := stream.Send(event.Type, event);
+        if (err != nil) {
+            log.Debug("Failed to send grading event.", err, request.Assignment, request.User);
+        }
+    };
+
+    return submit(request, options);
+}
+
+// Hidden questions (and raw output for assignments with hidden questions) are only sent to users that can see them.
+func shouldSendGradingEvent(assignment *model.Assignment, role model.UserRole, event *grader.GradingEvent) bool {
+    switch (event.Type) {
+        case grader.EVENT_STDOUT, grader.EVENT_STDERR:
+            return !assignment.ShouldHideGraderOutput(role);
+        case grader.EVENT_QUESTION:
+            return (!event.Question.Hidden || assignment.CanSeeHiddenQuestions(role));
+        default:
+            return true;
+    }
+}
@@ -0,0 +1,110 @@
+package submission
+
+import (
+    "testing"
+    "time"
+
+    "github.com/edulinq/autograder/api/core"
+    "github.com/edulinq/autograder/common"
+    "github.com/edulinq/autograder/config"
+    "github.com/edulinq/autograder/grader"
+    "github.com/edulinq/autograder/model"
+    "github.com/edulinq/autograder/util"
+)
+
+func TestSubmitStream(test *testing.T) {
+    testSubmissions, err := grader.GetTestSubmissions(config.GetCourseImportDir());
+    if (err != nil) {
+        test.Fatalf("Failed to get test submissions in '%s': '%v'.", config.GetCourseImportDir(), err);
+    }
+
+    for i, testSubmission := range testSubmissions {
+        fields := map[string]any{
+            "course-id": testSubmission.Assignment.GetCourse().GetID(),
+            "assignment-id": testSubmission.Assignment.GetID(),
+        }
+
+        events, response := core.SendTestAPIStreamRequestFull(test, core.NewEndpoint(`submission/submit/stream`), fields, testSubmission.Files, model.RoleStudent);
+        if (!response.Success) {
+            test.Errorf("Case %d: Response is not a success when it should be: '%v'.", i, response);
+            continue;
+        }
+
+        stages := make([]string, 0);
+        for _, event := range events {
+            // Students should never get raw output for assignments with unreleased hidden questions.
+            isOutput := ((event.Type == grader.EVENT_STDOUT) || (event.Type == grader.EVENT_STDERR));
+            if (isOutput && testSubmission.Assignment.ShouldHideGraderOutput(model.RoleStudent)) {
+                test.Errorf("Case %d: Student got raw grader output: '%v'.", i, event);
+            }
+
+            if (event.Type != grader.EVENT_STAGE) {
+                continue;
+            }
+
+            var gradingEvent grader.GradingEvent;
+            util.MustJSONFromString(event.Data, &gradingEvent);
+            stages = append(stages, gradingEvent.Stage);
+        }
+
+        expectedStages := []string{grader.STAGE_PREP, grader.STAGE_GRADING, grader.STAGE_DONE};
+        if (util.MustToJSON(expectedStages) != util.MustToJSON(stages)) {
+            test.Errorf("Case %d: Unexpected stages. Expected: '%v', Actual: '%v'.", i, expectedStages, stages);
+            continue;
+        }
+
+        var responseContent SubmitResponse;
+        util.MustJSONFromString(util.MustToJSON(response.Content), &responseContent);
+
+        if (!responseContent.GradingSucess) {
+            test.Errorf("Case %d: Response is not a grading success when it should be: '%v'.", i, responseContent);
+            continue;
+        }
+
+        if (!responseContent.GradingInfo.Equals(*testSubmission.TestSubmission.GradingInfo, !testSubmission.TestSubmission.IgnoreMessages)) {
+            test.Errorf("Case %d: Actual output:\n---\n%v\n---\ndoes not match expected output:\n---\n%v\n---\n.",
+                    i, responseContent.GradingInfo, testSubmission.TestSubmission.GradingInfo);
+            continue;
+        }
+    }
+}
+
+func TestShouldSendGradingEvent(test *testing.T) {
+    stdout := &grader.GradingEvent{Type: grader.EVENT_STDOUT, Text: "a\n"};
+    stderr := &grader.GradingEvent{Type: grader.EVENT_STDERR, Text: "b\n"};
+    stage := &grader.GradingEvent{Type: grader.EVENT_STAGE, Stage: grader.STAGE_GRADING};
+    question := &grader.GradingEvent{Type: grader.EVENT_QUESTION, Question: &model.GradedQuestion{Name: "Q1"}};
+    hiddenQuestion := &grader.GradingEvent{Type: grader.EVENT_QUESTION, Question: &model.GradedQuestion{Name: "Q2", Hidden: true}};
+
+    normal := &model.Assignment{};
+    hidden := &model.Assignment{HiddenQuestions: []string{"Q2"}};
+    released := &model.Assignment{HiddenQuestions: []string{"Q2"}, HiddenReleaseTime: common.TimestampFromTime(time.Now().Add(-time.Hour))};
+
+    testCases := []struct{assignment *model.Assignment; role model.UserRole; event *grader.GradingEvent; expected bool}{
+        // Students get output on assignments without hidden questions.
+        {normal, model.RoleStudent, stdout, true},
+        {normal, model.RoleStudent, stderr, true},
+        {normal, model.RoleStudent, stage, true},
+        {normal, model.RoleStudent, question, true},
+
+        // But not on assignments with unreleased hidden questions.
+        {hidden, model.RoleStudent, stdout, false},
+        {hidden, model.RoleStudent, stderr, false},
+        {hidden, model.RoleStudent, stage, true},
+        {hidden, model.RoleStudent, question, true},
+        {hidden, model.RoleStudent, hiddenQuestion, false},
+
+        {released, model.RoleStudent, stdout, true},
+        {released, model.RoleStudent, hiddenQuestion, true},
+
+        {hidden, model.RoleGrader, stdout, true},
+        {hidden, model.RoleGrader, hiddenQuestion, true},
+    };
+
+    for i, testCase := range testCases {
+        actual := shouldSendGradingEvent(testCase.assignment, testCase.role, testCase.event);
+        if (testCase.expected != actual) {
+            test.Errorf("Case %d: Unexpected result. Expected: %v, Actual: %v.", i, testCase.expected, actual);
+        }
+    }
+}
@@ -71,6 +71,39 @@ func TestSubmit(test *testing.T) {
     }
 }
 
+func TestSubmitHiddenQuestions(test *testing.T) {
+    defer db.ResetForTesting();
+
+    testCases := []struct{role model.UserRole; released bool; expectedQuestions []string; score float64; maxPoints float64}{
+        {model.RoleStudent, false, []string{"Q1", "Style"}, 1.0, 1.0},
+        {model.RoleStudent, true, []string{"Q1", "Q2", "Style"}, 2.0, 2.0},
+        {model.RoleGrader, false, []string{"Q1", "Q2", "Style"}, 2.0, 2.0},
+    };
+
+    for i, testCase := range testCases {
+        prepHiddenQuestionsTest(test, testCase.released);
+
+        assignment := db.MustGetTestAssignment();
+        paths := []string{filepath.Join(assignment.GetSourceDir(), SUBMISSION_RELPATH)};
+
+        response := core.SendTestAPIRequestFull(test, core.NewEndpoint(`submission/submit`), nil, paths, testCase.role);
+        if (!response.Success) {
+            test.Errorf("Case %d: Response is not a success when it should be: '%v'.", i, response);
+            continue;
+        }
+
+        var responseContent SubmitResponse;
+        util.MustJSONFromString(util.MustToJSON(response.Content), &responseContent);
+
+        if (!responseContent.GradingSucess) {
+            test.Errorf("Case %d: Response is not a grading success when it should be: '%v'.", i, responseContent);
+            continue;
+        }
+
+        checkHiddenQuestions(test, i, responseContent.GradingInfo, testCase.expectedQuestions, testCase.score, testCase.maxPoints);
+    }
+}
+
 func TestRejectSubmissionMaxAttempts(test *testing.T) {
     db.ResetForTesting();
     defer db.ResetForTesting();
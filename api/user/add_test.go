@@ -162,3 +162,45 @@ func TestUserAdd(test *testing.T) {
         }
     }
 }
+
+// A course admin cannot take over an account that exists outside of their course by adding it.
+func TestUserAddExistingAccount(test *testing.T) {
+    defer db.ResetForTesting();
+
+    for _, force := range []bool{false, true} {
+        fields := map[string]any{
+            "force": force,
+            "skip-emails": true,
+            "skip-lms-sync": true,
+            "new-users": []*core.UserInfoWithPass{
+                &core.UserInfoWithPass{core.UserInfo{db.TEST_SERVER_ADMIN_EMAIL, "new name", model.RoleStudent, ""}, util.Sha256HexFromString("new-pass")},
+            },
+        };
+
+        response := core.SendTestAPIRequestFull(test, core.NewEndpoint(`user/add`), fields, nil, model.RoleAdmin);
+        if (!response.Success) {
+            test.Fatalf("Force %v: Response is not a success when it should be: '%v'.", force, response);
+        }
+    }
+
+    serverUser, err := db.GetServerUser(db.TEST_SERVER_ADMIN_EMAIL);
+    if (err != nil) {
+        test.Fatalf("Failed to get server admin: '%v'.", err);
+    }
+
+    if (serverUser.ToUser().CheckPassword(util.Sha256HexFromString("new-pass"))) {
+        test.Fatalf("Account password was changed by a course admin.");
+    }
+
+    if (!serverUser.ToUser().CheckPassword(util.Sha256HexFromString(db.TEST_SERVER_ADMIN_PASS))) {
+        test.Fatalf("Account lost its original password.");
+    }
+
+    if ((serverUser.Name != db.TEST_SERVER_ADMIN_PASS) || !serverUser.IsAdmin()) {
+        test.Fatalf("Account was changed by a course admin: '%s'.", util.MustToJSONIndent(serverUser));
+    }
+
+    if (!serverUser.IsEnrolled(db.MustGetTestCourse().GetID())) {
+        test.Fatalf("Account was not enrolled in the course.");
+    }
+}
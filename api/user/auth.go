@@ -7,6 +7,7 @@ import (
 type AuthRequest struct {
     core.APIRequestCourseUserContext
     core.MinRoleOther
+    core.MinTokenScopeRead
 
     TargetUser core.TargetUser `json:"target-email"`
     TargetPass core.NonEmptyString `json:"target-pass"`
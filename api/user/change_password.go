@@ -32,6 +32,21 @@ func HandleChangePassword(request *ChangePasswordRequest) (*ChangePasswordRespon
                 "Cannot modify a user with a higher role.").Add("target-user", request.TargetUser.User.Email);
     }
 
+    // Accounts are server-wide (they may be in other courses),
+    // so only the account holder or a server admin can change an account's password.
+    if ((request.TargetUser.Email != request.User.Email) && !request.User.ServerAdmin) {
+        return nil, core.NewBadPermissionsError("-849", &request.APIRequestCourseUserContext, model.RoleOwner,
+                "Only the account holder or a server admin can change a password.").Add("target-user", request.TargetUser.User.Email);
+    }
+
+    // Changing someone else's password takes over their account, so it always needs a second factor.
+    if (request.TargetUser.Email != request.User.Email) {
+        apiErr := request.RequireSecondFactor();
+        if (apiErr != nil) {
+            return nil, apiErr;
+        }
+    }
+
     var err error;
     var pass string;
 
@@ -2,6 +2,7 @@ package user
 
 import (
     "testing"
+    "time"
 
     "github.com/edulinq/autograder/api/core"
     "github.com/edulinq/autograder/db"
@@ -16,45 +17,47 @@ func TestChangePassword(test *testing.T) {
     defer db.ResetForTesting();
 
     testCases := []struct{
-            role model.UserRole; permError bool; advPermError bool;
+            role model.UserRole; permError bool; advPermError bool; accountPermError bool;
             target string; newPass string;
             foundUser bool; hasEmail bool;
     }{
         // Self (context)
-        {model.RoleOther,   false, false, "", "new-pass", true, false},
-        {model.RoleStudent, false, false, "", "new-pass", true, false},
-        {model.RoleGrader,  false, false, "", "new-pass", true, false},
-        {model.RoleAdmin,   false, false, "", "new-pass", true, false},
-        {model.RoleOwner,   false, false, "", "new-pass", true, false},
+        {model.RoleOther,   false, false, false, "", "new-pass", true, false},
+        {model.RoleStudent, false, false, false, "", "new-pass", true, false},
+        {model.RoleGrader,  false, false, false, "", "new-pass", true, false},
+        {model.RoleAdmin,   false, false, false, "", "new-pass", true, false},
+        {model.RoleOwner,   false, false, false, "", "new-pass", true, false},
 
         // Self (direct)
-        {model.RoleOther,   false, false, "other@test.com",   "new-pass", true, false},
-        {model.RoleStudent, false, false, "student@test.com", "new-pass", true, false},
-        {model.RoleGrader,  false, false, "grader@test.com",  "new-pass", true, false},
-        {model.RoleAdmin,   false, false, "admin@test.com",   "new-pass", true, false},
-        {model.RoleOwner,   false, false, "owner@test.com",   "new-pass", true, false},
+        {model.RoleOther,   false, false, false, "other@test.com",   "new-pass", true, false},
+        {model.RoleStudent, false, false, false, "student@test.com", "new-pass", true, false},
+        {model.RoleGrader,  false, false, false, "grader@test.com",  "new-pass", true, false},
+        {model.RoleAdmin,   false, false, false, "admin@test.com",   "new-pass", true, false},
+        {model.RoleOwner,   false, false, false, "owner@test.com",   "new-pass", true, false},
 
         // Other
-        {model.RoleOther,   true,  false, "student@test.com", "new-pass", true, false},
-        {model.RoleStudent, true,  false, "other@test.com",   "new-pass", true, false},
-        {model.RoleGrader,  true,  false, "other@test.com",   "new-pass", true, false},
-        {model.RoleAdmin,   false, false, "other@test.com",   "new-pass", true, false},
-        {model.RoleOwner,   false, false, "other@test.com",   "new-pass", true, false},
+        {model.RoleOther,   true,  false, false, "student@test.com", "new-pass", true, false},
+        {model.RoleStudent, true,  false, false, "other@test.com",   "new-pass", true, false},
+        {model.RoleGrader,  true,  false, false, "other@test.com",   "new-pass", true, false},
+
+        // Other (course staff cannot change passwords, since accounts are server-wide).
+        {model.RoleAdmin,   false, false, true,  "other@test.com",   "new-pass", true, false},
+        {model.RoleOwner,   false, false, true,  "other@test.com",   "new-pass", true, false},
 
         // Advanced Perm Error
-        {model.RoleAdmin, false, true,  "owner@test.com", "new-pass", true, false},
-        {model.RoleOwner, false, false, "admin@test.com", "new-pass", true, false},
+        {model.RoleAdmin, false, true,  false, "owner@test.com", "new-pass", true, false},
+        {model.RoleOwner, false, false, true,  "admin@test.com", "new-pass", true, false},
 
         // Missing
-        {model.RoleOther,   true,  false, "ZZZ@test.com", "new-pass", false, false},
-        {model.RoleStudent, true,  false, "ZZZ@test.com", "new-pass", false, false},
-        {model.RoleGrader,  true,  false, "ZZZ@test.com", "new-pass", false, false},
-        {model.RoleAdmin,   false, false, "ZZZ@test.com", "new-pass", false, false},
-        {model.RoleOwner,   false, false, "ZZZ@test.com", "new-pass", false, false},
+        {model.RoleOther,   true,  false, false, "ZZZ@test.com", "new-pass", false, false},
+        {model.RoleStudent, true,  false, false, "ZZZ@test.com", "new-pass", false, false},
+        {model.RoleGrader,  true,  false, false, "ZZZ@test.com", "new-pass", false, false},
+        {model.RoleAdmin,   false, false, false, "ZZZ@test.com", "new-pass", false, false},
+        {model.RoleOwner,   false, false, false, "ZZZ@test.com", "new-pass", false, false},
 
         // Email
-        {model.RoleAdmin,   false, false, "other@test.com", "", true, true},
-        {model.RoleAdmin,   false, false, "admin@test.com", "", true, true},
+        {model.RoleAdmin,   false, false, true,  "other@test.com", "", true, false},
+        {model.RoleAdmin,   false, false, false, "admin@test.com", "", true, true},
     };
 
     for i, testCase := range testCases {
@@ -73,6 +76,8 @@ func TestChangePassword(test *testing.T) {
                 expectedLocator = "-033";
             } else if (testCase.advPermError) {
                 expectedLocator = "-805";
+            } else if (testCase.accountPermError) {
+                expectedLocator = "-849";
             }
 
             if (expectedLocator == "") {
@@ -87,6 +92,11 @@ func TestChangePassword(test *testing.T) {
             continue;
         }
 
+        if (testCase.permError || testCase.advPermError || testCase.accountPermError) {
+            test.Errorf("Case %d: Response is a success when it should not be: '%v'.", i, response);
+            continue;
+        }
+
         var responseContent ChangePasswordResponse;
         util.MustJSONFromString(util.MustToJSON(response.Content), &responseContent);
 
@@ -104,3 +114,86 @@ func TestChangePassword(test *testing.T) {
         }
     }
 }
+
+// Server admins can change any account's password.
+func TestChangePasswordServerAdmin(test *testing.T) {
+    defer db.ResetForTesting();
+
+    fields := map[string]any{
+        "course-id": db.TEST_COURSE_ID,
+        "target-email": "owner@test.com",
+        "new-pass": util.Sha256HexFromString("new-pass"),
+    };
+
+    response := core.SendTestServerAdminAPIRequest(test, core.NewEndpoint(`user/change/pass`), fields);
+    if (!response.Success) {
+        test.Fatalf("Response is not a success when it should be: '%v'.", response);
+    }
+
+    serverUser, err := db.GetServerUser("owner@test.com");
+    if (err != nil) {
+        test.Fatalf("Failed to get user: '%v'.", err);
+    }
+
+    if (!serverUser.ToUser().CheckPassword(util.Sha256HexFromString("new-pass"))) {
+        test.Fatalf("Password was not changed.");
+    }
+}
+
+// A server admin with TOTP needs a second factor to change someone else's password.
+func TestChangePasswordServerAdminSecondFactor(test *testing.T) {
+    db.ResetForTesting();
+    defer db.ResetForTesting();
+
+    defer core.ClearAllLockouts();
+
+    fields := map[string]any{"course-id": db.TEST_COURSE_ID};
+    response := core.SendTestServerAdminAPIRequest(test, core.NewEndpoint(`user/totp/enroll`), fields);
+    if (!response.Success) {
+        test.Fatalf("Enrollment was not successful: '%v'.", response);
+    }
+
+    var enrollment TOTPEnrollResponse;
+    util.MustJSONFromString(util.MustToJSON(response.Content), &enrollment);
+
+    fields["code"] = getTOTPCodeForTest(test, enrollment.Secret, time.Now(), 0);
+    response = core.SendTestServerAdminAPIRequest(test, core.NewEndpoint(`user/totp/confirm`), fields);
+    if (!response.Success) {
+        test.Fatalf("Confirmation was not successful: '%v'.", response);
+    }
+
+    fields = map[string]any{
+        "course-id": db.TEST_COURSE_ID,
+        "target-email": "student@test.com",
+        "new-pass": util.Sha256HexFromString("new-pass"),
+    };
+
+    response = core.SendTestServerAdminAPIRequest(test, core.NewEndpoint(`user/change/pass`), fields);
+    if (response.Locator != "-063") {
+        test.Fatalf("Unexpected response without a second factor. Expected '-063', found: '%v'.", response);
+    }
+
+    serverUser, err := db.GetServerUser("student@test.com");
+    if (err != nil) {
+        test.Fatalf("Failed to get user: '%v'.", err);
+    }
+
+    if (serverUser.ToUser().CheckPassword(util.Sha256HexFromString("new-pass"))) {
+        test.Fatalf("Password was changed without a second factor.");
+    }
+
+    fields["user-totp"] = getTOTPCodeForTest(test, enrollment.Secret, time.Now(), 1);
+    response = core.SendTestServerAdminAPIRequest(test, core.NewEndpoint(`user/change/pass`), fields);
+    if (!response.Success) {
+        test.Fatalf("Request with a TOTP code was not successful: '%v'.", response);
+    }
+
+    serverUser, err = db.GetServerUser("student@test.com");
+    if (err != nil) {
+        test.Fatalf("Failed to get user: '%v'.", err);
+    }
+
+    if (!serverUser.ToUser().CheckPassword(util.Sha256HexFromString("new-pass"))) {
+        test.Fatalf("Password was not changed with a second factor.");
+    }
+}
@@ -0,0 +1,59 @@
+package user
+
+import (
+    "github.com/edulinq/autograder/api/core"
+    "github.com/edulinq/autograder/db"
+    "github.com/edulinq/autograder/model"
+)
+
+type CoursesRequest struct {
+    core.APIRequestCourseUserContext
+    core.MinRoleOther
+    core.MinTokenScopeRead
+}
+
+type CoursesResponse struct {
+    Courses []*CourseInfo `json:"courses"`
+}
+
+// A course that a user is enrolled in.
+type CourseInfo struct {
+    ID string `json:"id"`
+    Name string `json:"name"`
+    Role model.UserRole `json:"role"`
+}
+
+// List all the courses (not just the context course) that the context user is enrolled in.
+func HandleCourses(request *CoursesRequest) (*CoursesResponse, *core.APIError) {
+    serverUser, err := db.GetServerUser(request.User.Email);
+    if (err != nil) {
+        return nil, core.NewInternalError("-831", &request.APIRequestCourseUserContext,
+                "Failed to get user account.").Err(err);
+    }
+
+    response := CoursesResponse{Courses: make([]*CourseInfo, 0)};
+    if (serverUser == nil) {
+        return &response, nil;
+    }
+
+    for _, courseID := range serverUser.GetCourseIDs() {
+        course, err := db.GetCourse(courseID);
+        if (err != nil) {
+            return nil, core.NewInternalError("-832", &request.APIRequestCourseUserContext,
+                    "Failed to get enrolled course.").Err(err).Add("enrolled-course", courseID);
+        }
+
+        // Skip enrollments for courses that no longer exist.
+        if (course == nil) {
+            continue;
+        }
+
+        response.Courses = append(response.Courses, &CourseInfo{
+            ID: course.GetID(),
+            Name: course.GetDisplayName(),
+            Role: serverUser.Enrollments[courseID].Role,
+        });
+    }
+
+    return &response, nil;
+}
@@ -0,0 +1,58 @@
+package user
+
+import (
+    "reflect"
+    "testing"
+
+    "github.com/edulinq/autograder/api/core"
+    "github.com/edulinq/autograder/db"
+    "github.com/edulinq/autograder/model"
+    "github.com/edulinq/autograder/util"
+)
+
+func TestUserCourses(test *testing.T) {
+    defer db.ResetForTesting();
+
+    allCourseIDs := []string{"course-languages", "course-with-lms", "course-without-source", "course101", "course101-with-zero-limit"};
+
+    testCases := []struct{ role model.UserRole; removeCourseIDs []string; expected []string }{
+        {model.RoleStudent, nil, allCourseIDs},
+        {model.RoleOwner, nil, allCourseIDs},
+        {model.RoleStudent, []string{"course-languages", "course-with-lms"}, []string{"course-without-source", "course101", "course101-with-zero-limit"}},
+    };
+
+    for i, testCase := range testCases {
+        db.ResetForTesting();
+
+        email := model.GetRoleString(testCase.role) + "@test.com";
+        for _, courseID := range testCase.removeCourseIDs {
+            _, err := db.RemoveUser(db.MustGetCourse(courseID), email);
+            if (err != nil) {
+                test.Fatalf("Case %d: Failed to remove user: '%v'.", i, err);
+            }
+        }
+
+        response := core.SendTestAPIRequestFull(test, core.NewEndpoint(`user/courses`), nil, nil, testCase.role);
+        if (!response.Success) {
+            test.Errorf("Case %d: Response is not a success when it should be: '%v'.", i, response);
+            continue;
+        }
+
+        var responseContent CoursesResponse;
+        util.MustJSONFromString(util.MustToJSON(response.Content), &responseContent);
+
+        actual := make([]string, 0, len(responseContent.Courses));
+        for _, course := range responseContent.Courses {
+            actual = append(actual, course.ID);
+
+            if (course.Role != testCase.role) {
+                test.Errorf("Case %d: Unexpected role in course '%s'. Expected: '%s', actual: '%s'.", i, course.ID, testCase.role, course.Role);
+            }
+        }
+
+        if (!reflect.DeepEqual(testCase.expected, actual)) {
+            test.Errorf("Case %d: Unexpected courses. Expected: '%v', actual: '%v'.", i, testCase.expected, actual);
+            continue;
+        }
+    }
+}
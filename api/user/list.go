@@ -9,6 +9,7 @@ import (
 type ListRequest struct {
     core.APIRequestCourseUserContext
     core.MinRoleGrader
+    core.MinTokenScopeRead
     Users core.CourseUsers `json:"-"`
 }
 
@@ -0,0 +1,74 @@
+package user
+
+import (
+    "time"
+
+    "github.com/edulinq/autograder/api/core"
+    "github.com/edulinq/autograder/common"
+    "github.com/edulinq/autograder/config"
+    "github.com/edulinq/autograder/db"
+    "github.com/edulinq/autograder/model"
+)
+
+type LoginRequest struct {
+    core.APIRequestCourseUserContext
+    core.MinRoleOther
+}
+
+// A session token can be sent in the `user-session` field of any request (in place of a password).
+// Once the session token expires, the refresh token can be used to get new tokens (see HandleLoginRefresh()).
+type LoginResponse struct {
+    SessionToken string `json:"session-token"`
+    SessionExpirationTime common.Timestamp `json:"session-expiration-time"`
+    RefreshToken string `json:"refresh-token"`
+    RefreshExpirationTime common.Timestamp `json:"refresh-expiration-time"`
+}
+
+func HandleLogin(request *LoginRequest) (*LoginResponse, *core.APIError) {
+    // Logins require a password.
+    if ((request.Token != nil) || (request.Session != nil)) {
+        return nil, core.NewBadCourseRequestError("-815", &request.APIRequestCourseUserContext,
+                "Logging in requires a password (not an API token or session).");
+    }
+
+    response, err := newLoginResponse(request.Course.GetID(), request.User, request.SecondFactor);
+    if (err != nil) {
+        return nil, core.NewInternalError("-816", &request.APIRequestCourseUserContext,
+                "Failed to create session tokens.").Err(err);
+    }
+
+    return response, nil;
+}
+
+// If |secondFactor| is true, then the sessions can be used for endpoints that require two-factor authentication.
+func newLoginResponse(courseID string, user *model.User, secondFactor bool) (*LoginResponse, error) {
+    keys, err := db.GetSessionKeys();
+    if (err != nil) {
+        return nil, err;
+    }
+
+    sessionClaims := model.NewSessionClaims(model.SESSION_TOKEN_TYPE_SESSION, courseID, user,
+            time.Duration(config.SESSION_TTL_MINS.Get()) * time.Minute);
+    sessionClaims.SecondFactor = secondFactor;
+    sessionToken, err := model.SignSessionClaims(keys[0], sessionClaims);
+    if (err != nil) {
+        return nil, err;
+    }
+
+    refreshClaims := model.NewSessionClaims(model.SESSION_TOKEN_TYPE_REFRESH, courseID, user,
+            time.Duration(config.SESSION_REFRESH_TTL_HOURS.Get()) * time.Hour);
+    refreshClaims.SecondFactor = secondFactor;
+    refreshToken, err := model.SignSessionClaims(keys[0], refreshClaims);
+    if (err != nil) {
+        return nil, err;
+    }
+
+    response := LoginResponse{
+        SessionToken: sessionToken,
+        SessionExpirationTime: sessionClaims.ExpirationTime,
+        RefreshToken: refreshToken,
+        RefreshExpirationTime: refreshClaims.ExpirationTime,
+    };
+
+    return &response, nil;
+}
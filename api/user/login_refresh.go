@@ -0,0 +1,54 @@
+package user
+
+import (
+    "github.com/edulinq/autograder/api/core"
+    "github.com/edulinq/autograder/db"
+    "github.com/edulinq/autograder/model"
+)
+
+// Refreshing does not need any other credentials, the refresh token identifies the course and user.
+type LoginRefreshRequest struct {
+    core.APIRequest
+
+    RefreshToken core.NonEmptyString `json:"refresh-token"`
+}
+
+func HandleLoginRefresh(request *LoginRefreshRequest) (*LoginResponse, *core.APIError) {
+    keys, err := db.GetSessionKeys();
+    if (err != nil) {
+        return nil, core.NewBareInternalError("-817", request.Endpoint, "Failed to get session keys.").Err(err);
+    }
+
+    claims, err := model.ParseSessionToken(string(request.RefreshToken), model.SESSION_TOKEN_TYPE_REFRESH, keys);
+    if (err != nil) {
+        return nil, core.NewBadRequestError("-818", &request.APIRequest, "Invalid or expired refresh token.").Err(err);
+    }
+
+    course, err := db.GetCourse(claims.CourseID);
+    if (err != nil) {
+        return nil, core.NewBareInternalError("-819", request.Endpoint, "Failed to get course.").Err(err).Course(claims.CourseID);
+    }
+
+    var user *model.User = nil;
+    if (course != nil) {
+        user, err = db.GetUser(course, claims.Email);
+        if (err != nil) {
+            return nil, core.NewBareInternalError("-820", request.Endpoint, "Failed to get user.").Err(err).
+                    Course(claims.CourseID).User(claims.Email);
+        }
+    }
+
+    // The course or user may have been removed, or the user's password may have changed.
+    if ((user == nil) || !claims.Matches(course.GetID(), user)) {
+        return nil, core.NewBadRequestError("-818", &request.APIRequest, "Invalid or expired refresh token.").
+                Course(claims.CourseID).User(claims.Email);
+    }
+
+    response, err := newLoginResponse(course.GetID(), user, claims.SecondFactor);
+    if (err != nil) {
+        return nil, core.NewBareInternalError("-821", request.Endpoint, "Failed to create session tokens.").Err(err).
+                Course(claims.CourseID).User(claims.Email);
+    }
+
+    return response, nil;
+}
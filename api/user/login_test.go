@@ -0,0 +1,126 @@
+package user
+
+import (
+    "testing"
+
+    "github.com/edulinq/autograder/api/core"
+    "github.com/edulinq/autograder/db"
+    "github.com/edulinq/autograder/model"
+    "github.com/edulinq/autograder/util"
+)
+
+func TestLoginSession(test *testing.T) {
+    defer db.ResetForTesting();
+    db.ResetForTesting();
+
+    login := loginForTest(test, model.RoleStudent);
+
+    // Use the session in place of a password.
+    sessionFields := map[string]any{
+        "user-pass": "",
+        "user-session": login.SessionToken,
+    };
+
+    response := core.SendTestAPIRequestFull(test, core.NewEndpoint(`user/token/list`), sessionFields, nil, model.RoleStudent);
+    if (!response.Success) {
+        test.Fatalf("Session request is not a success when it should be: '%v'.", response);
+    }
+
+    // Sessions cannot be used to log in again.
+    response = core.SendTestAPIRequestFull(test, core.NewEndpoint(`user/login`), sessionFields, nil, model.RoleStudent);
+    if (response.Locator != "-815") {
+        test.Fatalf("Unexpected response when logging in with a session. Expected locator '-815', found: '%v'.", response);
+    }
+
+    // Sessions only work for their own user.
+    response = core.SendTestAPIRequestFull(test, core.NewEndpoint(`user/token/list`), sessionFields, nil, model.RoleGrader);
+    if (response.HTTPStatus != core.HTTP_STATUS_AUTH_ERROR) {
+        test.Fatalf("Unexpected response when using another user's session. Expected an auth error, found: '%v'.", response);
+    }
+
+    // A refresh token is not a session token.
+    refreshAsSessionFields := map[string]any{
+        "user-pass": "",
+        "user-session": login.RefreshToken,
+    };
+
+    response = core.SendTestAPIRequestFull(test, core.NewEndpoint(`user/token/list`), refreshAsSessionFields, nil, model.RoleStudent);
+    if (response.HTTPStatus != core.HTTP_STATUS_AUTH_ERROR) {
+        test.Fatalf("Unexpected response when using a refresh token as a session. Expected an auth error, found: '%v'.", response);
+    }
+}
+
+func TestLoginRefresh(test *testing.T) {
+    defer db.ResetForTesting();
+    db.ResetForTesting();
+
+    login := loginForTest(test, model.RoleStudent);
+
+    fields := map[string]any{
+        "refresh-token": login.RefreshToken,
+    };
+
+    response := core.SendTestAPIRequestFull(test, core.NewEndpoint(`user/login/refresh`), fields, nil, model.RoleStudent);
+    if (!response.Success) {
+        test.Fatalf("Refresh is not a success when it should be: '%v'.", response);
+    }
+
+    var refreshed LoginResponse;
+    util.MustJSONFromString(util.MustToJSON(response.Content), &refreshed);
+
+    sessionFields := map[string]any{
+        "user-pass": "",
+        "user-session": refreshed.SessionToken,
+    };
+
+    response = core.SendTestAPIRequestFull(test, core.NewEndpoint(`user/token/list`), sessionFields, nil, model.RoleStudent);
+    if (!response.Success) {
+        test.Fatalf("Refreshed session request is not a success when it should be: '%v'.", response);
+    }
+
+    // Session tokens cannot be used to refresh.
+    badFields := map[string]any{
+        "refresh-token": login.SessionToken,
+    };
+
+    response = core.SendTestAPIRequestFull(test, core.NewEndpoint(`user/login/refresh`), badFields, nil, model.RoleStudent);
+    if (response.Locator != "-818") {
+        test.Fatalf("Unexpected response when refreshing with a session token. Expected locator '-818', found: '%v'.", response);
+    }
+
+    // Changing the password ends all sessions.
+    passFields := map[string]any{
+        "new-pass": util.Sha256HexFromString("new-pass"),
+    };
+
+    response = core.SendTestAPIRequestFull(test, core.NewEndpoint(`user/change/pass`), passFields, nil, model.RoleStudent);
+    if (!response.Success) {
+        test.Fatalf("Failed to change password: '%v'.", response);
+    }
+
+    response = core.SendTestAPIRequestFull(test, core.NewEndpoint(`user/login/refresh`), fields, nil, model.RoleStudent);
+    if (response.Locator != "-818") {
+        test.Fatalf("Unexpected response when refreshing after a password change. Expected locator '-818', found: '%v'.", response);
+    }
+
+    response = core.SendTestAPIRequestFull(test, core.NewEndpoint(`user/token/list`), sessionFields, nil, model.RoleStudent);
+    if (response.HTTPStatus != core.HTTP_STATUS_AUTH_ERROR) {
+        test.Fatalf("Unexpected response when using a session after a password change. Expected an auth error, found: '%v'.", response);
+    }
+}
+
+func loginForTest(test *testing.T, role model.UserRole) *LoginResponse {
+    response := core.SendTestAPIRequestFull(test, core.NewEndpoint(`user/login`), nil, nil, role);
+    if (!response.Success) {
+        test.Fatalf("Login is not a success when it should be: '%v'.", response);
+    }
+
+    var login LoginResponse;
+    util.MustJSONFromString(util.MustToJSON(response.Content), &login);
+
+    if ((login.SessionToken == "") || (login.RefreshToken == "")) {
+        test.Fatalf("Login is missing tokens: '%+v'.", login);
+    }
+
+    return &login;
+}
@@ -0,0 +1,74 @@
+package user
+
+import (
+    "github.com/edulinq/autograder/api/core"
+    "github.com/edulinq/autograder/db"
+    "github.com/edulinq/autograder/log"
+    "github.com/edulinq/autograder/model"
+    "github.com/edulinq/autograder/oidc"
+)
+
+// Finish a single sign-on login (see HandleOIDCStart()) and get a session.
+type OIDCFinishRequest struct {
+    core.APIRequest
+
+    Code core.NonEmptyString `json:"code"`
+    State core.NonEmptyString `json:"state"`
+}
+
+func HandleOIDCFinish(request *OIDCFinishRequest) (*LoginResponse, *core.APIError) {
+    if (!oidc.IsEnabled()) {
+        return nil, core.NewBadRequestError("-822", &request.APIRequest, "Single sign-on is not enabled on this server.");
+    }
+
+    keys, err := db.GetSessionKeys();
+    if (err != nil) {
+        return nil, core.NewBareInternalError("-826", request.Endpoint, "Failed to get session keys.").Err(err);
+    }
+
+    state, err := model.ParseSessionToken(string(request.State), model.SESSION_TOKEN_TYPE_OIDC_STATE, keys);
+    if (err != nil) {
+        return nil, core.NewBadRequestError("-828", &request.APIRequest, "Invalid or expired single sign-on state.").Err(err);
+    }
+
+    provider, err := oidc.GetProvider();
+    if (err != nil) {
+        return nil, core.NewBareInternalError("-825", request.Endpoint, "Failed to get single sign-on provider.").Err(err);
+    }
+
+    email, err := provider.Login(string(request.Code), state.Nonce);
+    if (err != nil) {
+        return nil, core.NewBadRequestError("-829", &request.APIRequest, "Single sign-on failed.").Err(err).Course(state.CourseID);
+    }
+
+    course, err := db.GetCourse(state.CourseID);
+    if (err != nil) {
+        return nil, core.NewBareInternalError("-823", request.Endpoint, "Failed to get course.").Err(err).Course(state.CourseID);
+    }
+
+    var user *model.User = nil;
+    if (course != nil) {
+        user, err = db.GetUser(course, email);
+        if (err != nil) {
+            return nil, core.NewBareInternalError("-820", request.Endpoint, "Failed to get user.").Err(err).
+                    Course(state.CourseID).User(email);
+        }
+    }
+
+    // Single sign-on only logs in existing users, it does not add users to a course.
+    if (user == nil) {
+        return nil, core.NewBadRequestError("-830", &request.APIRequest, "No user in this course matches the single sign-on account.").
+                Course(state.CourseID).User(email);
+    }
+
+    log.Info("User logged in with single sign-on.", course, user);
+
+    // The identity provider's login does not count as a second factor (we cannot tell what it checked).
+    response, err := newLoginResponse(course.GetID(), user, false);
+    if (err != nil) {
+        return nil, core.NewBareInternalError("-821", request.Endpoint, "Failed to create session tokens.").Err(err).
+                Course(state.CourseID).User(email);
+    }
+
+    return response, nil;
+}
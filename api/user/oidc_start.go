@@ -0,0 +1,71 @@
+package user
+
+import (
+    "time"
+
+    "github.com/edulinq/autograder/api/core"
+    "github.com/edulinq/autograder/common"
+    "github.com/edulinq/autograder/db"
+    "github.com/edulinq/autograder/model"
+    "github.com/edulinq/autograder/oidc"
+    "github.com/edulinq/autograder/util"
+)
+
+// How long a user has to log in at the identity provider.
+const OIDC_STATE_TTL = 10 * time.Minute;
+
+// Start a single sign-on login.
+// The client should send the user to the returned URL,
+// the identity provider will then send the user to the configured redirect URL (see config.OIDC_REDIRECT_URL)
+// with a code and state that should be passed to the user/oidc/finish endpoint.
+type OIDCStartRequest struct {
+    core.APIRequest
+
+    CourseID core.NonEmptyString `json:"course-id"`
+}
+
+type OIDCStartResponse struct {
+    AuthorizationURL string `json:"authorization-url"`
+}
+
+func HandleOIDCStart(request *OIDCStartRequest) (*OIDCStartResponse, *core.APIError) {
+    if (!oidc.IsEnabled()) {
+        return nil, core.NewBadRequestError("-822", &request.APIRequest, "Single sign-on is not enabled on this server.");
+    }
+
+    course, err := db.GetCourse(string(request.CourseID));
+    if (err != nil) {
+        return nil, core.NewBareInternalError("-823", request.Endpoint, "Failed to get course.").Err(err).Course(string(request.CourseID));
+    }
+
+    if (course == nil) {
+        return nil, core.NewBadRequestError("-824", &request.APIRequest, "Unknown course.").Course(string(request.CourseID));
+    }
+
+    provider, err := oidc.GetProvider();
+    if (err != nil) {
+        return nil, core.NewBareInternalError("-825", request.Endpoint, "Failed to get single sign-on provider.").Err(err);
+    }
+
+    keys, err := db.GetSessionKeys();
+    if (err != nil) {
+        return nil, core.NewBareInternalError("-826", request.Endpoint, "Failed to get session keys.").Err(err);
+    }
+
+    // The state is signed, so nothing needs to be stored for logins that are in progress.
+    now := time.Now();
+    claims := &model.SessionClaims{
+        Type: model.SESSION_TOKEN_TYPE_OIDC_STATE,
+        CourseID: course.GetID(),
+        IssuedTime: common.TimestampFromTime(now),
+        ExpirationTime: common.TimestampFromTime(now.Add(OIDC_STATE_TTL)),
+        Nonce: util.UUID(),
+    };
+
+    state, err := model.SignSessionClaims(keys[0], claims);
+    if (err != nil) {
+        return nil, core.NewBareInternalError("-827", request.Endpoint, "Failed to sign single sign-on state.").Err(err);
+    }
+
+    return &OIDCStartResponse{provider.AuthorizationURL(state, claims.Nonce)}, nil;
+}
@@ -0,0 +1,199 @@
+package user
+
+import (
+    "testing"
+
+    "github.com/edulinq/autograder/api/core"
+    "github.com/edulinq/autograder/config"
+    "github.com/edulinq/autograder/db"
+    "github.com/edulinq/autograder/model"
+    "github.com/edulinq/autograder/oidc"
+    "github.com/edulinq/autograder/util"
+)
+
+func TestOIDCLogin(test *testing.T) {
+    defer db.ResetForTesting();
+    db.ResetForTesting();
+
+    idp := startTestOIDC(test);
+    defer stopTestOIDC(idp);
+
+    testCases := []struct{email string; locator string}{
+        {"student@test.com", ""},
+        {"STUDENT@test.com", ""},
+        {"ZZZ@test.com", "-830"},
+    };
+
+    for i, testCase := range testCases {
+        code, state := oidcAuthorizeForTest(test, idp, map[string]any{"email": testCase.email});
+
+        fields := map[string]any{
+            "code": code,
+            "state": state,
+        };
+
+        response := core.SendTestAPIRequestFull(test, core.NewEndpoint(`user/oidc/finish`), fields, nil, model.RoleStudent);
+        if (!response.Success) {
+            if (testCase.locator == "") {
+                test.Errorf("Case %d: Response is not a success when it should be: '%v'.", i, response);
+            } else if (testCase.locator != response.Locator) {
+                test.Errorf("Case %d: Incorrect error returned. Expected '%s', found '%s'.", i, testCase.locator, response.Locator);
+            }
+
+            continue;
+        }
+
+        if (testCase.locator != "") {
+            test.Errorf("Case %d: Response is a success when it should not be.", i);
+            continue;
+        }
+
+        var login LoginResponse;
+        util.MustJSONFromString(util.MustToJSON(response.Content), &login);
+
+        sessionFields := map[string]any{
+            "user-pass": "",
+            "user-session": login.SessionToken,
+        };
+
+        response = core.SendTestAPIRequestFull(test, core.NewEndpoint(`user/token/list`), sessionFields, nil, model.RoleStudent);
+        if (!response.Success) {
+            test.Errorf("Case %d: Single sign-on session request is not a success when it should be: '%v'.", i, response);
+            continue;
+        }
+    }
+}
+
+func TestOIDCLoginBadState(test *testing.T) {
+    idp := startTestOIDC(test);
+    defer stopTestOIDC(idp);
+
+    code, state := oidcAuthorizeForTest(test, idp, map[string]any{"email": "student@test.com"});
+
+    // A session token is not a single sign-on state.
+    login := loginForTest(test, model.RoleStudent);
+
+    testCases := []struct{code string; state string; locator string}{
+        {code, "ZZZ", "-828"},
+        {code, login.SessionToken, "-828"},
+        {"ZZZ", state, "-829"},
+        {"", state, "-032"},
+    };
+
+    for i, testCase := range testCases {
+        fields := map[string]any{
+            "code": testCase.code,
+            "state": testCase.state,
+        };
+
+        response := core.SendTestAPIRequestFull(test, core.NewEndpoint(`user/oidc/finish`), fields, nil, model.RoleStudent);
+        if (response.Locator != testCase.locator) {
+            test.Errorf("Case %d: Incorrect error returned. Expected '%s', found: '%v'.", i, testCase.locator, response);
+        }
+    }
+}
+
+func TestOIDCNotEnabled(test *testing.T) {
+    for _, endpoint := range []string{`user/oidc/start`, `user/oidc/finish`} {
+        fields := map[string]any{
+            "code": "a",
+            "state": "b",
+        };
+
+        response := core.SendTestAPIRequestFull(test, core.NewEndpoint(endpoint), fields, nil, model.RoleStudent);
+        if (response.Locator != "-822") {
+            test.Errorf("Endpoint '%s': Incorrect error returned. Expected '-822', found: '%v'.", endpoint, response);
+        }
+    }
+}
+
+func TestDisablePasswordLogin(test *testing.T) {
+    defer db.ResetForTesting();
+    db.ResetForTesting();
+
+    idp := startTestOIDC(test);
+    defer stopTestOIDC(idp);
+
+    course := db.MustGetTestCourse();
+    course.DisablePasswordLogin = true;
+    err := db.SaveCourse(course);
+    if (err != nil) {
+        test.Fatalf("Failed to save course: '%v'.", err);
+    }
+
+    response := core.SendTestAPIRequestFull(test, core.NewEndpoint(`user/token/list`), nil, nil, model.RoleStudent);
+    if (response.HTTPStatus != core.HTTP_STATUS_AUTH_ERROR) {
+        test.Fatalf("Unexpected response for a password login. Expected an auth error, found: '%v'.", response);
+    }
+
+    code, state := oidcAuthorizeForTest(test, idp, map[string]any{"email": "student@test.com"});
+
+    fields := map[string]any{
+        "code": code,
+        "state": state,
+    };
+
+    response = core.SendTestAPIRequestFull(test, core.NewEndpoint(`user/oidc/finish`), fields, nil, model.RoleStudent);
+    if (!response.Success) {
+        test.Fatalf("Single sign-on is not a success when it should be: '%v'.", response);
+    }
+
+    var login LoginResponse;
+    util.MustJSONFromString(util.MustToJSON(response.Content), &login);
+
+    sessionFields := map[string]any{
+        "user-pass": "",
+        "user-session": login.SessionToken,
+    };
+
+    response = core.SendTestAPIRequestFull(test, core.NewEndpoint(`user/token/list`), sessionFields, nil, model.RoleStudent);
+    if (!response.Success) {
+        test.Fatalf("Single sign-on session request is not a success when it should be: '%v'.", response);
+    }
+}
+
+func startTestOIDC(test *testing.T) *oidc.MockIdP {
+    idp, err := oidc.NewMockIdP("autograder", "secret");
+    if (err != nil) {
+        test.Fatalf("Failed to start mock IdP: '%v'.", err);
+    }
+
+    config.OIDC_ISSUER.Set(idp.Issuer());
+    config.OIDC_CLIENT_ID.Set(idp.ClientID);
+    config.OIDC_CLIENT_SECRET.Set(idp.ClientSecret);
+    config.OIDC_REDIRECT_URL.Set("http://localhost/sso");
+
+    return idp;
+}
+
+func stopTestOIDC(idp *oidc.MockIdP) {
+    idp.Close();
+
+    config.OIDC_ISSUER.Set("");
+    config.OIDC_CLIENT_ID.Set("");
+    config.OIDC_CLIENT_SECRET.Set("");
+    config.OIDC_REDIRECT_URL.Set("");
+}
+
+// Start a login and have the user log in at the IdP.
+// Returns the code and state that the IdP gives back.
+func oidcAuthorizeForTest(test *testing.T, idp *oidc.MockIdP, claims map[string]any) (string, string) {
+    fields := map[string]any{
+        "course-id": "course101",
+    };
+
+    response := core.SendTestAPIRequestFull(test, core.NewEndpoint(`user/oidc/start`), fields, nil, model.RoleStudent);
+    if (!response.Success) {
+        test.Fatalf("Failed to start single sign-on: '%v'.", response);
+    }
+
+    var start OIDCStartResponse;
+    util.MustJSONFromString(util.MustToJSON(response.Content), &start);
+
+    code, state, err := idp.Authorize(start.AuthorizationURL, claims);
+    if (err != nil) {
+        test.Fatalf("Failed to authorize at the IdP: '%v'.", err);
+    }
+
+    return code, state;
+}
@@ -0,0 +1,124 @@
+package user
+
+import (
+    "sync"
+    "time"
+
+    "github.com/edulinq/autograder/api/core"
+    "github.com/edulinq/autograder/config"
+    "github.com/edulinq/autograder/db"
+    "github.com/edulinq/autograder/log"
+    "github.com/edulinq/autograder/model"
+)
+
+// Request that a password reset token be emailed to a user.
+// To avoid revealing which emails have accounts,
+// the response is the same whether or not a reset was actually started.
+type PasswordResetRequest struct {
+    core.APIRequest
+
+    Email core.NonEmptyString `json:"email"`
+}
+
+type PasswordResetResponse struct {}
+
+// Set a new password using an emailed reset token.
+type PasswordResetFinishRequest struct {
+    core.APIRequest
+
+    Email core.NonEmptyString `json:"email"`
+    ResetToken core.NonEmptyString `json:"reset-token"`
+    NewPass core.NonEmptyString `json:"new-pass"`
+}
+
+type PasswordResetFinishResponse struct {}
+
+// Password reset emails that are still being sent.
+var passwordResetEmails sync.WaitGroup;
+
+func HandlePasswordReset(request *PasswordResetRequest) (*PasswordResetResponse, *core.APIError) {
+    address := string(request.Email);
+
+    user, err := db.GetServerUser(address);
+    if (err != nil) {
+        return nil, core.NewBareInternalError("-833", request.Endpoint, "Failed to get user.").Err(err).User(address);
+    }
+
+    if (user == nil) {
+        log.Info("Password reset requested for unknown user.", log.NewUserAttr(address));
+        return &PasswordResetResponse{}, nil;
+    }
+
+    log.Info("Password reset requested.", user);
+
+    now := time.Now();
+    ttl := time.Duration(config.PASSWORD_RESET_TTL_MINS.Get()) * time.Minute;
+    window := time.Duration(config.PASSWORD_RESET_WINDOW_MINS.Get()) * time.Minute;
+
+    cleartext, err := user.StartPasswordReset(now, ttl, window, config.PASSWORD_RESET_MAX_REQUESTS.Get());
+    if (err != nil) {
+        return nil, core.NewBareInternalError("-834", request.Endpoint, "Failed to start password reset.").Err(err).User(address);
+    }
+
+    if (cleartext == "") {
+        log.Warn("Password reset request rate limited.", user);
+        return &PasswordResetResponse{}, nil;
+    }
+
+    err = db.SaveServerUser(user);
+    if (err != nil) {
+        return nil, core.NewBareInternalError("-835", request.Endpoint, "Failed to save user.").Err(err).User(address);
+    }
+
+    // Send in the background (failures are logged) so that the response (and its timing)
+    // does not depend on whether the account exists.
+    expirationTime := user.PasswordReset.ExpirationTime;
+
+    passwordResetEmails.Add(1);
+    go func() {
+        defer passwordResetEmails.Done();
+        model.SendPasswordResetEmail(address, cleartext, expirationTime);
+    }();
+
+    return &PasswordResetResponse{}, nil;
+}
+
+func HandlePasswordResetFinish(request *PasswordResetFinishRequest) (*PasswordResetFinishResponse, *core.APIError) {
+    address := string(request.Email);
+
+    user, err := db.GetServerUser(address);
+    if (err != nil) {
+        return nil, core.NewBareInternalError("-837", request.Endpoint, "Failed to get user.").Err(err).User(address);
+    }
+
+    if (user == nil) {
+        log.Warn("Password reset attempted for unknown user.", log.NewUserAttr(address));
+        return nil, core.NewBadRequestError("-838", &request.APIRequest, "Invalid or expired password reset token.").User(address);
+    }
+
+    if (!user.UsePasswordReset(string(request.ResetToken), time.Now())) {
+        log.Warn("Password reset attempted with a bad token.", user);
+
+        // Save the failed attempt.
+        err = db.SaveServerUser(user);
+        if (err != nil) {
+            return nil, core.NewBareInternalError("-839", request.Endpoint, "Failed to save user.").Err(err).User(address);
+        }
+
+        return nil, core.NewBadRequestError("-838", &request.APIRequest, "Invalid or expired password reset token.").User(address);
+    }
+
+    err = user.SetPassword(string(request.NewPass));
+    if (err != nil) {
+        return nil, core.NewBareInternalError("-840", request.Endpoint, "Failed to set password.").Err(err).User(address);
+    }
+
+    err = db.SaveServerUser(user);
+    if (err != nil) {
+        return nil, core.NewBareInternalError("-839", request.Endpoint, "Failed to save user.").Err(err).User(address);
+    }
+
+    log.Info("Password reset completed.", user);
+
+    return &PasswordResetFinishResponse{}, nil;
+}
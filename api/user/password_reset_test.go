@@ -0,0 +1,152 @@
+package user
+
+import (
+    "regexp"
+    "testing"
+
+    "github.com/edulinq/autograder/api/core"
+    "github.com/edulinq/autograder/config"
+    "github.com/edulinq/autograder/db"
+    "github.com/edulinq/autograder/email"
+    "github.com/edulinq/autograder/model"
+    "github.com/edulinq/autograder/util"
+)
+
+var resetTokenPattern = regexp.MustCompile(`reset token is '([0-9a-f]+)'`);
+
+func TestPasswordResetBase(test *testing.T) {
+    db.ResetForTesting();
+    defer db.ResetForTesting();
+
+    email.ClearTestMessages();
+    defer email.ClearTestMessages();
+
+    address := "student@test.com";
+
+    response := core.SendTestAPIRequestFull(test, core.NewEndpoint(`user/password/reset`), map[string]any{"email": address}, nil, model.RoleOther);
+    if (!response.Success) {
+        test.Fatalf("Reset request was not successful: '%v'.", response);
+    }
+
+    messages := getPasswordResetEmails();
+    if (len(messages) != 1) {
+        test.Fatalf("Unexpected number of emails. Expected: 1, Actual: %d.", len(messages));
+    }
+
+    if ((len(messages[0].To) != 1) || (messages[0].To[0] != address)) {
+        test.Fatalf("Reset email sent to the wrong address: '%v'.", messages[0].To);
+    }
+
+    match := resetTokenPattern.FindStringSubmatch(messages[0].Body);
+    if (match == nil) {
+        test.Fatalf("Could not find reset token in email: '%s'.", messages[0].Body);
+    }
+
+    fields := map[string]any{
+        "email": address,
+        "reset-token": match[1],
+        "new-pass": util.Sha256HexFromString("new-pass"),
+    };
+
+    response = core.SendTestAPIRequestFull(test, core.NewEndpoint(`user/password/reset/finish`), fields, nil, model.RoleOther);
+    if (!response.Success) {
+        test.Fatalf("Reset finish was not successful: '%v'.", response);
+    }
+
+    user, err := db.GetServerUser(address);
+    if (err != nil) {
+        test.Fatalf("Failed to get user: '%v'.", err);
+    }
+
+    if (!user.ToUser().CheckPassword(util.Sha256HexFromString("new-pass"))) {
+        test.Fatalf("Password was not changed.");
+    }
+
+    // The token can only be used once.
+    response = core.SendTestAPIRequestFull(test, core.NewEndpoint(`user/password/reset/finish`), fields, nil, model.RoleOther);
+    if (response.Success) {
+        test.Fatalf("Reset token was accepted twice.");
+    }
+
+    if (response.Locator != "-838") {
+        test.Fatalf("Unexpected locator. Expected: '-838', Actual: '%s'.", response.Locator);
+    }
+}
+
+func TestPasswordResetBadToken(test *testing.T) {
+    db.ResetForTesting();
+    defer db.ResetForTesting();
+
+    email.ClearTestMessages();
+    defer email.ClearTestMessages();
+
+    testCases := []struct{address string; token string}{
+        // No outstanding reset.
+        {"student@test.com", "ZZZ"},
+        // Unknown user.
+        {"zzz@test.com", "ZZZ"},
+    };
+
+    for i, testCase := range testCases {
+        fields := map[string]any{
+            "email": testCase.address,
+            "reset-token": testCase.token,
+            "new-pass": util.Sha256HexFromString("new-pass"),
+        };
+
+        response := core.SendTestAPIRequestFull(test, core.NewEndpoint(`user/password/reset/finish`), fields, nil, model.RoleOther);
+        if (response.Success) {
+            test.Errorf("Case %d: Bad reset token was accepted.", i);
+            continue;
+        }
+
+        if (response.Locator != "-838") {
+            test.Errorf("Case %d: Unexpected locator. Expected: '-838', Actual: '%s'.", i, response.Locator);
+        }
+    }
+}
+
+func TestPasswordResetUnknownUser(test *testing.T) {
+    db.ResetForTesting();
+    defer db.ResetForTesting();
+
+    email.ClearTestMessages();
+    defer email.ClearTestMessages();
+
+    // Unknown users look the same as known users, but no email is sent.
+    response := core.SendTestAPIRequestFull(test, core.NewEndpoint(`user/password/reset`), map[string]any{"email": "zzz@test.com"}, nil, model.RoleOther);
+    if (!response.Success) {
+        test.Fatalf("Reset request was not successful: '%v'.", response);
+    }
+
+    if (len(getPasswordResetEmails()) != 0) {
+        test.Fatalf("Email was sent for an unknown user.");
+    }
+}
+
+func TestPasswordResetRateLimit(test *testing.T) {
+    db.ResetForTesting();
+    defer db.ResetForTesting();
+
+    email.ClearTestMessages();
+    defer email.ClearTestMessages();
+
+    maxRequests := config.PASSWORD_RESET_MAX_REQUESTS.Get();
+
+    for i := 0; i < (maxRequests + 2); i++ {
+        response := core.SendTestAPIRequestFull(test, core.NewEndpoint(`user/password/reset`), map[string]any{"email": "student@test.com"}, nil, model.RoleOther);
+        if (!response.Success) {
+            test.Fatalf("Request %d: Reset request was not successful: '%v'.", i, response);
+        }
+    }
+
+    if (len(getPasswordResetEmails()) != maxRequests) {
+        test.Fatalf("Unexpected number of emails. Expected: %d, Actual: %d.", maxRequests, len(getPasswordResetEmails()));
+    }
+}
+
+// Reset emails are sent in the background.
+func getPasswordResetEmails() []*email.Message {
+    passwordResetEmails.Wait();
+    return email.GetTestMessages();
+}
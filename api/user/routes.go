@@ -10,9 +10,22 @@ var routes []*core.Route = []*core.Route{
     core.NewAPIRoute(core.NewEndpoint(`user/add`), HandleAdd),
     core.NewAPIRoute(core.NewEndpoint(`user/auth`), HandleAuth),
     core.NewAPIRoute(core.NewEndpoint(`user/change/pass`), HandleChangePassword),
+    core.NewAPIRoute(core.NewEndpoint(`user/courses`), HandleCourses),
     core.NewAPIRoute(core.NewEndpoint(`user/get`), HandleUserGet),
     core.NewAPIRoute(core.NewEndpoint(`user/list`), HandleList),
+    core.NewAPIRoute(core.NewEndpoint(`user/login`), HandleLogin),
+    core.NewAPIRoute(core.NewEndpoint(`user/login/refresh`), HandleLoginRefresh),
+    core.NewAPIRoute(core.NewEndpoint(`user/oidc/finish`), HandleOIDCFinish),
+    core.NewAPIRoute(core.NewEndpoint(`user/oidc/start`), HandleOIDCStart),
+    core.NewAPIRoute(core.NewEndpoint(`user/password/reset`), HandlePasswordReset),
+    core.NewAPIRoute(core.NewEndpoint(`user/password/reset/finish`), HandlePasswordResetFinish),
     core.NewAPIRoute(core.NewEndpoint(`user/remove`), HandleRemove),
+    core.NewAPIRoute(core.NewEndpoint(`user/token/create`), HandleTokenCreate),
+    core.NewAPIRoute(core.NewEndpoint(`user/token/list`), HandleTokenList),
+    core.NewAPIRoute(core.NewEndpoint(`user/token/revoke`), HandleTokenRevoke),
+    core.NewAPIRoute(core.NewEndpoint(`user/totp/confirm`), HandleTOTPConfirm),
+    core.NewAPIRoute(core.NewEndpoint(`user/totp/disable`), HandleTOTPDisable),
+    core.NewAPIRoute(core.NewEndpoint(`user/totp/enroll`), HandleTOTPEnroll),
 };
 
 func GetRoutes() *[]*core.Route {
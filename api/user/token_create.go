@@ -0,0 +1,79 @@
+package user
+
+import (
+    "time"
+
+    "github.com/edulinq/autograder/api/core"
+    "github.com/edulinq/autograder/common"
+    "github.com/edulinq/autograder/db"
+    "github.com/edulinq/autograder/model"
+)
+
+type TokenCreateRequest struct {
+    core.APIRequestCourseUserContext
+    core.MinRoleOther
+
+    Name core.NonEmptyString `json:"name"`
+    // Defaults to model.TokenScopeRead.
+    Scope model.TokenScope `json:"scope"`
+    // If empty, the token will not expire.
+    ExpirationTime common.Timestamp `json:"expiration-time"`
+}
+
+type TokenCreateResponse struct {
+    // The cleartext token, this is the only time it is available.
+    Token string `json:"token"`
+    TokenInfo *TokenInfo `json:"token-info"`
+}
+
+func HandleTokenCreate(request *TokenCreateRequest) (*TokenCreateResponse, *core.APIError) {
+    // Tokens cannot be used to create new (e.g. longer lived) tokens.
+    if (request.Token != nil) {
+        return nil, core.NewBadCourseRequestError("-809", &request.APIRequestCourseUserContext,
+                "API tokens cannot be created when authenticating with an API token, use a password.");
+    }
+
+    if (request.Scope == "") {
+        request.Scope = model.TokenScopeRead;
+    }
+
+    if (!request.Scope.IsValid()) {
+        return nil, core.NewBadCourseRequestError("-810", &request.APIRequestCourseUserContext,
+                "Unknown API token scope.").Add("scope", request.Scope);
+    }
+
+    if (!request.ExpirationTime.IsZero()) {
+        expiration, err := request.ExpirationTime.Time();
+        if (err != nil) {
+            return nil, core.NewBadCourseRequestError("-811", &request.APIRequestCourseUserContext,
+                    "Invalid expiration time.").Err(err).Add("expiration-time", request.ExpirationTime);
+        }
+
+        if (!expiration.After(time.Now())) {
+            return nil, core.NewBadCourseRequestError("-811", &request.APIRequestCourseUserContext,
+                    "Expiration time is in the past.").Add("expiration-time", request.ExpirationTime);
+        }
+    }
+
+    token, cleartext, err := model.NewAPIToken(string(request.Name), request.Scope, request.ExpirationTime);
+    if (err != nil) {
+        return nil, core.NewInternalError("-812", &request.APIRequestCourseUserContext,
+                "Failed to create API token.").Err(err);
+    }
+
+    token.SecondFactor = request.SecondFactor;
+    request.User.AddToken(token);
+
+    err = db.SaveUser(request.Course, request.User);
+    if (err != nil) {
+        return nil, core.NewInternalError("-813", &request.APIRequestCourseUserContext,
+                "Failed to save user.").Err(err);
+    }
+
+    response := TokenCreateResponse{
+        Token: cleartext,
+        TokenInfo: NewTokenInfo(token),
+    };
+
+    return &response, nil;
+}
@@ -0,0 +1,51 @@
+package user
+
+import (
+    "time"
+
+    "github.com/edulinq/autograder/api/core"
+    "github.com/edulinq/autograder/common"
+    "github.com/edulinq/autograder/model"
+)
+
+type TokenListRequest struct {
+    core.APIRequestCourseUserContext
+    core.MinRoleOther
+    core.MinTokenScopeRead
+}
+
+type TokenListResponse struct {
+    Tokens []*TokenInfo `json:"tokens"`
+}
+
+// How to represent a token in API responses (everything except the hash).
+type TokenInfo struct {
+    ID string `json:"id"`
+    Name string `json:"name"`
+    Scope model.TokenScope `json:"scope"`
+    CreationTime common.Timestamp `json:"creation-time"`
+    ExpirationTime common.Timestamp `json:"expiration-time"`
+    Expired bool `json:"expired"`
+    SecondFactor bool `json:"second-factor"`
+}
+
+func NewTokenInfo(token *model.APIToken) *TokenInfo {
+    return &TokenInfo{
+        ID: token.ID,
+        Name: token.Name,
+        Scope: token.Scope,
+        CreationTime: token.CreationTime,
+        ExpirationTime: token.ExpirationTime,
+        Expired: token.IsExpired(time.Now()),
+        SecondFactor: token.SecondFactor,
+    };
+}
+
+func HandleTokenList(request *TokenListRequest) (*TokenListResponse, *core.APIError) {
+    tokens := make([]*TokenInfo, 0, len(request.User.Tokens));
+    for _, token := range request.User.Tokens {
+        tokens = append(tokens, NewTokenInfo(token));
+    }
+
+    return &TokenListResponse{tokens}, nil;
+}
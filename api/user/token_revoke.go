@@ -0,0 +1,34 @@
+package user
+
+import (
+    "github.com/edulinq/autograder/api/core"
+    "github.com/edulinq/autograder/db"
+)
+
+type TokenRevokeRequest struct {
+    core.APIRequestCourseUserContext
+    core.MinRoleOther
+
+    TokenID core.NonEmptyString `json:"token-id"`
+}
+
+type TokenRevokeResponse struct {
+    FoundToken bool `json:"found-token"`
+}
+
+func HandleTokenRevoke(request *TokenRevokeRequest) (*TokenRevokeResponse, *core.APIError) {
+    response := TokenRevokeResponse{};
+
+    response.FoundToken = request.User.RemoveToken(string(request.TokenID));
+    if (!response.FoundToken) {
+        return &response, nil;
+    }
+
+    err := db.SaveUser(request.Course, request.User);
+    if (err != nil) {
+        return nil, core.NewInternalError("-814", &request.APIRequestCourseUserContext,
+                "Failed to save user.").Err(err).Add("token-id", request.TokenID);
+    }
+
+    return &response, nil;
+}
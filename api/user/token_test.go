@@ -0,0 +1,235 @@
+package user
+
+import (
+    "testing"
+    "time"
+
+    "github.com/edulinq/autograder/api/core"
+    "github.com/edulinq/autograder/common"
+    "github.com/edulinq/autograder/db"
+    "github.com/edulinq/autograder/model"
+    "github.com/edulinq/autograder/util"
+)
+
+func TestTokenCreate(test *testing.T) {
+    defer db.ResetForTesting();
+
+    past := common.TimestampFromTime(time.Now().Add(-time.Hour));
+    future := common.TimestampFromTime(time.Now().Add(time.Hour));
+
+    testCases := []struct{name string; scope string; expiration common.Timestamp; locator string; expectedScope model.TokenScope}{
+        {"a", "", "", "", model.TokenScopeRead},
+        {"a", "read", "", "", model.TokenScopeRead},
+        {"a", "submit", "", "", model.TokenScopeSubmit},
+        {"a", "admin", future, "", model.TokenScopeAdmin},
+
+        {"", "read", "", "-032", ""},
+        {"a", "ZZZ", "", "-810", ""},
+        {"a", "read", "ZZZ", "-811", ""},
+        {"a", "read", past, "-811", ""},
+    };
+
+    for i, testCase := range testCases {
+        db.ResetForTesting();
+
+        fields := map[string]any{
+            "name": testCase.name,
+            "scope": testCase.scope,
+            "expiration-time": testCase.expiration,
+        };
+
+        response := core.SendTestAPIRequestFull(test, core.NewEndpoint(`user/token/create`), fields, nil, model.RoleStudent);
+        if (!response.Success) {
+            if (testCase.locator == "") {
+                test.Errorf("Case %d: Response is not a success when it should be: '%v'.", i, response);
+            } else if (testCase.locator != response.Locator) {
+                test.Errorf("Case %d: Incorrect error returned. Expected '%s', found '%s'.", i, testCase.locator, response.Locator);
+            }
+
+            continue;
+        }
+
+        if (testCase.locator != "") {
+            test.Errorf("Case %d: Response is a success when it should not be.", i);
+            continue;
+        }
+
+        var responseContent TokenCreateResponse;
+        util.MustJSONFromString(util.MustToJSON(response.Content), &responseContent);
+
+        if (responseContent.TokenInfo.Scope != testCase.expectedScope) {
+            test.Errorf("Case %d: Unexpected scope. Expected: '%s', Actual: '%s'.", i, testCase.expectedScope, responseContent.TokenInfo.Scope);
+            continue;
+        }
+
+        user, err := db.GetUser(db.MustGetCourse("course101"), "student@test.com");
+        if (err != nil) {
+            test.Errorf("Case %d: Failed to get user: '%v'.", i, err);
+            continue;
+        }
+
+        token := user.CheckToken(responseContent.Token);
+        if (token == nil) {
+            test.Errorf("Case %d: Created token does not match the stored token.", i);
+            continue;
+        }
+
+        if (token.ID != responseContent.TokenInfo.ID) {
+            test.Errorf("Case %d: Unexpected token ID. Expected: '%s', Actual: '%s'.", i, token.ID, responseContent.TokenInfo.ID);
+            continue;
+        }
+    }
+}
+
+func TestTokenAuth(test *testing.T) {
+    defer db.ResetForTesting();
+    db.ResetForTesting();
+
+    readToken := createTestToken(test, model.RoleGrader, "read", "");
+    adminToken := createTestToken(test, model.RoleGrader, "admin", "");
+    expiredToken := createTestToken(test, model.RoleGrader, "admin", "");
+
+    // Expire a token directly in the DB.
+    course := db.MustGetCourse("course101");
+    user, err := db.GetUser(course, "grader@test.com");
+    if (err != nil) {
+        test.Fatalf("Failed to get user: '%v'.", err);
+    }
+
+    user.Tokens[2].ExpirationTime = common.TimestampFromTime(time.Now().Add(-time.Hour));
+    err = db.SaveUser(course, user);
+    if (err != nil) {
+        test.Fatalf("Failed to save user: '%v'.", err);
+    }
+
+    testCases := []struct{endpoint string; token string; fields map[string]any; authError bool; locator string}{
+        // Read endpoints.
+        {`user/token/list`, readToken, nil, false, ""},
+        {`user/token/list`, adminToken, nil, false, ""},
+        {`user/get`, readToken, map[string]any{"target-email": "student@test.com"}, false, ""},
+
+        // Admin endpoints.
+        {`user/token/revoke`, readToken, map[string]any{"token-id": "ZZZ"}, false, "-042"},
+        {`user/token/revoke`, adminToken, map[string]any{"token-id": "ZZZ"}, false, ""},
+        {`user/change/pass`, readToken, map[string]any{"new-pass": "abc"}, false, "-042"},
+
+        // Tokens cannot make tokens.
+        {`user/token/create`, adminToken, map[string]any{"name": "a"}, false, "-809"},
+
+        // Bad tokens.
+        {`user/token/list`, expiredToken, nil, true, ""},
+        {`user/token/list`, "ZZZ", nil, true, ""},
+        {`user/token/list`, readToken + "Z", nil, true, ""},
+    };
+
+    for i, testCase := range testCases {
+        fields := map[string]any{
+            "user-pass": "",
+            "user-token": testCase.token,
+        };
+
+        for key, value := range testCase.fields {
+            fields[key] = value;
+        }
+
+        response := core.SendTestAPIRequestFull(test, core.NewEndpoint(testCase.endpoint), fields, nil, model.RoleGrader);
+        if (!response.Success) {
+            if (testCase.authError) {
+                if (response.HTTPStatus != core.HTTP_STATUS_AUTH_ERROR) {
+                    test.Errorf("Case %d: Expected an auth error, found: '%v'.", i, response);
+                }
+            } else if (testCase.locator == "") {
+                test.Errorf("Case %d: Response is not a success when it should be: '%v'.", i, response);
+            } else if (testCase.locator != response.Locator) {
+                test.Errorf("Case %d: Incorrect error returned. Expected '%s', found '%s'.", i, testCase.locator, response.Locator);
+            }
+
+            continue;
+        }
+
+        if (testCase.authError || (testCase.locator != "")) {
+            test.Errorf("Case %d: Response is a success when it should not be.", i);
+            continue;
+        }
+    }
+}
+
+func TestTokenListRevoke(test *testing.T) {
+    defer db.ResetForTesting();
+    db.ResetForTesting();
+
+    createTestToken(test, model.RoleStudent, "read", "");
+    token := createTestToken(test, model.RoleStudent, "admin", "");
+
+    response := core.SendTestAPIRequestFull(test, core.NewEndpoint(`user/token/list`), nil, nil, model.RoleStudent);
+    if (!response.Success) {
+        test.Fatalf("Response is not a success when it should be: '%v'.", response);
+    }
+
+    var listContent TokenListResponse;
+    util.MustJSONFromString(util.MustToJSON(response.Content), &listContent);
+
+    if (len(listContent.Tokens) != 2) {
+        test.Fatalf("Unexpected number of tokens. Expected: 2, Actual: %d.", len(listContent.Tokens));
+    }
+
+    // Other users should not see these tokens.
+    response = core.SendTestAPIRequestFull(test, core.NewEndpoint(`user/token/list`), nil, nil, model.RoleGrader);
+    if (!response.Success) {
+        test.Fatalf("Response is not a success when it should be: '%v'.", response);
+    }
+
+    var otherListContent TokenListResponse;
+    util.MustJSONFromString(util.MustToJSON(response.Content), &otherListContent);
+
+    if (len(otherListContent.Tokens) != 0) {
+        test.Fatalf("Unexpected number of tokens for another user. Expected: 0, Actual: %d.", len(otherListContent.Tokens));
+    }
+
+    for _, expectedFound := range []bool{true, false} {
+        fields := map[string]any{
+            "token-id": listContent.Tokens[1].ID,
+        };
+
+        response = core.SendTestAPIRequestFull(test, core.NewEndpoint(`user/token/revoke`), fields, nil, model.RoleStudent);
+        if (!response.Success) {
+            test.Fatalf("Response is not a success when it should be: '%v'.", response);
+        }
+
+        var revokeContent TokenRevokeResponse;
+        util.MustJSONFromString(util.MustToJSON(response.Content), &revokeContent);
+
+        if (revokeContent.FoundToken != expectedFound) {
+            test.Fatalf("Unexpected found token. Expected: %v, Actual: %v.", expectedFound, revokeContent.FoundToken);
+        }
+    }
+
+    // The revoked token can no longer be used.
+    fields := map[string]any{
+        "user-pass": "",
+        "user-token": token,
+    };
+
+    response = core.SendTestAPIRequestFull(test, core.NewEndpoint(`user/token/list`), fields, nil, model.RoleStudent);
+    if (response.HTTPStatus != core.HTTP_STATUS_AUTH_ERROR) {
+        test.Fatalf("Unexpected response for a revoked token. Expected an auth error, found: '%v'.", response);
+    }
+}
+
+func createTestToken(test *testing.T, role model.UserRole, scope string, expiration common.Timestamp) string {
+    fields := map[string]any{
+        "name": "test",
+        "scope": scope,
+        "expiration-time": expiration,
+    };
+
+    response := core.SendTestAPIRequestFull(test, core.NewEndpoint(`user/token/create`), fields, nil, role);
+    if (!response.Success) {
+        test.Fatalf("Failed to create token: '%v'.", response);
+    }
+
+    var responseContent TokenCreateResponse;
+    util.MustJSONFromString(util.MustToJSON(response.Content), &responseContent);
+
+    return responseContent.Token;
+}
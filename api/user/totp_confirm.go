@@ -0,0 +1,54 @@
+package user
+
+import (
+    "time"
+
+    "github.com/edulinq/autograder/api/core"
+    "github.com/edulinq/autograder/db"
+    "github.com/edulinq/autograder/log"
+)
+
+// Finish setting up two-factor authentication with a code from the user's authenticator.
+type TOTPConfirmRequest struct {
+    core.APIRequestCourseUserContext
+    core.MinRoleOther
+
+    Code core.NonEmptyString `json:"code"`
+}
+
+type TOTPConfirmResponse struct {
+    // Each code can be used once (in place of a TOTP code), this is the only time they are available.
+    RecoveryCodes []string `json:"recovery-codes"`
+}
+
+func HandleTOTPConfirm(request *TOTPConfirmRequest) (*TOTPConfirmResponse, *core.APIError) {
+    if (request.Token != nil) {
+        return nil, core.NewBadCourseRequestError("-841", &request.APIRequestCourseUserContext,
+                "Two-factor authentication cannot be set up when authenticating with an API token.");
+    }
+
+    serverUser, err := db.GetServerUser(request.User.Email);
+    if (err != nil) {
+        return nil, core.NewInternalError("-842", &request.APIRequestCourseUserContext, "Failed to get user.").Err(err);
+    }
+
+    recoveryCodes, err := serverUser.ConfirmTOTP(string(request.Code), time.Now());
+    if (err != nil) {
+        return nil, core.NewBadCourseRequestError("-846", &request.APIRequestCourseUserContext,
+                "There is no two-factor authentication enrollment in progress, see 'user/totp/enroll'.").Err(err);
+    }
+
+    if (recoveryCodes == nil) {
+        return nil, core.NewBadCourseRequestError("-847", &request.APIRequestCourseUserContext,
+                "Incorrect two-factor authentication code.");
+    }
+
+    err = db.SaveServerUser(serverUser);
+    if (err != nil) {
+        return nil, core.NewInternalError("-845", &request.APIRequestCourseUserContext, "Failed to save user.").Err(err);
+    }
+
+    log.Info("Enabled two-factor authentication.", request.Course, request.User);
+
+    return &TOTPConfirmResponse{recoveryCodes}, nil;
+}
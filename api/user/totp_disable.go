@@ -0,0 +1,45 @@
+package user
+
+import (
+    "github.com/edulinq/autograder/api/core"
+    "github.com/edulinq/autograder/db"
+    "github.com/edulinq/autograder/log"
+)
+
+type TOTPDisableRequest struct {
+    core.APIRequestCourseUserContext
+    core.MinRoleOther
+}
+
+type TOTPDisableResponse struct {
+    // False if two-factor authentication was not enabled (or set up).
+    Found bool `json:"found"`
+}
+
+func HandleTOTPDisable(request *TOTPDisableRequest) (*TOTPDisableResponse, *core.APIError) {
+    serverUser, err := db.GetServerUser(request.User.Email);
+    if (err != nil) {
+        return nil, core.NewInternalError("-842", &request.APIRequestCourseUserContext, "Failed to get user.").Err(err);
+    }
+
+    if (serverUser.TOTP == nil) {
+        return &TOTPDisableResponse{false}, nil;
+    }
+
+    // A stolen password (or session/token without a second factor) should not be able to turn off the second factor.
+    if (serverUser.HasTOTP() && !request.SecondFactor) {
+        return nil, core.NewBadCourseRequestError("-848", &request.APIRequestCourseUserContext,
+                "Disabling two-factor authentication requires a code in 'user-totp'.");
+    }
+
+    serverUser.DisableTOTP();
+
+    err = db.SaveServerUser(serverUser);
+    if (err != nil) {
+        return nil, core.NewInternalError("-845", &request.APIRequestCourseUserContext, "Failed to save user.").Err(err);
+    }
+
+    log.Info("Disabled two-factor authentication.", request.Course, request.User);
+
+    return &TOTPDisableResponse{true}, nil;
+}
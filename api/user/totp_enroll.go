@@ -0,0 +1,58 @@
+package user
+
+import (
+    "github.com/edulinq/autograder/api/core"
+    "github.com/edulinq/autograder/db"
+    "github.com/edulinq/autograder/log"
+    "github.com/edulinq/autograder/model"
+)
+
+// Start setting up two-factor authentication.
+// The user adds the secret to their authenticator, and then confirms it (see HandleTOTPConfirm()).
+type TOTPEnrollRequest struct {
+    core.APIRequestCourseUserContext
+    core.MinRoleOther
+}
+
+type TOTPEnrollResponse struct {
+    Secret string `json:"secret"`
+    // Authenticator apps can add the secret from this URI (usually shown as a QR code).
+    URI string `json:"otpauth-uri"`
+}
+
+func HandleTOTPEnroll(request *TOTPEnrollRequest) (*TOTPEnrollResponse, *core.APIError) {
+    if (request.Token != nil) {
+        return nil, core.NewBadCourseRequestError("-841", &request.APIRequestCourseUserContext,
+                "Two-factor authentication cannot be set up when authenticating with an API token.");
+    }
+
+    serverUser, err := db.GetServerUser(request.User.Email);
+    if (err != nil) {
+        return nil, core.NewInternalError("-842", &request.APIRequestCourseUserContext, "Failed to get user.").Err(err);
+    }
+
+    if (serverUser.HasTOTP()) {
+        return nil, core.NewBadCourseRequestError("-843", &request.APIRequestCourseUserContext,
+                "Two-factor authentication is already enabled, disable it first.");
+    }
+
+    secret, err := serverUser.StartTOTPEnrollment();
+    if (err != nil) {
+        return nil, core.NewInternalError("-844", &request.APIRequestCourseUserContext,
+                "Failed to start two-factor authentication enrollment.").Err(err);
+    }
+
+    err = db.SaveServerUser(serverUser);
+    if (err != nil) {
+        return nil, core.NewInternalError("-845", &request.APIRequestCourseUserContext, "Failed to save user.").Err(err);
+    }
+
+    log.Info("Started two-factor authentication enrollment.", request.Course, request.User);
+
+    response := TOTPEnrollResponse{
+        Secret: secret,
+        URI: model.GetTOTPURI(secret, serverUser.Email),
+    };
+
+    return &response, nil;
+}
@@ -0,0 +1,165 @@
+package user
+
+import (
+    "testing"
+    "time"
+
+    "github.com/edulinq/autograder/api/core"
+    "github.com/edulinq/autograder/db"
+    "github.com/edulinq/autograder/model"
+    "github.com/edulinq/autograder/util"
+)
+
+func TestTOTPBase(test *testing.T) {
+    db.ResetForTesting();
+    defer db.ResetForTesting();
+
+    defer core.ClearAllLockouts();
+
+    secret, recoveryCodes := enrollTOTPForTest(test, model.RoleGrader);
+    now := time.Now();
+
+    // Privileged endpoints now need a second factor.
+    response := core.SendTestAPIRequestFull(test, core.NewEndpoint(`user/list`), nil, nil, model.RoleGrader);
+    if (response.Locator != "-063") {
+        test.Fatalf("Unexpected response without a second factor. Expected '-063', found: '%v'.", response);
+    }
+
+    // Unprivileged endpoints do not.
+    response = core.SendTestAPIRequestFull(test, core.NewEndpoint(`user/token/list`), nil, nil, model.RoleGrader);
+    if (!response.Success) {
+        test.Fatalf("Unprivileged request without a second factor was not successful: '%v'.", response);
+    }
+
+    fields := map[string]any{"user-totp": getTOTPCodeForTest(test, secret, now, 1)};
+    response = core.SendTestAPIRequestFull(test, core.NewEndpoint(`user/list`), fields, nil, model.RoleGrader);
+    if (!response.Success) {
+        test.Fatalf("Request with a TOTP code was not successful: '%v'.", response);
+    }
+
+    // Codes cannot be reused.
+    response = core.SendTestAPIRequestFull(test, core.NewEndpoint(`user/list`), fields, nil, model.RoleGrader);
+    if (response.HTTPStatus != core.HTTP_STATUS_AUTH_ERROR) {
+        test.Fatalf("Unexpected response for a reused code. Expected an auth error, found: '%v'.", response);
+    }
+
+    // A login with a second factor gives a session that can be used for privileged endpoints.
+    fields = map[string]any{"user-totp": recoveryCodes[0]};
+    response = core.SendTestAPIRequestFull(test, core.NewEndpoint(`user/login`), fields, nil, model.RoleGrader);
+    if (!response.Success) {
+        test.Fatalf("Login with a recovery code was not successful: '%v'.", response);
+    }
+
+    var login LoginResponse;
+    util.MustJSONFromString(util.MustToJSON(response.Content), &login);
+
+    fields = map[string]any{
+        "user-pass": "",
+        "user-session": login.SessionToken,
+    };
+
+    response = core.SendTestAPIRequestFull(test, core.NewEndpoint(`user/list`), fields, nil, model.RoleGrader);
+    if (!response.Success) {
+        test.Fatalf("Request with a second factor session was not successful: '%v'.", response);
+    }
+
+    // Disabling needs a second factor.
+    response = core.SendTestAPIRequestFull(test, core.NewEndpoint(`user/totp/disable`), nil, nil, model.RoleGrader);
+    if (response.Locator != "-848") {
+        test.Fatalf("Unexpected response when disabling without a second factor. Expected '-848', found: '%v'.", response);
+    }
+
+    response = core.SendTestAPIRequestFull(test, core.NewEndpoint(`user/totp/disable`), map[string]any{"user-totp": recoveryCodes[1]}, nil, model.RoleGrader);
+    if (!response.Success) {
+        test.Fatalf("Disabling with a recovery code was not successful: '%v'.", response);
+    }
+
+    response = core.SendTestAPIRequestFull(test, core.NewEndpoint(`user/list`), nil, nil, model.RoleGrader);
+    if (!response.Success) {
+        test.Fatalf("Request after disabling was not successful: '%v'.", response);
+    }
+}
+
+func TestTOTPConfirmErrors(test *testing.T) {
+    db.ResetForTesting();
+    defer db.ResetForTesting();
+
+    response := core.SendTestAPIRequestFull(test, core.NewEndpoint(`user/totp/confirm`), map[string]any{"code": "000000"}, nil, model.RoleStudent);
+    if (response.Locator != "-846") {
+        test.Fatalf("Unexpected response when confirming without enrolling. Expected '-846', found: '%v'.", response);
+    }
+
+    response = core.SendTestAPIRequestFull(test, core.NewEndpoint(`user/totp/enroll`), nil, nil, model.RoleStudent);
+    if (!response.Success) {
+        test.Fatalf("Enrollment was not successful: '%v'.", response);
+    }
+
+    // A guess (it is possible, but very unlikely, for this to be the correct code).
+    response = core.SendTestAPIRequestFull(test, core.NewEndpoint(`user/totp/confirm`), map[string]any{"code": "ZZZZZZ"}, nil, model.RoleStudent);
+    if (response.Locator != "-847") {
+        test.Fatalf("Unexpected response when confirming with a bad code. Expected '-847', found: '%v'.", response);
+    }
+}
+
+func TestTOTPCourseRequired(test *testing.T) {
+    db.ResetForTesting();
+    defer db.ResetForTesting();
+
+    course := db.MustGetTestCourse();
+    course.RequireTOTP = true;
+    err := db.SaveCourse(course);
+    if (err != nil) {
+        test.Fatalf("Failed to save course: '%v'.", err);
+    }
+
+    response := core.SendTestAPIRequestFull(test, core.NewEndpoint(`user/list`), nil, nil, model.RoleGrader);
+    if (response.Locator != "-064") {
+        test.Fatalf("Unexpected response for staff without a second factor. Expected '-064', found: '%v'.", response);
+    }
+
+    // Students (and unprivileged endpoints) are not affected.
+    response = core.SendTestAPIRequestFull(test, core.NewEndpoint(`user/token/list`), nil, nil, model.RoleStudent);
+    if (!response.Success) {
+        test.Fatalf("Unprivileged request was not successful: '%v'.", response);
+    }
+
+    secret, _ := enrollTOTPForTest(test, model.RoleGrader);
+
+    fields := map[string]any{"user-totp": getTOTPCodeForTest(test, secret, time.Now(), 1)};
+    response = core.SendTestAPIRequestFull(test, core.NewEndpoint(`user/list`), fields, nil, model.RoleGrader);
+    if (!response.Success) {
+        test.Fatalf("Request with a second factor was not successful: '%v'.", response);
+    }
+}
+
+// Enroll and confirm (using the code for the current step).
+// Returns the secret and recovery codes.
+func enrollTOTPForTest(test *testing.T, role model.UserRole) (string, []string) {
+    response := core.SendTestAPIRequestFull(test, core.NewEndpoint(`user/totp/enroll`), nil, nil, role);
+    if (!response.Success) {
+        test.Fatalf("Enrollment was not successful: '%v'.", response);
+    }
+
+    var enrollment TOTPEnrollResponse;
+    util.MustJSONFromString(util.MustToJSON(response.Content), &enrollment);
+
+    fields := map[string]any{"code": getTOTPCodeForTest(test, enrollment.Secret, time.Now(), 0)};
+    response = core.SendTestAPIRequestFull(test, core.NewEndpoint(`user/totp/confirm`), fields, nil, role);
+    if (!response.Success) {
+        test.Fatalf("Confirmation was not successful: '%v'.", response);
+    }
+
+    var confirmation TOTPConfirmResponse;
+    util.MustJSONFromString(util.MustToJSON(response.Content), &confirmation);
+
+    return enrollment.Secret, confirmation.RecoveryCodes;
+}
+
+func getTOTPCodeForTest(test *testing.T, secret string, now time.Time, offset int64) string {
+    code, err := model.GetTOTPCode(secret, model.GetTOTPStep(now) + offset);
+    if (err != nil) {
+        test.Fatalf("Failed to get TOTP code: '%v'.", err);
+    }
+
+    return code;
+}
@@ -18,6 +18,7 @@ var args struct {
     Course string `help:"ID of the course." arg:"" optional:""`
     Assignment string `help:"ID of the assignment." arg:"" optional:""`
     Force bool `help:"Force images build commands to be sent to docker even if the image is up-to-date." default:"false"`
+    ShowLogs bool `help:"Do not build any images, instead show the most recent recorded build for each image." default:"false"`
 }
 
 func main() {
@@ -44,16 +45,13 @@ func main() {
                 log.Fatal(fmt.Sprintf("Unknown assignment: '%s'.", args.Assignment));
             }
 
-            fmt.Printf("Building assignment image '%s' from %s.\n", assignment.GetID(), course.GetID());
             assignments = append(assignments, assignment);
         } else {
-            fmt.Printf("Building all assignment images from %s.\n", course.GetID());
             for _, assignment := range course.GetSortedAssignments() {
                 assignments = append(assignments, assignment);
             }
         }
     } else {
-        fmt.Println("Building all assignment images from all courses.");
         for _, course := range db.MustGetCourses() {
             for _, assignment := range course.GetSortedAssignments() {
                 assignments = append(assignments, assignment);
@@ -61,6 +59,19 @@ func main() {
         }
     }
 
+    if (args.ShowLogs) {
+        showBuildLogs(assignments);
+        return;
+    }
+
+    if (args.Assignment != "") {
+        fmt.Printf("Building assignment image '%s' from %s.\n", args.Assignment, args.Course);
+    } else if (args.Course != "") {
+        fmt.Printf("Building all assignment images from %s.\n", args.Course);
+    } else {
+        fmt.Println("Building all assignment images from all courses.");
+    }
+
     imageNames := buildImages(assignments);
 
     fmt.Printf("Successfully built %d images:\n", len(imageNames));
@@ -75,6 +86,11 @@ func buildImages(assignments []*model.Assignment) []string {
     for _, assignment := range assignments {
         err := docker.BuildImageFromSource(assignment, args.Force, false, &args.BuildOptions);
         if (err != nil) {
+            buildLog, _ := docker.GetLastBuildLog(assignment);
+            if ((buildLog != nil) && !buildLog.Success) {
+                fmt.Printf("Build output for '%s':\n%s\n", assignment.ImageName(), buildLog.Output);
+            }
+
             log.Fatal("Failed to build image.", assignment, err);
         }
 
@@ -83,3 +99,33 @@ func buildImages(assignments []*model.Assignment) []string {
 
     return imageNames;
 }
+
+func showBuildLogs(assignments []*model.Assignment) {
+    for i, assignment := range assignments {
+        if (i > 0) {
+            fmt.Println();
+        }
+
+        buildLog, err := docker.GetLastBuildLog(assignment);
+        if (err != nil) {
+            log.Fatal("Failed to get build log.", assignment, err);
+        }
+
+        if (buildLog == nil) {
+            fmt.Printf("Image '%s' has no recorded builds.\n", assignment.ImageName());
+            continue;
+        }
+
+        fmt.Printf("Image: %s\n", buildLog.ImageName);
+        fmt.Printf("Base Image: %s (%s)\n", buildLog.BaseImage, buildLog.BaseImageDigest);
+        fmt.Printf("Start Time: %s\n", buildLog.StartTime.ShouldPrettyString());
+        fmt.Printf("Duration: %.2fs\n", float64(buildLog.DurationMS) / 1000.0);
+        fmt.Printf("Success: %v\n", buildLog.Success);
+
+        if (buildLog.Error != "") {
+            fmt.Printf("Error: %s\n", buildLog.Error);
+        }
+
+        fmt.Printf("Output:\n%s\n", buildLog.Output);
+    }
+}
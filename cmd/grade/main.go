@@ -33,6 +33,11 @@ func main() {
         log.Fatal("Could not load config options.", err);
     }
 
+    err = grader.ValidateRunnerConfig();
+    if (err != nil) {
+        log.Fatal("Invalid grading runner.", err);
+    }
+
     db.MustOpen();
     defer db.MustClose();
 
@@ -0,0 +1,98 @@
+package main
+
+import (
+    "fmt"
+    "os"
+    "text/tabwriter"
+
+    "github.com/alecthomas/kong"
+
+    "github.com/edulinq/autograder/config"
+    "github.com/edulinq/autograder/db"
+    "github.com/edulinq/autograder/log"
+    "github.com/edulinq/autograder/task"
+    "github.com/edulinq/autograder/util"
+)
+
+type ListImages struct {
+    JSON bool `help:"Output the images as JSON." default:"false"`
+}
+
+func (this *ListImages) Run() error {
+    inventory, err := task.GetImageInventory();
+    if (err != nil) {
+        return err;
+    }
+
+    if (this.JSON) {
+        fmt.Println(util.MustToJSONIndent(inventory));
+        return nil;
+    }
+
+    printInventory(inventory);
+    return nil;
+}
+
+type PruneImages struct {
+    Course string `help:"Only prune images that belong to this course (empty for all courses)." default:""`
+    DryRun bool `help:"Do not actually remove any images, just state what would be removed." default:"false"`
+}
+
+func (this *PruneImages) Run() error {
+    removed, err := task.PruneImages(this.Course, this.DryRun);
+    if (err != nil) {
+        return err;
+    }
+
+    if (this.DryRun) {
+        fmt.Println("Doing a dry run, no images will be removed.");
+    }
+
+    fmt.Printf("Pruned %d images:\n", len(removed));
+    printInventory(removed);
+
+    return nil;
+}
+
+var cli struct {
+    config.ConfigArgs
+
+    Ls ListImages `cmd:"" help:"List all autograder images, their sizes, last use, and owning course/assignment."`
+    Prune PruneImages `cmd:"" help:"Remove images for courses/assignments that no longer exist."`
+}
+
+func main() {
+    context := kong.Parse(&cli,
+        kong.Description("Manage the docker images built for assignments."),
+    );
+
+    err := config.HandleConfigArgs(cli.ConfigArgs);
+    if (err != nil) {
+        log.Fatal("Could not load config options.", err);
+    }
+
+    db.MustOpen();
+    defer db.MustClose();
+
+    err = context.Run();
+    if (err != nil) {
+        log.Fatal("Failed to run command.", err);
+    }
+}
+
+func printInventory(inventory []*task.ImageInventoryEntry) {
+    writer := tabwriter.NewWriter(os.Stdout, 0, 4, 2, ' ', 0);
+    defer writer.Flush();
+
+    fmt.Fprintln(writer, "Image\tHost\tSize (MB)\tLast Used\tCourse\tAssignment\tOrphaned");
+    for _, entry := range inventory {
+        lastUsed := "never";
+        if (!entry.LastUsedTime.IsZero()) {
+            lastUsed = entry.LastUsedTime.ShouldPrettyString();
+        }
+
+        fmt.Fprintf(writer, "%s\t%s\t%.1f\t%s\t%s\t%s\t%v\n",
+            entry.Name, entry.Host, float64(entry.SizeBytes) / 1024.0 / 1024.0, lastUsed,
+            entry.CourseID, entry.AssignmentID, entry.Orphaned);
+    }
+}
@@ -0,0 +1,205 @@
+package main
+
+import (
+    "fmt"
+    "slices"
+
+    "github.com/alecthomas/kong"
+
+    "github.com/edulinq/autograder/config"
+    "github.com/edulinq/autograder/db"
+    "github.com/edulinq/autograder/log"
+    "github.com/edulinq/autograder/model"
+    "github.com/edulinq/autograder/util"
+)
+
+type AddUser struct {
+    Email string `help:"Email for the user." arg:"" required:""`
+    Name string `help:"Name for the user. Defaults to the user's email." short:"n"`
+    Role string `help:"Server role for the user (user or admin)." short:"r" default:"user"`
+    Pass string `help:"Password for the user. Defaults to a random string (will be output)." short:"p"`
+}
+
+func (this *AddUser) Run() error {
+    role := model.ServerRole(this.Role);
+    if (!role.IsValid()) {
+        return fmt.Errorf("Unknown server role: '%s'.", this.Role);
+    }
+
+    user, err := db.GetServerUser(this.Email);
+    if (err != nil) {
+        return fmt.Errorf("Failed to get user: '%w'.", err);
+    }
+
+    if (user != nil) {
+        return fmt.Errorf("User '%s' already exists.", this.Email);
+    }
+
+    name := this.Name;
+    if (name == "") {
+        name = this.Email;
+    }
+
+    user = &model.ServerUser{
+        Email: this.Email,
+        Name: name,
+        Role: role,
+        Enrollments: make(map[string]*model.Enrollment),
+    };
+
+    // If set, the password comes in cleartext.
+    pass := this.Pass;
+    if (pass == "") {
+        pass, err = util.RandHex(model.DEFAULT_PASSWORD_LEN);
+        if (err != nil) {
+            return fmt.Errorf("Failed to generate random password: '%w'.", err);
+        }
+    }
+
+    err = user.SetPassword(util.Sha256HexFromString(pass));
+    if (err != nil) {
+        return err;
+    }
+
+    err = db.SaveServerUser(user);
+    if (err != nil) {
+        return fmt.Errorf("Failed to save user: '%w'.", err);
+    }
+
+    fmt.Printf("Added user '%s' with server role '%s'.\n", user.Email, user.Role);
+
+    // Wait to the very end to output the generated password.
+    if (this.Pass == "") {
+        fmt.Printf("Generated password: '%s'.\n", pass);
+    }
+
+    return nil;
+}
+
+type SetRole struct {
+    Email string `help:"Email for the user." arg:"" required:""`
+    Role string `help:"Server role for the user (user or admin)." arg:"" required:""`
+}
+
+func (this *SetRole) Run() error {
+    role := model.ServerRole(this.Role);
+    if (!role.IsValid()) {
+        return fmt.Errorf("Unknown server role: '%s'.", this.Role);
+    }
+
+    user, err := db.GetServerUser(this.Email);
+    if (err != nil) {
+        return fmt.Errorf("Failed to get user: '%w'.", err);
+    }
+
+    if (user == nil) {
+        return fmt.Errorf("User '%s' does not exist.", this.Email);
+    }
+
+    user.Role = role;
+
+    err = db.SaveServerUser(user);
+    if (err != nil) {
+        return fmt.Errorf("Failed to save user: '%w'.", err);
+    }
+
+    fmt.Printf("User '%s' now has server role '%s'.\n", user.Email, user.Role);
+
+    return nil;
+}
+
+type DisableTOTP struct {
+    Email string `help:"Email for the user." arg:"" required:""`
+}
+
+// For users who have lost both their authenticator and recovery codes.
+func (this *DisableTOTP) Run() error {
+    user, err := db.GetServerUser(this.Email);
+    if (err != nil) {
+        return fmt.Errorf("Failed to get user: '%w'.", err);
+    }
+
+    if (user == nil) {
+        return fmt.Errorf("User '%s' does not exist.", this.Email);
+    }
+
+    if (user.TOTP == nil) {
+        fmt.Printf("User '%s' does not have two-factor authentication set up.\n", user.Email);
+        return nil;
+    }
+
+    user.DisableTOTP();
+
+    err = db.SaveServerUser(user);
+    if (err != nil) {
+        return fmt.Errorf("Failed to save user: '%w'.", err);
+    }
+
+    log.Info("Disabled two-factor authentication.", user);
+    fmt.Printf("Disabled two-factor authentication for user '%s'.\n", user.Email);
+
+    return nil;
+}
+
+type ListUsers struct {
+    Admins bool `help:"Only show server admins." default:"false"`
+}
+
+func (this *ListUsers) Run() error {
+    users, err := db.GetServerUsers();
+    if (err != nil) {
+        return fmt.Errorf("Failed to load users: '%w'.", err);
+    }
+
+    emails := make([]string, 0, len(users));
+    for email, user := range users {
+        if (this.Admins && !user.IsAdmin()) {
+            continue;
+        }
+
+        emails = append(emails, email);
+    }
+    slices.Sort(emails);
+
+    fmt.Printf("%s\t%s\t%s\n", "Email", "Name", "Server Role");
+    for _, email := range emails {
+        user := users[email];
+
+        role := user.Role;
+        if (role == "") {
+            role = model.ServerRoleUser;
+        }
+
+        fmt.Printf("%s\t%s\t%s\n", user.Email, user.Name, role);
+    }
+
+    return nil;
+}
+
+var cli struct {
+    config.ConfigArgs
+
+    Add AddUser `cmd:"" help:"Add a user account (not enrolled in any course)."`
+    DisableTotp DisableTOTP `cmd:"" help:"Turn off a user's two-factor authentication (e.g., if they lost their authenticator)."`
+    Ls ListUsers `cmd:"" help:"List user accounts."`
+    SetRole SetRole `cmd:"" help:"Set a user's server role."`
+}
+
+func main() {
+    context := kong.Parse(&cli,
+        kong.Description("Manage server-wide user accounts and server roles."),
+    );
+
+    err := config.HandleConfigArgs(cli.ConfigArgs);
+    if (err != nil) {
+        log.Fatal("Could not load config options.", err);
+    }
+
+    db.MustOpen();
+    defer db.MustClose();
+
+    err = context.Run();
+    if (err != nil) {
+        log.Fatal("Failed to run command.", err);
+    }
+}
@@ -8,6 +8,8 @@ import (
     "github.com/edulinq/autograder/api"
     "github.com/edulinq/autograder/config"
     "github.com/edulinq/autograder/db"
+    "github.com/edulinq/autograder/docker"
+    "github.com/edulinq/autograder/grader"
     "github.com/edulinq/autograder/log"
     "github.com/edulinq/autograder/model"
     "github.com/edulinq/autograder/procedures"
@@ -25,6 +27,11 @@ func main() {
         log.Fatal("Could not load config options.", err);
     }
 
+    err = grader.ValidateRunnerConfig();
+    if (err != nil) {
+        log.Fatal("Invalid grading runner.", err);
+    }
+
     log.Info("Autograder Version", log.NewAttr("version", util.GetAutograderFullVersion()));
 
     workingDir, err := os.Getwd();
@@ -56,6 +63,9 @@ func main() {
     // Cleanup any temp dirs.
     defer util.RemoveRecordedTempDirs();
 
+    // Remove any pre-created grading containers.
+    defer docker.ClearAllContainerPools();
+
     err = api.StartServer();
     if (err != nil) {
         log.Fatal("Server was stopped.", err);
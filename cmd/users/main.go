@@ -22,7 +22,7 @@ type AddUser struct {
     Name string `help:"Name for the user. Defaults to the user's email." short:"n"`
     Role string `help:"Role for the user. Defaults to student." short:"r" default:"student"`
     Pass string `help:"Password for the user. Defaults to a random string (will be output)." short:"p"`
-    Force bool `help:"Overwrite any existing user." short:"f" default:"false"`
+    Force bool `help:"Overwrite any existing user (this changes the user's account for all of their courses)." short:"f" default:"false"`
     SendEmail bool `help:"Send an email to the user about adding them. Errors sending emails will be noted, but will not halt operations." default:"false"`
     DryRun bool `help:"Do not actually write out the user's file or send emails, just state what you would do." default:"false"`
     SyncLMS bool `help:"After adding users, sync the course users (all of them) with the course's LMS." default:"false"`
@@ -190,6 +190,7 @@ func (this *ListUsers) Run(course *model.Course) error {
     return nil
 }
 
+// Accounts are server-wide, so this changes the user's password for all of their courses.
 type ChangePassword struct {
     Email string `help:"Email for the user." arg:"" required:""`
     Pass string `help:"Password for the user. Defaults to a random string (will be output)." short:"p"`
@@ -206,7 +207,11 @@ func (this *ChangePassword) Run(course *model.Course) error {
         return fmt.Errorf("User '%s' does not exist.", this.Email);
     }
 
-    user.Pass = this.Pass;
+    // If set, the password comes in cleartext.
+    user.Pass = "";
+    if (this.Pass != "") {
+        user.Pass = util.Sha256HexFromString(this.Pass);
+    }
 
     result, err := db.SyncUser(course, user, true, false, this.SendEmail);
     if (err != nil) {
@@ -221,6 +226,27 @@ func (this *ChangePassword) Run(course *model.Course) error {
     return nil
 }
 
+type ListCourses struct {
+    Email string `help:"Email for the user." arg:"" required:""`
+}
+
+func (this *ListCourses) Run(course *model.Course) error {
+    user, err := db.GetServerUser(this.Email);
+    if (err != nil) {
+        return fmt.Errorf("Failed to get user: '%w'.", err);
+    }
+
+    if (user == nil) {
+        return fmt.Errorf("User '%s' does not exist.", this.Email);
+    }
+
+    for _, courseID := range user.GetCourseIDs() {
+        fmt.Printf("%s\t%s\n", courseID, user.Enrollments[courseID].Role);
+    }
+
+    return nil;
+}
+
 type RmUser struct {
     Email string `help:"Email for the user to be removed." arg:"" required:""`
 }
@@ -235,7 +261,7 @@ func (this *RmUser) Run(course *model.Course) error {
         return fmt.Errorf("User does not exist '%s'.", this.Email);
     }
 
-    fmt.Printf("User '%s' removed.\n", this.Email);
+    fmt.Printf("User '%s' removed from course '%s'.\n", this.Email, course.GetID());
 
     return nil;
 }
@@ -247,9 +273,11 @@ var cli struct {
     Add AddUser `cmd:"" help:"Add a user."`
     AddTSV AddTSV `cmd:"" help:"Add users from a TSV file formatted as: '<email>[\t<name>[\t<role>[\t<password>]]]'. See add for default values."`
     Auth AuthUser `cmd:"" help:"Authenticate as a user."`
+    ChangePass ChangePassword `cmd:"" help:"Change a user's password (for all of their courses)."`
+    Courses ListCourses `cmd:"" help:"List all the courses a user is enrolled in."`
     Get GetUser `cmd:"" help:"Get a user."`
     Ls ListUsers `cmd:"" help:"List users."`
-    Rm RmUser `cmd:"" help:"Remove a user."`
+    Rm RmUser `cmd:"" help:"Remove a user from the course (their account is kept)."`
 }
 
 func main() {
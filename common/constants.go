@@ -6,9 +6,22 @@ const GRADING_WORK_DIRNAME = "work"
 
 const GRADER_OUTPUT_RESULT_FILENAME = "result.json"
 
+// Graders may write lines with this prefix (followed by a JSON graded question) to stdout
+// to report progress on a question while grading is still running.
+const GRADER_PROGRESS_PREFIX = "AUTOGRADER-PROGRESS:"
+
 const SUBMISSION_STDOUT_FILENAME = "stdout.txt"
 const SUBMISSION_STDERR_FILENAME = "stderr.txt"
+const SUBMISSION_SIDECAR_LOGS_FILENAME = "sidecar-logs.json"
 
 const AUTOGRADER_COMMENT_IDENTITY_KEY = "__autograder__"
 
 const SUBMISSION_ID_DELIM = "::"
+
+// The different environments that a grader can be run in.
+const RUNNER_DOCKER = "docker"
+const RUNNER_PODMAN = "podman"
+const RUNNER_BUBBLEWRAP = "bubblewrap"
+const RUNNER_NODOCKER = "nodocker"
+
+var RUNNERS = []string{RUNNER_DOCKER, RUNNER_PODMAN, RUNNER_BUBBLEWRAP, RUNNER_NODOCKER}
@@ -40,6 +40,10 @@ func MustEnableUnitTestingMode() {
 func EnableUnitTestingMode() error {
     TESTING_MODE.Set(true);
     NO_TASKS.Set(true);
+    RATE_LIMIT_DISABLE.Set(true);
+
+    // The test courses use unpinned images.
+    DOCKER_REQUIRE_PINNED.Set(false);
 
     tempWorkDir, err := util.MkDirTemp("autograder-unit-testing-");
     if (err != nil) {
@@ -67,6 +71,8 @@ func EnableTestingMode() {
     NO_AUTH.Set(true);
     NO_STORE.Set(true);
     NO_TASKS.Set(true);
+    RATE_LIMIT_DISABLE.Set(true);
+    DOCKER_REQUIRE_PINNED.Set(false);
 
     DEBUG.Set(true);
     InitLoggingFromConfig();
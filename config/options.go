@@ -31,6 +31,48 @@ var (
 
     // Docker
     DOCKER_DISABLE = MustNewBoolOption("docker.disable", false, "Disable the use of docker (usually for testing).");
+    DOCKER_REQUIRE_PINNED = MustNewBoolOption("docker.pinned", true,
+            "Require all base images to be pinned to a digest (e.g. 'image@sha256:...')." +
+            " Set to false to allow unpinned images (e.g. for local development).");
+    DOCKER_PROXY_IMAGE = MustNewStringOption("docker.proxy.image", "alpine/socat",
+            "The image for the sidecar that connects graders on an allowlist network to the allowlist proxy." +
+            " The image's entrypoint must be socat.");
+    DOCKER_PULL_ATTEMPTS = MustNewIntOption("docker.pull.attempts", 3, "The number of times to try pulling an image before giving up.");
+    DOCKER_PULL_BACKOFF_SECS = MustNewIntOption("docker.pull.backoff", 2,
+            "The number of seconds to wait after the first failed image pull (doubled after each failure).");
+    DOCKER_REGISTRY_SERVER = MustNewStringOption("docker.registry.server", "",
+            "The registry (e.g. 'registry.example.com:5000') that the registry credentials are for." +
+            " Defaults to Docker Hub.");
+    DOCKER_REGISTRY_USER = MustNewStringOption("docker.registry.user", "", "Username for the image registry. Empty for no auth.");
+    DOCKER_REGISTRY_PASS = MustNewStringOption("docker.registry.pass", "", "Password (or token) for the image registry.");
+
+    // Grading Runners
+    GRADER_RUNNER = MustNewStringOption("grader.runner", "docker",
+            "The default environment to run graders in (courses may override this)." +
+            " One of: docker, podman, bubblewrap, nodocker.");
+    PODMAN_HOST = MustNewStringOption("podman.host", "",
+            "The host (socket) of the podman service to use for the podman runner." +
+            " Defaults to the current user's rootless podman socket.");
+    BUBBLEWRAP_PATH = MustNewStringOption("bubblewrap.path", "bwrap", "The path to the bubblewrap (bwrap) executable.");
+    BUBBLEWRAP_RO_BINDS = MustNewStringOption("bubblewrap.robinds", "/usr,/bin,/lib,/lib64,/etc,/opt",
+            "A comma-separated list of host paths that are mounted (read-only) inside the bubblewrap sandbox.");
+
+    // Limits for graders run directly on the host (nodocker and bubblewrap runners).
+    // A non-positive value disables a limit.
+    GRADER_LIMIT_TIMEOUT_SECS = MustNewIntOption("grader.limits.timeout", 10 * 60,
+            "The maximum wall-clock time (in seconds) that a host grader may run for.");
+    GRADER_LIMIT_CPU_SECS = MustNewIntOption("grader.limits.cpu", 5 * 60,
+            "The maximum CPU time (in seconds) that a host grader may use.");
+    GRADER_LIMIT_MEMORY_MB = MustNewIntOption("grader.limits.memorymb", 4 * 1024,
+            "The maximum virtual memory (in MB) that a host grader process may use.");
+    GRADER_LIMIT_FILE_SIZE_MB = MustNewIntOption("grader.limits.filesizemb", 256,
+            "The maximum size (in MB) of any file a host grader may write.");
+    GRADER_LIMIT_PROCS = MustNewIntOption("grader.limits.procs", 0,
+            "The maximum number of processes for the user running a host grader." +
+            " Since this limit is per-user, it should only be used with grader.user.");
+    GRADER_USER = MustNewStringOption("grader.user", "",
+            "A dedicated unprivileged user to run host graders as." +
+            " Only used when the server is running as root.");
 
     // Tasks
     NO_TASKS = MustNewBoolOption("tasks.disable", false, "Disable all scheduled tasks.");
@@ -43,6 +85,63 @@ var (
     WEB_PORT = MustNewIntOption("web.port", 8080, "The port for the web interface to serve on.");
     WEB_MAX_FILE_SIZE_KB = MustNewIntOption("web.maxsizekb", 2 * 1024, "The maximum allowed file size (in KB) submitted via POST request. The default is 2048 KB (2 MB).");
 
+    // Sessions
+    SESSION_TTL_MINS = MustNewIntOption("web.session.ttl", 60, "How long (in minutes) a session token (from logging in) is valid for.");
+    SESSION_REFRESH_TTL_HOURS = MustNewIntOption("web.session.refreshttl", 7 * 24,
+            "How long (in hours) a session refresh token is valid for.");
+    SESSION_KEY_ROTATION_HOURS = MustNewIntOption("web.session.rotation", 24,
+            "How often (in hours) the key used to sign session tokens is replaced." +
+            " Old keys are kept until all the tokens they signed have expired.");
+
+    // Lockouts
+    LOCKOUT_USER_ATTEMPTS = MustNewIntOption("web.lockout.userattempts", 5,
+            "The number of failed authentication attempts for an account before it is locked out. Zero to disable account lockouts.");
+    LOCKOUT_IP_ATTEMPTS = MustNewIntOption("web.lockout.ipattempts", 20,
+            "The number of failed authentication attempts from an IP before it is locked out. Zero to disable IP lockouts.");
+    LOCKOUT_BASE_SECS = MustNewIntOption("web.lockout.basesecs", 30,
+            "How long (in seconds) the first lockout lasts. Each further failed attempt doubles the lockout.");
+    LOCKOUT_MAX_MINS = MustNewIntOption("web.lockout.maxmins", 30, "The longest (in minutes) that a lockout can last.");
+    LOCKOUT_RESET_MINS = MustNewIntOption("web.lockout.resetmins", 60,
+            "Failed authentication attempts are forgotten after this many minutes without another failure.");
+
+    // Rate Limits
+    // Limits are token buckets kept per endpoint group (e.g., 'submission'), see api/core/ratelimit.go.
+    // Groups and roles can be overridden with keys like 'web.ratelimit.user.<group>[.<role>].rate'.
+    RATE_LIMIT_DISABLE = MustNewBoolOption("web.ratelimit.disable", false, "Disable all API rate limits.");
+    RATE_LIMIT_USER_RATE = MustNewFloatOption("web.ratelimit.user.rate", 5.0,
+            "The number of requests per second that a user can sustain to a group of endpoints. Zero to disable user rate limits.");
+    RATE_LIMIT_USER_BURST = MustNewIntOption("web.ratelimit.user.burst", 30,
+            "The number of requests that a user can make in a burst to a group of endpoints.");
+    RATE_LIMIT_IP_RATE = MustNewFloatOption("web.ratelimit.ip.rate", 20.0,
+            "The number of requests per second that an IP can sustain to a group of endpoints. Zero to disable IP rate limits.");
+    RATE_LIMIT_IP_BURST = MustNewIntOption("web.ratelimit.ip.burst", 100,
+            "The number of requests that an IP can make in a burst to a group of endpoints.");
+
+    // Password Hashing (argon2id)
+    // Raising these will rehash each user's password the next time they log in with it.
+    PASSWORD_HASH_TIME = MustNewIntOption("passwords.hash.time", 1, "The number of passes (time cost) when hashing passwords.");
+    PASSWORD_HASH_MEMORY_KB = MustNewIntOption("passwords.hash.memorykb", 64 * 1024, "The memory (in KB) used when hashing passwords.");
+    PASSWORD_HASH_THREADS = MustNewIntOption("passwords.hash.threads", 4, "The number of threads used when hashing passwords.");
+
+    // Password Resets
+    PASSWORD_RESET_TTL_MINS = MustNewIntOption("web.reset.ttl", 30, "How long (in minutes) an emailed password reset token is valid for.");
+    PASSWORD_RESET_MAX_REQUESTS = MustNewIntOption("web.reset.maxrequests", 3,
+            "The maximum number of password resets a user can request within the rate limit window (see web.reset.window).");
+    PASSWORD_RESET_WINDOW_MINS = MustNewIntOption("web.reset.window", 60, "The window (in minutes) used for rate limiting password reset requests.");
+    PASSWORD_RESET_URL = MustNewStringOption("web.reset.url", "",
+            "The page that password reset emails link to (with the email and reset token as query parameters)." +
+            " The page should pass them (along with the new password) to the user/password/reset/finish endpoint." +
+            " Empty to only include the token in the email.");
+
+    // Single Sign-On (OpenID Connect)
+    OIDC_ISSUER = MustNewStringOption("oidc.issuer", "", "The issuer URL of the OpenID Connect identity provider. Empty to disable single sign-on.");
+    OIDC_CLIENT_ID = MustNewStringOption("oidc.clientid", "", "The client ID of the autograder at the identity provider.");
+    OIDC_CLIENT_SECRET = MustNewStringOption("oidc.clientsecret", "", "The client secret of the autograder at the identity provider.");
+    OIDC_REDIRECT_URL = MustNewStringOption("oidc.redirecturl", "",
+            "Where the identity provider sends users after they log in (registered with the identity provider)." +
+            " The page at this URL should pass the code and state it receives to the user/oidc/finish endpoint.");
+    OIDC_EMAIL_CLAIM = MustNewStringOption("oidc.emailclaim", "email", "The ID token claim that holds the user's email.");
+
     // Database
     DB_TYPE = MustNewStringOption("db.type", "disk", "The type of database to use.");
     DB_PG_URI = MustNewStringOption("db.pg.uri", "", "Connection string to connect to a Postgres Databse. Empty if not using Postgres.");
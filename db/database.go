@@ -61,17 +61,32 @@ type Backend interface {
     // Explicitly save an assignment.
     SaveAssignment(assignment *model.Assignment) error;
 
+    // Get all server-wide accounts (keyed by email).
+    GetServerUsers() (map[string]*model.ServerUser, error);
+
+    // Get a specific account.
+    // Returns nil if no matching account exists.
+    GetServerUser(email string) (*model.ServerUser, error);
+
+    // Upsert the given accounts.
+    SaveServerUsers(users map[string]*model.ServerUser) error;
+
+    // Get the users enrolled in a course.
     GetUsers(course *model.Course) (map[string]*model.User, error);
 
     // Get a specific user.
     // Returns nil if no matching user exists.
     GetUser(course *model.Course, email string) (*model.User, error);
 
-    // Upsert the given users.
+    // Upsert the given users (their accounts and their enrollment in this course).
     SaveUsers(course *model.Course, users map[string]*model.User) error;
 
-    // Remove a user.
-    // Do nothing and return nil if the user does not exist.
+    // Upsert only the enrollment of the given users in this course.
+    // Existing accounts are otherwise unchanged, new accounts are created from the users.
+    EnrollUsers(course *model.Course, users map[string]*model.User) error;
+
+    // Remove a user from a course (the user's account is kept).
+    // Do nothing and return nil if the user is not enrolled.
     RemoveUser(course *model.Course, email string) error;
 
     // Remove a submission.
@@ -131,6 +146,12 @@ type Backend interface {
     // Will return a zero time (time.Time{}).
     GetLastTaskCompletion(courseID string, taskID string) (time.Time, error);
 
+    // Get all the stored session keys, newest first.
+    GetSessionKeys() ([]*model.SessionKey, error);
+
+    // Replace all the stored session keys.
+    SaveSessionKeys(keys []*model.SessionKey) error;
+
     // DB backends will also be used as logging storage backends.
     log.StorageBackend
 
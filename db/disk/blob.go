@@ -0,0 +1,132 @@
+package disk
+
+// Submission files are stored content-addressed:
+// each file is hard linked to a blob named after the hash of its contents,
+// so identical files (e.g. from re-submissions) only take up space once.
+// Blobs are removed once no submission links to them.
+// If hard links are not available, files are just kept as normal (duplicate) copies.
+
+import (
+    "fmt"
+    "os"
+    "path/filepath"
+
+    "github.com/edulinq/autograder/log"
+    "github.com/edulinq/autograder/util"
+)
+
+const DISK_DB_BLOBS_DIR = "blobs";
+
+// Replace each file in a dir with a link to its blob.
+// Failures to link are logged, but not returned (the file is left as-is).
+func (this *backend) linkBlobs(dir string) error {
+    if (!util.PathExists(dir)) {
+        return nil;
+    }
+
+    paths, err := util.FindFiles("", dir);
+    if (err != nil) {
+        return fmt.Errorf("Failed to find files in '%s': '%w'.", dir, err);
+    }
+
+    for _, path := range paths {
+        blobPath, err := this.getBlobPath(path);
+        if (err != nil) {
+            return err;
+        }
+
+        err = linkBlob(path, blobPath);
+        if (err != nil) {
+            log.Debug("Failed to link submission file to blob.", err, log.NewAttr("path", path), log.NewAttr("blob", blobPath));
+        }
+    }
+
+    return nil;
+}
+
+// Get the blobs that the files in a dir (may) link to.
+func (this *backend) getBlobPaths(dir string) ([]string, error) {
+    blobPaths := make([]string, 0);
+
+    if (!util.PathExists(dir)) {
+        return blobPaths, nil;
+    }
+
+    paths, err := util.FindFiles("", dir);
+    if (err != nil) {
+        return nil, fmt.Errorf("Failed to find files in '%s': '%w'.", dir, err);
+    }
+
+    for _, path := range paths {
+        blobPath, err := this.getBlobPath(path);
+        if (err != nil) {
+            return nil, err;
+        }
+
+        blobPaths = append(blobPaths, blobPath);
+    }
+
+    return blobPaths, nil;
+}
+
+// Remove any of the given blobs that are no longer linked to by any submission.
+func (this *backend) pruneBlobs(blobPaths []string) {
+    for _, blobPath := range blobPaths {
+        if (!util.PathExists(blobPath)) {
+            continue;
+        }
+
+        count, err := util.GetLinkCount(blobPath);
+        if (err != nil) {
+            log.Warn("Failed to get blob link count.", err, log.NewAttr("blob", blobPath));
+            continue;
+        }
+
+        // Only the blob itself is left.
+        if (count == 1) {
+            err = os.Remove(blobPath);
+            if (err != nil) {
+                log.Warn("Failed to remove unused blob.", err, log.NewAttr("blob", blobPath));
+            }
+        }
+    }
+}
+
+func (this *backend) getBlobPath(path string) (string, error) {
+    data, err := os.ReadFile(path);
+    if (err != nil) {
+        return "", fmt.Errorf("Failed to read file '%s' for hashing: '%w'.", path, err);
+    }
+
+    hash := util.Sha256Hex(data);
+    return filepath.Join(this.baseDir, DISK_DB_BLOBS_DIR, hash[0:2], hash), nil;
+}
+
+func linkBlob(path string, blobPath string) error {
+    if (!util.PathExists(blobPath)) {
+        err := util.MkDir(filepath.Dir(blobPath));
+        if (err != nil) {
+            return err;
+        }
+
+        // This file becomes the blob.
+        return os.Link(path, blobPath);
+    }
+
+    // Replace the file with a link to the existing blob.
+    // Link to a temp path first so the file is never missing.
+    tempPath := path + ".blob-link";
+
+    err := os.Link(blobPath, tempPath);
+    if (err != nil) {
+        return err;
+    }
+
+    err = os.Rename(tempPath, path);
+    if (err != nil) {
+        os.Remove(tempPath);
+        return err;
+    }
+
+    return nil;
+}
@@ -0,0 +1,93 @@
+package disk
+
+import (
+    "os"
+    "path/filepath"
+    "testing"
+
+    "github.com/edulinq/autograder/util"
+)
+
+func TestLinkBlobs(test *testing.T) {
+    tempDir, err := util.MkDirTemp("autograder-test-blobs-");
+    if (err != nil) {
+        test.Fatalf("Failed to make temp dir: '%v'.", err);
+    }
+    defer os.RemoveAll(tempDir);
+
+    backend := &backend{baseDir: tempDir};
+
+    dirA := filepath.Join(tempDir, "a");
+    dirB := filepath.Join(tempDir, "b");
+
+    for _, dir := range []string{dirA, dirB} {
+        util.MkDir(filepath.Join(dir, "sub"));
+        util.WriteFile("same", filepath.Join(dir, "same.txt"));
+        util.WriteFile(dir, filepath.Join(dir, "sub", "different.txt"));
+
+        err = backend.linkBlobs(dir);
+        if (err != nil) {
+            test.Fatalf("Failed to link blobs for '%s': '%v'.", dir, err);
+        }
+    }
+
+    if (!isSameFile(test, filepath.Join(dirA, "same.txt"), filepath.Join(dirB, "same.txt"))) {
+        test.Fatalf("Identical files were not linked.");
+    }
+
+    if (isSameFile(test, filepath.Join(dirA, "sub", "different.txt"), filepath.Join(dirB, "sub", "different.txt"))) {
+        test.Fatalf("Different files were linked.");
+    }
+
+    blobPaths, err := backend.getBlobPaths(dirA);
+    if (err != nil) {
+        test.Fatalf("Failed to get blob paths: '%v'.", err);
+    }
+
+    err = util.RemoveDirent(dirA);
+    if (err != nil) {
+        test.Fatalf("Failed to remove dir: '%v'.", err);
+    }
+
+    backend.pruneBlobs(blobPaths);
+
+    sameBlobPath, err := backend.getBlobPath(filepath.Join(dirB, "same.txt"));
+    if (err != nil) {
+        test.Fatalf("Failed to get blob path: '%v'.", err);
+    }
+
+    count, _ := util.GetLinkCount(sameBlobPath);
+    if (count < 0) {
+        // This platform does not support link counts, so blobs are never pruned.
+        return;
+    }
+
+    if (!util.PathExists(sameBlobPath)) {
+        test.Fatalf("Blob that is still in use was pruned.");
+    }
+
+    for _, blobPath := range blobPaths {
+        if ((blobPath != sameBlobPath) && util.PathExists(blobPath)) {
+            test.Fatalf("Unused blob was not pruned: '%s'.", blobPath);
+        }
+    }
+
+    contents, err := util.ReadFile(filepath.Join(dirB, "same.txt"));
+    if ((err != nil) || (contents != "same")) {
+        test.Fatalf("Remaining file was changed. Contents: '%s', Error: '%v'.", contents, err);
+    }
+}
+
+func isSameFile(test *testing.T, a string, b string) bool {
+    infoA, err := os.Stat(a);
+    if (err != nil) {
+        test.Fatalf("Failed to stat '%s': '%v'.", a, err);
+    }
+
+    infoB, err := os.Stat(b);
+    if (err != nil) {
+        test.Fatalf("Failed to stat '%s': '%v'.", b, err);
+    }
+
+    return os.SameFile(infoA, infoB);
+}
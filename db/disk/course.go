@@ -20,6 +20,11 @@ func (this *backend) ClearCourse(course *model.Course) error {
         return fmt.Errorf("Failed to remove course dir for '%s': '%w'.", course.GetID(), err);
     }
 
+    err = this.removeCourseUsersLock(course);
+    if (err != nil) {
+        return fmt.Errorf("Failed to remove course users for '%s': '%w'.", course.GetID(), err);
+    }
+
     return nil;
 }
 
@@ -91,6 +96,14 @@ func (this *backend) DumpCourse(course *model.Course, targetDir string) error {
         return fmt.Errorf("Failed to copy disk db '%s' into '%s': '%w'.", this.baseDir, targetDir, err);
     }
 
+    // Users are not stored in the course's dir.
+    util.RemoveDirent(filepath.Join(targetDir, model.USERS_FILENAME + MIGRATED_USERS_SUFFIX));
+
+    err = this.dumpUsersLock(course, targetDir);
+    if (err != nil) {
+        return fmt.Errorf("Failed to dump users for '%s': '%w'.", course.GetID(), err);
+    }
+
     return nil;
 }
 
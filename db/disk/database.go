@@ -6,11 +6,13 @@ package disk
 
 import (
     "fmt"
+    "os"
     "path/filepath"
     "sync"
 
     "github.com/edulinq/autograder/config"
     "github.com/edulinq/autograder/log"
+    "github.com/edulinq/autograder/model"
     "github.com/edulinq/autograder/util"
 )
 
@@ -20,6 +22,14 @@ type backend struct {
     baseDir string
     lock sync.RWMutex
     logLock sync.RWMutex
+
+    // All accounts, cached from the users file so requests do not need to read and parse the whole file.
+    // Nil until first read, and reloaded if the file changes (see getCachedServerUsersLock()).
+    // Writers (holding the write lock) replace it when saving,
+    // and usersCacheLock guards loading it while only holding the read lock.
+    serverUsers map[string]*model.ServerUser
+    serverUsersStat os.FileInfo
+    usersCacheLock sync.Mutex
 }
 
 func Open() (*backend, error) {
@@ -40,7 +50,7 @@ func (this *backend) Close() error {
 }
 
 func (this *backend) EnsureTables() error {
-    return nil;
+    return this.migrateCourseUsers();
 }
 
 func (this *backend) Clear() error {
@@ -60,5 +70,7 @@ func (this *backend) Clear() error {
         return fmt.Errorf("Failed to make db dir '%s': '%w'.", this.baseDir, err);
     }
 
+    this.clearServerUsersCache();
+
     return nil;
 }
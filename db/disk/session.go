@@ -0,0 +1,55 @@
+package disk
+
+import (
+    "fmt"
+    "os"
+    "path/filepath"
+
+    "github.com/edulinq/autograder/model"
+    "github.com/edulinq/autograder/util"
+)
+
+const DISK_DB_SESSION_KEYS_FILENAME = "session-keys.json";
+
+func (this *backend) GetSessionKeys() ([]*model.SessionKey, error) {
+    this.lock.RLock();
+    defer this.lock.RUnlock();
+
+    keys := make([]*model.SessionKey, 0);
+
+    path := this.getSessionKeysPath();
+    if (!util.PathExists(path)) {
+        return keys, nil;
+    }
+
+    err := util.JSONFromFile(path, &keys);
+    if (err != nil) {
+        return nil, fmt.Errorf("Failed to read session keys '%s': '%w'.", path, err);
+    }
+
+    return keys, nil;
+}
+
+func (this *backend) SaveSessionKeys(keys []*model.SessionKey) error {
+    this.lock.Lock();
+    defer this.lock.Unlock();
+
+    path := this.getSessionKeysPath();
+
+    err := util.ToJSONFileIndent(keys, path);
+    if (err != nil) {
+        return fmt.Errorf("Failed to write session keys '%s': '%w'.", path, err);
+    }
+
+    // The keys are secrets.
+    err = os.Chmod(path, 0600);
+    if (err != nil) {
+        return fmt.Errorf("Failed to set permissions on session keys '%s': '%w'.", path, err);
+    }
+
+    return nil;
+}
+
+func (this *backend) getSessionKeysPath() string {
+    return filepath.Join(this.baseDir, DISK_DB_SESSION_KEYS_FILENAME);
+}
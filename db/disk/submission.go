@@ -30,16 +30,34 @@ func (this *backend) saveSubmissionsLock(course *model.Course, submissions []*mo
             return fmt.Errorf("Failed to write submission result '%s': '%w'.", resultPath, err);
         }
 
-        err = util.GzipBytesToDirectory(filepath.Join(baseDir, common.GRADING_INPUT_DIRNAME), submission.InputFilesGZip);
+        inputDir := filepath.Join(baseDir, common.GRADING_INPUT_DIRNAME);
+        outputDir := filepath.Join(baseDir, common.GRADING_OUTPUT_DIRNAME);
+
+        // Files are linked to shared blobs, so any existing files cannot be written over in place.
+        oldBlobPaths, err := this.clearSubmissionFiles(inputDir, outputDir);
+        if (err != nil) {
+            return err;
+        }
+
+        err = util.GzipBytesToDirectory(inputDir, submission.InputFilesGZip);
         if (err != nil) {
             return fmt.Errorf("Failed to write submission input files: '%w'.", err);
         }
 
-        err = util.GzipBytesToDirectory(filepath.Join(baseDir, common.GRADING_OUTPUT_DIRNAME), submission.OutputFilesGZip);
+        err = util.GzipBytesToDirectory(outputDir, submission.OutputFilesGZip);
         if (err != nil) {
             return fmt.Errorf("Failed to write submission input files: '%w'.", err);
         }
 
+        for _, dir := range []string{inputDir, outputDir} {
+            err = this.linkBlobs(dir);
+            if (err != nil) {
+                return fmt.Errorf("Failed to store submission files: '%w'.", err);
+            }
+        }
+
+        this.pruneBlobs(oldBlobPaths);
+
         err = util.WriteFile(submission.Stdout, filepath.Join(baseDir, common.SUBMISSION_STDOUT_FILENAME));
         if (err != nil) {
             return fmt.Errorf("Failed to write submission stdout file: '%w'.", err);
@@ -49,6 +67,13 @@ func (this *backend) saveSubmissionsLock(course *model.Course, submissions []*mo
         if (err != nil) {
             return fmt.Errorf("Failed to write submission stderr file: '%w'.", err);
         }
+
+        if (len(submission.SidecarLogs) > 0) {
+            err = util.ToJSONFileIndent(submission.SidecarLogs, filepath.Join(baseDir, common.SUBMISSION_SIDECAR_LOGS_FILENAME));
+            if (err != nil) {
+                return fmt.Errorf("Failed to write submission sidecar logs file: '%w'.", err);
+            }
+        }
     }
 
     return nil;
@@ -315,15 +340,46 @@ func (this *backend) RemoveSubmission(assignment *model.Assignment, email string
         return false, nil;
     }
 
+    blobPaths, err := this.clearSubmissionFiles(
+            filepath.Join(submissionDir, common.GRADING_INPUT_DIRNAME),
+            filepath.Join(submissionDir, common.GRADING_OUTPUT_DIRNAME));
+    if (err != nil) {
+        return false, fmt.Errorf("Failed to remove submission '%s' files: '%w'", shortSubmissionID, err);
+    }
+
     err = util.RemoveDirent(submissionDir);
     if (err != nil) {
         wrappedErr := fmt.Errorf("Failed to remove submission '%s': '%w'", shortSubmissionID, err);
         return false, wrappedErr;
     }
 
+    this.pruneBlobs(blobPaths);
+
     return true, nil;
 }
 
+// Remove submission file dirs.
+// Returns the blobs that the removed files were linked to (see pruneBlobs()).
+func (this *backend) clearSubmissionFiles(dirs ...string) ([]string, error) {
+    blobPaths := make([]string, 0);
+
+    for _, dir := range dirs {
+        paths, err := this.getBlobPaths(dir);
+        if (err != nil) {
+            return nil, err;
+        }
+
+        blobPaths = append(blobPaths, paths...);
+
+        err = util.RemoveDirent(dir);
+        if (err != nil) {
+            return nil, fmt.Errorf("Failed to remove submission dir '%s': '%w'.", dir, err);
+        }
+    }
+
+    return blobPaths, nil;
+}
+
 func (this *backend) GetSubmissionAttempts(assignment *model.Assignment, email string) ([]*model.GradingResult, error) {
     submissions := make([]*model.GradingResult, 0);
 
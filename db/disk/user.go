@@ -2,12 +2,55 @@ package disk
 
 import (
     "fmt"
+    "os"
     "path/filepath"
 
+    "github.com/edulinq/autograder/log"
     "github.com/edulinq/autograder/model"
     "github.com/edulinq/autograder/util"
 )
 
+// All accounts (for all courses) are stored in a single file.
+const DISK_DB_USERS_FILENAME = "users.json";
+
+// Course user files from before accounts were server-wide are renamed with this suffix once they are migrated.
+const MIGRATED_USERS_SUFFIX = ".migrated";
+
+func (this *backend) GetServerUsers() (map[string]*model.ServerUser, error) {
+    this.lock.RLock();
+    defer this.lock.RUnlock();
+
+    return this.getServerUsersLock();
+}
+
+func (this *backend) GetServerUser(email string) (*model.ServerUser, error) {
+    this.lock.RLock();
+    defer this.lock.RUnlock();
+
+    users, err := this.getCachedServerUsersLock();
+    if (err != nil) {
+        return nil, fmt.Errorf("Failed to get users when searching for '%s': '%w'.", email, err);
+    }
+
+    return copyServerUser(users[email]);
+}
+
+func (this *backend) SaveServerUsers(newUsers map[string]*model.ServerUser) error {
+    this.lock.Lock();
+    defer this.lock.Unlock();
+
+    users, err := this.getServerUsersLock();
+    if (err != nil) {
+        return fmt.Errorf("Failed to get users to merge before saving: '%w'.", err);
+    }
+
+    for email, user := range newUsers {
+        users[email] = user;
+    }
+
+    return this.saveServerUsersLock(users);
+}
+
 func (this *backend) GetUsers(course *model.Course) (map[string]*model.User, error) {
     return this.getUsersLock(course, true);
 }
@@ -18,81 +61,313 @@ func (this *backend) getUsersLock(course *model.Course, acquireLock bool) (map[s
         defer this.lock.RUnlock();
     }
 
-    users := make(map[string]*model.User);
-
-    path := this.getUsersPath(course);
-    if (!util.PathExists(path)) {
-        return users, nil;
-    }
-
-    err := util.JSONFromFile(path, &users);
+    serverUsers, err := this.getServerUsersLock();
     if (err != nil) {
         return nil, err;
     }
 
+    users := make(map[string]*model.User);
+    for email, serverUser := range serverUsers {
+        user := serverUser.ToCourseUser(course.GetID());
+        if (user != nil) {
+            users[email] = user;
+        }
+    }
+
     return users, nil;
 }
 
 func (this *backend) GetUser(course *model.Course, email string) (*model.User, error) {
-    users, err := this.GetUsers(course);
+    serverUser, err := this.GetServerUser(email);
     if (err != nil) {
-        return nil, fmt.Errorf("Failed to get users when searching for '%s': '%w'.", email, err);
+        return nil, err;
+    }
+
+    if (serverUser == nil) {
+        return nil, nil;
     }
 
-    return users[email], nil;
+    return serverUser.ToCourseUser(course.GetID()), nil;
 }
 
 func (this *backend) SaveUsers(course *model.Course, users map[string]*model.User) error {
+    this.lock.Lock();
+    defer this.lock.Unlock();
+
     return this.saveUsersLock(course, users, true);
 }
 
-func (this *backend) saveUsersLock(course *model.Course, newUsers map[string]*model.User, acquireLock bool) error {
-    if (acquireLock) {
-        this.lock.Lock();
-        defer this.lock.Unlock();
+func (this *backend) EnrollUsers(course *model.Course, users map[string]*model.User) error {
+    this.lock.Lock();
+    defer this.lock.Unlock();
+
+    return this.saveUsersLock(course, users, false);
+}
+
+// Save course users into their accounts.
+// If |overwrite| is true, then the account (name, credentials, tokens) will be set from the course user.
+// Otherwise, only the enrollment is saved for existing accounts.
+// The caller must hold the write lock.
+func (this *backend) saveUsersLock(course *model.Course, newUsers map[string]*model.User, overwrite bool) error {
+    if (len(newUsers) == 0) {
+        return nil;
     }
 
-    users, err := this.getUsersLock(course, false);
+    serverUsers, err := this.getServerUsersLock();
     if (err != nil) {
         return fmt.Errorf("Failed to get users to merge before saving: '%w'.", err);
     }
 
-    for key, value := range newUsers {
-        users[key] = value;
-    }
-
-    err = util.ToJSONFileIndent(users, this.getUsersPath(course));
-    if (err != nil) {
-        return fmt.Errorf("Unable to save user's file: '%w'.", err);
+    for email, user := range newUsers {
+        serverUser := serverUsers[email];
+        if (serverUser == nil) {
+            serverUsers[email] = model.NewServerUserFromCourseUser(course.GetID(), user);
+        } else if (overwrite) {
+            serverUser.SetCourseUser(course.GetID(), user);
+        } else {
+            serverUser.Enroll(course.GetID(), user);
+        }
     }
 
-    return nil;
+    return this.saveServerUsersLock(serverUsers);
 }
 
+// Remove a user from a course.
+// The user's account (and any other enrollments) are kept.
 func (this *backend) RemoveUser(course *model.Course, email string) error {
     this.lock.Lock();
     defer this.lock.Unlock();
 
-    users, err := this.getUsersLock(course, false);
+    users, err := this.getServerUsersLock();
     if (err != nil) {
         return fmt.Errorf("Failed to get users when removing for '%s': '%w'.", email, err);
     }
 
-    _, ok := users[email];
-    if (!ok) {
+    user := users[email];
+    if ((user == nil) || !user.Unenroll(course.GetID())) {
+        return nil;
+    }
+
+    return this.saveServerUsersLock(users);
+}
+
+// Remove all enrollments for a course.
+// The caller must hold the write lock.
+func (this *backend) removeCourseUsersLock(course *model.Course) error {
+    users, err := this.getServerUsersLock();
+    if (err != nil) {
+        return fmt.Errorf("Failed to get users when clearing course: '%w'.", err);
+    }
+
+    changed := false;
+    for _, user := range users {
+        if (user.Unenroll(course.GetID())) {
+            changed = true;
+        }
+    }
+
+    if (!changed) {
         return nil;
     }
 
-    delete(users, email);
+    return this.saveServerUsersLock(users);
+}
 
-    err = util.ToJSONFileIndent(users, this.getUsersPath(course));
+// Write a course's users in the format of a course's static users file.
+func (this *backend) dumpUsersLock(course *model.Course, targetDir string) error {
+    users, err := this.getUsersLock(course, false);
     if (err != nil) {
-        return fmt.Errorf("Unable to save user's file: '%w'.", err);
+        return err;
+    }
+
+    if (len(users) == 0) {
+        return nil;
     }
 
+    return util.ToJSONFileIndent(users, filepath.Join(targetDir, model.USERS_FILENAME));
+}
+
+// Move users from course user files (from before accounts were server-wide) into accounts.
+// Courses are migrated in order of their IDs,
+// and credentials from the first course a user appears in are kept (later courses only add an enrollment).
+func (this *backend) migrateCourseUsers() error {
+    this.lock.Lock();
+    defer this.lock.Unlock();
+
+    coursesDir := filepath.Join(this.baseDir, DISK_DB_COURSES_DIR);
+    if (!util.PathExists(coursesDir)) {
+        return nil;
+    }
+
+    // ReadDir() returns entries sorted by name.
+    dirents, err := os.ReadDir(coursesDir);
+    if (err != nil) {
+        return fmt.Errorf("Failed to list course dirs in '%s': '%w'.", coursesDir, err);
+    }
+
+    for _, dirent := range dirents {
+        if (!dirent.IsDir()) {
+            continue;
+        }
+
+        path := filepath.Join(coursesDir, dirent.Name(), model.USERS_FILENAME);
+        if (!util.PathExists(path)) {
+            continue;
+        }
+
+        users := make(map[string]*model.User);
+        err = util.JSONFromFile(path, &users);
+        if (err != nil) {
+            return fmt.Errorf("Failed to read course users file '%s': '%w'.", path, err);
+        }
+
+        course, err := model.ReadCourseConfig(filepath.Join(coursesDir, dirent.Name(), model.COURSE_CONFIG_FILENAME));
+        if (err != nil) {
+            return fmt.Errorf("Failed to load course for user migration '%s': '%w'.", dirent.Name(), err);
+        }
+
+        err = this.saveUsersLock(course, users, false);
+        if (err != nil) {
+            return fmt.Errorf("Failed to migrate course users '%s': '%w'.", path, err);
+        }
+
+        err = os.Rename(path, path + MIGRATED_USERS_SUFFIX);
+        if (err != nil) {
+            return fmt.Errorf("Failed to move migrated course users file '%s': '%w'.", path, err);
+        }
+
+        log.Info("Migrated course users to server accounts.", course, log.NewAttr("num-users", len(users)));
+    }
+
+    return nil;
+}
+
+// Get a copy of all the accounts (that the caller is free to modify).
+// The caller must hold the lock (read or write).
+func (this *backend) getServerUsersLock() (map[string]*model.ServerUser, error) {
+    cachedUsers, err := this.getCachedServerUsersLock();
+    if (err != nil) {
+        return nil, err;
+    }
+
+    users := make(map[string]*model.ServerUser, len(cachedUsers));
+    for email, cachedUser := range cachedUsers {
+        users[email], err = copyServerUser(cachedUser);
+        if (err != nil) {
+            return nil, err;
+        }
+    }
+
+    return users, nil;
+}
+
+// Get the cached accounts (loading them if necessary).
+// Other processes (e.g., the cmd tools) may write the users file,
+// so the cache is reloaded whenever the file changes.
+// The returned users must not be modified.
+// The caller must hold the lock (read or write).
+func (this *backend) getCachedServerUsersLock() (map[string]*model.ServerUser, error) {
+    this.usersCacheLock.Lock();
+    defer this.usersCacheLock.Unlock();
+
+    path := this.getServerUsersPath();
+
+    var stat os.FileInfo = nil;
+    if (util.PathExists(path)) {
+        var err error;
+        stat, err = os.Stat(path);
+        if (err != nil) {
+            return nil, fmt.Errorf("Failed to stat users file '%s': '%w'.", path, err);
+        }
+    }
+
+    if ((this.serverUsers != nil) && sameFileInfo(this.serverUsersStat, stat)) {
+        return this.serverUsers, nil;
+    }
+
+    users := make(map[string]*model.ServerUser);
+
+    if (stat != nil) {
+        err := util.JSONFromFile(path, &users);
+        if (err != nil) {
+            return nil, err;
+        }
+    }
+
+    this.serverUsers = users;
+    this.serverUsersStat = stat;
+
+    return this.serverUsers, nil;
+}
+
+// Save all the accounts, and cache what was written.
+// The caller must hold the write lock.
+func (this *backend) saveServerUsersLock(users map[string]*model.ServerUser) error {
+    text, err := util.ToJSONIndent(users);
+    if (err != nil) {
+        return fmt.Errorf("Unable to serialize users: '%w'.", err);
+    }
+
+    // Make sure the cache will never be stale, even if the write fails part way.
+    this.clearServerUsersCache();
+
+    err = util.WriteFile(text, this.getServerUsersPath());
+    if (err != nil) {
+        return fmt.Errorf("Unable to save users file: '%w'.", err);
+    }
+
+    // The cache gets its own copy (from what was written), so the caller's users can still be modified.
+    cachedUsers := make(map[string]*model.ServerUser);
+    err = util.JSONFromString(text, &cachedUsers);
+    if (err != nil) {
+        return fmt.Errorf("Unable to cache users: '%w'.", err);
+    }
+
+    stat, err := os.Stat(this.getServerUsersPath());
+    if (err != nil) {
+        return fmt.Errorf("Failed to stat saved users file: '%w'.", err);
+    }
+
+    this.usersCacheLock.Lock();
+    defer this.usersCacheLock.Unlock();
+
+    this.serverUsers = cachedUsers;
+    this.serverUsersStat = stat;
+
     return nil;
 }
 
-func (this *backend) getUsersPath(course *model.Course) string {
-    return filepath.Join(this.getCourseDir(course), model.USERS_FILENAME);
+func (this *backend) clearServerUsersCache() {
+    this.usersCacheLock.Lock();
+    defer this.usersCacheLock.Unlock();
+
+    this.serverUsers = nil;
+    this.serverUsersStat = nil;
+}
+
+func sameFileInfo(a os.FileInfo, b os.FileInfo) bool {
+    if ((a == nil) || (b == nil)) {
+        return (a == b);
+    }
+
+    return (a.ModTime().Equal(b.ModTime()) && (a.Size() == b.Size()));
+}
+
+// Deep copy an account (nil stays nil).
+func copyServerUser(user *model.ServerUser) (*model.ServerUser, error) {
+    if (user == nil) {
+        return nil, nil;
+    }
+
+    var userCopy model.ServerUser;
+    err := util.JSONFromString(util.MustToJSON(user), &userCopy);
+    if (err != nil) {
+        return nil, fmt.Errorf("Failed to copy user '%s': '%w'.", user.Email, err);
+    }
+
+    return &userCopy, nil;
+}
+
+func (this *backend) getServerUsersPath() string {
+    return filepath.Join(this.baseDir, DISK_DB_USERS_FILENAME);
 }
@@ -0,0 +1,140 @@
+package disk
+
+import (
+    "os"
+    "path/filepath"
+    "testing"
+
+    "github.com/edulinq/autograder/model"
+    "github.com/edulinq/autograder/util"
+)
+
+func TestMigrateCourseUsers(test *testing.T) {
+    tempDir, err := util.MkDirTemp("autograder-test-migrate-users-");
+    if (err != nil) {
+        test.Fatalf("Failed to make temp dir: '%v'.", err);
+    }
+    defer os.RemoveAll(tempDir);
+
+    backend := &backend{baseDir: tempDir};
+
+    courseUsers := map[string]map[string]*model.User{
+        "course-a": map[string]*model.User{
+            "shared@test.com": &model.User{Email: "shared@test.com", Name: "shared", Role: model.RoleStudent, Pass: "pass-a", Salt: "salt-a"},
+            "only-a@test.com": &model.User{Email: "only-a@test.com", Name: "a", Role: model.RoleGrader, Pass: "pass-only-a", Salt: "salt-only-a"},
+        },
+        "course-b": map[string]*model.User{
+            "shared@test.com": &model.User{Email: "shared@test.com", Name: "shared", Role: model.RoleAdmin, Pass: "pass-b", Salt: "salt-b", LMSID: "lms-shared"},
+        },
+    };
+
+    for courseID, users := range courseUsers {
+        courseDir := filepath.Join(tempDir, DISK_DB_COURSES_DIR, courseID);
+        util.MkDir(courseDir);
+
+        err = util.ToJSONFile(map[string]any{"id": courseID}, filepath.Join(courseDir, model.COURSE_CONFIG_FILENAME));
+        if (err != nil) {
+            test.Fatalf("Failed to write course config: '%v'.", err);
+        }
+
+        err = util.ToJSONFile(users, filepath.Join(courseDir, model.USERS_FILENAME));
+        if (err != nil) {
+            test.Fatalf("Failed to write course users: '%v'.", err);
+        }
+    }
+
+    err = backend.EnsureTables();
+    if (err != nil) {
+        test.Fatalf("Failed to migrate users: '%v'.", err);
+    }
+
+    users, err := backend.GetServerUsers();
+    if (err != nil) {
+        test.Fatalf("Failed to get users: '%v'.", err);
+    }
+
+    if (len(users) != 2) {
+        test.Fatalf("Unexpected number of accounts. Expected: 2, Actual: %d.", len(users));
+    }
+
+    shared := users["shared@test.com"];
+    if (shared == nil) {
+        test.Fatalf("Shared user was not migrated.");
+    }
+
+    // The first course's credentials are kept.
+    if ((shared.Pass != "pass-a") || (shared.Salt != "salt-a")) {
+        test.Fatalf("Unexpected shared user credentials: '%s', '%s'.", shared.Pass, shared.Salt);
+    }
+
+    if ((shared.Enrollments["course-a"].Role != model.RoleStudent) || (shared.Enrollments["course-b"].Role != model.RoleAdmin)) {
+        test.Fatalf("Unexpected shared user enrollments: '%s'.", util.MustToJSON(shared.Enrollments));
+    }
+
+    if (shared.Enrollments["course-b"].LMSID != "lms-shared") {
+        test.Fatalf("LMS ID was not migrated: '%s'.", shared.Enrollments["course-b"].LMSID);
+    }
+
+    if (users["only-a@test.com"].IsEnrolled("course-b")) {
+        test.Fatalf("User was enrolled in the wrong course.");
+    }
+
+    for courseID, _ := range courseUsers {
+        path := filepath.Join(tempDir, DISK_DB_COURSES_DIR, courseID, model.USERS_FILENAME);
+        if (util.PathExists(path) || !util.PathExists(path + MIGRATED_USERS_SUFFIX)) {
+            test.Fatalf("Course users file was not moved: '%s'.", path);
+        }
+    }
+
+    // Migrating again does nothing.
+    err = backend.EnsureTables();
+    if (err != nil) {
+        test.Fatalf("Failed to migrate users a second time: '%v'.", err);
+    }
+
+    newUsers, err := backend.GetServerUsers();
+    if (err != nil) {
+        test.Fatalf("Failed to get users: '%v'.", err);
+    }
+
+    if (util.MustToJSON(users) != util.MustToJSON(newUsers)) {
+        test.Fatalf("Second migration changed the users.");
+    }
+}
+
+// The users file may be changed by another process (e.g., a cmd tool), so the cache should notice.
+func TestServerUsersCacheExternalChange(test *testing.T) {
+    tempDir, err := util.MkDirTemp("autograder-test-users-cache-");
+    if (err != nil) {
+        test.Fatalf("Failed to make temp dir: '%v'.", err);
+    }
+    defer os.RemoveAll(tempDir);
+
+    cachedBackend := &backend{baseDir: tempDir};
+
+    err = cachedBackend.SaveServerUsers(map[string]*model.ServerUser{
+        "a@test.com": &model.ServerUser{Email: "a@test.com", Name: "a"},
+    });
+    if (err != nil) {
+        test.Fatalf("Failed to save users: '%v'.", err);
+    }
+
+    user, err := cachedBackend.GetServerUser("a@test.com");
+    if ((err != nil) || (user == nil) || (user.Name != "a")) {
+        test.Fatalf("Unexpected user before external change: '%v' (err: '%v').", user, err);
+    }
+
+    // Another process (a separate backend) writes the file.
+    otherBackend := &backend{baseDir: tempDir};
+    err = otherBackend.SaveServerUsers(map[string]*model.ServerUser{
+        "a@test.com": &model.ServerUser{Email: "a@test.com", Name: "changed externally"},
+    });
+    if (err != nil) {
+        test.Fatalf("Failed to save users externally: '%v'.", err);
+    }
+
+    user, err = cachedBackend.GetServerUser("a@test.com");
+    if ((err != nil) || (user == nil) || (user.Name != "changed externally")) {
+        test.Fatalf("Unexpected user after external change: '%s' (err: '%v').", util.MustToJSON(user), err);
+    }
+}
@@ -0,0 +1,88 @@
+package db
+
+import (
+    "fmt"
+    "sync"
+    "time"
+
+    "github.com/edulinq/autograder/config"
+    "github.com/edulinq/autograder/log"
+    "github.com/edulinq/autograder/model"
+)
+
+var sessionKeyLock sync.Mutex;
+
+// Get all the session keys that tokens may still be signed with, newest first.
+// Keys will be rotated as needed (see config.SESSION_KEY_ROTATION_HOURS),
+// so there is always at least one key and the first key should be used to sign new tokens.
+func GetSessionKeys() ([]*model.SessionKey, error) {
+    if (backend == nil) {
+        return nil, fmt.Errorf("Database has not been opened.");
+    }
+
+    sessionKeyLock.Lock();
+    defer sessionKeyLock.Unlock();
+
+    keys, err := backend.GetSessionKeys();
+    if (err != nil) {
+        return nil, fmt.Errorf("Failed to get session keys: '%w'.", err);
+    }
+
+    newKeys, changed, err := rotateSessionKeys(keys, time.Now());
+    if (err != nil) {
+        return nil, err;
+    }
+
+    if (changed) {
+        err = backend.SaveSessionKeys(newKeys);
+        if (err != nil) {
+            return nil, fmt.Errorf("Failed to save session keys: '%w'.", err);
+        }
+    }
+
+    return newKeys, nil;
+}
+
+// Add a new key if the newest key is too old, and remove keys that can no longer have any valid tokens.
+// A key signs new tokens for one rotation period, and the longest lived token (a refresh token) is valid after that.
+func rotateSessionKeys(keys []*model.SessionKey, now time.Time) ([]*model.SessionKey, bool, error) {
+    rotation := time.Duration(config.SESSION_KEY_ROTATION_HOURS.Get()) * time.Hour;
+    retirement := rotation + (time.Duration(config.SESSION_REFRESH_TTL_HOURS.Get()) * time.Hour);
+
+    changed := false;
+    newKeys := make([]*model.SessionKey, 0, len(keys) + 1);
+
+    for _, key := range keys {
+        creation, err := key.CreationTime.Time();
+        if (err != nil) {
+            log.Warn("Removing session key with a bad creation time.", err, log.NewAttr("key-id", key.ID));
+            changed = true;
+            continue;
+        }
+
+        if (now.Sub(creation) >= retirement) {
+            changed = true;
+            continue;
+        }
+
+        newKeys = append(newKeys, key);
+    }
+
+    needsKey := (len(newKeys) == 0);
+    if (!needsKey) {
+        creation, _ := newKeys[0].CreationTime.Time();
+        needsKey = (now.Sub(creation) >= rotation);
+    }
+
+    if (needsKey) {
+        key, err := model.NewSessionKey();
+        if (err != nil) {
+            return nil, false, err;
+        }
+
+        newKeys = append([]*model.SessionKey{key}, newKeys...);
+        changed = true;
+    }
+
+    return newKeys, changed, nil;
+}
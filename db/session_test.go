@@ -0,0 +1,85 @@
+package db
+
+import (
+    "testing"
+    "time"
+
+    "github.com/edulinq/autograder/common"
+    "github.com/edulinq/autograder/config"
+    "github.com/edulinq/autograder/model"
+)
+
+func (this *DBTests) DBTestGetSessionKeys(test *testing.T) {
+    defer ResetForTesting();
+    ResetForTesting();
+
+    keys, err := GetSessionKeys();
+    if (err != nil) {
+        test.Fatalf("Failed to get session keys: '%v'.", err);
+    }
+
+    if (len(keys) != 1) {
+        test.Fatalf("Unexpected number of initial keys. Expected: 1, Actual: %d.", len(keys));
+    }
+
+    // Keys are stable until they need to be rotated.
+    sameKeys, err := GetSessionKeys();
+    if (err != nil) {
+        test.Fatalf("Failed to get session keys: '%v'.", err);
+    }
+
+    if ((len(sameKeys) != 1) || (sameKeys[0].ID != keys[0].ID)) {
+        test.Fatalf("Keys changed without needing rotation. Expected: '%s', Actual: '%v'.", keys[0].ID, sameKeys);
+    }
+
+    rotation := time.Duration(config.SESSION_KEY_ROTATION_HOURS.Get()) * time.Hour;
+    retirement := rotation + (time.Duration(config.SESSION_REFRESH_TTL_HOURS.Get()) * time.Hour);
+
+    // Age the key so that it needs to be rotated (but is not retired).
+    keys[0].CreationTime = common.TimestampFromTime(time.Now().Add(-rotation));
+    err = backend.SaveSessionKeys(keys);
+    if (err != nil) {
+        test.Fatalf("Failed to save session keys: '%v'.", err);
+    }
+
+    rotatedKeys, err := GetSessionKeys();
+    if (err != nil) {
+        test.Fatalf("Failed to get session keys: '%v'.", err);
+    }
+
+    if (len(rotatedKeys) != 2) {
+        test.Fatalf("Unexpected number of keys after rotation. Expected: 2, Actual: %d.", len(rotatedKeys));
+    }
+
+    if ((rotatedKeys[0].ID == keys[0].ID) || (rotatedKeys[1].ID != keys[0].ID)) {
+        test.Fatalf("Rotation did not put a new key first: '%v'.", rotatedKeys);
+    }
+
+    // Retire the old key.
+    rotatedKeys[1].CreationTime = common.TimestampFromTime(time.Now().Add(-retirement));
+    err = backend.SaveSessionKeys(rotatedKeys);
+    if (err != nil) {
+        test.Fatalf("Failed to save session keys: '%v'.", err);
+    }
+
+    retiredKeys, err := GetSessionKeys();
+    if (err != nil) {
+        test.Fatalf("Failed to get session keys: '%v'.", err);
+    }
+
+    if ((len(retiredKeys) != 1) || (retiredKeys[0].ID != rotatedKeys[0].ID)) {
+        test.Fatalf("Old key was not retired: '%v'.", retiredKeys);
+    }
+
+    // Tokens signed by a retired key no longer work.
+    user := &model.User{Email: "student@test.com"};
+    token, err := model.SignSessionClaims(rotatedKeys[1], model.NewSessionClaims(model.SESSION_TOKEN_TYPE_SESSION, "course101", user, time.Hour));
+    if (err != nil) {
+        test.Fatalf("Failed to sign session: '%v'.", err);
+    }
+
+    _, err = model.ParseSessionToken(token, model.SESSION_TOKEN_TYPE_SESSION, retiredKeys);
+    if (err == nil) {
+        test.Fatalf("Token signed by a retired key is still valid.");
+    }
+}
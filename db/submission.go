@@ -2,6 +2,7 @@ package db
 
 import (
     "fmt"
+    "sort"
 
     "github.com/edulinq/autograder/common"
     "github.com/edulinq/autograder/model"
@@ -122,3 +123,71 @@ func GetSubmissionAttempts(assignment *model.Assignment, email string) ([]*model
 
     return backend.GetSubmissionAttempts(assignment, email);
 }
+
+// Get the history items for all submissions (from users of the given role) that were graded by a matching grader version.
+// A role of model.RoleUnknown means all users.
+// See model.GraderVersion.Matches() for matching rules.
+func GetSubmissionsByGraderVersion(assignment *model.Assignment, query *model.GraderVersion, filterRole model.UserRole) ([]*model.SubmissionHistoryItem, error) {
+    if (backend == nil) {
+        return nil, fmt.Errorf("Database has not been opened.");
+    }
+
+    users, err := backend.GetUsers(assignment.GetCourse());
+    if (err != nil) {
+        return nil, fmt.Errorf("Failed to get users: '%w'.", err);
+    }
+
+    results := make([]*model.SubmissionHistoryItem, 0);
+    for email, user := range users {
+        if ((filterRole != model.RoleUnknown) && (filterRole != user.Role)) {
+            continue;
+        }
+
+        history, err := backend.GetSubmissionHistory(assignment, email);
+        if (err != nil) {
+            return nil, fmt.Errorf("Failed to get submission history for user '%s': '%w'.", email, err);
+        }
+
+        for _, item := range history {
+            if (item.GraderVersion.Matches(query)) {
+                results = append(results, item);
+            }
+        }
+    }
+
+    sort.Slice(results, func(i int, j int) bool {
+        return results[i].ID < results[j].ID;
+    });
+
+    return results, nil;
+}
+
+// Get the most recent submission from a user with the given submission hash (see model.ComputeSubmissionHash()).
+// Returns nil if there is no matching submission.
+func GetSubmissionByHash(assignment *model.Assignment, email string, submissionHash string) (*model.GradingResult, error) {
+    if (backend == nil) {
+        return nil, fmt.Errorf("Database has not been opened.");
+    }
+
+    if (submissionHash == "") {
+        return nil, nil;
+    }
+
+    attempts, err := backend.GetSubmissionAttempts(assignment, email);
+    if (err != nil) {
+        return nil, fmt.Errorf("Failed to get submission attempts for user '%s': '%w'.", email, err);
+    }
+
+    var result *model.GradingResult = nil;
+    for _, attempt := range attempts {
+        if ((attempt == nil) || (attempt.Info == nil) || (attempt.Info.SubmissionHash != submissionHash)) {
+            continue;
+        }
+
+        if ((result == nil) || (attempt.Info.ShortID > result.Info.ShortID)) {
+            result = attempt;
+        }
+    }
+
+    return result, nil;
+}
@@ -4,6 +4,8 @@ import (
     "reflect"
     "testing"
 
+    "github.com/edulinq/autograder/common"
+    "github.com/edulinq/autograder/model"
     "github.com/edulinq/autograder/util"
 )
 
@@ -136,3 +138,176 @@ func (this *DBTests) DBTestFetchAttempts(test *testing.T) {
         test.Fatalf("Unexpected result length. Expected: '%d', Actual: '%d'.", 0, len(graderAttempts));
     }
 }
+
+func (this *DBTests) DBTestGetSubmissionsByGraderVersion(test *testing.T) {
+    defer ResetForTesting();
+    ResetForTesting();
+
+    assignment := MustGetTestAssignment();
+
+    // Existing test submissions have no grader version.
+    allItems, err := GetSubmissionsByGraderVersion(assignment, nil, model.RoleUnknown);
+    if (err != nil) {
+        test.Fatalf("Failed to get all submissions: '%v'.", err);
+    }
+
+    if (len(allItems) == 0) {
+        test.Fatalf("Found no existing submissions.");
+    }
+
+    version := &model.GraderVersion{ImageID: "sha256:1234", CommitHash: "abcd", ConfigHash: "ef01"};
+
+    result, err := GetSubmissionContents(assignment, "student@test.com", "");
+    if (err != nil) {
+        test.Fatalf("Failed to get submission contents: '%v'.", err);
+    }
+
+    shortID, err := GetNextSubmissionID(assignment, "student@test.com");
+    if (err != nil) {
+        test.Fatalf("Failed to get next submission ID: '%v'.", err);
+    }
+
+    result.Info.ShortID = shortID;
+    result.Info.ID = common.CreateFullSubmissionID(assignment.GetCourse().GetID(), assignment.GetID(), "student@test.com", shortID);
+    result.Info.GraderVersion = version;
+
+    err = SaveSubmission(assignment, result);
+    if (err != nil) {
+        test.Fatalf("Failed to save submission: '%v'.", err);
+    }
+
+    testCases := []struct{query *model.GraderVersion; role model.UserRole; expectedIDs []string}{
+        {version, model.RoleUnknown, []string{result.Info.ID}},
+        {&model.GraderVersion{ImageID: "sha256:1234"}, model.RoleUnknown, []string{result.Info.ID}},
+        {&model.GraderVersion{CommitHash: "abcd", ConfigHash: "ef01"}, model.RoleStudent, []string{result.Info.ID}},
+        {&model.GraderVersion{ImageID: "sha256:1234"}, model.RoleGrader, []string{}},
+        {&model.GraderVersion{ImageID: "sha256:1234", CommitHash: "ZZZ"}, model.RoleUnknown, []string{}},
+        {&model.GraderVersion{ImageID: "ZZZ"}, model.RoleUnknown, []string{}},
+    };
+
+    for i, testCase := range testCases {
+        items, err := GetSubmissionsByGraderVersion(assignment, testCase.query, testCase.role);
+        if (err != nil) {
+            test.Errorf("Case %d: Failed to get submissions: '%v'.", i, err);
+            continue;
+        }
+
+        ids := make([]string, 0, len(items));
+        for _, item := range items {
+            ids = append(ids, item.ID);
+        }
+
+        if (!reflect.DeepEqual(testCase.expectedIDs, ids)) {
+            test.Errorf("Case %d: Unexpected submissions. Expected: '%s', Actual: '%s'.", i, testCase.expectedIDs, ids);
+        }
+    }
+
+    // An empty query matches everything.
+    afterItems, err := GetSubmissionsByGraderVersion(assignment, &model.GraderVersion{}, model.RoleUnknown);
+    if (err != nil) {
+        test.Fatalf("Failed to get all submissions: '%v'.", err);
+    }
+
+    if (len(afterItems) != (len(allItems) + 1)) {
+        test.Fatalf("Unexpected number of submissions. Expected: %d, Actual: %d.", len(allItems) + 1, len(afterItems));
+    }
+}
+
+func (this *DBTests) DBTestSubmissionSidecarLogs(test *testing.T) {
+    defer ResetForTesting();
+    ResetForTesting();
+
+    assignment := MustGetTestAssignment();
+    email := "student@test.com";
+
+    result, err := GetSubmissionContents(assignment, email, "");
+    if (err != nil) {
+        test.Fatalf("Failed to get submission contents: '%v'.", err);
+    }
+
+    if (result.SidecarLogs != nil) {
+        test.Fatalf("Existing submission unexpectedly has sidecar logs: '%v'.", result.SidecarLogs);
+    }
+
+    shortID, err := GetNextSubmissionID(assignment, email);
+    if (err != nil) {
+        test.Fatalf("Failed to get next submission ID: '%v'.", err);
+    }
+
+    result.Info.ShortID = shortID;
+    result.Info.ID = common.CreateFullSubmissionID(assignment.GetCourse().GetID(), assignment.GetID(), email, shortID);
+    result.SidecarLogs = map[string]string{
+        "db": "database system is ready to accept connections\n",
+        "cache": "",
+    };
+
+    err = SaveSubmission(assignment, result);
+    if (err != nil) {
+        test.Fatalf("Failed to save submission: '%v'.", err);
+    }
+
+    loaded, err := GetSubmissionContents(assignment, email, shortID);
+    if (err != nil) {
+        test.Fatalf("Failed to get saved submission contents: '%v'.", err);
+    }
+
+    if (!reflect.DeepEqual(result.SidecarLogs, loaded.SidecarLogs)) {
+        test.Fatalf("Unexpected sidecar logs. Expected: '%v', Actual: '%v'.", result.SidecarLogs, loaded.SidecarLogs);
+    }
+}
+
+func (this *DBTests) DBTestGetSubmissionByHash(test *testing.T) {
+    defer ResetForTesting();
+    ResetForTesting();
+
+    assignment := MustGetTestAssignment();
+    email := "student@test.com";
+
+    result, err := GetSubmissionByHash(assignment, email, "abc123");
+    if (err != nil) {
+        test.Fatalf("Failed to get submission by hash: '%v'.", err);
+    }
+
+    if (result != nil) {
+        test.Fatalf("Found a submission before any were hashed: '%s'.", result.Info.ID);
+    }
+
+    // Give two submissions the same hash, the most recent one should be returned.
+    for _, shortID := range []string{"1697406256", "1697406265"} {
+        submission, err := GetSubmissionContents(assignment, email, shortID);
+        if (err != nil) {
+            test.Fatalf("Failed to get submission contents: '%v'.", err);
+        }
+
+        submission.Info.SubmissionHash = "abc123";
+
+        err = SaveSubmission(assignment, submission);
+        if (err != nil) {
+            test.Fatalf("Failed to save submission: '%v'.", err);
+        }
+    }
+
+    testCases := []struct{email string; hash string; expectedShortID string}{
+        {email, "abc123", "1697406265"},
+        {email, "ZZZ", ""},
+        {email, "", ""},
+        {"grader@test.com", "abc123", ""},
+    };
+
+    for i, testCase := range testCases {
+        result, err := GetSubmissionByHash(assignment, testCase.email, testCase.hash);
+        if (err != nil) {
+            test.Errorf("Case %d: Failed to get submission by hash: '%v'.", i, err);
+            continue;
+        }
+
+        shortID := "";
+        if (result != nil) {
+            shortID = result.Info.ShortID;
+        }
+
+        if (testCase.expectedShortID != shortID) {
+            test.Errorf("Case %d: Unexpected submission. Expected: '%s', Actual: '%s'.", i, testCase.expectedShortID, shortID);
+        }
+    }
+}
@@ -10,6 +10,11 @@ import (
 const TEST_COURSE_ID = "COURSE101";
 const TEST_ASSIGNMENT_ID = "hw0";
 
+// A server admin that is not enrolled in any course.
+// The password is the cleartext password (like the other test users, it is the same as the user's name).
+const TEST_SERVER_ADMIN_EMAIL = "server-admin@test.com";
+const TEST_SERVER_ADMIN_PASS = "server-admin";
+
 func MustGetTestCourse() *model.Course {
     return MustGetCourse(TEST_COURSE_ID);
 }
@@ -36,6 +41,26 @@ func PrepForTestingMain() {
 func ResetForTesting() {
     MustClear();
     MustAddCourses();
+    mustAddTestServerAdmin();
+}
+
+func mustAddTestServerAdmin() {
+    user := &model.ServerUser{
+        Email: TEST_SERVER_ADMIN_EMAIL,
+        Name: TEST_SERVER_ADMIN_PASS,
+        Role: model.ServerRoleAdmin,
+        Enrollments: make(map[string]*model.Enrollment),
+    };
+
+    err := user.SetPassword(util.Sha256HexFromString(TEST_SERVER_ADMIN_PASS));
+    if (err != nil) {
+        log.Fatal("Failed to set test server admin password.", err);
+    }
+
+    err = SaveServerUser(user);
+    if (err != nil) {
+        log.Fatal("Failed to save test server admin.", err);
+    }
 }
 
 func CleanupTestingMain() {
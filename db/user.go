@@ -10,6 +10,35 @@ import (
     "github.com/edulinq/autograder/model"
 )
 
+// Get all the server-wide accounts.
+func GetServerUsers() (map[string]*model.ServerUser, error) {
+    if (backend == nil) {
+        return nil, fmt.Errorf("Database has not been opened.");
+    }
+
+    return backend.GetServerUsers();
+}
+
+// Get a server-wide account.
+// Returns (nil, nil) if the account does not exist.
+func GetServerUser(email string) (*model.ServerUser, error) {
+    if (backend == nil) {
+        return nil, fmt.Errorf("Database has not been opened.");
+    }
+
+    return backend.GetServerUser(email);
+}
+
+// Save (override) a server-wide account, including all of its enrollments.
+func SaveServerUser(user *model.ServerUser) error {
+    if (backend == nil) {
+        return fmt.Errorf("Database has not been opened.");
+    }
+
+    return backend.SaveServerUsers(map[string]*model.ServerUser{user.Email: user});
+}
+
+// Get the users enrolled in a course.
 func GetUsers(course *model.Course) (map[string]*model.User, error) {
     if (backend == nil) {
         return nil, fmt.Errorf("Database has not been opened.");
@@ -62,6 +91,7 @@ func GetUser(course *model.Course, email string) (*model.User, error) {
 }
 
 // Insert the given users (overriding any conflicting users).
+// Since accounts are server-wide, the name, password, and tokens of a user are changed for all their courses.
 // For user merging (instead of overriding), user db.SyncUsers().
 func SaveUsers(course *model.Course, users map[string]*model.User) error {
     if (backend == nil) {
@@ -77,7 +107,17 @@ func SaveUser(course *model.Course, user *model.User) error {
     return SaveUsers(course, users);
 }
 
-// Remove a user.
+// Enroll the given users in a course (overriding any conflicting enrollments).
+// Unlike SaveUsers(), the accounts of existing users (name, password, tokens) are left alone.
+func EnrollUsers(course *model.Course, users map[string]*model.User) error {
+    if (backend == nil) {
+        return fmt.Errorf("Database has not been opened.");
+    }
+
+    return backend.EnrollUsers(course, users);
+}
+
+// Remove a user from a course (the user's account and other enrollments are kept).
 // Returns a boolean indicating if the user exists.
 // If true, then the user exists and was removed.
 // If false (and the error is nil), then the user did not exist.
@@ -115,10 +155,13 @@ func SyncUser(course *model.Course, user *model.User,
 
 // Sync (merge) new users with existing users.
 // The db takes ownership of the passed-in users (they may be modified).
-// If |merge| is true, then existing users will be updated with non-empty fields.
+// If |merge| is true, then existing users will have their enrollment (role and LMS ID) updated with non-empty fields.
 // Otherwise existing users will be ignored.
-// Any non-ignored user WILL have their password changed.
-// Passwords should either be left empty (and they will be randomly generated),
+// Users that already have an account (e.g., from another course) are only enrolled,
+// the account itself (name and credentials) is never changed by a sync
+// (only the account holder or a server admin may change those).
+// New accounts have their password set:
+// passwords should either be left empty (and they will be randomly generated),
 // or set to the hash of the desired password.
 func SyncUsers(course *model.Course, newUsers map[string]*model.User,
         merge bool, dryRun bool, sendEmails bool) (*model.UserSyncResult, error) {
@@ -131,12 +174,20 @@ func SyncUsers(course *model.Course, newUsers map[string]*model.User,
         return nil, fmt.Errorf("Failed to fetch local users: '%w'.", err);
     }
 
+    serverUsers, err := GetServerUsers();
+    if (err != nil) {
+        return nil, fmt.Errorf("Failed to fetch server users: '%w'.", err);
+    }
+
     // Summary of results.
     syncResult := model.NewUserSyncResult();
 
-    // Users that require saving in the DB.
+    // New accounts that require saving in the DB.
     syncUsers := make(map[string]*model.User);
 
+    // Existing accounts that only need their enrollment saved.
+    enrollUsers := make(map[string]*model.User);
+
     for _, newUser := range newUsers {
         localUser := localUsers[newUser.Email];
 
@@ -146,6 +197,42 @@ func SyncUsers(course *model.Course, newUsers map[string]*model.User,
             continue;
         }
 
+        // Default role-less users to model.RoleOther.
+        if ((localUser == nil) && (newUser.Role == model.RoleUnknown)) {
+            newUser.Role = model.RoleOther;
+        }
+
+        serverUser := serverUsers[newUser.Email];
+        if ((localUser == nil) && (serverUser != nil)) {
+            // An existing account that is new to this course.
+            enrolledUser := &model.User{
+                Email: newUser.Email,
+                Name: serverUser.Name,
+                Role: newUser.Role,
+                LMSID: newUser.LMSID,
+            };
+
+            syncResult.AddResolveResult(&model.UserResolveResult{Add: enrolledUser});
+            enrollUsers[newUser.Email] = enrolledUser;
+
+            continue;
+        }
+
+        if (localUser != nil) {
+            // Merge the enrollment.
+            changed := localUser.MergeEnrollment(newUser);
+            if (changed) {
+                syncResult.AddResolveResult(&model.UserResolveResult{Mod: localUser});
+                enrollUsers[newUser.Email] = localUser;
+            } else {
+                syncResult.AddResolveResult(&model.UserResolveResult{Unchanged: localUser});
+            }
+
+            continue;
+        }
+
+        // New account.
+
         if (newUser.Pass == "") {
             clearTextPass, err := newUser.SetRandomPassword();
             if (err != nil) {
@@ -162,28 +249,8 @@ func SyncUsers(course *model.Course, newUsers map[string]*model.User,
             }
         }
 
-        if (localUser == nil) {
-            // New user.
-
-            // Default role-less users to model.RoleOther.
-            if (newUser.Role == model.RoleUnknown) {
-                newUser.Role = model.RoleOther;
-            }
-
-            syncResult.AddResolveResult(&model.UserResolveResult{Add: newUser});
-            syncUsers[newUser.Email] = newUser;
-
-            continue;
-        }
-
-        // Merge.
-        changed := localUser.Merge(newUser);
-        if (changed) {
-            syncResult.AddResolveResult(&model.UserResolveResult{Mod: localUser});
-            syncUsers[newUser.Email] = localUser;
-        } else {
-            syncResult.AddResolveResult(&model.UserResolveResult{Unchanged: localUser});
-        }
+        syncResult.AddResolveResult(&model.UserResolveResult{Add: newUser});
+        syncUsers[newUser.Email] = newUser;
     }
 
     if (dryRun) {
@@ -195,24 +262,25 @@ func SyncUsers(course *model.Course, newUsers map[string]*model.User,
         return nil, fmt.Errorf("Failed to save users file: '%w'.", err);
     }
 
+    err = EnrollUsers(course, enrollUsers);
+    if (err != nil) {
+        return nil, fmt.Errorf("Failed to save user enrollments: '%w'.", err);
+    }
+
     if (sendEmails) {
         sleep := (len(newUsers) > 1);
 
         err = nil;
 
         for _, newUser := range syncResult.Add {
-            clearTextPass := syncResult.ClearTextPasswords[newUser.Email];
-            err = errors.Join(err, model.SendUserAddEmail(course, newUser, clearTextPass, (clearTextPass != ""), false, dryRun, sleep));
-        }
-
-        for _, newUser := range syncResult.Mod {
-            clearTextPass := syncResult.ClearTextPasswords[newUser.Email];
-            if (clearTextPass == "") {
-                // Unlike new users, only send an email on generated passwords.
+            _, enrolled := enrollUsers[newUser.Email];
+            if (enrolled) {
+                // An existing account, nothing was created.
                 continue;
             }
 
-            err = errors.Join(err, model.SendUserAddEmail(course, newUser, clearTextPass, true, true, dryRun, sleep));
+            clearTextPass := syncResult.ClearTextPasswords[newUser.Email];
+            err = errors.Join(err, model.SendUserAddEmail(course, newUser, clearTextPass, (clearTextPass != ""), false, dryRun, sleep));
         }
 
         if (err != nil) {
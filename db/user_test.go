@@ -191,6 +191,183 @@ func (this *DBTests) DBTestResolveUsers(test *testing.T) {
     }
 }
 
+func (this *DBTests) DBTestServerUserAcrossCourses(test *testing.T) {
+    defer ResetForTesting();
+
+    courseA := MustGetCourse(TEST_COURSE_ID);
+    courseB := MustGetCourse("course-languages");
+
+    user, err := GetUser(courseA, "student@test.com");
+    if (err != nil) {
+        test.Fatalf("Failed to get user: '%v'.", err);
+    }
+
+    // Changes to the account show up in every course, but the role is per course.
+    pass := util.Sha256HexFromString("new-pass");
+    err = user.SetPassword(pass);
+    if (err != nil) {
+        test.Fatalf("Failed to set password: '%v'.", err);
+    }
+
+    user.Role = model.RoleGrader;
+
+    err = SaveUser(courseA, user);
+    if (err != nil) {
+        test.Fatalf("Failed to save user: '%v'.", err);
+    }
+
+    otherUser, err := GetUser(courseB, "student@test.com");
+    if (err != nil) {
+        test.Fatalf("Failed to get user from other course: '%v'.", err);
+    }
+
+    if (!otherUser.CheckPassword(pass)) {
+        test.Fatalf("Password change did not apply to the other course.");
+    }
+
+    if (otherUser.Role != model.RoleStudent) {
+        test.Fatalf("Role change applied to the other course: '%s'.", otherUser.Role);
+    }
+
+    // Removing a user only removes them from the one course.
+    exists, err := RemoveUser(courseA, "student@test.com");
+    if (err != nil) {
+        test.Fatalf("Failed to remove user: '%v'.", err);
+    }
+
+    if (!exists) {
+        test.Fatalf("Removed user did not exist.");
+    }
+
+    user, err = GetUser(courseA, "student@test.com");
+    if (err != nil) {
+        test.Fatalf("Failed to get removed user: '%v'.", err);
+    }
+
+    if (user != nil) {
+        test.Fatalf("Removed user is still in the course.");
+    }
+
+    serverUser, err := GetServerUser("student@test.com");
+    if (err != nil) {
+        test.Fatalf("Failed to get server user: '%v'.", err);
+    }
+
+    if ((serverUser == nil) || serverUser.IsEnrolled(courseA.GetID()) || !serverUser.IsEnrolled("course-languages")) {
+        test.Fatalf("Unexpected server user after removal: '%s'.", util.MustToJSON(serverUser));
+    }
+
+    if (!serverUser.ToCourseUser("course-languages").CheckPassword(pass)) {
+        test.Fatalf("Account lost its password after removal from a course.");
+    }
+}
+
+// Users are cached, but callers always get their own copy.
+func (this *DBTests) DBTestServerUserUnsavedChanges(test *testing.T) {
+    defer ResetForTesting();
+
+    user, err := GetServerUser("student@test.com");
+    if (err != nil) {
+        test.Fatalf("Failed to get user: '%v'.", err);
+    }
+
+    user.Name = "unsaved";
+    user.Enrollments = nil;
+
+    users, err := GetServerUsers();
+    if (err != nil) {
+        test.Fatalf("Failed to get users: '%v'.", err);
+    }
+
+    users["student@test.com"].Pass = "unsaved";
+
+    user, err = GetServerUser("student@test.com");
+    if (err != nil) {
+        test.Fatalf("Failed to get user again: '%v'.", err);
+    }
+
+    if ((user.Name != "student") || (user.Pass == "unsaved") || (len(user.Enrollments) == 0)) {
+        test.Fatalf("Unsaved changes are visible: '%s'.", util.MustToJSONIndent(user));
+    }
+
+    // Saved changes are visible (both to accounts and course users).
+    user.Name = "saved";
+
+    err = SaveServerUser(user);
+    if (err != nil) {
+        test.Fatalf("Failed to save user: '%v'.", err);
+    }
+
+    courseUser, err := GetUser(MustGetTestCourse(), "student@test.com");
+    if (err != nil) {
+        test.Fatalf("Failed to get course user: '%v'.", err);
+    }
+
+    if ((courseUser == nil) || (courseUser.Name != "saved")) {
+        test.Fatalf("Saved changes are not visible: '%s'.", util.MustToJSONIndent(courseUser));
+    }
+}
+
+// A course-level sync cannot take over an account that exists outside of the course.
+func (this *DBTests) DBTestCourseSyncExistingAccount(test *testing.T) {
+    defer ResetForTesting();
+
+    course := MustGetCourse(TEST_COURSE_ID);
+
+    before, err := GetServerUser(TEST_SERVER_ADMIN_EMAIL);
+    if (err != nil) {
+        test.Fatalf("Failed to get server admin: '%v'.", err);
+    }
+
+    email.ClearTestMessages();
+
+    for _, merge := range []bool{false, true} {
+        newUsers := map[string]*model.User{
+            TEST_SERVER_ADMIN_EMAIL: &model.User{
+                Email: TEST_SERVER_ADMIN_EMAIL,
+                Name: "new name",
+                Pass: util.Sha256HexFromString("new-pass"),
+                Role: model.RoleStudent,
+            },
+        };
+
+        result, err := SyncUsers(course, newUsers, merge, false, true);
+        if (err != nil) {
+            test.Fatalf("Merge %v: Failed to sync users: '%v'.", merge, err);
+        }
+
+        if (len(result.ClearTextPasswords) != 0) {
+            test.Fatalf("Merge %v: Found cleartext passwords: '%s'.", merge, util.MustToJSON(result.ClearTextPasswords));
+        }
+    }
+
+    if (len(email.GetTestMessages()) != 0) {
+        test.Fatalf("Found emails when an existing account was enrolled: '%s'.", util.MustToJSON(email.GetTestMessages()));
+    }
+
+    after, err := GetServerUser(TEST_SERVER_ADMIN_EMAIL);
+    if (err != nil) {
+        test.Fatalf("Failed to get server admin after sync: '%v'.", err);
+    }
+
+    if ((after.Name != before.Name) || (after.Pass != before.Pass) || (after.Salt != before.Salt) || (after.Role != before.Role)) {
+        test.Fatalf("Account was changed by a sync. Before: '%s', After: '%s'.", util.MustToJSON(before), util.MustToJSON(after));
+    }
+
+    if (!after.ToUser().CheckPassword(util.Sha256HexFromString(TEST_SERVER_ADMIN_PASS))) {
+        test.Fatalf("Account password was changed by a sync.");
+    }
+
+    user, err := GetUser(course, TEST_SERVER_ADMIN_EMAIL);
+    if (err != nil) {
+        test.Fatalf("Failed to get enrolled user: '%v'.", err);
+    }
+
+    if ((user == nil) || (user.Role != model.RoleStudent)) {
+        test.Fatalf("Account was not enrolled: '%s'.", util.MustToJSON(user));
+    }
+}
+
 func (this *DBTests) DBTestCourseSyncNewUsers(test *testing.T) {
     defer ResetForTesting();
 
@@ -331,11 +508,12 @@ func getSynNewUsersTestUsers() (
             Role: model.RoleStudent,
             LMSID: "lms-mod@test.com",
         },
+        // Passwords of existing users are never changed by a sync, so this is unchanged.
         "student@test.com": &model.User{
             Email: "student@test.com",
             Pass: util.Sha256HexFromString("mod-pass"),
         },
-        // No change, should be marked as mod (because of password).
+        // No change.
         "grader@test.com": &model.User{
             Email: "grader@test.com",
             // No role change.
@@ -363,7 +541,8 @@ func getSynNewUsersTestUsers() (
     var shortCleartextPassUsers []string = []string{"add@test.com"};
 
     // The users that will have cleartext passwords when users are merged.
-    var fullCleartextPassUsers []string = []string{"add@test.com", "other@test.com", "grader@test.com"};
+    // Only new accounts get passwords.
+    var fullCleartextPassUsers []string = []string{"add@test.com"};
 
     // The emails when users are skipped.
     var shortEmails []*email.Message = []*email.Message{
@@ -391,39 +570,18 @@ func getSynNewUsersTestUsers() (
             Subject: "Autograder course101 -- User Account Created",
             HTML: false,
         },
-        &email.Message{
-            To: []string{"other@test.com"},
-            Subject: "Autograder course101 -- User Password Changed",
-            HTML: false,
-        },
-        &email.Message{
-            To: []string{"grader@test.com"},
-            Subject: "Autograder course101 -- User Password Changed",
-            HTML: false,
-        },
     };
 
     // The users that are marked as mods.
     // These will not appear in every case.
+    // Only the enrollment (role and LMS ID) is merged, the account's name is kept.
     var modUsers []*model.User = []*model.User{
         &model.User{
             Email: "other@test.com",
-            Name: "modified",
+            Name: "other",
             Role: model.RoleStudent,
             LMSID: "lms-mod@test.com",
         },
-        &model.User{
-            Email: "student@test.com",
-            Name: "student",
-            Role: model.RoleStudent,
-            LMSID: "",
-        },
-        &model.User{
-            Email: "grader@test.com",
-            Name: "grader",
-            Role: model.RoleGrader,
-            LMSID: "",
-        },
     };
 
     // The users that are marked as skips.
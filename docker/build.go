@@ -9,6 +9,7 @@ import (
     "os"
     "path/filepath"
     "strings"
+    "time"
 
 	"github.com/docker/docker/api/types"
     "github.com/docker/docker/pkg/archive"
@@ -25,11 +26,13 @@ const (
 
 type BuildOptions struct {
     Rebuild bool `help:"Rebuild images ignoring caches." default:"false"`
+    Pull bool `help:"Pull (refresh) base images before building, even if they already exist." default:"false"`
 }
 
 func NewBuildOptions() *BuildOptions {
     return &BuildOptions{
         Rebuild: false,
+        Pull: false,
     };
 }
 
@@ -37,9 +40,44 @@ func BuildImage(imageSource ImageSource) error {
     return BuildImageWithOptions(imageSource, NewBuildOptions());
 }
 
+// Build an image and record the build (see GetBuildLogs()).
 func BuildImageWithOptions(imageSource ImageSource, options *BuildOptions) error {
     imageInfo := imageSource.GetImageInfo();
 
+    buildLog := &BuildLog{
+        ImageName: imageInfo.Name,
+        BaseImage: imageInfo.Image,
+        StartTime: common.NowTimestamp(),
+    };
+
+    startTime := time.Now();
+    err := buildImageWithOptions(imageSource, options, buildLog);
+    buildLog.DurationMS = time.Since(startTime).Milliseconds();
+
+    buildLog.Success = (err == nil);
+    if (err != nil) {
+        buildLog.Error = err.Error();
+    }
+
+    recordErr := RecordBuildLog(imageSource, buildLog);
+    if (recordErr != nil) {
+        log.Warn("Failed to record image build log.", recordErr, imageSource);
+    }
+
+    if (err != nil) {
+        return err;
+    }
+
+    // Any pooled containers are using the old image.
+    ClearContainerPool(imageInfo);
+
+    return nil;
+}
+
+// Build an image, filling in the build log's output and base image digest.
+func buildImageWithOptions(imageSource ImageSource, options *BuildOptions, buildLog *BuildLog) error {
+    imageInfo := imageSource.GetImageInfo();
+
     tempDir, err := util.MkDirTemp(TEMPDIR_PREFIX + imageInfo.Name + "-");
     if (err != nil) {
         return fmt.Errorf("Failed to create temp build directory for '%s': '%w'.", imageInfo.Name, err);
@@ -56,9 +94,15 @@ func BuildImageWithOptions(imageSource ImageSource, options *BuildOptions) error
         return err;
     }
 
+    err = PullImage(imageInfo.Host, imageInfo.Image, options.Pull);
+    if (err != nil) {
+        return fmt.Errorf("Failed to pull base image for '%s': '%w'.", imageInfo.Name, err);
+    }
+
     buildOptions := types.ImageBuildOptions{
         Tags: []string{imageInfo.Name},
         Dockerfile: "Dockerfile",
+        AuthConfigs: getBuildAuthConfigs(imageInfo.Image),
     };
 
     if (options.Rebuild) {
@@ -71,11 +115,11 @@ func BuildImageWithOptions(imageSource ImageSource, options *BuildOptions) error
         return fmt.Errorf("Failed to create tar build context for image '%s': '%w'.", imageInfo.Name, err);
     }
 
-    return buildImage(imageSource, buildOptions, tar);
+    return buildImage(imageSource, buildOptions, tar, buildLog);
 }
 
-func buildImage(imageSource ImageSource, buildOptions types.ImageBuildOptions, tar io.ReadCloser) error {
-	ctx, docker, err := getDockerClient();
+func buildImage(imageSource ImageSource, buildOptions types.ImageBuildOptions, tar io.ReadCloser, buildLog *BuildLog) error {
+	ctx, docker, err := getDockerClient(imageSource.GetImageInfo().Host);
     if (err != nil) {
         return err;
     }
@@ -86,17 +130,36 @@ func buildImage(imageSource ImageSource, buildOptions types.ImageBuildOptions, t
         return fmt.Errorf("Failed to run docker image build command: '%w'.", err);
     }
 
-    output := collectBuildOutput(imageSource, response);
+    output, buildErrors := collectBuildOutput(imageSource, response);
     log.Debug("Image Build Output", imageSource, log.NewAttr("image-build-output", output));
 
+    buildLog.Output = output;
+
+    if (len(buildErrors) > 0) {
+        return fmt.Errorf("Docker image build failed: '%s'.", strings.Join(buildErrors, "; "));
+    }
+
+    // Once the build is done, the base image will be available locally.
+    baseImage, _, err := docker.ImageInspectWithRaw(ctx, imageSource.GetImageInfo().Image);
+    if (err != nil) {
+        log.Warn("Failed to inspect base image.", err, imageSource, log.NewAttr("base-image", imageSource.GetImageInfo().Image));
+    } else if (len(baseImage.RepoDigests) > 0) {
+        buildLog.BaseImageDigest = baseImage.RepoDigests[0];
+    } else {
+        buildLog.BaseImageDigest = baseImage.ID;
+    }
+
     return nil;
 }
 
 // Try to get the build output from a build response.
 // Note that the response may be from a failure.
-func collectBuildOutput(imageSource ImageSource, response types.ImageBuildResponse) string {
+// Returns: (output, any error messages from the build).
+func collectBuildOutput(imageSource ImageSource, response types.ImageBuildResponse) (string, []string) {
+    buildErrors := make([]string, 0);
+
     if (response.Body == nil) {
-        return "";
+        return "", buildErrors;
     }
 
     defer response.Body.Close();
@@ -127,6 +190,7 @@ func collectBuildOutput(imageSource ImageSource, response types.ImageBuildRespon
 
             log.Warn("Docker image build had an error entry.", err, imageSource, log.NewAttr("message", text));
             buildStringOutput.WriteString(text);
+            buildErrors = append(buildErrors, strings.TrimSpace(text));
         }
 
         rawText, ok = jsonData["stream"];
@@ -145,7 +209,7 @@ func collectBuildOutput(imageSource ImageSource, response types.ImageBuildRespon
         log.Warn("Failed to scan docker image build response.", err, imageSource);
     }
 
-    return buildStringOutput.String();
+    return buildStringOutput.String(), buildErrors;
 }
 
 // Write a full docker build context (Dockerfile and static files) to the given directory.
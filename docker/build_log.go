@@ -0,0 +1,90 @@
+package docker
+
+// Records of image builds (output, timing, and result) so that builds can be debugged after the fact.
+// The most recent builds for each image source are kept in the source's build log file (see ImageSource.GetBuildLogPath()).
+
+import (
+    "fmt"
+
+    "github.com/edulinq/autograder/common"
+    "github.com/edulinq/autograder/util"
+)
+
+// The number of builds to keep for each image source.
+const MAX_BUILD_LOGS = 10;
+
+// Only the end of long build outputs is kept (that is where errors usually are).
+const MAX_BUILD_LOG_OUTPUT_LENGTH = 256 * 1024;
+
+const BUILD_LOG_TRUNCATED_MESSAGE = "<NOTE: The start of this build's output has been truncated.>\n";
+
+type BuildLog struct {
+    ImageName string `json:"image-name"`
+    BaseImage string `json:"base-image"`
+    // The digest (or ID when no digest is available) of the base image used in the build.
+    // Empty if the build failed before the base image could be inspected.
+    BaseImageDigest string `json:"base-image-digest,omitempty"`
+
+    StartTime common.Timestamp `json:"start-time"`
+    DurationMS int64 `json:"duration-ms"`
+
+    Success bool `json:"success"`
+    Error string `json:"error,omitempty"`
+    Output string `json:"output"`
+}
+
+// Get the recorded builds for an image source (most recent first).
+// Returns an empty slice if there are no recorded builds.
+func GetBuildLogs(imageSource ImageSource) ([]*BuildLog, error) {
+    path := imageSource.GetBuildLogPath();
+
+    buildLogs := make([]*BuildLog, 0);
+    if (!util.PathExists(path)) {
+        return buildLogs, nil;
+    }
+
+    err := util.JSONFromFile(path, &buildLogs);
+    if (err != nil) {
+        return nil, fmt.Errorf("Failed to read build logs for image source '%s': '%w'.", imageSource.FullID(), err);
+    }
+
+    return buildLogs, nil;
+}
+
+// Get the most recent build for an image source (or nil if there are no recorded builds).
+func GetLastBuildLog(imageSource ImageSource) (*BuildLog, error) {
+    buildLogs, err := GetBuildLogs(imageSource);
+    if (err != nil) {
+        return nil, err;
+    }
+
+    if (len(buildLogs) == 0) {
+        return nil, nil;
+    }
+
+    return buildLogs[0], nil;
+}
+
+// Record a build for an image source (dropping the oldest builds past MAX_BUILD_LOGS).
+func RecordBuildLog(imageSource ImageSource, buildLog *BuildLog) error {
+    buildLogs, err := GetBuildLogs(imageSource);
+    if (err != nil) {
+        return err;
+    }
+
+    if (len(buildLog.Output) > MAX_BUILD_LOG_OUTPUT_LENGTH) {
+        buildLog.Output = BUILD_LOG_TRUNCATED_MESSAGE + buildLog.Output[(len(buildLog.Output) - MAX_BUILD_LOG_OUTPUT_LENGTH):];
+    }
+
+    buildLogs = append([]*BuildLog{buildLog}, buildLogs...);
+    if (len(buildLogs) > MAX_BUILD_LOGS) {
+        buildLogs = buildLogs[:MAX_BUILD_LOGS];
+    }
+
+    err = util.ToJSONFileIndent(buildLogs, imageSource.GetBuildLogPath());
+    if (err != nil) {
+        return fmt.Errorf("Failed to write build logs for image source '%s': '%w'.", imageSource.FullID(), err);
+    }
+
+    return nil;
+}
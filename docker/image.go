@@ -19,6 +19,7 @@ type ImageSource interface {
     GetSourceDir() string;
     GetCachePath() string;
     GetFileCachePath() string;
+    GetBuildLogPath() string;
     GetImageInfo() *ImageInfo;
     GetImageLock() *sync.Mutex;
 }
@@ -77,7 +78,9 @@ func NeedImageRebuild(imageSource ImageSource, quick bool) (bool, error) {
     }
 
     // Check if the image info has changed.
-    imageInfoHash, err := util.MD5StringHex(util.MustToJSON(imageSource.GetImageInfo()));
+    // The host is not serialized, but an image needs to be built for each host.
+    imageInfo := imageSource.GetImageInfo();
+    imageInfoHash, err := util.MD5StringHex(util.MustToJSON(imageInfo) + imageInfo.Host);
     if (err != nil) {
         return false, fmt.Errorf("Failed to hash image info for image source '%s': '%w'.", imageSource.FullID(), err);
     }
@@ -154,3 +157,19 @@ func CheckFileChanges(imageSource ImageSource, quick bool) (bool, error) {
 
     return (gitChanges || pathChanges), nil;
 }
+
+// Get the ID (content digest) of a built image.
+func GetImageID(imageInfo *ImageInfo) (string, error) {
+    ctx, docker, err := getDockerClient(imageInfo.Host);
+    if (err != nil) {
+        return "", err;
+    }
+    defer docker.Close()
+
+    image, _, err := docker.ImageInspectWithRaw(ctx, imageInfo.Name);
+    if (err != nil) {
+        return "", fmt.Errorf("Failed to inspect image '%s': '%w'.", imageInfo.Name, err);
+    }
+
+    return image.ID, nil;
+}
@@ -0,0 +1,124 @@
+package docker
+
+// Tracking the images that the autograder has built.
+
+import (
+    "fmt"
+    "path/filepath"
+    "strings"
+    "time"
+
+    "github.com/docker/docker/api/types"
+
+    "github.com/edulinq/autograder/common"
+    "github.com/edulinq/autograder/config"
+    "github.com/edulinq/autograder/log"
+    "github.com/edulinq/autograder/util"
+)
+
+// All assignment images start with this prefix (see model.Assignment.ImageName()).
+const IMAGE_NAME_PREFIX = "autograder."
+
+const IMAGE_USAGE_CACHE_FILENAME = "image-usage.json"
+
+type ImageSummary struct {
+    Name string `json:"name"`
+    ID string `json:"id"`
+    Host string `json:"host,omitempty"`
+    SizeBytes int64 `json:"size-bytes"`
+    CreatedTime common.Timestamp `json:"created-time"`
+    // Zero if the image has never been used (since usage tracking started).
+    LastUsedTime common.Timestamp `json:"last-used-time"`
+}
+
+// List all the autograder assignment images on a host (empty for the default docker host).
+func ListImages(host string) ([]*ImageSummary, error) {
+    ctx, docker, err := getDockerClient(host);
+    if (err != nil) {
+        return nil, err;
+    }
+    defer docker.Close()
+
+    rawImages, err := docker.ImageList(ctx, types.ImageListOptions{});
+    if (err != nil) {
+        return nil, fmt.Errorf("Failed to list images: '%w'.", err);
+    }
+
+    images := make([]*ImageSummary, 0);
+    for _, rawImage := range rawImages {
+        for _, tag := range rawImage.RepoTags {
+            name, _, _ := strings.Cut(tag, ":");
+            if (!strings.HasPrefix(name, IMAGE_NAME_PREFIX)) {
+                continue;
+            }
+
+            images = append(images, &ImageSummary{
+                Name: name,
+                ID: rawImage.ID,
+                Host: host,
+                SizeBytes: rawImage.Size,
+                CreatedTime: common.TimestampFromTime(time.Unix(rawImage.Created, 0)),
+                LastUsedTime: GetImageLastUsedTime(host, name),
+            });
+        }
+    }
+
+    return images, nil;
+}
+
+// Remove an image (by name) from a host.
+func RemoveImage(host string, name string) error {
+    ctx, docker, err := getDockerClient(host);
+    if (err != nil) {
+        return err;
+    }
+    defer docker.Close()
+
+    _, err = docker.ImageRemove(ctx, name, types.ImageRemoveOptions{PruneChildren: true});
+    if (err != nil) {
+        return fmt.Errorf("Failed to remove image '%s': '%w'.", name, err);
+    }
+
+    return nil;
+}
+
+// Record that an image was just used for grading.
+// Failures are logged, but not returned.
+func RecordImageUse(imageInfo *ImageInfo) {
+    _, _, err := util.CachePut(getImageUsageCachePath(), getImageUsageKey(imageInfo.Host, imageInfo.Name), common.NowTimestamp().String());
+    if (err != nil) {
+        log.Warn("Failed to record image usage.", err, log.NewAttr("image", imageInfo.Name));
+    }
+}
+
+// Get the last time an image was used for grading (zero if unknown).
+func GetImageLastUsedTime(host string, name string) common.Timestamp {
+    value, exists, err := util.CacheFetch(getImageUsageCachePath(), getImageUsageKey(host, name));
+    if (err != nil) {
+        log.Warn("Failed to fetch image usage.", err, log.NewAttr("image", name));
+        return common.Timestamp("");
+    }
+
+    if (!exists) {
+        return common.Timestamp("");
+    }
+
+    text, ok := value.(string);
+    if (!ok) {
+        return common.Timestamp("");
+    }
+
+    return common.Timestamp(text);
+}
+
+func getImageUsageCachePath() string {
+    return filepath.Join(config.GetCacheDir(), IMAGE_USAGE_CACHE_FILENAME);
+}
+
+func getImageUsageKey(host string, name string) string {
+    if (host == "") {
+        return name;
+    }
+
+    return host + "::" + name;
+}
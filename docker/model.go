@@ -2,12 +2,14 @@ package docker
 
 import (
     "fmt"
+    "strings"
 
     "github.com/edulinq/autograder/common"
 )
 
 const (
     DEFAULT_IMAGE = "edulinq/autograder.base"
+    DEFAULT_PRE_CHECK_TIMEOUT_SECS = 60
 )
 
 type ImageInfo struct {
@@ -17,6 +19,18 @@ type ImageInfo struct {
 
     Invocation []string `json:"invocation,omitempty"`
 
+    // An optional fast check (e.g. compiling) that is run before the full grader.
+    // Submissions that fail the pre-check will not be graded (or count as an attempt).
+    PreCheckInvocation []string `json:"pre-check-invocation,omitempty"`
+    PreCheckTimeoutSecs int `json:"pre-check-timeout-secs,omitempty"`
+
+    // An optional pool of containers that are created before they are needed (see pool.go).
+    ContainerPool *ContainerPoolInfo `json:"container-pool,omitempty"`
+
+    // Network access for the grader (see network.go).
+    // Defaults to no network access.
+    Network *NetworkInfo `json:"network,omitempty"`
+
     StaticFiles []*common.FileSpec `json:"static-files,omitempty"`
 
     PreStaticFileOperations []common.FileOperation `json:"pre-static-files-ops,omitempty"`
@@ -29,6 +43,8 @@ type ImageInfo struct {
     Name string `json:"-"`
     // Dir used for relative paths.
     BaseDir string `json:"-"`
+    // The container engine host to build and run on (empty for the default docker host).
+    Host string `json:"-"`
 }
 
 // A subset of the image information that is passed to docker images for config during grading.
@@ -44,6 +60,10 @@ func (this *ImageInfo) GetGradingConfig() *GradingConfig {
     };
 }
 
+func (this *ImageInfo) HasPreCheck() bool {
+    return (len(this.PreCheckInvocation) > 0);
+}
+
 func (this *ImageInfo) Validate() error {
     if (this.Name == "") {
         return fmt.Errorf("Missing name.");
@@ -65,6 +85,45 @@ func (this *ImageInfo) Validate() error {
         this.Image = DEFAULT_IMAGE;
     }
 
+    if (strings.Contains(this.Image, "@") && !IsPinnedImage(this.Image)) {
+        return fmt.Errorf("Image '%s' has a malformed digest, expected 'image@sha256:<64 hex characters>'.", this.Image);
+    }
+
+    if (!IsAllowedImage(this.Image)) {
+        return fmt.Errorf("Image '%s' is not pinned to a digest (e.g. 'image@sha256:...'), but pinned images are required (see the docker.pinned option).", this.Image);
+    }
+
+    if (this.PreCheckInvocation == nil) {
+        this.PreCheckInvocation = make([]string, 0);
+    }
+
+    if (this.PreCheckTimeoutSecs < 0) {
+        return fmt.Errorf("Pre-check timeout cannot be negative, found: %d.", this.PreCheckTimeoutSecs);
+    }
+
+    if (this.PreCheckTimeoutSecs == 0) {
+        this.PreCheckTimeoutSecs = DEFAULT_PRE_CHECK_TIMEOUT_SECS;
+    }
+
+    if (this.ContainerPool != nil) {
+        err := this.ContainerPool.Validate();
+        if (err != nil) {
+            return fmt.Errorf("Failed to validate container pool: '%w'.", err);
+        }
+    }
+
+    if (this.Network != nil) {
+        err := this.Network.Validate();
+        if (err != nil) {
+            return fmt.Errorf("Failed to validate network: '%w'.", err);
+        }
+    }
+
+    // Pooled containers are created ahead of time, before any network exists.
+    if (this.HasContainerPool() && this.HasNetwork()) {
+        return fmt.Errorf("Container pools cannot be used with a network.");
+    }
+
     if (this.PreStaticDockerCommands == nil) {
         this.PreStaticDockerCommands = make([]string, 0);
     }
@@ -0,0 +1,354 @@
+package docker
+
+// Network access for grading containers.
+// By default, grading containers have no network access at all.
+// Assignments can instead declare a network policy:
+//  - private: The grader is placed on a private network (with no outside access) shared with the assignment's sidecar containers.
+//  - allowlist: Like private, but the grader can also reach an allowlist of outside hosts through an HTTP(S) proxy run by the autograder.
+// Sidecars (see sidecar.go) and the network are started before each grading run and torn down after it.
+// Grading networks are internal and the host has no address on them, so the grader cannot reach the host or anything past it.
+// In allowlist mode, a proxy sidecar (see PROXY_SIDECAR_NAME) is the only container that is also on a second (egress) network,
+// where it forwards connections to the allowlist proxy (listening on the egress network's gateway).
+
+import (
+    "context"
+    "fmt"
+    "regexp"
+    "slices"
+    "strconv"
+    "strings"
+    "time"
+
+    "github.com/docker/docker/api/types"
+    "github.com/docker/docker/client"
+
+    "github.com/edulinq/autograder/config"
+    "github.com/edulinq/autograder/log"
+)
+
+const (
+    NETWORK_NONE = "none"
+    NETWORK_PRIVATE = "private"
+    NETWORK_ALLOWLIST = "allowlist"
+)
+
+var NETWORK_MODES []string = []string{NETWORK_NONE, NETWORK_PRIVATE, NETWORK_ALLOWLIST};
+
+// The hostname (on the grading network) of the sidecar that forwards to the allowlist proxy.
+const PROXY_SIDECAR_NAME = "autograder-proxy";
+const PROXY_SIDECAR_PORT = 3128;
+
+var hostnamePattern *regexp.Regexp = regexp.MustCompile(`^[a-z0-9]([a-z0-9\-]*[a-z0-9])?(\.[a-z0-9]([a-z0-9\-]*[a-z0-9])?)*$`);
+
+type NetworkInfo struct {
+    Mode string `json:"mode,omitempty"`
+
+    // Outside hosts that the grader may reach (allowlist mode only).
+    // Entries are hostnames, or wildcards that match any subdomain (e.g. "*.example.com").
+    // Entries can only be reached on ports 80 and 443, unless they have their own port (e.g. "example.com:8080").
+    Allowlist []string `json:"allowlist,omitempty"`
+
+    Sidecars []*SidecarInfo `json:"sidecars,omitempty"`
+}
+
+// A running network (and sidecars) for a single grading run.
+type gradingNetwork struct {
+    id string
+    name string
+    sidecars []*runningSidecar
+
+    // Allowlist mode only.
+    egressID string
+    egressName string
+    proxySidecar *runningSidecar
+    proxy *allowlistProxy
+
+    // Environment variables to pass into the grading container.
+    env []string
+}
+
+func (this *NetworkInfo) Validate() error {
+    this.Mode = strings.ToLower(strings.TrimSpace(this.Mode));
+    if (this.Mode == "") {
+        // Sidecars need a network.
+        if (len(this.Sidecars) > 0) {
+            this.Mode = NETWORK_PRIVATE;
+        } else {
+            this.Mode = NETWORK_NONE;
+        }
+    }
+
+    if (!slices.Contains(NETWORK_MODES, this.Mode)) {
+        return fmt.Errorf("Unknown network mode '%s', must be one of: %s.", this.Mode, strings.Join(NETWORK_MODES, ", "));
+    }
+
+    if (this.Allowlist == nil) {
+        this.Allowlist = make([]string, 0);
+    }
+
+    if (this.Sidecars == nil) {
+        this.Sidecars = make([]*SidecarInfo, 0);
+    }
+
+    if ((this.Mode == NETWORK_NONE) && (len(this.Sidecars) > 0)) {
+        return fmt.Errorf("Sidecars require a network mode other than '%s'.", NETWORK_NONE);
+    }
+
+    if ((this.Mode != NETWORK_ALLOWLIST) && (len(this.Allowlist) > 0)) {
+        return fmt.Errorf("An allowlist can only be used with the '%s' network mode.", NETWORK_ALLOWLIST);
+    }
+
+    if ((this.Mode == NETWORK_ALLOWLIST) && (len(this.Allowlist) == 0)) {
+        return fmt.Errorf("The '%s' network mode requires a non-empty allowlist.", NETWORK_ALLOWLIST);
+    }
+
+    if ((this.Mode == NETWORK_ALLOWLIST) && !IsAllowedImage(config.DOCKER_PROXY_IMAGE.Get())) {
+        return fmt.Errorf("Proxy image '%s' is not pinned to a digest, but pinned images are required (see the docker.proxy.image and docker.pinned options).",
+                config.DOCKER_PROXY_IMAGE.Get());
+    }
+
+    for i, entry := range this.Allowlist {
+        entry = strings.ToLower(strings.TrimSpace(entry));
+
+        host, port, hasPort := strings.Cut(entry, ":");
+        if (!hostnamePattern.MatchString(strings.TrimPrefix(host, "*."))) {
+            return fmt.Errorf("Allowlist entry '%s' is not a valid hostname (or wildcard hostname).", this.Allowlist[i]);
+        }
+
+        if (hasPort) {
+            portNumber, err := strconv.Atoi(port);
+            if ((err != nil) || (portNumber < 1) || (portNumber > 65535) || (strconv.Itoa(portNumber) != port)) {
+                return fmt.Errorf("Allowlist entry '%s' does not have a valid port.", this.Allowlist[i]);
+            }
+        }
+
+        this.Allowlist[i] = entry;
+    }
+
+    names := make(map[string]bool, len(this.Sidecars));
+    for i, sidecar := range this.Sidecars {
+        if (sidecar == nil) {
+            return fmt.Errorf("Sidecar at index %d is empty.", i);
+        }
+
+        err := sidecar.Validate();
+        if (err != nil) {
+            return fmt.Errorf("Failed to validate sidecar at index %d: '%w'.", i, err);
+        }
+
+        if (names[sidecar.Name]) {
+            return fmt.Errorf("Duplicate sidecar name: '%s'.", sidecar.Name);
+        }
+
+        if ((this.Mode == NETWORK_ALLOWLIST) && (sidecar.Name == PROXY_SIDECAR_NAME)) {
+            return fmt.Errorf("Sidecar name '%s' is reserved for the allowlist proxy.", sidecar.Name);
+        }
+
+        names[sidecar.Name] = true;
+    }
+
+    return nil;
+}
+
+func (this *ImageInfo) HasNetwork() bool {
+    return ((this.Network != nil) && (this.Network.Mode != NETWORK_NONE));
+}
+
+// Start the network (and sidecars) for a grading run.
+// Returns nil if the image does not use a network.
+// The caller should always call stop() on the result (it is nil-safe).
+func startGradingNetwork(ctx context.Context, docker *client.Client, logId log.Loggable, imageInfo *ImageInfo, name string) (*gradingNetwork, error) {
+    if (!imageInfo.HasNetwork()) {
+        return nil, nil;
+    }
+
+    gradingNetwork := &gradingNetwork{
+        name: name + "-net",
+        sidecars: make([]*runningSidecar, 0, len(imageInfo.Network.Sidecars)),
+        env: make([]string, 0),
+    };
+
+    // Internal networks are not routed anywhere,
+    // and without an address on the bridge the host itself cannot be reached either.
+    options := types.NetworkCreate{
+        CheckDuplicate: true,
+        Driver: "bridge",
+        Internal: true,
+        Labels: map[string]string{"autograder": "true"},
+        Options: map[string]string{"com.docker.network.bridge.inhibit_ipv4": "true"},
+    };
+
+    response, err := docker.NetworkCreate(ctx, gradingNetwork.name, options);
+    if (err != nil) {
+        return nil, fmt.Errorf("Failed to create grading network '%s': '%w'.", gradingNetwork.name, err);
+    }
+
+    gradingNetwork.id = response.ID;
+
+    err = gradingNetwork.start(ctx, docker, imageInfo, name);
+    if (err != nil) {
+        gradingNetwork.stop(ctx, docker, logId);
+        return nil, err;
+    }
+
+    return gradingNetwork, nil;
+}
+
+func (this *gradingNetwork) start(ctx context.Context, docker *client.Client, imageInfo *ImageInfo, name string) error {
+    for _, sidecar := range imageInfo.Network.Sidecars {
+        runningSidecar, err := startSidecar(ctx, docker, imageInfo.Host, sidecar, name, this.name);
+        if (runningSidecar != nil) {
+            this.sidecars = append(this.sidecars, runningSidecar);
+        }
+
+        if (err != nil) {
+            return err;
+        }
+    }
+
+    // Wait for all the sidecars to be ready before the grader starts.
+    for _, runningSidecar := range this.sidecars {
+        err := runningSidecar.waitForHealthy(ctx, docker);
+        if (err != nil) {
+            return err;
+        }
+    }
+
+    if (imageInfo.Network.Mode != NETWORK_ALLOWLIST) {
+        return nil;
+    }
+
+    err := this.startProxy(ctx, docker, imageInfo, name);
+    if (err != nil) {
+        return err;
+    }
+
+    proxyURL := fmt.Sprintf("http://%s:%d", PROXY_SIDECAR_NAME, PROXY_SIDECAR_PORT);
+    noProxy := make([]string, 0, len(imageInfo.Network.Sidecars));
+    for _, sidecar := range imageInfo.Network.Sidecars {
+        noProxy = append(noProxy, sidecar.Name);
+    }
+
+    for _, key := range []string{"HTTP_PROXY", "HTTPS_PROXY", "http_proxy", "https_proxy"} {
+        this.env = append(this.env, key + "=" + proxyURL);
+    }
+
+    for _, key := range []string{"NO_PROXY", "no_proxy"} {
+        this.env = append(this.env, key + "=" + strings.Join(noProxy, ","));
+    }
+
+    return nil;
+}
+
+// Start the allowlist proxy on a new egress network,
+// and the proxy sidecar that forwards to it from the grading network.
+func (this *gradingNetwork) startProxy(ctx context.Context, docker *client.Client, imageInfo *ImageInfo, name string) error {
+    this.egressName = name + "-egress";
+
+    // The egress network only needs to reach the host (for the proxy), but nothing past it.
+    options := types.NetworkCreate{
+        CheckDuplicate: true,
+        Driver: "bridge",
+        Labels: map[string]string{"autograder": "true"},
+        Options: map[string]string{"com.docker.network.bridge.enable_ip_masquerade": "false"},
+    };
+
+    response, err := docker.NetworkCreate(ctx, this.egressName, options);
+    if (err != nil) {
+        return fmt.Errorf("Failed to create egress network '%s': '%w'.", this.egressName, err);
+    }
+
+    this.egressID = response.ID;
+
+    resource, err := docker.NetworkInspect(ctx, this.egressID, types.NetworkInspectOptions{});
+    if (err != nil) {
+        return fmt.Errorf("Failed to inspect egress network '%s': '%w'.", this.egressName, err);
+    }
+
+    if ((len(resource.IPAM.Config) == 0) || (resource.IPAM.Config[0].Gateway == "")) {
+        return fmt.Errorf("Egress network '%s' does not have a gateway.", this.egressName);
+    }
+
+    // The proxy listens on the egress network's gateway (the host), so only the proxy sidecar can reach it.
+    this.proxy, err = startAllowlistProxy(resource.IPAM.Config[0].Gateway + ":0", imageInfo.Network.Allowlist);
+    if (err != nil) {
+        return fmt.Errorf("Failed to start allowlist proxy for grading network '%s': '%w'.", this.name, err);
+    }
+
+    // The proxy image's entrypoint is socat.
+    sidecar := &SidecarInfo{
+        Name: PROXY_SIDECAR_NAME,
+        Image: config.DOCKER_PROXY_IMAGE.Get(),
+        Env: make([]string, 0),
+        Command: []string{
+            fmt.Sprintf("TCP-LISTEN:%d,fork,reuseaddr", PROXY_SIDECAR_PORT),
+            "TCP:" + this.proxy.Address(),
+        },
+    };
+
+    this.proxySidecar, err = startSidecar(ctx, docker, imageInfo.Host, sidecar, name, this.name);
+    if (err != nil) {
+        return err;
+    }
+
+    err = docker.NetworkConnect(ctx, this.egressID, this.proxySidecar.id, nil);
+    if (err != nil) {
+        return fmt.Errorf("Failed to connect the proxy sidecar to egress network '%s': '%w'.", this.egressName, err);
+    }
+
+    return this.proxySidecar.waitForHealthy(ctx, docker);
+}
+
+// Get the logs from all the sidecars (keyed by sidecar name).
+// Returns nil if there are no sidecars.
+func (this *gradingNetwork) collectLogs(ctx context.Context, docker *client.Client, logId log.Loggable) map[string]string {
+    if ((this == nil) || (len(this.sidecars) == 0)) {
+        return nil;
+    }
+
+    logs := make(map[string]string, len(this.sidecars));
+    for _, runningSidecar := range this.sidecars {
+        logs[runningSidecar.info.Name] = runningSidecar.getLogs(ctx, docker, logId);
+    }
+
+    return logs;
+}
+
+// Remove the sidecars and network.
+// Errors are logged, but not returned.
+func (this *gradingNetwork) stop(ctx context.Context, docker *client.Client, logId log.Loggable) {
+    if (this == nil) {
+        return;
+    }
+
+    if (this.proxy != nil) {
+        this.proxy.Close();
+    }
+
+    if (this.proxySidecar != nil) {
+        this.proxySidecar.remove(ctx, docker, logId);
+    }
+
+    for _, runningSidecar := range this.sidecars {
+        runningSidecar.remove(ctx, docker, logId);
+    }
+
+    removeNetwork(ctx, docker, logId, this.egressID, this.egressName);
+    removeNetwork(ctx, docker, logId, this.id, this.name);
+}
+
+// Errors are logged, but not returned.
+func removeNetwork(ctx context.Context, docker *client.Client, logId log.Loggable, id string, name string) {
+    if (id == "") {
+        return;
+    }
+
+    // Containers may still be in the process of being removed.
+    err := withRetries(5, 250 * time.Millisecond, func() error {
+        return docker.NetworkRemove(ctx, id);
+    });
+
+    if (err != nil) {
+        log.Warn("Failed to remove grading network.", err, logId, log.NewAttr("network", name));
+    }
+}
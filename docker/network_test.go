@@ -0,0 +1,191 @@
+package docker
+
+import (
+    "context"
+    "fmt"
+    "net"
+    "testing"
+    "time"
+
+    "github.com/docker/docker/api/types"
+    "github.com/docker/docker/api/types/container"
+    "github.com/docker/docker/client"
+
+    "github.com/edulinq/autograder/config"
+    "github.com/edulinq/autograder/util"
+)
+
+func TestNetworkInfoValidate(test *testing.T) {
+    testCases := []struct{info *NetworkInfo; valid bool; mode string}{
+        {&NetworkInfo{}, true, NETWORK_NONE},
+        {&NetworkInfo{Mode: " Private "}, true, NETWORK_PRIVATE},
+        {&NetworkInfo{Mode: "private", Sidecars: []*SidecarInfo{&SidecarInfo{Name: "db", Image: "postgres"}}}, true, NETWORK_PRIVATE},
+        {&NetworkInfo{Sidecars: []*SidecarInfo{&SidecarInfo{Name: "db", Image: "postgres"}}}, true, NETWORK_PRIVATE},
+        {&NetworkInfo{Mode: "allowlist", Allowlist: []string{"example.com", "*.Example.org", "127.0.0.1"}}, true, NETWORK_ALLOWLIST},
+        {&NetworkInfo{Mode: "allowlist", Allowlist: []string{"example.com"}, Sidecars: []*SidecarInfo{&SidecarInfo{Name: "web", Image: "nginx"}}}, true, NETWORK_ALLOWLIST},
+        {&NetworkInfo{Mode: "allowlist", Allowlist: []string{"example.com:8080", "*.example.org:22"}}, true, NETWORK_ALLOWLIST},
+
+        {&NetworkInfo{Mode: "zzz"}, false, ""},
+        {&NetworkInfo{Mode: "none", Sidecars: []*SidecarInfo{&SidecarInfo{Name: "db", Image: "postgres"}}}, false, ""},
+        {&NetworkInfo{Mode: "private", Allowlist: []string{"example.com"}}, false, ""},
+        {&NetworkInfo{Mode: "allowlist"}, false, ""},
+        {&NetworkInfo{Mode: "allowlist", Allowlist: []string{"http://example.com"}}, false, ""},
+        {&NetworkInfo{Mode: "allowlist", Allowlist: []string{"*"}}, false, ""},
+        {&NetworkInfo{Mode: "allowlist", Allowlist: []string{"example.com:"}}, false, ""},
+        {&NetworkInfo{Mode: "allowlist", Allowlist: []string{"example.com:0"}}, false, ""},
+        {&NetworkInfo{Mode: "allowlist", Allowlist: []string{"example.com:65536"}}, false, ""},
+        {&NetworkInfo{Mode: "allowlist", Allowlist: []string{"example.com:080"}}, false, ""},
+        {&NetworkInfo{Mode: "allowlist", Allowlist: []string{"example.com:http"}}, false, ""},
+        {&NetworkInfo{Mode: "allowlist", Allowlist: []string{"example.com:80:80"}}, false, ""},
+        {&NetworkInfo{Mode: "private", Sidecars: []*SidecarInfo{nil}}, false, ""},
+        {&NetworkInfo{Mode: "private", Sidecars: []*SidecarInfo{&SidecarInfo{Name: "db"}}}, false, ""},
+        {&NetworkInfo{Mode: "private", Sidecars: []*SidecarInfo{&SidecarInfo{Name: "d_b", Image: "postgres"}}}, false, ""},
+        {&NetworkInfo{Mode: "private", Sidecars: []*SidecarInfo{&SidecarInfo{Name: "db.local", Image: "postgres"}}}, false, ""},
+        {&NetworkInfo{Mode: "private", Sidecars: []*SidecarInfo{
+            &SidecarInfo{Name: "db", Image: "postgres"},
+            &SidecarInfo{Name: "DB", Image: "mysql"},
+        }}, false, ""},
+    };
+
+    for i, testCase := range testCases {
+        err := testCase.info.Validate();
+        if (testCase.valid && (err != nil)) {
+            test.Errorf("Case %d: Unexpected validation error: '%v'.", i, err);
+            continue;
+        }
+
+        if (!testCase.valid) {
+            if (err == nil) {
+                test.Errorf("Case %d: Did not get an expected validation error.", i);
+            }
+
+            continue;
+        }
+
+        if (testCase.mode != testCase.info.Mode) {
+            test.Errorf("Case %d: Unexpected mode. Expected: '%s', Actual: '%s'.", i, testCase.mode, testCase.info.Mode);
+        }
+    }
+}
+
+func TestImageInfoNetworkPoolValidate(test *testing.T) {
+    imageInfo := &ImageInfo{
+        Invocation: []string{"true"},
+        Name: "test",
+        BaseDir: ".",
+        ContainerPool: &ContainerPoolInfo{Size: 1},
+        Network: &NetworkInfo{Mode: NETWORK_PRIVATE},
+    };
+
+    err := imageInfo.Validate();
+    if (err == nil) {
+        test.Fatalf("Did not get an expected validation error.");
+    }
+
+    imageInfo.Network.Mode = NETWORK_NONE;
+
+    err = imageInfo.Validate();
+    if (err != nil) {
+        test.Fatalf("Unexpected validation error: '%v'.", err);
+    }
+}
+
+func TestAllowlistNetworkHostUnreachable(test *testing.T) {
+    if (config.DOCKER_DISABLE.Get()) {
+        test.Skip("Docker is disabled, skipping test.");
+    }
+
+    if (!CanAccessDocker()) {
+        test.Fatal("Could not access docker.");
+    }
+
+    // A host port that is not the proxy.
+    listener, err := net.Listen("tcp", "0.0.0.0:0");
+    if (err != nil) {
+        test.Fatalf("Failed to listen: '%v'.", err);
+    }
+    defer listener.Close();
+
+    go func() {
+        for {
+            connection, err := listener.Accept();
+            if (err != nil) {
+                return;
+            }
+
+            connection.Close();
+        }
+    }();
+
+    _, hostPort, _ := net.SplitHostPort(listener.Addr().String());
+
+    imageInfo := &ImageInfo{
+        Network: &NetworkInfo{Mode: NETWORK_ALLOWLIST, Allowlist: []string{"example.com"}},
+    };
+
+    err = imageInfo.Network.Validate();
+    if (err != nil) {
+        test.Fatalf("Failed to validate network: '%v'.", err);
+    }
+
+    ctx, docker, err := getDockerClient("");
+    if (err != nil) {
+        test.Fatalf("Failed to get docker client: '%v'.", err);
+    }
+    defer docker.Close();
+
+    name := cleanContainerName("autograder-test-network-" + util.UUID());
+
+    gradingNetwork, err := startGradingNetwork(ctx, docker, nil, imageInfo, name);
+    if (err != nil) {
+        test.Fatalf("Failed to start grading network: '%v'.", err);
+    }
+    defer gradingNetwork.stop(ctx, docker, nil);
+
+    proxyHost, _, _ := net.SplitHostPort(gradingNetwork.proxy.Address());
+
+    testCases := []struct{target string; reachable bool}{
+        {fmt.Sprintf("%s:%d", PROXY_SIDECAR_NAME, PROXY_SIDECAR_PORT), true},
+        // The host address that the proxy listens on (but a different port).
+        {net.JoinHostPort(proxyHost, hostPort), false},
+        {net.JoinHostPort("host.docker.internal", hostPort), false},
+    };
+
+    for i, testCase := range testCases {
+        exitCode, err := runNetworkProbe(ctx, docker, gradingNetwork, fmt.Sprintf("%s-probe-%d", name, i), testCase.target);
+        if (err != nil) {
+            test.Errorf("Case %d: Failed to run probe: '%v'.", i, err);
+            continue;
+        }
+
+        reachable := (exitCode == 0);
+        if (testCase.reachable != reachable) {
+            test.Errorf("Case %d: Unexpected reachability for '%s'. Expected: %v, Actual: %v.", i, testCase.target, testCase.reachable, reachable);
+        }
+    }
+}
+
+// Try to connect to a target (host:port) from a container on the grading network.
+// Returns the exit code of the probe (zero if the connection was made).
+func runNetworkProbe(ctx context.Context, docker *client.Client, gradingNetwork *gradingNetwork, name string, target string) (int, error) {
+    containerInstance, err := docker.ContainerCreate(
+        ctx,
+        &container.Config{
+            Image: config.DOCKER_PROXY_IMAGE.Get(),
+            Cmd: []string{"-T1", "/dev/null", "TCP:" + target + ",connect-timeout=5"},
+            Env: gradingNetwork.env,
+        },
+        &container.HostConfig{
+            NetworkMode: container.NetworkMode(gradingNetwork.name),
+        },
+        nil,
+        nil,
+        name);
+    if (err != nil) {
+        return -1, err;
+    }
+    defer docker.ContainerRemove(ctx, containerInstance.ID, types.ContainerRemoveOptions{Force: true});
+
+    _, _, exitCode, err := runCreatedContainer(ctx, docker, nil, containerInstance.ID, name, 30 * time.Second, nil, nil);
+    return exitCode, err;
+}
@@ -0,0 +1,333 @@
+package docker
+
+// Pools of grading containers that are created ahead of time (but not started) for an image,
+// so that grading does not have to wait for a container to be created.
+// Each pooled container is used exactly once and then discarded (the pool will be refilled in the background).
+// A pool that goes unused for its idle timeout will remove all of its containers.
+
+import (
+    "fmt"
+    "io"
+    "os"
+    "sync"
+    "time"
+
+    "github.com/docker/docker/api/types"
+
+    "github.com/edulinq/autograder/common"
+    "github.com/edulinq/autograder/log"
+    "github.com/edulinq/autograder/util"
+)
+
+const DEFAULT_POOL_IDLE_TIMEOUT_SECS = 600;
+
+type ContainerPoolInfo struct {
+    Size int `json:"size"`
+    IdleTimeoutSecs int `json:"idle-timeout-secs,omitempty"`
+}
+
+// A container that has been created (but not started) with its grading dirs already mounted.
+type PooledContainer struct {
+    ID string
+    Name string
+    TempDir string
+    InputDir string
+    OutputDir string
+
+    host string
+}
+
+type containerPool struct {
+    key string
+    size int
+    idleTimeout time.Duration
+
+    containers []*PooledContainer
+    filling bool
+    // Set when the pool has been drained for being idle, cleared on the next take.
+    idle bool
+    closed bool
+    idleTimer *time.Timer
+    lock sync.Mutex
+
+    // Overridable for testing.
+    create func() (*PooledContainer, error)
+    remove func(*PooledContainer)
+}
+
+var pools map[string]*containerPool = make(map[string]*containerPool);
+var poolsLock sync.Mutex;
+
+func (this *ContainerPoolInfo) Validate() error {
+    if (this.Size < 0) {
+        return fmt.Errorf("Container pool size cannot be negative, found: %d.", this.Size);
+    }
+
+    if (this.IdleTimeoutSecs < 0) {
+        return fmt.Errorf("Container pool idle timeout cannot be negative, found: %d.", this.IdleTimeoutSecs);
+    }
+
+    if (this.IdleTimeoutSecs == 0) {
+        this.IdleTimeoutSecs = DEFAULT_POOL_IDLE_TIMEOUT_SECS;
+    }
+
+    return nil;
+}
+
+func (this *ImageInfo) HasContainerPool() bool {
+    return ((this.ContainerPool != nil) && (this.ContainerPool.Size > 0));
+}
+
+// Take a container from the image's pool (and start refilling the pool).
+// Returns nil if the image does not use a pool or the pool is currently empty,
+// in which case the caller should just run a new container.
+// The caller owns the returned container and should run it with RunPooledContainer().
+func TakePooledContainer(imageInfo *ImageInfo) *PooledContainer {
+    if (!imageInfo.HasContainerPool()) {
+        return nil;
+    }
+
+    return getContainerPool(imageInfo).take();
+}
+
+// Run a container that was taken from a pool.
+// Once run, the container will be removed (but not its grading dirs, see PooledContainer.Cleanup()).
+func RunPooledContainer(logId log.Loggable, imageInfo *ImageInfo, pooledContainer *PooledContainer,
+        stdoutStream io.Writer, stderrStream io.Writer) (string, string, error) {
+    ctx, docker, err := getDockerClient(imageInfo.Host);
+    if (err != nil) {
+        return "", "", err;
+    }
+    defer docker.Close()
+
+    RecordImageUse(imageInfo);
+
+    stdout, stderr, _, err := runCreatedContainer(ctx, docker, logId, pooledContainer.ID, pooledContainer.Name, 0, stdoutStream, stderrStream);
+    return stdout, stderr, err;
+}
+
+// Remove a pooled container's grading dirs.
+func (this *PooledContainer) Cleanup() {
+    os.RemoveAll(this.TempDir);
+}
+
+// Remove all the pooled containers for an image (e.g. because the image was rebuilt).
+func ClearContainerPool(imageInfo *ImageInfo) {
+    poolsLock.Lock();
+    pool, ok := pools[getPoolKey(imageInfo)];
+    if (ok) {
+        delete(pools, pool.key);
+    }
+    poolsLock.Unlock();
+
+    if (ok) {
+        pool.close();
+    }
+}
+
+// Remove all pooled containers for all images.
+func ClearAllContainerPools() {
+    poolsLock.Lock();
+    oldPools := pools;
+    pools = make(map[string]*containerPool);
+    poolsLock.Unlock();
+
+    for _, pool := range oldPools {
+        pool.close();
+    }
+}
+
+func getPoolKey(imageInfo *ImageInfo) string {
+    return imageInfo.Host + "::" + imageInfo.Name;
+}
+
+func getContainerPool(imageInfo *ImageInfo) *containerPool {
+    poolsLock.Lock();
+    defer poolsLock.Unlock();
+
+    key := getPoolKey(imageInfo);
+
+    pool, ok := pools[key];
+    if (ok) {
+        return pool;
+    }
+
+    pool = newContainerPool(key, imageInfo.ContainerPool);
+    pool.create = func() (*PooledContainer, error) {
+        return createPooledContainer(imageInfo);
+    };
+    pool.remove = removePooledContainer;
+
+    pools[key] = pool;
+
+    return pool;
+}
+
+func newContainerPool(key string, info *ContainerPoolInfo) *containerPool {
+    return &containerPool{
+        key: key,
+        size: info.Size,
+        idleTimeout: time.Duration(info.IdleTimeoutSecs) * time.Second,
+        containers: make([]*PooledContainer, 0, info.Size),
+    };
+}
+
+func (this *containerPool) take() *PooledContainer {
+    this.lock.Lock();
+    defer this.lock.Unlock();
+
+    if (this.closed) {
+        return nil;
+    }
+
+    this.idle = false;
+    this.resetIdleTimer();
+
+    var result *PooledContainer = nil;
+    if (len(this.containers) > 0) {
+        result = this.containers[0];
+        this.containers = this.containers[1:];
+    }
+
+    if (!this.filling) {
+        this.filling = true;
+        go this.fill();
+    }
+
+    return result;
+}
+
+// Assumes the lock is held.
+func (this *containerPool) resetIdleTimer() {
+    if (this.idleTimer != nil) {
+        this.idleTimer.Stop();
+    }
+
+    this.idleTimer = time.AfterFunc(this.idleTimeout, this.drain);
+}
+
+// Create containers until the pool is full.
+// Only one fill will run at a time (see containerPool.filling).
+func (this *containerPool) fill() {
+    for {
+        // Check and clear the filling flag together, so a take cannot miss starting a new fill.
+        this.lock.Lock();
+        done := (this.closed || this.idle || (len(this.containers) >= this.size));
+        if (done) {
+            this.filling = false;
+        }
+        this.lock.Unlock();
+
+        if (done) {
+            return;
+        }
+
+        pooledContainer, err := this.create();
+        if (err != nil) {
+            log.Warn("Failed to create pooled container.", err, log.NewAttr("pool", this.key));
+
+            this.lock.Lock();
+            this.filling = false;
+            this.lock.Unlock();
+
+            return;
+        }
+
+        this.lock.Lock();
+        stopped := (this.closed || this.idle);
+        if (!stopped) {
+            this.containers = append(this.containers, pooledContainer);
+        }
+        this.lock.Unlock();
+
+        // The pool was closed (or went idle) while we were creating this container.
+        // The next loop will see this and stop.
+        if (stopped) {
+            this.remove(pooledContainer);
+        }
+    }
+}
+
+// Remove all the current containers (the pool can still be used and will refill on the next take).
+func (this *containerPool) drain() {
+    this.lock.Lock();
+    this.idle = true;
+    containers := this.containers;
+    this.containers = make([]*PooledContainer, 0, this.size);
+    this.lock.Unlock();
+
+    if (len(containers) > 0) {
+        log.Debug("Draining idle container pool.", log.NewAttr("pool", this.key), log.NewAttr("count", len(containers)));
+    }
+
+    for _, pooledContainer := range containers {
+        this.remove(pooledContainer);
+    }
+}
+
+// Remove all the current containers and stop the pool from being used.
+func (this *containerPool) close() {
+    this.lock.Lock();
+    this.closed = true;
+    if (this.idleTimer != nil) {
+        this.idleTimer.Stop();
+    }
+    this.lock.Unlock();
+
+    this.drain();
+}
+
+func (this *containerPool) count() int {
+    this.lock.Lock();
+    defer this.lock.Unlock();
+
+    return len(this.containers);
+}
+
+func createPooledContainer(imageInfo *ImageInfo) (*PooledContainer, error) {
+    tempDir, inputDir, outputDir, _, err := common.PrepTempGradingDir("docker-pool");
+    if (err != nil) {
+        return nil, err;
+    }
+
+    ctx, docker, err := getDockerClient(imageInfo.Host);
+    if (err != nil) {
+        os.RemoveAll(tempDir);
+        return nil, err;
+    }
+    defer docker.Close()
+
+    name := cleanContainerName(fmt.Sprintf("pool-%s-%s", imageInfo.Name, util.UUID()));
+
+    containerID, err := createContainer(ctx, docker, imageInfo, inputDir, outputDir, name, nil, nil);
+    if (err != nil) {
+        os.RemoveAll(tempDir);
+        return nil, err;
+    }
+
+    return &PooledContainer{
+        ID: containerID,
+        Name: name,
+        TempDir: tempDir,
+        InputDir: inputDir,
+        OutputDir: outputDir,
+        host: imageInfo.Host,
+    }, nil;
+}
+
+// Remove a container that was never used.
+func removePooledContainer(pooledContainer *PooledContainer) {
+    defer pooledContainer.Cleanup();
+
+    ctx, docker, err := getDockerClient(pooledContainer.host);
+    if (err != nil) {
+        log.Warn("Failed to get docker client to remove pooled container.", err, log.NewAttr("container-name", pooledContainer.Name));
+        return;
+    }
+    defer docker.Close()
+
+    err = docker.ContainerRemove(ctx, pooledContainer.ID, types.ContainerRemoveOptions{Force: true});
+    if (err != nil) {
+        log.Warn("Failed to remove pooled container.", err, log.NewAttr("container-name", pooledContainer.Name));
+    }
+}
@@ -0,0 +1,167 @@
+package docker
+
+import (
+    "fmt"
+    "sync"
+    "testing"
+    "time"
+)
+
+func TestContainerPoolTake(test *testing.T) {
+    pool, created, removed := newTestContainerPool(3, time.Hour);
+    defer pool.close();
+
+    // The first take is cold.
+    if (pool.take() != nil) {
+        test.Fatalf("Got a container from an empty pool.");
+    }
+
+    waitForPoolCount(test, pool, 3);
+
+    seen := make(map[string]bool);
+    for i := 0; i < 10; i++ {
+        waitForPoolCount(test, pool, 3);
+
+        pooledContainer := pool.take();
+        if (pooledContainer == nil) {
+            test.Fatalf("Case %d: Did not get a container from a full pool.", i);
+        }
+
+        if (seen[pooledContainer.ID]) {
+            test.Fatalf("Case %d: Container was used more than once: '%s'.", i, pooledContainer.ID);
+        }
+
+        seen[pooledContainer.ID] = true;
+    }
+
+    waitForPoolCount(test, pool, 3);
+
+    if (created() != 13) {
+        test.Fatalf("Unexpected number of created containers. Expected: 13, Actual: %d.", created());
+    }
+
+    if (removed() != 0) {
+        test.Fatalf("Unexpected number of removed containers. Expected: 0, Actual: %d.", removed());
+    }
+}
+
+func TestContainerPoolIdle(test *testing.T) {
+    pool, _, removed := newTestContainerPool(2, 100 * time.Millisecond);
+    defer pool.close();
+
+    pool.take();
+    waitForPoolCount(test, pool, 2);
+
+    // Wait for the idle timeout to drain the pool.
+    waitForPoolCount(test, pool, 0);
+
+    if (removed() != 2) {
+        test.Fatalf("Unexpected number of removed containers. Expected: 2, Actual: %d.", removed());
+    }
+
+    // The pool should refill on the next take.
+    pool.take();
+    waitForPoolCount(test, pool, 2);
+}
+
+func TestContainerPoolClose(test *testing.T) {
+    pool, _, removed := newTestContainerPool(2, time.Hour);
+
+    pool.take();
+    waitForPoolCount(test, pool, 2);
+
+    pool.close();
+
+    if (removed() != 2) {
+        test.Fatalf("Unexpected number of removed containers. Expected: 2, Actual: %d.", removed());
+    }
+
+    if (pool.take() != nil) {
+        test.Fatalf("Got a container from a closed pool.");
+    }
+
+    if (pool.count() != 0) {
+        test.Fatalf("Closed pool has containers: %d.", pool.count());
+    }
+}
+
+func TestContainerPoolInfoValidate(test *testing.T) {
+    testCases := []struct{info ContainerPoolInfo; expected *ContainerPoolInfo}{
+        {ContainerPoolInfo{Size: 1}, &ContainerPoolInfo{Size: 1, IdleTimeoutSecs: DEFAULT_POOL_IDLE_TIMEOUT_SECS}},
+        {ContainerPoolInfo{Size: 0, IdleTimeoutSecs: 10}, &ContainerPoolInfo{Size: 0, IdleTimeoutSecs: 10}},
+        {ContainerPoolInfo{Size: -1}, nil},
+        {ContainerPoolInfo{Size: 1, IdleTimeoutSecs: -1}, nil},
+    };
+
+    for i, testCase := range testCases {
+        err := testCase.info.Validate();
+        if (testCase.expected == nil) {
+            if (err == nil) {
+                test.Errorf("Case %d: Did not get an expected error.", i);
+            }
+
+            continue;
+        }
+
+        if (err != nil) {
+            test.Errorf("Case %d: Got an unexpected error: '%v'.", i, err);
+            continue;
+        }
+
+        if (testCase.info != *testCase.expected) {
+            test.Errorf("Case %d: Unexpected result. Expected: '%+v', Actual: '%+v'.", i, *testCase.expected, testCase.info);
+        }
+    }
+}
+
+// Get a pool that does not actually create containers.
+// Also returns functions to get the number of created and removed containers.
+func newTestContainerPool(size int, idleTimeout time.Duration) (*containerPool, func() int, func() int) {
+    var lock sync.Mutex;
+    createCount := 0;
+    removeCount := 0;
+
+    pool := newContainerPool("test", &ContainerPoolInfo{Size: size});
+    pool.idleTimeout = idleTimeout;
+
+    pool.create = func() (*PooledContainer, error) {
+        lock.Lock();
+        defer lock.Unlock();
+
+        createCount++;
+        return &PooledContainer{ID: fmt.Sprintf("%03d", createCount)}, nil;
+    };
+
+    pool.remove = func(pooledContainer *PooledContainer) {
+        lock.Lock();
+        defer lock.Unlock();
+
+        removeCount++;
+    };
+
+    created := func() int {
+        lock.Lock();
+        defer lock.Unlock();
+        return createCount;
+    };
+
+    removed := func() int {
+        lock.Lock();
+        defer lock.Unlock();
+        return removeCount;
+    };
+
+    return pool, created, removed;
+}
+
+func waitForPoolCount(test *testing.T, pool *containerPool, expected int) {
+    for i := 0; i < 200; i++ {
+        if (pool.count() == expected) {
+            return;
+        }
+
+        time.Sleep(10 * time.Millisecond);
+    }
+
+    test.Fatalf("Pool did not reach the expected size. Expected: %d, Actual: %d.", expected, pool.count());
+}
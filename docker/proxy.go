@@ -0,0 +1,285 @@
+package docker
+
+// A small HTTP(S) proxy that only allows requests to an allowlist of hosts.
+// Used to give grading containers limited access to the outside world (see network.go).
+
+import (
+    "context"
+    "fmt"
+    "io"
+    "net"
+    "net/http"
+    "slices"
+    "strings"
+    "sync"
+    "syscall"
+    "time"
+
+    "github.com/edulinq/autograder/log"
+)
+
+const PROXY_DIAL_TIMEOUT = 10 * time.Second;
+
+// The ports that allowlisted hosts can be reached on,
+// unless an allowlist entry has its own port (e.g. "example.com:8080").
+var PROXY_DEFAULT_PORTS []string = []string{"80", "443"};
+
+// Allow the proxy to connect to local (loopback, private, etc.) addresses.
+// Should only be used for testing.
+var proxyAllowLocalTargets bool = false;
+
+// Headers that only apply to a single connection and should not be forwarded.
+var hopHeaders []string = []string{
+    "Connection",
+    "Keep-Alive",
+    "Proxy-Authenticate",
+    "Proxy-Authorization",
+    "Proxy-Connection",
+    "Te",
+    "Trailer",
+    "Transfer-Encoding",
+    "Upgrade",
+};
+
+type allowlistProxy struct {
+    allowlist []string
+    listener net.Listener
+    server *http.Server
+    dialer *net.Dialer
+    transport *http.Transport
+
+    // Tunnels (CONNECT) are hijacked from the server, so they need to be closed separately.
+    tunnels map[net.Conn]bool
+    lock sync.Mutex
+}
+
+// Start a proxy listening on the given address (e.g. "172.17.0.1:0").
+func startAllowlistProxy(address string, allowlist []string) (*allowlistProxy, error) {
+    listener, err := net.Listen("tcp", address);
+    if (err != nil) {
+        return nil, fmt.Errorf("Failed to listen on '%s': '%w'.", address, err);
+    }
+
+    // Names are checked against the allowlist, but the addresses they resolve to are checked when dialing.
+    dialer := &net.Dialer{
+        Timeout: PROXY_DIAL_TIMEOUT,
+        Control: checkProxyDialAddress,
+    };
+
+    proxy := &allowlistProxy{
+        allowlist: allowlist,
+        listener: listener,
+        dialer: dialer,
+        transport: &http.Transport{
+            Proxy: nil,
+            DialContext: dialer.DialContext,
+        },
+        tunnels: make(map[net.Conn]bool),
+    };
+
+    proxy.server = &http.Server{Handler: proxy};
+
+    go func() {
+        err := proxy.server.Serve(listener);
+        if ((err != nil) && (err != http.ErrServerClosed)) {
+            log.Warn("Allowlist proxy stopped unexpectedly.", err, log.NewAttr("address", address));
+        }
+    }();
+
+    return proxy, nil;
+}
+
+func (this *allowlistProxy) Address() string {
+    return this.listener.Addr().String();
+}
+
+func (this *allowlistProxy) Close() {
+    this.server.Close();
+    this.transport.CloseIdleConnections();
+
+    this.lock.Lock();
+    defer this.lock.Unlock();
+
+    for conn, _ := range this.tunnels {
+        conn.Close();
+    }
+
+    this.tunnels = make(map[net.Conn]bool);
+}
+
+func (this *allowlistProxy) ServeHTTP(response http.ResponseWriter, request *http.Request) {
+    host := request.URL.Hostname();
+    port := request.URL.Port();
+    if (port == "") {
+        port = "80";
+    }
+
+    if (request.Method == http.MethodConnect) {
+        var err error;
+        host, port, err = net.SplitHostPort(request.Host);
+        if (err != nil) {
+            http.Error(response, fmt.Sprintf("Bad tunnel target '%s'.", request.Host), http.StatusBadRequest);
+            return;
+        }
+    }
+
+    if (!isTargetAllowed(host, port, this.allowlist)) {
+        log.Debug("Allowlist proxy blocked a request.", log.NewAttr("host", host), log.NewAttr("port", port));
+        http.Error(response, fmt.Sprintf("Target '%s' is not on the allowlist.", net.JoinHostPort(host, port)), http.StatusForbidden);
+        return;
+    }
+
+    if (request.Method == http.MethodConnect) {
+        this.tunnel(response, request);
+    } else {
+        this.forward(response, request);
+    }
+}
+
+// Handle a CONNECT request by piping bytes between the client and target.
+func (this *allowlistProxy) tunnel(response http.ResponseWriter, request *http.Request) {
+    target, err := this.dialer.Dial("tcp", request.Host);
+    if (err != nil) {
+        http.Error(response, fmt.Sprintf("Failed to connect to '%s'.", request.Host), http.StatusBadGateway);
+        return;
+    }
+
+    hijacker, ok := response.(http.Hijacker);
+    if (!ok) {
+        target.Close();
+        http.Error(response, "Tunneling is not supported.", http.StatusInternalServerError);
+        return;
+    }
+
+    client, _, err := hijacker.Hijack();
+    if (err != nil) {
+        target.Close();
+        http.Error(response, "Failed to hijack connection.", http.StatusInternalServerError);
+        return;
+    }
+
+    _, err = client.Write([]byte("HTTP/1.1 200 Connection Established\r\n\r\n"));
+    if (err != nil) {
+        client.Close();
+        target.Close();
+        return;
+    }
+
+    this.lock.Lock();
+    this.tunnels[client] = true;
+    this.tunnels[target] = true;
+    this.lock.Unlock();
+
+    done := make(chan any, 2);
+    pipe := func(dst net.Conn, src net.Conn) {
+        io.Copy(dst, src);
+        done <- nil;
+    };
+
+    go pipe(target, client);
+    go pipe(client, target);
+
+    go func() {
+        // Once either side is done, close both.
+        <-done;
+
+        client.Close();
+        target.Close();
+
+        this.lock.Lock();
+        delete(this.tunnels, client);
+        delete(this.tunnels, target);
+        this.lock.Unlock();
+    }();
+}
+
+// Handle a plain HTTP request by sending it on to the target.
+func (this *allowlistProxy) forward(response http.ResponseWriter, request *http.Request) {
+    if (!request.URL.IsAbs()) {
+        http.Error(response, "Proxy requests must use an absolute URL.", http.StatusBadRequest);
+        return;
+    }
+
+    outRequest := request.Clone(context.Background());
+    outRequest.RequestURI = "";
+    for _, header := range hopHeaders {
+        outRequest.Header.Del(header);
+    }
+
+    outResponse, err := this.transport.RoundTrip(outRequest);
+    if (err != nil) {
+        http.Error(response, fmt.Sprintf("Failed to reach '%s'.", request.URL.Host), http.StatusBadGateway);
+        return;
+    }
+    defer outResponse.Body.Close();
+
+    for _, header := range hopHeaders {
+        outResponse.Header.Del(header);
+    }
+
+    for key, values := range outResponse.Header {
+        for _, value := range values {
+            response.Header().Add(key, value);
+        }
+    }
+
+    response.WriteHeader(outResponse.StatusCode);
+    io.Copy(response, outResponse.Body);
+}
+
+// Check if a target (host and port) matches an allowlist.
+// Wildcard entries ("*.example.com") match any subdomain (but not the domain itself).
+// Entries without a port only match the default ports (see PROXY_DEFAULT_PORTS).
+func isTargetAllowed(host string, port string, allowlist []string) bool {
+    host = strings.ToLower(strings.TrimSuffix(host, "."));
+    if (host == "") {
+        return false;
+    }
+
+    for _, entry := range allowlist {
+        entryHost, entryPort, hasPort := strings.Cut(entry, ":");
+        if (hasPort && (port != entryPort)) {
+            continue;
+        }
+
+        if (!hasPort && !slices.Contains(PROXY_DEFAULT_PORTS, port)) {
+            continue;
+        }
+
+        if (strings.HasPrefix(entryHost, "*.")) {
+            if (strings.HasSuffix(host, entryHost[1:])) {
+                return true;
+            }
+        } else if (host == entryHost) {
+            return true;
+        }
+    }
+
+    return false;
+}
+
+// Called with the resolved address for every connection the proxy makes.
+// The proxy runs on the autograder's host, so it must not be used to reach the host itself or its local networks
+// (even if an allowlisted name resolves to a local address).
+func checkProxyDialAddress(network string, address string, conn syscall.RawConn) error {
+    host, _, err := net.SplitHostPort(address);
+    if (err != nil) {
+        return fmt.Errorf("Bad proxy dial address '%s': '%w'.", address, err);
+    }
+
+    ip := net.ParseIP(host);
+    if (ip == nil) {
+        return fmt.Errorf("Proxy dial address '%s' is not an IP address.", address);
+    }
+
+    if (!proxyAllowLocalTargets && isLocalIP(ip)) {
+        return fmt.Errorf("Proxy will not connect to local address '%s'.", address);
+    }
+
+    return nil;
+}
+
+// Check if an IP is not a public (global unicast) address.
+func isLocalIP(ip net.IP) bool {
+    return (ip.IsLoopback() || ip.IsPrivate() || ip.IsUnspecified() || !ip.IsGlobalUnicast());
+}
@@ -0,0 +1,156 @@
+package docker
+
+import (
+    "crypto/tls"
+    "io"
+    "net"
+    "net/http"
+    "net/http/httptest"
+    "net/url"
+    "testing"
+)
+
+func TestIsTargetAllowed(test *testing.T) {
+    allowlist := []string{"example.com", "*.example.org", "example.net:8080"};
+
+    testCases := []struct{host string; port string; expected bool}{
+        {"example.com", "443", true},
+        {"example.com", "80", true},
+        {"EXAMPLE.com.", "443", true},
+        {"www.example.com", "443", false},
+        {"example.org", "443", false},
+        {"www.example.org", "443", true},
+        {"a.b.example.org", "80", true},
+        {"badexample.org", "443", false},
+        {"example.com.evil.com", "443", false},
+        {"", "443", false},
+
+        // Ports.
+        {"example.com", "22", false},
+        {"www.example.org", "8080", false},
+        {"example.net", "8080", true},
+        {"example.net", "443", false},
+        {"example.net", "22", false},
+    };
+
+    for i, testCase := range testCases {
+        actual := isTargetAllowed(testCase.host, testCase.port, allowlist);
+        if (testCase.expected != actual) {
+            test.Errorf("Case %d ('%s:%s'): Unexpected result. Expected: %v, Actual: %v.", i, testCase.host, testCase.port, testCase.expected, actual);
+        }
+    }
+}
+
+func TestCheckProxyDialAddress(test *testing.T) {
+    testCases := []struct{address string; allowed bool}{
+        {"93.184.216.34:443", true},
+        {"[2606:2800:220:1:248:1893:25c8:1946]:443", true},
+
+        {"127.0.0.1:443", false},
+        {"[::1]:443", false},
+        {"0.0.0.0:80", false},
+        {"10.1.2.3:443", false},
+        {"172.17.0.1:443", false},
+        {"192.168.1.1:80", false},
+        {"169.254.169.254:80", false},
+        {"[fe80::1]:443", false},
+        {"[fd00::1]:443", false},
+        {"224.0.0.1:80", false},
+        {"example.com:443", false},
+    };
+
+    for i, testCase := range testCases {
+        err := checkProxyDialAddress("tcp", testCase.address, nil);
+        if (testCase.allowed && (err != nil)) {
+            test.Errorf("Case %d ('%s'): Unexpected error: '%v'.", i, testCase.address, err);
+        } else if (!testCase.allowed && (err == nil)) {
+            test.Errorf("Case %d ('%s'): Address was not blocked.", i, testCase.address);
+        }
+    }
+}
+
+func TestAllowlistProxyHTTP(test *testing.T) {
+    server := httptest.NewServer(http.HandlerFunc(func(response http.ResponseWriter, request *http.Request) {
+        io.WriteString(response, "hello");
+    }));
+    defer server.Close();
+
+    serverURL, _ := url.Parse(server.URL);
+
+    testAllowlistProxy(test, server.URL, serverURL.Host, nil);
+}
+
+func TestAllowlistProxyHTTPS(test *testing.T) {
+    server := httptest.NewTLSServer(http.HandlerFunc(func(response http.ResponseWriter, request *http.Request) {
+        io.WriteString(response, "hello");
+    }));
+    defer server.Close();
+
+    serverURL, _ := url.Parse(server.URL);
+
+    testAllowlistProxy(test, server.URL, serverURL.Host, &tls.Config{InsecureSkipVerify: true});
+}
+
+// The test servers are local, so local targets are allowed (except for the last case).
+func testAllowlistProxy(test *testing.T, target string, hostPort string, tlsConfig *tls.Config) {
+    defer func(old bool) {
+        proxyAllowLocalTargets = old;
+    }(proxyAllowLocalTargets);
+
+    host, _, _ := net.SplitHostPort(hostPort);
+
+    testCases := []struct{allowlist []string; allowLocal bool; allowed bool}{
+        {[]string{hostPort}, true, true},
+        {[]string{"example.com"}, true, false},
+
+        // The test server is not on a default port.
+        {[]string{host}, true, false},
+
+        // Local addresses are never reached.
+        {[]string{hostPort}, false, false},
+    };
+
+    for i, testCase := range testCases {
+        proxyAllowLocalTargets = testCase.allowLocal;
+
+        proxy, err := startAllowlistProxy("127.0.0.1:0", testCase.allowlist);
+        if (err != nil) {
+            test.Fatalf("Case %d: Failed to start proxy: '%v'.", i, err);
+        }
+
+        proxyURL, _ := url.Parse("http://" + proxy.Address());
+        client := &http.Client{
+            Transport: &http.Transport{
+                Proxy: http.ProxyURL(proxyURL),
+                TLSClientConfig: tlsConfig,
+            },
+        };
+
+        response, err := client.Get(target);
+
+        if (testCase.allowed) {
+            if (err != nil) {
+                test.Errorf("Case %d: Failed to make request: '%v'.", i, err);
+            } else {
+                body, _ := io.ReadAll(response.Body);
+                response.Body.Close();
+
+                if ((response.StatusCode != http.StatusOK) || (string(body) != "hello")) {
+                    test.Errorf("Case %d: Unexpected response. Status: %d, Body: '%s'.", i, response.StatusCode, string(body));
+                }
+            }
+        } else {
+            // HTTPS requests fail when the CONNECT is rejected, HTTP requests just get the rejection.
+            if (err == nil) {
+                response.Body.Close();
+
+                if ((response.StatusCode != http.StatusForbidden) && (response.StatusCode != http.StatusBadGateway)) {
+                    test.Errorf("Case %d: Unexpected status. Expected a rejection, Actual: %d.", i, response.StatusCode);
+                }
+            }
+        }
+
+        client.CloseIdleConnections();
+        proxy.Close();
+    }
+}
@@ -0,0 +1,187 @@
+package docker
+
+// Pulling base images (with retries) from public or private registries.
+
+import (
+    "bufio"
+    "fmt"
+    "io"
+    "regexp"
+    "strings"
+    "time"
+
+    "github.com/docker/docker/api/types"
+    "github.com/docker/docker/api/types/registry"
+    "github.com/docker/docker/client"
+
+    "github.com/edulinq/autograder/config"
+    "github.com/edulinq/autograder/log"
+    "github.com/edulinq/autograder/util"
+)
+
+const DEFAULT_REGISTRY = "docker.io";
+
+var pinnedImagePattern *regexp.Regexp = regexp.MustCompile(`@sha256:[0-9a-f]{64}$`);
+
+// Check if an image reference is pinned to a specific digest ('image@sha256:...').
+func IsPinnedImage(image string) bool {
+    return pinnedImagePattern.MatchString(image);
+}
+
+// Check if an image can be used under the docker.pinned option.
+// The autograder's own default images (DEFAULT_IMAGE and the default docker.proxy.image) are always allowed,
+// since they are chosen (and updated) with the autograder rather than by a course or server config.
+func IsAllowedImage(image string) bool {
+    if (!config.DOCKER_REQUIRE_PINNED.Get() || IsPinnedImage(image)) {
+        return true;
+    }
+
+    return ((image == DEFAULT_IMAGE) || (image == config.DOCKER_PROXY_IMAGE.DefaultValue));
+}
+
+// Get the registry (domain) that an image reference points to.
+// This follows docker's rules: the first path component is a registry if it looks like a host.
+func GetImageRegistry(image string) string {
+    first, _, found := strings.Cut(image, "/");
+    if (!found) {
+        return DEFAULT_REGISTRY;
+    }
+
+    if (strings.ContainsAny(first, ".:") || (first == "localhost")) {
+        return first;
+    }
+
+    return DEFAULT_REGISTRY;
+}
+
+// Get the configured registry credentials for an image (nil if there are none).
+func getRegistryAuth(image string) *registry.AuthConfig {
+    if (config.DOCKER_REGISTRY_USER.Get() == "") {
+        return nil;
+    }
+
+    server := config.DOCKER_REGISTRY_SERVER.Get();
+    if (server == "") {
+        server = DEFAULT_REGISTRY;
+    }
+
+    if (server != GetImageRegistry(image)) {
+        return nil;
+    }
+
+    return &registry.AuthConfig{
+        Username: config.DOCKER_REGISTRY_USER.Get(),
+        Password: config.DOCKER_REGISTRY_PASS.Get(),
+        ServerAddress: server,
+    };
+}
+
+// Get the credentials to pass along with a build (so the build can pull the base image).
+func getBuildAuthConfigs(image string) map[string]registry.AuthConfig {
+    auth := getRegistryAuth(image);
+    if (auth == nil) {
+        return nil;
+    }
+
+    return map[string]registry.AuthConfig{auth.ServerAddress: *auth};
+}
+
+// Ensure that an image is available on a host.
+// If refresh is true, then the image will always be pulled (pinned images will only be pulled if they are missing).
+// Pulls are retried according to the docker.pull.* options.
+func PullImage(host string, image string, refresh bool) error {
+    ctx, docker, err := getDockerClient(host);
+    if (err != nil) {
+        return err;
+    }
+    defer docker.Close()
+
+    // A pinned image will never change.
+    if (!refresh || IsPinnedImage(image)) {
+        _, _, err = docker.ImageInspectWithRaw(ctx, image);
+        if (err == nil) {
+            return nil;
+        }
+
+        if (!client.IsErrNotFound(err)) {
+            return fmt.Errorf("Failed to inspect image '%s': '%w'.", image, err);
+        }
+    }
+
+    options := types.ImagePullOptions{};
+
+    auth := getRegistryAuth(image);
+    if (auth != nil) {
+        options.RegistryAuth, err = registry.EncodeAuthConfig(*auth);
+        if (err != nil) {
+            return fmt.Errorf("Failed to encode registry credentials: '%w'.", err);
+        }
+    }
+
+    backoff := time.Duration(config.DOCKER_PULL_BACKOFF_SECS.Get()) * time.Second;
+
+    return withRetries(config.DOCKER_PULL_ATTEMPTS.Get(), backoff, func() error {
+        log.Debug("Pulling image.", log.NewAttr("image", image), log.NewAttr("host", host));
+
+        response, err := docker.ImagePull(ctx, image, options);
+        if (err != nil) {
+            return fmt.Errorf("Failed to pull image '%s': '%w'.", image, err);
+        }
+        defer response.Close();
+
+        return checkPullOutput(image, response);
+    });
+}
+
+// Pull responses report errors in the output stream (after the request succeeded).
+func checkPullOutput(image string, response io.Reader) error {
+    scanner := bufio.NewScanner(response);
+    for scanner.Scan() {
+        line := strings.TrimSpace(scanner.Text());
+        if (line == "") {
+            continue;
+        }
+
+        jsonData, err := util.JSONMapFromString(line);
+        if (err != nil) {
+            continue;
+        }
+
+        message, ok := jsonData["error"];
+        if (ok) {
+            return fmt.Errorf("Failed to pull image '%s': '%v'.", image, message);
+        }
+    }
+
+    err := scanner.Err();
+    if (err != nil) {
+        return fmt.Errorf("Failed to read pull response for image '%s': '%w'.", image, err);
+    }
+
+    return nil;
+}
+
+// Call a function until it succeeds (or runs out of attempts).
+// The wait between attempts starts at backoff and doubles after each failure.
+// Returns the last error.
+func withRetries(attempts int, backoff time.Duration, operation func() error) error {
+    if (attempts < 1) {
+        attempts = 1;
+    }
+
+    var err error;
+    for attempt := 1; attempt <= attempts; attempt++ {
+        err = operation();
+        if (err == nil) {
+            return nil;
+        }
+
+        if (attempt < attempts) {
+            log.Warn("Operation failed, retrying.", err, log.NewAttr("attempt", attempt), log.NewAttr("wait", backoff.String()));
+            time.Sleep(backoff);
+            backoff *= 2;
+        }
+    }
+
+    return fmt.Errorf("Failed after %d attempts: '%w'.", attempts, err);
+}
@@ -0,0 +1,201 @@
+package docker
+
+import (
+    "errors"
+    "reflect"
+    "strings"
+    "testing"
+
+    "github.com/docker/docker/api/types/registry"
+
+    "github.com/edulinq/autograder/config"
+)
+
+const TEST_DIGEST = "sha256:0123456789abcdef0123456789abcdef0123456789abcdef0123456789abcdef";
+
+func TestIsPinnedImage(test *testing.T) {
+    testCases := []struct{image string; expected bool}{
+        {"edulinq/autograder.base", false},
+        {"edulinq/autograder.base:latest", false},
+        {"edulinq/autograder.base@" + TEST_DIGEST, true},
+        {"localhost:5000/grader@" + TEST_DIGEST, true},
+        {"edulinq/autograder.base@sha256:1234", false},
+        {"edulinq/autograder.base@md5:0123456789abcdef0123456789abcdef", false},
+    };
+
+    for i, testCase := range testCases {
+        actual := IsPinnedImage(testCase.image);
+        if (testCase.expected != actual) {
+            test.Errorf("Case %d ('%s'): Unexpected result. Expected: %v, Actual: %v.", i, testCase.image, testCase.expected, actual);
+        }
+    }
+}
+
+func TestGetImageRegistry(test *testing.T) {
+    testCases := []struct{image string; expected string}{
+        {"ubuntu", DEFAULT_REGISTRY},
+        {"edulinq/autograder.base", DEFAULT_REGISTRY},
+        {"edulinq/autograder.base@" + TEST_DIGEST, DEFAULT_REGISTRY},
+        {"localhost/grader", "localhost"},
+        {"localhost:5000/grader:1.0", "localhost:5000"},
+        {"registry.example.com/course/grader", "registry.example.com"},
+    };
+
+    for i, testCase := range testCases {
+        actual := GetImageRegistry(testCase.image);
+        if (testCase.expected != actual) {
+            test.Errorf("Case %d ('%s'): Unexpected registry. Expected: '%s', Actual: '%s'.", i, testCase.image, testCase.expected, actual);
+        }
+    }
+}
+
+func TestGetRegistryAuth(test *testing.T) {
+    defer config.DOCKER_REGISTRY_SERVER.Set(config.DOCKER_REGISTRY_SERVER.Get());
+    defer config.DOCKER_REGISTRY_USER.Set(config.DOCKER_REGISTRY_USER.Get());
+    defer config.DOCKER_REGISTRY_PASS.Set(config.DOCKER_REGISTRY_PASS.Get());
+
+    testCases := []struct{server string; user string; image string; expected *registry.AuthConfig}{
+        {"", "", "edulinq/autograder.base", nil},
+        {"localhost:5000", "", "localhost:5000/grader", nil},
+        {"", "alice", "edulinq/autograder.base", &registry.AuthConfig{Username: "alice", Password: "pass", ServerAddress: DEFAULT_REGISTRY}},
+        {"", "alice", "localhost:5000/grader", nil},
+        {"localhost:5000", "alice", "localhost:5000/grader", &registry.AuthConfig{Username: "alice", Password: "pass", ServerAddress: "localhost:5000"}},
+        {"localhost:5000", "alice", "edulinq/autograder.base", nil},
+    };
+
+    for i, testCase := range testCases {
+        config.DOCKER_REGISTRY_SERVER.Set(testCase.server);
+        config.DOCKER_REGISTRY_USER.Set(testCase.user);
+        config.DOCKER_REGISTRY_PASS.Set("pass");
+
+        actual := getRegistryAuth(testCase.image);
+        if (!reflect.DeepEqual(testCase.expected, actual)) {
+            test.Errorf("Case %d: Unexpected auth. Expected: '%v', Actual: '%v'.", i, testCase.expected, actual);
+        }
+    }
+}
+
+func TestImageInfoPinnedValidate(test *testing.T) {
+    defer config.DOCKER_REQUIRE_PINNED.Set(config.DOCKER_REQUIRE_PINNED.Get());
+
+    testCases := []struct{image string; required bool; valid bool}{
+        {"edulinq/autograder.python", false, true},
+        {"edulinq/autograder.python", true, false},
+        {"edulinq/autograder.python@" + TEST_DIGEST, true, true},
+
+        // The default image is always allowed.
+        {"", true, true},
+        {DEFAULT_IMAGE, true, true},
+
+        {"edulinq/autograder.base@" + TEST_DIGEST, true, true},
+        {"edulinq/autograder.base@" + TEST_DIGEST, false, true},
+        {"edulinq/autograder.base@sha256:1234", false, false},
+    };
+
+    for i, testCase := range testCases {
+        config.DOCKER_REQUIRE_PINNED.Set(testCase.required);
+
+        imageInfo := &ImageInfo{Image: testCase.image, Invocation: []string{"true"}, Name: "test", BaseDir: "."};
+        err := imageInfo.Validate();
+
+        if (testCase.valid && (err != nil)) {
+            test.Errorf("Case %d: Unexpected validation error: '%v'.", i, err);
+        } else if (!testCase.valid && (err == nil)) {
+            test.Errorf("Case %d: Did not get an expected validation error.", i);
+        }
+    }
+}
+
+// Images that do not set an image must work with the default config.
+func TestImageInfoDefaultImageDefaultConfig(test *testing.T) {
+    defer config.DOCKER_REQUIRE_PINNED.Set(config.DOCKER_REQUIRE_PINNED.Get());
+    config.DOCKER_REQUIRE_PINNED.Set(config.DOCKER_REQUIRE_PINNED.DefaultValue);
+
+    imageInfo := &ImageInfo{Invocation: []string{"true"}, Name: "test", BaseDir: "."};
+    err := imageInfo.Validate();
+    if (err != nil) {
+        test.Fatalf("Failed to validate an image info without an image: '%v'.", err);
+    }
+
+    if (imageInfo.Image != DEFAULT_IMAGE) {
+        test.Fatalf("Unexpected image. Expected: '%s', Actual: '%s'.", DEFAULT_IMAGE, imageInfo.Image);
+    }
+}
+
+func TestNetworkInfoProxyImagePinned(test *testing.T) {
+    defer config.DOCKER_REQUIRE_PINNED.Set(config.DOCKER_REQUIRE_PINNED.Get());
+    defer config.DOCKER_PROXY_IMAGE.Set(config.DOCKER_PROXY_IMAGE.Get());
+
+    testCases := []struct{proxyImage string; required bool; valid bool}{
+        {config.DOCKER_PROXY_IMAGE.DefaultValue.(string), true, true},
+        {"example/socat", false, true},
+        {"example/socat", true, false},
+        {"example/socat@" + TEST_DIGEST, true, true},
+    };
+
+    for i, testCase := range testCases {
+        config.DOCKER_REQUIRE_PINNED.Set(testCase.required);
+        config.DOCKER_PROXY_IMAGE.Set(testCase.proxyImage);
+
+        info := &NetworkInfo{Mode: NETWORK_ALLOWLIST, Allowlist: []string{"example.com"}};
+        err := info.Validate();
+
+        if (testCase.valid && (err != nil)) {
+            test.Errorf("Case %d: Unexpected validation error: '%v'.", i, err);
+        } else if (!testCase.valid && (err == nil)) {
+            test.Errorf("Case %d: Did not get an expected validation error.", i);
+        }
+    }
+}
+
+func TestWithRetries(test *testing.T) {
+    calls := 0;
+    err := withRetries(3, 0, func() error {
+        calls++;
+        if (calls < 3) {
+            return errors.New("fail");
+        }
+
+        return nil;
+    });
+
+    if (err != nil) {
+        test.Fatalf("Unexpected error: '%v'.", err);
+    }
+
+    if (calls != 3) {
+        test.Fatalf("Unexpected number of calls. Expected: 3, Actual: %d.", calls);
+    }
+
+    calls = 0;
+    err = withRetries(2, 0, func() error {
+        calls++;
+        return errors.New("always fail");
+    });
+
+    if (err == nil) {
+        test.Fatalf("Did not get an expected error.");
+    }
+
+    if (!strings.Contains(err.Error(), "always fail")) {
+        test.Fatalf("Error does not contain the last failure: '%v'.", err);
+    }
+
+    if (calls != 2) {
+        test.Fatalf("Unexpected number of calls. Expected: 2, Actual: %d.", calls);
+    }
+}
+
+func TestCheckPullOutput(test *testing.T) {
+    output := `{"status": "Pulling from library/ubuntu"}` + "\n" + `{"status": "Downloading"}` + "\n";
+    err := checkPullOutput("ubuntu", strings.NewReader(output));
+    if (err != nil) {
+        test.Fatalf("Unexpected error: '%v'.", err);
+    }
+
+    output += `{"error": "manifest unknown"}` + "\n";
+    err = checkPullOutput("ubuntu", strings.NewReader(output));
+    if ((err == nil) || !strings.Contains(err.Error(), "manifest unknown")) {
+        test.Fatalf("Did not get the expected error, found: '%v'.", err);
+    }
+}
@@ -1,40 +1,103 @@
 package docker
 
 import (
+    "context"
+    "errors"
     "fmt"
+    "io"
     "regexp"
     "strings"
+    "time"
 
     "github.com/docker/docker/api/types"
     "github.com/docker/docker/api/types/container"
     "github.com/docker/docker/api/types/mount"
+    "github.com/docker/docker/client"
     "github.com/docker/docker/pkg/stdcopy"
 
     "github.com/edulinq/autograder/log"
     "github.com/edulinq/autograder/util"
 )
 
-func RunContainer(logId log.Loggable, imageName string, inputDir string, outputDir string, gradingID string) (string, string, error) {
-    ctx, docker, err := getDockerClient();
+// Run a grading container and return its output.
+// If the stream writers are non-nil, then the container's output will also be written to them as it is produced.
+// Returns: (stdout, stderr, sidecar logs (keyed by sidecar name, nil if there are no sidecars), error).
+func RunContainer(logId log.Loggable, imageInfo *ImageInfo, inputDir string, outputDir string, gradingID string,
+        stdoutStream io.Writer, stderrStream io.Writer) (string, string, map[string]string, error) {
+    stdout, stderr, _, sidecarLogs, err := runContainerCommand(logId, imageInfo, inputDir, outputDir, gradingID, nil, 0, stdoutStream, stderrStream);
+    return stdout, stderr, sidecarLogs, err;
+}
+
+// Run a grading container with a specific command (nil uses the image's default command).
+// If the timeout is positive and the container runs longer than it,
+// then the container will be killed and an error wrapping context.DeadlineExceeded will be returned.
+// Returns: (stdout, stderr, exit code, error).
+func RunContainerCommand(logId log.Loggable, imageInfo *ImageInfo, inputDir string, outputDir string, gradingID string,
+        command []string, timeout time.Duration, stdoutStream io.Writer, stderrStream io.Writer) (string, string, int, error) {
+    stdout, stderr, exitCode, _, err := runContainerCommand(logId, imageInfo, inputDir, outputDir, gradingID, command, timeout, stdoutStream, stderrStream);
+    return stdout, stderr, exitCode, err;
+}
+
+// Returns: (stdout, stderr, exit code, sidecar logs, error).
+func runContainerCommand(logId log.Loggable, imageInfo *ImageInfo, inputDir string, outputDir string, gradingID string,
+        command []string, timeout time.Duration, stdoutStream io.Writer, stderrStream io.Writer) (string, string, int, map[string]string, error) {
+    ctx, docker, err := getDockerClient(imageInfo.Host);
     if (err != nil) {
-        return "", "", err;
+        return "", "", -1, nil, err;
     }
     defer docker.Close()
 
+    name := cleanContainerName(fmt.Sprintf("%s-%s", gradingID, util.UUID()));
+
+    gradingNetwork, err := startGradingNetwork(ctx, docker, logId, imageInfo, name);
+    if (err != nil) {
+        return "", "", -1, nil, err;
+    }
+    defer gradingNetwork.stop(ctx, docker, logId);
+
+    containerID, err := createContainer(ctx, docker, imageInfo, inputDir, outputDir, name, command, gradingNetwork);
+    if (err != nil) {
+        return "", "", -1, nil, err;
+    }
+
+    RecordImageUse(imageInfo);
+
+    stdout, stderr, exitCode, err := runCreatedContainer(ctx, docker, logId, containerID, name, timeout, stdoutStream, stderrStream);
+
+    // Collect the sidecar logs (even if the grader failed) before the sidecars are removed.
+    sidecarLogs := gradingNetwork.collectLogs(ctx, docker, logId);
+
+    return stdout, stderr, exitCode, sidecarLogs, err;
+}
+
+// Create (but do not start) a grading container.
+// The container will be removed once it stops.
+// A nil network means that the container will have no network access.
+func createContainer(ctx context.Context, docker *client.Client, imageInfo *ImageInfo,
+        inputDir string, outputDir string, name string, command []string, gradingNetwork *gradingNetwork) (string, error) {
     inputDir = util.ShouldAbs(inputDir);
     outputDir = util.ShouldAbs(outputDir);
 
-    name := cleanContainerName(fmt.Sprintf("%s-%s", gradingID, util.UUID()));
+    containerConfig := &container.Config{
+        Image: imageInfo.Name,
+        Cmd: command,
+        Tty: false,
+        NetworkDisabled: true,
+    };
+
+    var networkMode container.NetworkMode = "";
+    if (gradingNetwork != nil) {
+        containerConfig.NetworkDisabled = false;
+        containerConfig.Env = gradingNetwork.env;
+        networkMode = container.NetworkMode(gradingNetwork.name);
+    }
 
     containerInstance, err := docker.ContainerCreate(
         ctx,
-        &container.Config{
-            Image: imageName,
-            Tty: false,
-            NetworkDisabled: true,
-        },
+        containerConfig,
         &container.HostConfig{
             AutoRemove: true,
+            NetworkMode: networkMode,
             Mounts: []mount.Mount{
                 mount.Mount{
                     Type: "bind",
@@ -55,16 +118,23 @@ func RunContainer(logId log.Loggable, imageName string, inputDir string, outputD
         name)
 
     if (err != nil) {
-        return "", "", fmt.Errorf("Failed to create container '%s': '%w'.", name, err);
+        return "", fmt.Errorf("Failed to create container '%s': '%w'.", name, err);
     }
 
-    err = docker.ContainerStart(ctx, containerInstance.ID, types.ContainerStartOptions{});
+    return containerInstance.ID, nil;
+}
+
+// Start a created container, wait for it to finish, and return its output.
+// Returns: (stdout, stderr, exit code, error).
+func runCreatedContainer(ctx context.Context, docker *client.Client, logId log.Loggable, containerID string, name string,
+        timeout time.Duration, stdoutStream io.Writer, stderrStream io.Writer) (string, string, int, error) {
+    err := docker.ContainerStart(ctx, containerID, types.ContainerStartOptions{});
     if (err != nil) {
-        return "", "", fmt.Errorf("Failed to start container '%s' (%s): '%w'.", name, containerInstance.ID, err);
+        return "", "", -1, fmt.Errorf("Failed to start container '%s' (%s): '%w'.", name, containerID, err);
     }
 
     // Get the output reader before the container dies.
-    out, err := docker.ContainerLogs(ctx, containerInstance.ID, types.ContainerLogsOptions{
+    out, err := docker.ContainerLogs(ctx, containerID, types.ContainerLogsOptions{
         ShowStdout: true,
         ShowStderr: true,
         Follow: true,
@@ -73,43 +143,90 @@ func RunContainer(logId log.Loggable, imageName string, inputDir string, outputD
     if (err != nil) {
         log.Warn("Failed to get output from container (but run did not throw an error).",
                 err, logId,
-                log.NewAttr("container-name", name), log.NewAttr("container-id", containerInstance.ID));
+                log.NewAttr("container-name", name), log.NewAttr("container-id", containerID));
         out = nil;
+    } else {
+        defer out.Close();
     }
-    defer out.Close()
 
-    statusChan, errorChan := docker.ContainerWait(ctx, containerInstance.ID, container.WaitConditionNotRunning);
+    outBuffer := new(strings.Builder);
+    errBuffer := new(strings.Builder);
+
+    // Read the output as the container runs (the reader will close when the container stops).
+    outputDone := make(chan any);
+    go func() {
+        defer close(outputDone);
+
+        if (out == nil) {
+            return;
+        }
+
+        stdcopy.StdCopy(teeWriter(outBuffer, stdoutStream), teeWriter(errBuffer, stderrStream), out);
+    }();
+
+    // Stop reading output early (the container may still be running),
+    // and wait for the reader to finish so it does not write into the streams after we return.
+    stopOutput := func() {
+        if (out != nil) {
+            out.Close();
+        }
+
+        <-outputDone;
+    };
+
+    waitCtx := ctx;
+    if (timeout > 0) {
+        var cancel context.CancelFunc;
+        waitCtx, cancel = context.WithTimeout(ctx, timeout);
+        defer cancel();
+    }
+
+    exitCode := -1;
+
+    statusChan, errorChan := docker.ContainerWait(waitCtx, containerID, container.WaitConditionNotRunning);
     select {
         case err := <-errorChan:
+            if (errors.Is(err, context.DeadlineExceeded)) {
+                // The container will be removed once it is killed.
+                killErr := docker.ContainerKill(ctx, containerID, "KILL");
+                if (killErr != nil) {
+                    log.Warn("Failed to kill timed out container.", killErr, logId,
+                            log.NewAttr("container-name", name), log.NewAttr("container-id", containerID));
+                }
+
+                stopOutput();
+                return "", "", -1, fmt.Errorf("Container '%s' (%s) timed out after %s: '%w'.", name, containerID, timeout, err);
+            }
+
             if (err != nil) {
-                return "", "", fmt.Errorf("Got an error when running container '%s' (%s): '%w'.", name, containerInstance.ID, err);
+                stopOutput();
+                return "", "", -1, fmt.Errorf("Got an error when running container '%s' (%s): '%w'.", name, containerID, err);
             }
-        case <-statusChan:
-            // Waiting is complete.
+        case status := <-statusChan:
+            exitCode = int(status.StatusCode);
     }
 
-    stdout := "";
-    stderr := "";
+    <-outputDone;
 
-    // Read the output after the container is done.
-    if (out != nil) {
-        outBuffer := new(strings.Builder);
-        errBuffer := new(strings.Builder);
+    stdout := outBuffer.String();
+    stderr := errBuffer.String();
 
-        stdcopy.StdCopy(outBuffer, errBuffer, out);
+    log.Debug("Container output.",
+            logId,
+            log.NewAttr("container-name", name),
+            log.NewAttr("container-id", containerID),
+            log.NewAttr("stdout", stdout),
+            log.NewAttr("stderr", stderr));
 
-        stdout = outBuffer.String();
-        stderr = errBuffer.String();
+    return stdout, stderr, exitCode, nil;
+}
 
-        log.Debug("Container output.",
-                logId,
-                log.NewAttr("container-name", name),
-                log.NewAttr("container-id", containerInstance.ID),
-                log.NewAttr("stdout", stdout),
-                log.NewAttr("stderr", stderr));
+func teeWriter(buffer io.Writer, stream io.Writer) io.Writer {
+    if (stream == nil) {
+        return buffer;
     }
 
-    return stdout, stderr, nil;
+    return io.MultiWriter(buffer, stream);
 }
 
 func cleanContainerName(text string) string {
@@ -0,0 +1,301 @@
+package docker
+
+// Sidecars are service containers (e.g. a database) that run alongside the grader on its private network.
+// Each grading run gets its own sidecars, which are removed (along with the network) once grading is done.
+// The logs from each sidecar are collected after grading so they can be attached to the grading result.
+
+import (
+    "context"
+    "fmt"
+    "strings"
+    "time"
+
+    "github.com/docker/docker/api/types"
+    "github.com/docker/docker/api/types/container"
+    "github.com/docker/docker/api/types/network"
+    "github.com/docker/docker/client"
+    "github.com/docker/docker/pkg/stdcopy"
+
+    "github.com/edulinq/autograder/log"
+)
+
+const (
+    DEFAULT_HEALTH_CHECK_INTERVAL_SECS = 1
+    DEFAULT_HEALTH_CHECK_TIMEOUT_SECS = 30
+)
+
+// Only the end of long sidecar logs are kept.
+const MAX_SIDECAR_LOG_LENGTH = 64 * 1024;
+
+// A service container that runs alongside the grader (e.g. a database).
+type SidecarInfo struct {
+    // The hostname that the grader can reach this sidecar at.
+    Name string `json:"name"`
+    Image string `json:"image"`
+    Env []string `json:"env,omitempty"`
+    Command []string `json:"command,omitempty"`
+
+    // If present, the grader will not start until this check passes.
+    HealthCheck *SidecarHealthCheck `json:"health-check,omitempty"`
+}
+
+// A command that is run inside a sidecar until it succeeds (exits zero).
+type SidecarHealthCheck struct {
+    Command []string `json:"command"`
+    IntervalSecs int `json:"interval-secs,omitempty"`
+    TimeoutSecs int `json:"timeout-secs,omitempty"`
+}
+
+type runningSidecar struct {
+    info *SidecarInfo
+    id string
+    containerName string
+}
+
+func (this *SidecarInfo) Validate() error {
+    this.Name = strings.ToLower(strings.TrimSpace(this.Name));
+    if (!hostnamePattern.MatchString(this.Name) || strings.Contains(this.Name, ".")) {
+        return fmt.Errorf("Sidecar name '%s' is not a valid hostname (letters, numbers, and dashes).", this.Name);
+    }
+
+    if (this.Image == "") {
+        return fmt.Errorf("Sidecar '%s' is missing an image.", this.Name);
+    }
+
+    if (!IsAllowedImage(this.Image)) {
+        return fmt.Errorf("Sidecar image '%s' is not pinned to a digest, but pinned images are required (see the docker.pinned option).", this.Image);
+    }
+
+    if (this.Env == nil) {
+        this.Env = make([]string, 0);
+    }
+
+    if (this.Command == nil) {
+        this.Command = make([]string, 0);
+    }
+
+    if (this.HealthCheck != nil) {
+        err := this.HealthCheck.Validate();
+        if (err != nil) {
+            return fmt.Errorf("Sidecar '%s' has an invalid health check: '%w'.", this.Name, err);
+        }
+    }
+
+    return nil;
+}
+
+func (this *SidecarHealthCheck) Validate() error {
+    if (len(this.Command) == 0) {
+        return fmt.Errorf("Health check command cannot be empty.");
+    }
+
+    if (this.IntervalSecs < 0) {
+        return fmt.Errorf("Health check interval cannot be negative, found: %d.", this.IntervalSecs);
+    }
+
+    if (this.IntervalSecs == 0) {
+        this.IntervalSecs = DEFAULT_HEALTH_CHECK_INTERVAL_SECS;
+    }
+
+    if (this.TimeoutSecs < 0) {
+        return fmt.Errorf("Health check timeout cannot be negative, found: %d.", this.TimeoutSecs);
+    }
+
+    if (this.TimeoutSecs == 0) {
+        this.TimeoutSecs = DEFAULT_HEALTH_CHECK_TIMEOUT_SECS;
+    }
+
+    return nil;
+}
+
+// Create and start a sidecar on a grading network.
+// A non-nil sidecar may be returned with an error (if the container was created but failed to start),
+// it should still be removed.
+func startSidecar(ctx context.Context, docker *client.Client, host string, sidecar *SidecarInfo,
+        gradingName string, networkName string) (*runningSidecar, error) {
+    err := PullImage(host, sidecar.Image, false);
+    if (err != nil) {
+        return nil, fmt.Errorf("Failed to pull image for sidecar '%s': '%w'.", sidecar.Name, err);
+    }
+
+    containerName := cleanContainerName(fmt.Sprintf("%s-%s", gradingName, sidecar.Name));
+
+    var command []string = nil;
+    if (len(sidecar.Command) > 0) {
+        command = sidecar.Command;
+    }
+
+    containerInstance, err := docker.ContainerCreate(
+        ctx,
+        &container.Config{
+            Image: sidecar.Image,
+            Cmd: command,
+            Env: sidecar.Env,
+            Hostname: sidecar.Name,
+        },
+        &container.HostConfig{
+            // Sidecars are removed manually so that their logs are still available if they crash.
+            AutoRemove: false,
+            NetworkMode: container.NetworkMode(networkName),
+        },
+        &network.NetworkingConfig{
+            EndpointsConfig: map[string]*network.EndpointSettings{
+                networkName: &network.EndpointSettings{
+                    Aliases: []string{sidecar.Name},
+                },
+            },
+        },
+        nil,
+        containerName);
+    if (err != nil) {
+        return nil, fmt.Errorf("Failed to create sidecar '%s': '%w'.", sidecar.Name, err);
+    }
+
+    runningSidecar := &runningSidecar{
+        info: sidecar,
+        id: containerInstance.ID,
+        containerName: containerName,
+    };
+
+    err = docker.ContainerStart(ctx, containerInstance.ID, types.ContainerStartOptions{});
+    if (err != nil) {
+        return runningSidecar, fmt.Errorf("Failed to start sidecar '%s': '%w'.", sidecar.Name, err);
+    }
+
+    return runningSidecar, nil;
+}
+
+// Wait until the sidecar's health check passes.
+// Sidecars without a health check are considered healthy as long as they are running.
+func (this *runningSidecar) waitForHealthy(ctx context.Context, docker *client.Client) error {
+    healthCheck := this.info.HealthCheck;
+    if (healthCheck == nil) {
+        return this.checkRunning(ctx, docker);
+    }
+
+    interval := time.Duration(healthCheck.IntervalSecs) * time.Second;
+    deadline := time.Now().Add(time.Duration(healthCheck.TimeoutSecs) * time.Second);
+
+    var lastErr error = nil;
+    for {
+        err := this.checkRunning(ctx, docker);
+        if (err != nil) {
+            return err;
+        }
+
+        lastErr = this.runHealthCheck(ctx, docker);
+        if (lastErr == nil) {
+            return nil;
+        }
+
+        if (time.Now().Add(interval).After(deadline)) {
+            break;
+        }
+
+        time.Sleep(interval);
+    }
+
+    return fmt.Errorf("Sidecar '%s' did not become healthy within %d seconds (last check: '%v'). Sidecar logs:\n%s",
+            this.info.Name, healthCheck.TimeoutSecs, lastErr, this.getLogs(ctx, docker, nil));
+}
+
+func (this *runningSidecar) checkRunning(ctx context.Context, docker *client.Client) error {
+    info, err := docker.ContainerInspect(ctx, this.id);
+    if (err != nil) {
+        return fmt.Errorf("Failed to inspect sidecar '%s': '%w'.", this.info.Name, err);
+    }
+
+    if ((info.State == nil) || !info.State.Running) {
+        exitCode := -1;
+        if (info.State != nil) {
+            exitCode = info.State.ExitCode;
+        }
+
+        return fmt.Errorf("Sidecar '%s' stopped (exit code: %d). Sidecar logs:\n%s",
+                this.info.Name, exitCode, this.getLogs(ctx, docker, nil));
+    }
+
+    return nil;
+}
+
+// Run the health check command once.
+// Returns nil if the command exited zero.
+func (this *runningSidecar) runHealthCheck(ctx context.Context, docker *client.Client) error {
+    exec, err := docker.ContainerExecCreate(ctx, this.id, types.ExecConfig{
+        Cmd: this.info.HealthCheck.Command,
+        Detach: true,
+    });
+    if (err != nil) {
+        return fmt.Errorf("Failed to create health check: '%w'.", err);
+    }
+
+    err = docker.ContainerExecStart(ctx, exec.ID, types.ExecStartCheck{Detach: true});
+    if (err != nil) {
+        return fmt.Errorf("Failed to start health check: '%w'.", err);
+    }
+
+    // A single check should not run longer than the interval between checks.
+    checkDeadline := time.Now().Add(time.Duration(this.info.HealthCheck.IntervalSecs) * time.Second);
+
+    for {
+        result, err := docker.ContainerExecInspect(ctx, exec.ID);
+        if (err != nil) {
+            return fmt.Errorf("Failed to inspect health check: '%w'.", err);
+        }
+
+        if (!result.Running) {
+            if (result.ExitCode != 0) {
+                return fmt.Errorf("Health check exited with code %d.", result.ExitCode);
+            }
+
+            return nil;
+        }
+
+        if (time.Now().After(checkDeadline)) {
+            return fmt.Errorf("Health check is still running after %d seconds.", this.info.HealthCheck.IntervalSecs);
+        }
+
+        time.Sleep(100 * time.Millisecond);
+    }
+}
+
+// Get the sidecar's output (stdout and stderr combined).
+// Failures are logged and an empty string is returned.
+func (this *runningSidecar) getLogs(ctx context.Context, docker *client.Client, logId log.Loggable) string {
+    out, err := docker.ContainerLogs(ctx, this.id, types.ContainerLogsOptions{
+        ShowStdout: true,
+        ShowStderr: true,
+    });
+    if (err != nil) {
+        log.Warn("Failed to get sidecar logs.", err, logId,
+                log.NewAttr("sidecar", this.info.Name), log.NewAttr("container-id", this.id));
+        return "";
+    }
+    defer out.Close();
+
+    buffer := new(strings.Builder);
+    _, err = stdcopy.StdCopy(buffer, buffer, out);
+    if (err != nil) {
+        log.Warn("Failed to read sidecar logs.", err, logId,
+                log.NewAttr("sidecar", this.info.Name), log.NewAttr("container-id", this.id));
+    }
+
+    return truncateSidecarLog(buffer.String());
+}
+
+func (this *runningSidecar) remove(ctx context.Context, docker *client.Client, logId log.Loggable) {
+    err := docker.ContainerRemove(ctx, this.id, types.ContainerRemoveOptions{Force: true});
+    if (err != nil) {
+        log.Warn("Failed to remove sidecar.", err, logId,
+                log.NewAttr("sidecar", this.info.Name), log.NewAttr("container-id", this.id));
+    }
+}
+
+// Keep the end of the log (where errors usually are).
+func truncateSidecarLog(text string) string {
+    if (len(text) <= MAX_SIDECAR_LOG_LENGTH) {
+        return text;
+    }
+
+    return "<truncated>\n" + text[len(text) - MAX_SIDECAR_LOG_LENGTH:];
+}
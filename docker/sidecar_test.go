@@ -0,0 +1,75 @@
+package docker
+
+import (
+    "strings"
+    "testing"
+)
+
+func TestSidecarHealthCheckValidate(test *testing.T) {
+    testCases := []struct{healthCheck *SidecarHealthCheck; valid bool; intervalSecs int; timeoutSecs int}{
+        {&SidecarHealthCheck{Command: []string{"pg_isready"}}, true, DEFAULT_HEALTH_CHECK_INTERVAL_SECS, DEFAULT_HEALTH_CHECK_TIMEOUT_SECS},
+        {&SidecarHealthCheck{Command: []string{"redis-cli", "ping"}, IntervalSecs: 5, TimeoutSecs: 60}, true, 5, 60},
+
+        {&SidecarHealthCheck{}, false, 0, 0},
+        {&SidecarHealthCheck{Command: []string{}}, false, 0, 0},
+        {&SidecarHealthCheck{Command: []string{"true"}, IntervalSecs: -1}, false, 0, 0},
+        {&SidecarHealthCheck{Command: []string{"true"}, TimeoutSecs: -1}, false, 0, 0},
+    };
+
+    for i, testCase := range testCases {
+        sidecar := &SidecarInfo{Name: "db", Image: "postgres", HealthCheck: testCase.healthCheck};
+
+        err := sidecar.Validate();
+        if (testCase.valid && (err != nil)) {
+            test.Errorf("Case %d: Unexpected validation error: '%v'.", i, err);
+            continue;
+        }
+
+        if (!testCase.valid) {
+            if (err == nil) {
+                test.Errorf("Case %d: Did not get an expected validation error.", i);
+            }
+
+            continue;
+        }
+
+        if (testCase.intervalSecs != testCase.healthCheck.IntervalSecs) {
+            test.Errorf("Case %d: Unexpected interval. Expected: %d, Actual: %d.", i, testCase.intervalSecs, testCase.healthCheck.IntervalSecs);
+        }
+
+        if (testCase.timeoutSecs != testCase.healthCheck.TimeoutSecs) {
+            test.Errorf("Case %d: Unexpected timeout. Expected: %d, Actual: %d.", i, testCase.timeoutSecs, testCase.healthCheck.TimeoutSecs);
+        }
+    }
+}
+
+func TestTruncateSidecarLog(test *testing.T) {
+    short := "ready\n";
+    if (truncateSidecarLog(short) != short) {
+        test.Fatalf("Short log was changed: '%s'.", truncateSidecarLog(short));
+    }
+
+    long := strings.Repeat("a", MAX_SIDECAR_LOG_LENGTH) + "END";
+    truncated := truncateSidecarLog(long);
+
+    if (!strings.HasSuffix(truncated, "END")) {
+        test.Fatalf("Truncated log lost its end.");
+    }
+
+    if (len(truncated) >= (len(long) + len("<truncated>\n"))) {
+        test.Fatalf("Log was not truncated. Length: %d.", len(truncated));
+    }
+}
+
+// Nil networks (no network or no sidecars) have no logs.
+func TestCollectLogsNoSidecars(test *testing.T) {
+    var nilNetwork *gradingNetwork = nil;
+    if (nilNetwork.collectLogs(nil, nil, nil) != nil) {
+        test.Fatalf("Nil network returned logs.");
+    }
+
+    emptyNetwork := &gradingNetwork{sidecars: make([]*runningSidecar, 0)};
+    if (emptyNetwork.collectLogs(nil, nil, nil) != nil) {
+        test.Fatalf("Network without sidecars returned logs.");
+    }
+}
@@ -3,12 +3,20 @@ package docker
 import (
 	"context"
 	"fmt"
+	"os"
 
 	"github.com/docker/docker/client"
+
+	"github.com/edulinq/autograder/config"
 )
 
 func CanAccessDocker() bool {
-    _, docker, err := getDockerClient();
+    return CanAccessHost("");
+}
+
+// Check if the container engine at the given host (empty for the default docker host) can be accessed.
+func CanAccessHost(host string) bool {
+    _, docker, err := getDockerClient(host);
     if (docker != nil) {
         defer docker.Close();
     }
@@ -16,9 +24,28 @@ func CanAccessDocker() bool {
     return (err == nil);
 }
 
-func getDockerClient() (context.Context, *client.Client, error) {
+// Get the host for the podman service.
+// Podman exposes a docker-compatible API, so the standard docker client can talk to it.
+func GetPodmanHost() string {
+    host := config.PODMAN_HOST.Get();
+    if (host != "") {
+        return host;
+    }
+
+    return fmt.Sprintf("unix:///run/user/%d/podman/podman.sock", os.Getuid());
+}
+
+// Get a client for the container engine at the given host.
+// An empty host will use the default docker host (as specified by the environment).
+func getDockerClient(host string) (context.Context, *client.Client, error) {
 	ctx := context.Background()
-	docker, err := client.NewClientWithOpts(client.FromEnv, client.WithAPIVersionNegotiation())
+
+    options := []client.Opt{client.FromEnv, client.WithAPIVersionNegotiation()};
+    if (host != "") {
+        options = append(options, client.WithHost(host));
+    }
+
+	docker, err := client.NewClientWithOpts(options...)
 	if err != nil {
 		return ctx, nil, fmt.Errorf("Cannot create Docker client: '%w'.", err);
 	}
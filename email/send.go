@@ -3,12 +3,14 @@ package email
 import (
     "fmt"
     "net/smtp"
+    "sync"
 
     "github.com/edulinq/autograder/config"
 )
 
 // Messages that are stored (instead of sent) in testing mode.
 var testMessages []*Message = nil;
+var testMessagesLock sync.Mutex;
 
 func Send(to []string, subject string, body string, html bool) error {
     return SendMessage(&Message{
@@ -27,6 +29,9 @@ func SendMessage(message *Message) error {
 
     // In testing mode, just store the message.
     if (config.TESTING_MODE.Get()) {
+        testMessagesLock.Lock();
+        defer testMessagesLock.Unlock();
+
         testMessages = append(testMessages, message);
         return nil;
     }
@@ -35,9 +40,15 @@ func SendMessage(message *Message) error {
 }
 
 func GetTestMessages() []*Message {
+    testMessagesLock.Lock();
+    defer testMessagesLock.Unlock();
+
     return testMessages;
 }
 
 func ClearTestMessages() {
+    testMessagesLock.Lock();
+    defer testMessagesLock.Unlock();
+
     testMessages = nil;
 }
@@ -0,0 +1,64 @@
+package grader
+
+import (
+    "fmt"
+    "os/exec"
+    "strings"
+
+    "github.com/edulinq/autograder/common"
+    "github.com/edulinq/autograder/config"
+    "github.com/edulinq/autograder/model"
+)
+
+// Grade on the host inside of a bubblewrap (https://github.com/containers/bubblewrap) sandbox.
+// This works the same as the non-docker grader,
+// but the grader cannot see most of the host filesystem (only BUBBLEWRAP_RO_BINDS read-only),
+// cannot access the network, and can only write to the grading dirs (except input).
+func runBubblewrapGrader(assignment *model.Assignment, submissionPath string, options GradeOptions, fullSubmissionID string) (
+        *model.GradingInfo, map[string][]byte, string, string, error) {
+    return runLocalGrader(assignment, submissionPath, options, common.RUNNER_BUBBLEWRAP, wrapBubblewrapCommand);
+}
+
+func checkBubblewrap() error {
+    _, err := exec.LookPath(config.BUBBLEWRAP_PATH.Get());
+    if (err != nil) {
+        return fmt.Errorf("Could not find bubblewrap executable ('%s'): '%w'.", config.BUBBLEWRAP_PATH.Get(), err);
+    }
+
+    return nil;
+}
+
+func wrapBubblewrapCommand(cmd *exec.Cmd, tempDir string, inputDir string) (*exec.Cmd, error) {
+    args := []string{
+        "--unshare-all",
+        "--die-with-parent",
+        "--new-session",
+        "--dev", "/dev",
+        "--proc", "/proc",
+        "--tmpfs", "/tmp",
+    };
+
+    for _, path := range strings.Split(config.BUBBLEWRAP_RO_BINDS.Get(), ",") {
+        path = strings.TrimSpace(path);
+        if (path == "") {
+            continue;
+        }
+
+        args = append(args, "--ro-bind-try", path, path);
+    }
+
+    // Later mounts take precedence, so the grading dirs are mounted last.
+    args = append(args,
+        "--bind", tempDir, tempDir,
+        "--ro-bind", inputDir, inputDir,
+        "--chdir", cmd.Dir,
+        "--");
+
+    args = append(args, cmd.Args...);
+
+    wrappedCmd := exec.Command(config.BUBBLEWRAP_PATH.Get(), args...);
+    wrappedCmd.Dir = cmd.Dir;
+    wrappedCmd.Env = cmd.Env;
+
+    return wrappedCmd, nil;
+}
@@ -18,10 +18,22 @@ import (
 //  - output -- Passed in directory that will be mounted at DOCKER_OUTPUT_DIR.
 //  - work -- Should already be created inside the docker image, will only exist within the container.
 func runDockerGrader(assignment *model.Assignment, submissionPath string, options GradeOptions, fullSubmissionID string) (
-        *model.GradingInfo, map[string][]byte, string, string, error) {
-    tempDir, inputDir, outputDir, _, err := common.PrepTempGradingDir("docker");
-    if (err != nil) {
-        return nil, nil, "", "", err;
+        *model.GradingResult, error) {
+    imageInfo := assignment.GetImageInfo();
+
+    // Use a pre-created container if one is available.
+    pooledContainer := docker.TakePooledContainer(imageInfo);
+
+    var tempDir, inputDir, outputDir string;
+    var err error;
+
+    if (pooledContainer != nil) {
+        tempDir, inputDir, outputDir = pooledContainer.TempDir, pooledContainer.InputDir, pooledContainer.OutputDir;
+    } else {
+        tempDir, inputDir, outputDir, _, err = common.PrepTempGradingDir("docker");
+        if (err != nil) {
+            return &model.GradingResult{}, err;
+        }
     }
 
     if (!options.LeaveTempDir) {
@@ -33,30 +45,47 @@ func runDockerGrader(assignment *model.Assignment, submissionPath string, option
     // Copy over submission files to the temp input dir.
     err = util.CopyDirent(submissionPath, inputDir, true);
     if (err != nil) {
-        return nil, nil, "", "", fmt.Errorf("Failed to copy over submission/input contents: '%w'.", err);
+        return &model.GradingResult{}, fmt.Errorf("Failed to copy over submission/input contents: '%w'.", err);
     }
 
-    stdout, stderr, err := docker.RunContainer(assignment, assignment.ImageName(), inputDir, outputDir, fullSubmissionID);
+    stdoutEvents := newEventWriter(options.Listener, EVENT_STDOUT, assignment);
+    stderrEvents := newEventWriter(options.Listener, EVENT_STDERR, assignment);
+
+    result := &model.GradingResult{};
+    if (pooledContainer != nil) {
+        // Pooled containers never have a network (and therefore no sidecars).
+        result.Stdout, result.Stderr, err = docker.RunPooledContainer(assignment, imageInfo, pooledContainer,
+                stdoutEvents.asWriter(), stderrEvents.asWriter());
+    } else {
+        result.Stdout, result.Stderr, result.SidecarLogs, err = docker.RunContainer(assignment, imageInfo, inputDir, outputDir, fullSubmissionID,
+                stdoutEvents.asWriter(), stderrEvents.asWriter());
+    }
+
+    stdoutEvents.Close();
+    stderrEvents.Close();
+
     if (err != nil) {
-        return nil, nil, stdout, stderr, err;
+        return result, err;
     }
 
     resultPath := filepath.Join(outputDir, common.GRADER_OUTPUT_RESULT_FILENAME);
     if (!util.PathExists(resultPath)) {
-        return nil, nil, stdout, stderr,
-                fmt.Errorf("Cannot find output file ('%s') after the grading container (%s) was run.", resultPath, assignment.ImageName());
+        return result, fmt.Errorf("Cannot find output file ('%s') after the grading container (%s) was run.", resultPath, assignment.ImageName());
     }
 
     var gradingInfo model.GradingInfo;
     err = util.JSONFromFile(resultPath, &gradingInfo);
     if (err != nil) {
-        return nil, nil, stdout, stderr, err;
+        return result, err;
     }
 
     fileContents, err := util.GzipDirectoryToBytes(outputDir);
     if (err != nil) {
-        return nil, nil, stdout, stderr, fmt.Errorf("Failed to copy grading output '%s': '%w'.", outputDir, err);
+        return result, fmt.Errorf("Failed to copy grading output '%s': '%w'.", outputDir, err);
     }
 
-    return &gradingInfo, fileContents, stdout, stderr, nil;
+    result.Info = &gradingInfo;
+    result.OutputFilesGZip = fileContents;
+
+    return result, nil;
 }
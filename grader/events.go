@@ -0,0 +1,157 @@
+package grader
+
+// Live events that are sent out while a submission is being graded.
+
+import (
+    "bytes"
+    "io"
+    "strings"
+    "sync"
+
+    "github.com/edulinq/autograder/common"
+    "github.com/edulinq/autograder/log"
+    "github.com/edulinq/autograder/model"
+    "github.com/edulinq/autograder/util"
+)
+
+const (
+    EVENT_STAGE = "stage"
+    EVENT_STDOUT = "stdout"
+    EVENT_STDERR = "stderr"
+    EVENT_QUESTION = "question"
+)
+
+const (
+    STAGE_PREP = "prep"
+    STAGE_PRE_CHECK = "pre-check"
+    STAGE_GRADING = "grading"
+    STAGE_DONE = "done"
+)
+
+type GradingEvent struct {
+    Type string `json:"type"`
+    Stage string `json:"stage,omitempty"`
+    Text string `json:"text,omitempty"`
+    Question *model.GradedQuestion `json:"question,omitempty"`
+}
+
+// A function that gets called for every grading event.
+// Calls will never be concurrent.
+type GradingListener func(event *GradingEvent);
+
+// Make a listener safe to call from multiple goroutines.
+// A nil listener will stay nil.
+func syncListener(listener GradingListener) GradingListener {
+    if (listener == nil) {
+        return nil;
+    }
+
+    var lock sync.Mutex;
+
+    return func(event *GradingEvent) {
+        lock.Lock();
+        defer lock.Unlock();
+
+        listener(event);
+    };
+}
+
+func sendStageEvent(listener GradingListener, stage string) {
+    if (listener == nil) {
+        return;
+    }
+
+    listener(&GradingEvent{Type: EVENT_STAGE, Stage: stage});
+}
+
+// A writer that splits output into lines and sends each line to a listener as an event.
+// Stdout lines with the progress prefix (common.GRADER_PROGRESS_PREFIX) are sent as question events.
+type eventWriter struct {
+    listener GradingListener
+    eventType string
+    assignment *model.Assignment
+    buffer bytes.Buffer
+}
+
+// Get a writer for the given stream (EVENT_STDOUT or EVENT_STDERR).
+// Returns nil if there is no listener.
+// Callers should call Close() when output is complete to flush any partial line.
+func newEventWriter(listener GradingListener, eventType string, assignment *model.Assignment) *eventWriter {
+    if (listener == nil) {
+        return nil;
+    }
+
+    return &eventWriter{
+        listener: listener,
+        eventType: eventType,
+        assignment: assignment,
+    };
+}
+
+func (this *eventWriter) Write(data []byte) (int, error) {
+    this.buffer.Write(data);
+
+    for {
+        index := bytes.IndexByte(this.buffer.Bytes(), '\n');
+        if (index < 0) {
+            break;
+        }
+
+        line := string(this.buffer.Next(index + 1));
+        this.sendLine(line);
+    }
+
+    return len(data), nil;
+}
+
+// Safe to call on a nil writer.
+func (this *eventWriter) Close() error {
+    if (this == nil) {
+        return nil;
+    }
+
+    if (this.buffer.Len() > 0) {
+        this.sendLine(this.buffer.String());
+        this.buffer.Reset();
+    }
+
+    return nil;
+}
+
+func (this *eventWriter) sendLine(line string) {
+    if ((this.eventType == EVENT_STDOUT) && strings.HasPrefix(line, common.GRADER_PROGRESS_PREFIX)) {
+        question := this.parseProgress(line);
+        if (question != nil) {
+            this.listener(&GradingEvent{Type: EVENT_QUESTION, Question: question});
+            return;
+        }
+    }
+
+    this.listener(&GradingEvent{Type: this.eventType, Text: line});
+}
+
+func (this *eventWriter) parseProgress(line string) *model.GradedQuestion {
+    text := strings.TrimSpace(strings.TrimPrefix(line, common.GRADER_PROGRESS_PREFIX));
+
+    var question model.GradedQuestion;
+    err := util.JSONFromString(text, &question);
+    if (err != nil) {
+        log.Debug("Failed to parse grader progress line.", err, this.assignment, log.NewAttr("line", line));
+        return nil;
+    }
+
+    if (this.assignment != nil) {
+        this.assignment.MarkHiddenQuestions(&model.GradingInfo{Questions: []*model.GradedQuestion{&question}});
+    }
+
+    return &question;
+}
+
+// Get this writer as an io.Writer, avoiding non-nil interfaces that hold a nil pointer.
+func (this *eventWriter) asWriter() io.Writer {
+    if (this == nil) {
+        return nil;
+    }
+
+    return this;
+}
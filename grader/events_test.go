@@ -0,0 +1,94 @@
+package grader
+
+import (
+    "reflect"
+    "testing"
+
+    "github.com/edulinq/autograder/db"
+    "github.com/edulinq/autograder/model"
+    "github.com/edulinq/autograder/util"
+)
+
+func TestEventWriter(test *testing.T) {
+    assignment := db.MustGetTestAssignment();
+    oldHiddenQuestions := assignment.HiddenQuestions;
+    assignment.HiddenQuestions = []string{"Q2"};
+    defer func() {
+        assignment.HiddenQuestions = oldHiddenQuestions;
+    }();
+
+    testCases := []struct{eventType string; writes []string; expected []*GradingEvent}{
+        {EVENT_STDOUT, []string{}, []*GradingEvent{}},
+        {
+            EVENT_STDOUT,
+            []string{"a\nb", "c\n", "d"},
+            []*GradingEvent{
+                &GradingEvent{Type: EVENT_STDOUT, Text: "a\n"},
+                &GradingEvent{Type: EVENT_STDOUT, Text: "bc\n"},
+                &GradingEvent{Type: EVENT_STDOUT, Text: "d"},
+            },
+        },
+        {
+            EVENT_STDOUT,
+            []string{`AUTOGRADER-PROGRESS: {"name": "Q1", "max_points": 2, "score": 1}` + "\n"},
+            []*GradingEvent{
+                &GradingEvent{Type: EVENT_QUESTION, Question: &model.GradedQuestion{Name: "Q1", MaxPoints: 2, Score: 1}},
+            },
+        },
+        {
+            EVENT_STDOUT,
+            []string{`AUTOGRADER-PROGRESS: {"name": "Q2", `, `"max_points": 2, "score": 1}` + "\n"},
+            []*GradingEvent{
+                &GradingEvent{Type: EVENT_QUESTION, Question: &model.GradedQuestion{Name: "Q2", MaxPoints: 2, Score: 1, Hidden: true}},
+            },
+        },
+        // Bad progress lines are passed through as normal output.
+        {
+            EVENT_STDOUT,
+            []string{"AUTOGRADER-PROGRESS: zzz\n"},
+            []*GradingEvent{
+                &GradingEvent{Type: EVENT_STDOUT, Text: "AUTOGRADER-PROGRESS: zzz\n"},
+            },
+        },
+        // Progress is only parsed on stdout.
+        {
+            EVENT_STDERR,
+            []string{`AUTOGRADER-PROGRESS: {"name": "Q1"}` + "\n"},
+            []*GradingEvent{
+                &GradingEvent{Type: EVENT_STDERR, Text: `AUTOGRADER-PROGRESS: {"name": "Q1"}` + "\n"},
+            },
+        },
+    };
+
+    for i, testCase := range testCases {
+        events := make([]*GradingEvent, 0);
+        listener := func(event *GradingEvent) {
+            events = append(events, event);
+        };
+
+        writer := newEventWriter(listener, testCase.eventType, assignment);
+        for _, text := range testCase.writes {
+            writer.Write([]byte(text));
+        }
+        writer.Close();
+
+        if (!reflect.DeepEqual(testCase.expected, events)) {
+            test.Errorf("Case %d: Unexpected events. Expected: '%s', Actual: '%s'.",
+                    i, util.MustToJSON(testCase.expected), util.MustToJSON(events));
+        }
+    }
+}
+
+func TestEventWriterNil(test *testing.T) {
+    writer := newEventWriter(nil, EVENT_STDOUT, nil);
+    if (writer != nil) {
+        test.Fatalf("Got a writer for a nil listener.");
+    }
+
+    if (writer.asWriter() != nil) {
+        test.Fatalf("Got a non-nil io.Writer for a nil writer.");
+    }
+
+    // Should not panic.
+    writer.Close();
+}
@@ -7,7 +7,7 @@ import (
     "github.com/edulinq/autograder/common"
     "github.com/edulinq/autograder/config"
     "github.com/edulinq/autograder/db"
-    "github.com/edulinq/autograder/docker"
+    "github.com/edulinq/autograder/log"
     "github.com/edulinq/autograder/model"
     "github.com/edulinq/autograder/util"
 )
@@ -17,6 +17,9 @@ var submissionLocks sync.Map;
 type GradeOptions struct {
     NoDocker bool
     LeaveTempDir bool
+
+    // If set, this will be called with live events (output, progress) while grading.
+    Listener GradingListener
 }
 
 func GetDefaultGradeOptions() GradeOptions {
@@ -55,46 +58,90 @@ func Grade(assignment *model.Assignment, submissionPath string, user string, mes
     lock.Lock();
     defer lock.Unlock()
 
-    submissionID, inputFileContents, err := prepForGrading(assignment, submissionPath, user);
+    // Output may come in from multiple goroutines.
+    options.Listener = syncListener(options.Listener);
+
+    runner, err := GetRunner(assignment, options);
     if (err != nil) {
-        return nil, nil, fmt.Errorf("Failed to prep for grading: '%w'.", err);
+        return nil, nil, err;
     }
 
-    var gradingResult model.GradingResult;
-    gradingResult.InputFilesGZip = inputFileContents;
+    sendStageEvent(options.Listener, STAGE_PREP);
+
+    submissionID, inputFileContents, err := prepForGrading(runner, assignment, submissionPath, user);
+    if (err != nil) {
+        return nil, nil, fmt.Errorf("Failed to prep for grading: '%w'.", err);
+    }
 
     fullSubmissionID := common.CreateFullSubmissionID(assignment.GetCourse().GetID(), assignment.GetID(), user, submissionID);
 
-    var gradingInfo *model.GradingInfo;
-    var outputFileContents map[string][]byte;
-    var stdout string;
-    var stderr string;
+    graderVersion := getGraderVersion(runner, assignment);
 
-    startTimestamp := common.NowTimestamp();
+    inputHash, err := util.Sha256HexFromDirectory(submissionPath);
+    if (err != nil) {
+        return nil, nil, fmt.Errorf("Failed to hash submission input: '%w'.", err);
+    }
+
+    submissionHash := model.ComputeSubmissionHash(inputHash, graderVersion);
+
+    if (assignment.ReuseResults) {
+        startTimestamp := common.NowTimestamp();
+
+        gradingResult := getReusableResult(assignment, user, submissionHash);
+        if (gradingResult != nil) {
+            log.Debug("Reusing the result of an identical submission.", assignment, log.NewUserAttr(user),
+                    log.NewAttr("reused-from", gradingResult.Info.ReusedFrom));
+
+            sendStageEvent(options.Listener, STAGE_DONE);
+
+            gradingResult.InputFilesGZip = inputFileContents;
+            gradingResult.Info.GraderVersion = graderVersion;
+            gradingResult.Info.InputHash = inputHash;
+            gradingResult.Info.SubmissionHash = submissionHash;
+
+            // The result is treated as if the submission was just graded (e.g. for late policies).
+            gradingResult.Info.GradingStartTime = startTimestamp;
+            gradingResult.Info.GradingEndTime = common.NowTimestamp();
+
+            return finishGrading(assignment, gradingResult, fullSubmissionID, submissionID, user, message);
+        }
+    }
 
-    if (options.NoDocker) {
-        gradingInfo, outputFileContents, stdout, stderr, err = runNoDockerGrader(assignment, submissionPath, options, fullSubmissionID);
-    } else {
-        gradingInfo, outputFileContents, stdout, stderr, err = runDockerGrader(assignment, submissionPath, options, fullSubmissionID);
+    // A failed pre-check is not saved, so it will not count as an attempt.
+    reject, err := runPreCheck(runner, assignment, submissionPath, options, fullSubmissionID);
+    if (err != nil) {
+        return nil, nil, fmt.Errorf("Failed to run pre-check: '%w'.", err);
     }
 
+    if (reject != nil) {
+        return nil, reject, nil;
+    }
+
+    sendStageEvent(options.Listener, STAGE_GRADING);
+
+    startTimestamp := common.NowTimestamp();
+
+    gradingResult, err := runner.Run(assignment, submissionPath, options, fullSubmissionID);
+
     endTimestamp := common.NowTimestamp();
 
-    // Copy over stdout and stderr even if an error occured.
-    gradingResult.Stdout = stdout;
-    gradingResult.Stderr = stderr;
+    sendStageEvent(options.Listener, STAGE_DONE);
+
+    if (gradingResult == nil) {
+        gradingResult = &model.GradingResult{};
+    }
+
+    // Keep any output (stdout, stderr, sidecar logs) even if an error occured.
+    gradingResult.InputFilesGZip = inputFileContents;
 
     if (err != nil) {
-        return &gradingResult, nil, err;
+        return gradingResult, nil, err;
     }
 
-    // Set all the autograder fields in the grading info.
-    gradingInfo.ID = fullSubmissionID;
-    gradingInfo.ShortID = submissionID;
-    gradingInfo.CourseID = assignment.GetCourse().GetID();
-    gradingInfo.AssignmentID = assignment.GetID();
-    gradingInfo.User = user;
-    gradingInfo.Message = message;
+    gradingInfo := gradingResult.Info;
+    gradingInfo.GraderVersion = graderVersion;
+    gradingInfo.InputHash = inputHash;
+    gradingInfo.SubmissionHash = submissionHash;
 
     if (gradingInfo.GradingStartTime.IsZero()) {
         gradingInfo.GradingStartTime = startTimestamp;
@@ -104,26 +151,108 @@ func Grade(assignment *model.Assignment, submissionPath string, user string, mes
         gradingInfo.GradingEndTime = endTimestamp;
     }
 
-    gradingInfo.ComputePoints();
+    return finishGrading(assignment, gradingResult, fullSubmissionID, submissionID, user, message);
+}
+
+// Set all the autograder fields in the grading info, compute the final points, and save the result.
+func finishGrading(assignment *model.Assignment, gradingResult *model.GradingResult,
+        fullSubmissionID string, submissionID string, user string, message string) (*model.GradingResult, RejectReason, error) {
+    gradingInfo := gradingResult.Info;
+
+    gradingInfo.ID = fullSubmissionID;
+    gradingInfo.ShortID = submissionID;
+    gradingInfo.CourseID = assignment.GetCourse().GetID();
+    gradingInfo.AssignmentID = assignment.GetID();
+    gradingInfo.User = user;
+    gradingInfo.Message = message;
 
-    gradingResult.Info = gradingInfo;
-    gradingResult.OutputFilesGZip = outputFileContents;
+    assignment.MarkHiddenQuestions(gradingInfo);
+    gradingInfo.ComputePoints();
 
     if (!config.NO_STORE.Get()) {
-        err = db.SaveSubmission(assignment, &gradingResult);
+        err := db.SaveSubmission(assignment, gradingResult);
         if (err != nil) {
-            return &gradingResult, nil, fmt.Errorf("Failed to save grading result: '%w'.", err);
+            return gradingResult, nil, fmt.Errorf("Failed to save grading result: '%w'.", err);
         }
     }
 
-    return &gradingResult, nil, nil;
+    return gradingResult, nil, nil;
+}
+
+// Get a copy of the most recent result from the user with the same submission hash (or nil).
+// The copy has its points and question times cleared (so they can be computed again) and ReusedFrom set.
+// Failures are logged and the submission will just be graded.
+func getReusableResult(assignment *model.Assignment, user string, submissionHash string) *model.GradingResult {
+    previous, err := db.GetSubmissionByHash(assignment, user, submissionHash);
+    if (err != nil) {
+        log.Warn("Failed to look for an identical submission.", err, assignment, log.NewUserAttr(user));
+        return nil;
+    }
+
+    if (previous == nil) {
+        return nil;
+    }
+
+    // Deep copy the grading info.
+    var gradingInfo model.GradingInfo;
+    err = util.JSONFromString(util.MustToJSON(previous.Info), &gradingInfo);
+    if (err != nil) {
+        log.Warn("Failed to copy the result of an identical submission.", err, assignment, log.NewUserAttr(user));
+        return nil;
+    }
+
+    gradingInfo.ReusedFrom = previous.Info.ID;
+    if (previous.Info.ReusedFrom != "") {
+        gradingInfo.ReusedFrom = previous.Info.ReusedFrom;
+    }
+
+    gradingInfo.MaxPoints = 0.0;
+    gradingInfo.Score = 0.0;
+    gradingInfo.HiddenMaxPoints = 0.0;
+    gradingInfo.HiddenScore = 0.0;
+
+    for _, question := range gradingInfo.Questions {
+        question.GradingStartTime = common.Timestamp("");
+        question.GradingEndTime = common.Timestamp("");
+    }
+
+    return &model.GradingResult{
+        Info: &gradingInfo,
+        OutputFilesGZip: previous.OutputFilesGZip,
+        SidecarLogs: previous.SidecarLogs,
+    };
+}
+
+// Get the version of the grader that was just run.
+// Parts of the version that cannot be determined are left empty.
+func getGraderVersion(runner Runner, assignment *model.Assignment) *model.GraderVersion {
+    version := &model.GraderVersion{};
+    var err error;
+
+    version.ImageID, err = runner.GetImageID(assignment);
+    if (err != nil) {
+        log.Warn("Failed to get grader image ID.", err, assignment);
+    }
+
+    // Not all course sources are git repos.
+    version.CommitHash, err = util.GitGetCommitHash(assignment.GetCourse().GetBaseSourceDir());
+    if (err != nil) {
+        log.Trace("Failed to get course source commit hash.", err, assignment);
+    }
+
+    version.ConfigHash, err = assignment.GetConfigHash();
+    if (err != nil) {
+        log.Warn("Failed to get assignment config hash.", err, assignment);
+    }
+
+    return version;
 }
 
-func prepForGrading(assignment *model.Assignment, submissionPath string, user string) (string, map[string][]byte, error) {
-    // Ensure the assignment docker image is built.
-    err := docker.BuildImageFromSourceQuick(assignment);
+func prepForGrading(runner Runner, assignment *model.Assignment, submissionPath string, user string) (string, map[string][]byte, error) {
+    // Ensure the runner is ready (e.g. the assignment docker image is built).
+    err := runner.Prep(assignment);
     if (err != nil) {
-        return "", nil, fmt.Errorf("Failed to build assignment assignment '%s' docker image: '%w'.", assignment.FullID(), err);
+        return "", nil, err;
     }
 
     submissionID, err := db.GetNextSubmissionID(assignment, user);
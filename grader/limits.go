@@ -0,0 +1,230 @@
+package grader
+
+// Resource limits for graders that run directly on the host (see runLocalGrader()).
+
+import (
+    "bytes"
+    "errors"
+    "fmt"
+    "io"
+    "os/exec"
+    "strings"
+    "time"
+
+    "github.com/edulinq/autograder/config"
+)
+
+const WAIT_DELAY = 5 * time.Second;
+
+const (
+    LIMIT_TIMEOUT = "timeout"
+    LIMIT_CPU = "cpu-time"
+    LIMIT_FILE_SIZE = "file-size"
+    LIMIT_MEMORY = "memory"
+    LIMIT_PROCS = "processes"
+)
+
+// Memory and process limits are usually not signaled, instead allocations (or forks) just fail
+// (a SIGKILL from the OOM killer is checked for in checkLimitSignal()).
+// So as a fallback, these are guessed by looking for common failure messages in the grader's stderr (case-insensitive).
+// The grader (and therefore student code) controls its own stderr, so this is only a best-effort guess:
+// a violation can be faked by printing a marker or hidden by not printing one.
+// Errors found this way are marked as guessed and should only be used to give feedback, never to make decisions.
+// Failures that do not print one of these (e.g. a segfault after an unchecked allocation) cannot be told apart from other crashes.
+var memoryLimitMarkers []string = []string{
+    "cannot allocate",
+    "out of memory",
+    "memoryerror",
+    "std::bad_alloc",
+    "outofmemoryerror",
+};
+
+var procsLimitMarkers []string = []string{
+    "fork: resource temporarily unavailable",
+    "fork: retry: resource temporarily unavailable",
+    "can't start new thread",
+    "unable to create native thread",
+};
+
+// An error returned when a grader was stopped for exceeding a resource limit.
+type ResourceLimitError struct {
+    Limit string `json:"limit"`
+    Value int `json:"value"`
+    // The violation was guessed from the grader's output (see memoryLimitMarkers) and cannot be trusted.
+    Guessed bool `json:"guessed,omitempty"`
+}
+
+func (this *ResourceLimitError) Error() string {
+    message := this.limitMessage();
+    if (this.Guessed) {
+        message = fmt.Sprintf("%s (Guessed from the grader's output.)", message);
+    }
+
+    return message;
+}
+
+func (this *ResourceLimitError) limitMessage() string {
+    switch (this.Limit) {
+        case LIMIT_TIMEOUT:
+            return fmt.Sprintf("Grader exceeded the time limit (%d seconds).", this.Value);
+        case LIMIT_CPU:
+            return fmt.Sprintf("Grader exceeded the CPU time limit (%d seconds).", this.Value);
+        case LIMIT_FILE_SIZE:
+            return fmt.Sprintf("Grader exceeded the file size limit (%d MB).", this.Value);
+        case LIMIT_MEMORY:
+            return fmt.Sprintf("Grader exceeded the memory limit (%d MB).", this.Value);
+        case LIMIT_PROCS:
+            return fmt.Sprintf("Grader exceeded the process limit (%d processes).", this.Value);
+        default:
+            return fmt.Sprintf("Grader exceeded a resource limit ('%s': %d).", this.Limit, this.Value);
+    }
+}
+
+// Wrap a command so that it runs with the configured rlimits,
+// in its own process group, and (if possible) as the configured grader user.
+// The grader user will be given ownership of the temp dir.
+// Process groups, grader users, and limit signals are only supported on unix (see limits_unix.go).
+func applyResourceLimits(cmd *exec.Cmd, tempDir string) (*exec.Cmd, error) {
+    limits := make([]string, 0, 5);
+
+    // Only the soft limit is hit for CPU time (giving the process a SIGXCPU),
+    // the hard limit is just a backstop.
+    if (config.GRADER_LIMIT_CPU_SECS.Get() > 0) {
+        // The soft limit must be lowered first (the hard limit cannot go below it).
+        limits = append(limits, fmt.Sprintf("ulimit -S -t %d", config.GRADER_LIMIT_CPU_SECS.Get()));
+        limits = append(limits, fmt.Sprintf("ulimit -H -t %d", config.GRADER_LIMIT_CPU_SECS.Get() + 1));
+    }
+
+    // Bash uses KB for memory and file sizes.
+    if (config.GRADER_LIMIT_MEMORY_MB.Get() > 0) {
+        limits = append(limits, fmt.Sprintf("ulimit -v %d", config.GRADER_LIMIT_MEMORY_MB.Get() * 1024));
+    }
+
+    if (config.GRADER_LIMIT_FILE_SIZE_MB.Get() > 0) {
+        limits = append(limits, fmt.Sprintf("ulimit -f %d", config.GRADER_LIMIT_FILE_SIZE_MB.Get() * 1024));
+    }
+
+    if (config.GRADER_LIMIT_PROCS.Get() > 0) {
+        limits = append(limits, fmt.Sprintf("ulimit -u %d", config.GRADER_LIMIT_PROCS.Get()));
+    }
+
+    if (len(limits) > 0) {
+        // Use exec so the grader keeps the same PID (and we see any signals it gets).
+        script := fmt.Sprintf("%s && exec \"$@\"", strings.Join(limits, " && "));
+
+        args := []string{"-c", script, "autograder-limits", cmd.Path};
+        args = append(args, cmd.Args[1:]...);
+
+        limitedCmd := exec.Command("bash", args...);
+        limitedCmd.Dir = cmd.Dir;
+        limitedCmd.Env = cmd.Env;
+
+        cmd = limitedCmd;
+    }
+
+    err := setProcessAttributes(cmd, tempDir);
+    if (err != nil) {
+        return nil, err;
+    }
+
+    return cmd, nil;
+}
+
+// Run a command (that has been through applyResourceLimits()) with a timeout (non-positive for no timeout).
+// When the command finishes (or times out), the entire process group will be killed.
+// If the command was stopped for exceeding a limit, a *ResourceLimitError will be returned.
+// If the stream writers are non-nil, then output will also be written to them as it is produced.
+func runLimitedCMD(cmd *exec.Cmd, timeoutSecs int, stdoutStream io.Writer, stderrStream io.Writer) (string, string, error) {
+    var outBuffer bytes.Buffer;
+    var errBuffer bytes.Buffer;
+
+    cmd.Stdout = &outBuffer;
+    if (stdoutStream != nil) {
+        cmd.Stdout = io.MultiWriter(&outBuffer, stdoutStream);
+    }
+
+    cmd.Stderr = &errBuffer;
+    if (stderrStream != nil) {
+        cmd.Stderr = io.MultiWriter(&errBuffer, stderrStream);
+    }
+
+    // Don't wait forever on output pipes held open by escaped processes.
+    cmd.WaitDelay = WAIT_DELAY;
+
+    err := cmd.Start();
+    if (err != nil) {
+        return "", "", err;
+    }
+
+    done := make(chan error, 1);
+    go func() {
+        done <- cmd.Wait();
+    }();
+
+    var timeout <-chan time.Time = nil;
+    if (timeoutSecs > 0) {
+        timeout = time.After(time.Duration(timeoutSecs) * time.Second);
+    }
+
+    timedOut := false;
+
+    select {
+        case err = <-done:
+            // Command finished.
+        case <-timeout:
+            timedOut = true;
+            killProcessGroup(cmd);
+            err = <-done;
+    }
+
+    // Clean up anything the grader may have left behind.
+    killProcessGroup(cmd);
+
+    stdout := outBuffer.String();
+    stderr := errBuffer.String();
+
+    if (timedOut) {
+        return stdout, stderr, &ResourceLimitError{Limit: LIMIT_TIMEOUT, Value: timeoutSecs};
+    }
+
+    limitErr := checkLimitSignal(err);
+    if (limitErr == nil) {
+        limitErr = checkLimitOutput(err, stderr);
+    }
+
+    if (limitErr != nil) {
+        return stdout, stderr, errors.Join(limitErr, err);
+    }
+
+    return stdout, stderr, err;
+}
+
+// Guess if a failed command's stderr indicates that a memory or process limit was hit (see memoryLimitMarkers).
+// Any returned error is marked as guessed.
+func checkLimitOutput(err error, stderr string) *ResourceLimitError {
+    if (err == nil) {
+        return nil;
+    }
+
+    stderr = strings.ToLower(stderr);
+
+    if ((config.GRADER_LIMIT_MEMORY_MB.Get() > 0) && containsAny(stderr, memoryLimitMarkers)) {
+        return &ResourceLimitError{Limit: LIMIT_MEMORY, Value: config.GRADER_LIMIT_MEMORY_MB.Get(), Guessed: true};
+    }
+
+    if ((config.GRADER_LIMIT_PROCS.Get() > 0) && containsAny(stderr, procsLimitMarkers)) {
+        return &ResourceLimitError{Limit: LIMIT_PROCS, Value: config.GRADER_LIMIT_PROCS.Get(), Guessed: true};
+    }
+
+    return nil;
+}
+
+func containsAny(text string, substrings []string) bool {
+    for _, substring := range substrings {
+        if (strings.Contains(text, substring)) {
+            return true;
+        }
+    }
+
+    return false;
+}
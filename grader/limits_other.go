@@ -0,0 +1,39 @@
+//go:build !unix
+
+package grader
+
+import (
+    "errors"
+    "fmt"
+    "os"
+    "os/exec"
+
+    "github.com/edulinq/autograder/config"
+    "github.com/edulinq/autograder/log"
+)
+
+// Process groups and switching users are not supported on this platform.
+func setProcessAttributes(cmd *exec.Cmd, tempDir string) error {
+    if (config.GRADER_USER.Get() != "") {
+        return fmt.Errorf("A grader user is configured, but switching users is not supported on this platform.");
+    }
+
+    return nil;
+}
+
+// Without process groups, only the grader process itself can be killed.
+func killProcessGroup(cmd *exec.Cmd) {
+    if (cmd.Process == nil) {
+        return;
+    }
+
+    err := cmd.Process.Kill();
+    if ((err != nil) && !errors.Is(err, os.ErrProcessDone)) {
+        log.Debug("Failed to kill grader process.", err, log.NewAttr("pid", cmd.Process.Pid));
+    }
+}
+
+// Limits are not signaled on this platform.
+func checkLimitSignal(err error) *ResourceLimitError {
+    return nil;
+}
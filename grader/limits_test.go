@@ -0,0 +1,171 @@
+package grader
+
+import (
+    "errors"
+    "os/exec"
+    "path/filepath"
+    "testing"
+    "time"
+
+    "github.com/edulinq/autograder/config"
+    "github.com/edulinq/autograder/util"
+)
+
+func TestRunLimitedCMDBase(test *testing.T) {
+    stdout, _, err := runTestLimitedCMD(test, "echo 'foo'");
+    if (err != nil) {
+        test.Fatalf("Failed to run command: '%v'.", err);
+    }
+
+    if (stdout != "foo\n") {
+        test.Fatalf("Unexpected stdout. Expected: 'foo', Actual: '%s'.", stdout);
+    }
+}
+
+func TestRunLimitedCMDTimeout(test *testing.T) {
+    oldValue := config.GRADER_LIMIT_TIMEOUT_SECS.Get();
+    config.GRADER_LIMIT_TIMEOUT_SECS.Set(1);
+    defer config.GRADER_LIMIT_TIMEOUT_SECS.Set(oldValue);
+
+    // The background sleep is also in the process group and should get killed.
+    startTime := time.Now();
+    _, _, err := runTestLimitedCMD(test, "sleep 30 & sleep 30");
+    duration := time.Since(startTime);
+
+    checkLimitError(test, err, LIMIT_TIMEOUT);
+
+    if (duration > (10 * time.Second)) {
+        test.Fatalf("Command was not killed in time, took %s.", duration);
+    }
+}
+
+func TestRunLimitedCMDCPU(test *testing.T) {
+    oldValue := config.GRADER_LIMIT_CPU_SECS.Get();
+    config.GRADER_LIMIT_CPU_SECS.Set(1);
+    defer config.GRADER_LIMIT_CPU_SECS.Set(oldValue);
+
+    _, _, err := runTestLimitedCMD(test, "while true ; do : ; done");
+    checkLimitError(test, err, LIMIT_CPU);
+}
+
+func TestRunLimitedCMDFileSize(test *testing.T) {
+    oldValue := config.GRADER_LIMIT_FILE_SIZE_MB.Get();
+    config.GRADER_LIMIT_FILE_SIZE_MB.Set(1);
+    defer config.GRADER_LIMIT_FILE_SIZE_MB.Set(oldValue);
+
+    tempDir, err := util.MkDirTemp("autograder-test-limits-");
+    if (err != nil) {
+        test.Fatalf("Failed to make temp dir: '%v'.", err);
+    }
+    defer util.RemoveDirent(tempDir);
+
+    // Exec so that the shell is the process that gets the signal.
+    path := filepath.Join(tempDir, "out.txt");
+    _, _, err = runTestLimitedCMD(test, "exec head -c 2000000 /dev/zero > '" + path + "'");
+    checkLimitError(test, err, LIMIT_FILE_SIZE);
+}
+
+func TestRunLimitedCMDMemory(test *testing.T) {
+    oldValue := config.GRADER_LIMIT_MEMORY_MB.Get();
+    config.GRADER_LIMIT_MEMORY_MB.Set(64);
+    defer config.GRADER_LIMIT_MEMORY_MB.Set(oldValue);
+
+    // Bash will fail to allocate space for the output.
+    _, _, err := runTestLimitedCMD(test, "x=$(head -c 100000000 /dev/zero | tr '\\0' 'a')");
+    checkLimitError(test, err, LIMIT_MEMORY);
+}
+
+func TestRunLimitedCMDMemoryKilled(test *testing.T) {
+    oldValue := config.GRADER_LIMIT_MEMORY_MB.Get();
+    config.GRADER_LIMIT_MEMORY_MB.Set(64);
+    defer config.GRADER_LIMIT_MEMORY_MB.Set(oldValue);
+
+    // Look like the OOM killer.
+    _, _, err := runTestLimitedCMD(test, "kill -9 $$");
+    limitErr := checkLimitError(test, err, LIMIT_MEMORY);
+
+    if (limitErr.Guessed) {
+        test.Fatalf("A memory limit detected from the wait status should not be guessed.");
+    }
+}
+
+func TestRunLimitedCMDMemoryFakeOutput(test *testing.T) {
+    oldValue := config.GRADER_LIMIT_MEMORY_MB.Get();
+    config.GRADER_LIMIT_MEMORY_MB.Set(64);
+    defer config.GRADER_LIMIT_MEMORY_MB.Set(oldValue);
+
+    // A grader can claim a violation through its output, so it must be marked as guessed.
+    _, _, err := runTestLimitedCMD(test, "echo 'MemoryError' >&2 ; exit 1");
+    limitErr := checkLimitError(test, err, LIMIT_MEMORY);
+
+    if (!limitErr.Guessed) {
+        test.Fatalf("A memory limit detected from stderr should be guessed.");
+    }
+}
+
+func TestCheckLimitOutput(test *testing.T) {
+    oldMemory := config.GRADER_LIMIT_MEMORY_MB.Get();
+    config.GRADER_LIMIT_MEMORY_MB.Set(64);
+    defer config.GRADER_LIMIT_MEMORY_MB.Set(oldMemory);
+
+    oldProcs := config.GRADER_LIMIT_PROCS.Get();
+    config.GRADER_LIMIT_PROCS.Set(10);
+    defer config.GRADER_LIMIT_PROCS.Set(oldProcs);
+
+    failed := errors.New("exit status 1");
+
+    testCases := []struct{err error; stderr string; expected string}{
+        {failed, "bash: xmalloc: cannot allocate 100 bytes", LIMIT_MEMORY},
+        {failed, "MemoryError", LIMIT_MEMORY},
+        {failed, "terminate called after throwing an instance of 'std::bad_alloc'", LIMIT_MEMORY},
+        {failed, "bash: fork: retry: Resource temporarily unavailable", LIMIT_PROCS},
+        {failed, "RuntimeError: can't start new thread", LIMIT_PROCS},
+
+        {failed, "", ""},
+        {failed, "Segmentation fault", ""},
+        {nil, "MemoryError", ""},
+    };
+
+    for i, testCase := range testCases {
+        limitErr := checkLimitOutput(testCase.err, testCase.stderr);
+
+        actual := "";
+        if (limitErr != nil) {
+            actual = limitErr.Limit;
+
+            if (!limitErr.Guessed) {
+                test.Errorf("Case %d: Limit from output was not marked as guessed.", i);
+            }
+        }
+
+        if (testCase.expected != actual) {
+            test.Errorf("Case %d: Unexpected limit. Expected: '%s', Actual: '%s'.", i, testCase.expected, actual);
+        }
+    }
+}
+
+func runTestLimitedCMD(test *testing.T, script string) (string, string, error) {
+    cmd, err := applyResourceLimits(exec.Command("bash", "-c", script), "");
+    if (err != nil) {
+        test.Fatalf("Failed to apply resource limits: '%v'.", err);
+    }
+
+    return runLimitedCMD(cmd, config.GRADER_LIMIT_TIMEOUT_SECS.Get(), nil, nil);
+}
+
+func checkLimitError(test *testing.T, err error, expectedLimit string) *ResourceLimitError {
+    if (err == nil) {
+        test.Fatalf("Did not get an error when a limit ('%s') was exceeded.", expectedLimit);
+    }
+
+    var limitErr *ResourceLimitError;
+    if (!errors.As(err, &limitErr)) {
+        test.Fatalf("Did not get a limit error, got: '%v'.", err);
+    }
+
+    if (limitErr.Limit != expectedLimit) {
+        test.Fatalf("Unexpected limit. Expected: '%s', Actual: '%s'.", expectedLimit, limitErr.Limit);
+    }
+
+    return limitErr;
+}
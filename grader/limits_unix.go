@@ -0,0 +1,124 @@
+//go:build unix
+
+package grader
+
+import (
+    "errors"
+    "fmt"
+    "io/fs"
+    "os"
+    "os/exec"
+    "os/user"
+    "path/filepath"
+    "strconv"
+    "syscall"
+
+    "github.com/edulinq/autograder/config"
+    "github.com/edulinq/autograder/log"
+)
+
+// Run the command in its own process group, and (if possible) as the configured grader user.
+func setProcessAttributes(cmd *exec.Cmd, tempDir string) error {
+    cmd.SysProcAttr = &syscall.SysProcAttr{
+        Setpgid: true,
+    };
+
+    credential, err := getGraderCredential();
+    if (err != nil) {
+        return err;
+    }
+
+    if (credential != nil) {
+        err = chownTree(tempDir, int(credential.Uid), int(credential.Gid));
+        if (err != nil) {
+            return fmt.Errorf("Failed to give grader user ownership of the grading dir: '%w'.", err);
+        }
+
+        cmd.SysProcAttr.Credential = credential;
+    }
+
+    return nil;
+}
+
+// Get the credential for the dedicated grader user.
+// Will return nil if there is no grader user or we are not able to switch users.
+func getGraderCredential() (*syscall.Credential, error) {
+    username := config.GRADER_USER.Get();
+    if (username == "") {
+        return nil, nil;
+    }
+
+    if (os.Geteuid() != 0) {
+        log.Warn("A grader user is configured, but the server is not running as root. Running grader as the current user.",
+                log.NewAttr("grader-user", username));
+        return nil, nil;
+    }
+
+    graderUser, err := user.Lookup(username);
+    if (err != nil) {
+        return nil, fmt.Errorf("Failed to lookup grader user '%s': '%w'.", username, err);
+    }
+
+    uid, err := strconv.ParseUint(graderUser.Uid, 10, 32);
+    if (err != nil) {
+        return nil, fmt.Errorf("Grader user '%s' has a bad uid ('%s'): '%w'.", username, graderUser.Uid, err);
+    }
+
+    gid, err := strconv.ParseUint(graderUser.Gid, 10, 32);
+    if (err != nil) {
+        return nil, fmt.Errorf("Grader user '%s' has a bad gid ('%s'): '%w'.", username, graderUser.Gid, err);
+    }
+
+    return &syscall.Credential{Uid: uint32(uid), Gid: uint32(gid)}, nil;
+}
+
+func chownTree(dir string, uid int, gid int) error {
+    return filepath.WalkDir(dir, func(path string, dirent fs.DirEntry, err error) error {
+        if (err != nil) {
+            return err;
+        }
+
+        return os.Lchown(path, uid, gid);
+    });
+}
+
+func killProcessGroup(cmd *exec.Cmd) {
+    if ((cmd.Process == nil) || (cmd.SysProcAttr == nil) || !cmd.SysProcAttr.Setpgid) {
+        return;
+    }
+
+    err := syscall.Kill(-cmd.Process.Pid, syscall.SIGKILL);
+    if ((err != nil) && !errors.Is(err, syscall.ESRCH)) {
+        log.Warn("Failed to kill grader process group.", err, log.NewAttr("pid", cmd.Process.Pid));
+    }
+}
+
+// Check if the command died from a signal that indicates a limit was hit.
+// We only send a SIGKILL after a timeout (which is checked first) or after the grader exits,
+// so a SIGKILL here most likely came from the OOM killer.
+func checkLimitSignal(err error) *ResourceLimitError {
+    var exitErr *exec.ExitError;
+    if (!errors.As(err, &exitErr)) {
+        return nil;
+    }
+
+    status, ok := exitErr.Sys().(syscall.WaitStatus);
+    if (!ok || !status.Signaled()) {
+        return nil;
+    }
+
+    switch (status.Signal()) {
+        case syscall.SIGXCPU:
+            return &ResourceLimitError{Limit: LIMIT_CPU, Value: config.GRADER_LIMIT_CPU_SECS.Get()};
+        case syscall.SIGXFSZ:
+            return &ResourceLimitError{Limit: LIMIT_FILE_SIZE, Value: config.GRADER_LIMIT_FILE_SIZE_MB.Get()};
+        case syscall.SIGKILL:
+            if (config.GRADER_LIMIT_MEMORY_MB.Get() <= 0) {
+                return nil;
+            }
+
+            return &ResourceLimitError{Limit: LIMIT_MEMORY, Value: config.GRADER_LIMIT_MEMORY_MB.Get()};
+        default:
+            return nil;
+    }
+}
@@ -1,7 +1,6 @@
 package grader
 
 import (
-    "bytes"
     "fmt"
     "os"
     "os/exec"
@@ -9,6 +8,8 @@ import (
     "strings"
 
     "github.com/edulinq/autograder/common"
+    "github.com/edulinq/autograder/config"
+    "github.com/edulinq/autograder/docker"
     "github.com/edulinq/autograder/log"
     "github.com/edulinq/autograder/model"
     "github.com/edulinq/autograder/util"
@@ -18,81 +19,141 @@ const PYTHON_AUTOGRADER_INVOCATION = "python3 -m autograder.cli.grading.grade-di
 const PYTHON_GRADER_FILENAME = "grader.py"
 const PYTHON_DOCKER_IMAGE_BASENAME = "autograder.python";
 
+// A function that can wrap a grader command before it is run on the host, e.g., to sandbox it.
+// The temp dir (which contains the other grading dirs) and the input dir are also passed.
+type commandWrapper func(cmd *exec.Cmd, tempDir string, inputDir string) (*exec.Cmd, error);
+
 func runNoDockerGrader(assignment *model.Assignment, submissionPath string, options GradeOptions, fullSubmissionID string) (
         *model.GradingInfo, map[string][]byte, string, string, error) {
+    return runLocalGrader(assignment, submissionPath, options, common.RUNNER_NODOCKER, nil);
+}
+
+// Run a grader directly on the host (as opposed to inside a container).
+// If a wrapper is provided, then it will be applied to the grader command before running.
+func runLocalGrader(assignment *model.Assignment, submissionPath string, options GradeOptions, name string, wrapper commandWrapper) (
+        *model.GradingInfo, map[string][]byte, string, string, error) {
+    tempDir, outputDir, stdout, stderr, err := runLocalCommand(assignment, submissionPath, options, name, wrapper, false);
+    defer cleanupTempDir(tempDir, options);
+
+    if (err != nil) {
+        return nil, nil, stdout, stderr,
+                fmt.Errorf("Failed to run %s grader for assignment '%s': '%w'.", name, assignment.FullID(), err);
+    }
+
+    resultPath := filepath.Join(outputDir, common.GRADER_OUTPUT_RESULT_FILENAME);
+    if (!util.PathExists(resultPath)) {
+        return nil, nil, stdout, stderr, fmt.Errorf("Cannot find output file ('%s') after %s grading.", resultPath, name);
+    }
+
+    var gradingInfo model.GradingInfo;
+    err = util.JSONFromFile(resultPath, &gradingInfo);
+    if (err != nil) {
+        return nil, nil, stdout, stderr, err;
+    }
+
+    fileContents, err := util.GzipDirectoryToBytes(outputDir);
+    if (err != nil) {
+        return nil, nil, stdout, stderr, fmt.Errorf("Failed to copy grading output '%s': '%w'.", outputDir, err);
+    }
+
+    return &gradingInfo, fileContents, stdout, stderr, nil;
+}
+
+// Run an assignment's pre-check directly on the host.
+func runLocalPreCheck(assignment *model.Assignment, submissionPath string, options GradeOptions, name string, wrapper commandWrapper) (
+        *PreCheckResult, error) {
+    tempDir, _, stdout, stderr, err := runLocalCommand(assignment, submissionPath, options, name, wrapper, true);
+    defer cleanupTempDir(tempDir, options);
+
+    return toPreCheckResult(stdout, stderr, err);
+}
+
+// Setup the grading dirs and run either the assignment's grader or pre-check on the host.
+// The caller is responsible for cleaning up the returned temp dir (see cleanupTempDir()), even on error.
+// Returns: (temp dir, output dir, stdout, stderr, error).
+func runLocalCommand(assignment *model.Assignment, submissionPath string, options GradeOptions,
+        name string, wrapper commandWrapper, preCheck bool) (string, string, string, string, error) {
     imageInfo := assignment.GetImageInfo();
     if (imageInfo == nil) {
-        return nil, nil, "", "", fmt.Errorf("No image information associated with assignment: '%s'.", assignment.FullID());
+        return "", "", "", "", fmt.Errorf("No image information associated with assignment: '%s'.", assignment.FullID());
     }
 
-    tempDir, inputDir, outputDir, workDir, err := common.PrepTempGradingDir("nodocker");
+    tempDir, inputDir, outputDir, workDir, err := common.PrepTempGradingDir(name);
     if (err != nil) {
-        return nil, nil, "", "", err;
+        return "", "", "", "", err;
     }
 
-    if (!options.LeaveTempDir) {
-        defer os.RemoveAll(tempDir);
+    var cmd *exec.Cmd;
+    timeoutSecs := config.GRADER_LIMIT_TIMEOUT_SECS.Get();
+
+    if (preCheck) {
+        cmd, err = getLocalCommand(imageInfo.PreCheckInvocation, tempDir, inputDir, outputDir, workDir);
+        timeoutSecs = imageInfo.PreCheckTimeoutSecs;
     } else {
-        log.Info("Leaving behind temp grading dir.", log.NewAttr("path", tempDir));
+        cmd, err = getAssignmentInvocation(assignment, tempDir, inputDir, outputDir, workDir);
     }
 
-    cmd, err := getAssignmentInvocation(assignment, tempDir, inputDir, outputDir, workDir);
     if (err != nil) {
-        return nil, nil, "", "", err;
+        return tempDir, outputDir, "", "", err;
     }
 
     // Copy over the static files (and do any file ops).
     err = common.CopyFileSpecs(imageInfo.BaseDir, workDir, tempDir,
             imageInfo.StaticFiles, false, imageInfo.PreStaticFileOperations, imageInfo.PostStaticFileOperations);
     if (err != nil) {
-        return nil, nil, "", "", fmt.Errorf("Failed to copy static assignment files: '%w'.", err);
+        return tempDir, outputDir, "", "", fmt.Errorf("Failed to copy static assignment files: '%w'.", err);
     }
 
-    // Copy over the submission files (and do any file ops).
-    err = common.CopyFileSpecs(submissionPath, inputDir, tempDir,
-            []*common.FileSpec{common.GetPathFileSpec(".")}, true, []common.FileOperation{}, imageInfo.PostSubmissionFileOperations);
-    if (err != nil) {
-        return nil, nil, "", "", fmt.Errorf("Failed to copy submission ssignment files: '%w'.", err);
-    }
-
-    stdout, stderr, err := runCMD(cmd);
+    err = copySubmission(imageInfo, submissionPath, inputDir, tempDir);
     if (err != nil) {
-        return nil, nil, stdout, stderr,
-                fmt.Errorf("Failed to run non-docker grader for assignment '%s': '%w'.", assignment.FullID(), err);
+        return tempDir, outputDir, "", "", err;
     }
 
-    resultPath := filepath.Join(outputDir, common.GRADER_OUTPUT_RESULT_FILENAME);
-    if (!util.PathExists(resultPath)) {
-        return nil, nil, stdout, stderr, fmt.Errorf("Cannot find output file ('%s') after non-docker grading.", resultPath);
+    if (wrapper != nil) {
+        cmd, err = wrapper(cmd, tempDir, inputDir);
+        if (err != nil) {
+            return tempDir, outputDir, "", "", fmt.Errorf("Failed to prepare %s grader command: '%w'.", name, err);
+        }
     }
 
-    var gradingInfo model.GradingInfo;
-    err = util.JSONFromFile(resultPath, &gradingInfo);
+    cmd, err = applyResourceLimits(cmd, tempDir);
     if (err != nil) {
-        return nil, nil, stdout, stderr, err;
+        return tempDir, outputDir, "", "", fmt.Errorf("Failed to apply resource limits to %s grader command: '%w'.", name, err);
     }
 
-    fileContents, err := util.GzipDirectoryToBytes(outputDir);
-    if (err != nil) {
-        return nil, nil, stdout, stderr, fmt.Errorf("Failed to copy grading output '%s': '%w'.", outputDir, err);
-    }
+    stdoutEvents := newEventWriter(options.Listener, EVENT_STDOUT, assignment);
+    stderrEvents := newEventWriter(options.Listener, EVENT_STDERR, assignment);
 
-    return &gradingInfo, fileContents, stdout, stderr, nil;
-}
+    stdout, stderr, err := runLimitedCMD(cmd, timeoutSecs, stdoutEvents.asWriter(), stderrEvents.asWriter());
+
+    stdoutEvents.Close();
+    stderrEvents.Close();
 
-func runCMD(cmd *exec.Cmd) (string, string, error) {
-    var outBuffer bytes.Buffer;
-    var errBuffer bytes.Buffer;
+    return tempDir, outputDir, stdout, stderr, err;
+}
 
-    cmd.Stdout = &outBuffer;
-    cmd.Stderr = &errBuffer;
+// Copy over the submission files into the input dir and do any post-submission file ops (relative to the temp dir).
+// This is the same for graders and pre-checks.
+func copySubmission(imageInfo *docker.ImageInfo, submissionPath string, inputDir string, tempDir string) error {
+    err := common.CopyFileSpecs(submissionPath, inputDir, tempDir,
+            []*common.FileSpec{common.GetPathFileSpec(".")}, true, []common.FileOperation{}, imageInfo.PostSubmissionFileOperations);
+    if (err != nil) {
+        return fmt.Errorf("Failed to copy submission files: '%w'.", err);
+    }
 
-    err := cmd.Run();
+    return nil;
+}
 
-    stdout := outBuffer.String();
-    stderr := errBuffer.String();
+func cleanupTempDir(tempDir string, options GradeOptions) {
+    if (tempDir == "") {
+        return;
+    }
 
-    return stdout, stderr, err;
+    if (!options.LeaveTempDir) {
+        os.RemoveAll(tempDir);
+    } else {
+        log.Info("Leaving behind temp grading dir.", log.NewAttr("path", tempDir));
+    }
 }
 
 // Get a command to invoke the non-docker grader.
@@ -118,6 +179,15 @@ func getAssignmentInvocation(assignment *model.Assignment,
         return nil, fmt.Errorf("Cannot get non-docker grader invocation for assignment: '%s'.", assignment.FullID());
     }
 
+    return getLocalCommand(rawCommand, baseDir, inputDir, outputDir, workDir);
+}
+
+// Get a command to run on the host, replacing any path placeholders (e.g. "<inputdir>").
+func getLocalCommand(rawCommand []string, baseDir string, inputDir string, outputDir string, workDir string) (*exec.Cmd, error) {
+    if (len(rawCommand) == 0) {
+        return nil, fmt.Errorf("Cannot run an empty command.");
+    }
+
     cleanCommand := make([]string, 0, len(rawCommand));
     for _, value := range rawCommand {
         if (value == "<grader>") {
@@ -0,0 +1,113 @@
+package grader
+
+// Pre-checks are fast checks (e.g. compiling) that run before the full grader.
+// A submission that fails its pre-check is rejected (and does not count as an attempt).
+
+import (
+    "context"
+    "errors"
+    "os/exec"
+    "time"
+
+    "github.com/edulinq/autograder/common"
+    "github.com/edulinq/autograder/docker"
+    "github.com/edulinq/autograder/model"
+)
+
+type PreCheckResult struct {
+    Passed bool `json:"passed"`
+    TimedOut bool `json:"timed-out,omitempty"`
+    Stdout string `json:"stdout"`
+    Stderr string `json:"stderr"`
+}
+
+type RejectPreCheckFailed struct {
+    Result *PreCheckResult
+}
+
+func (this *RejectPreCheckFailed) String() string {
+    if (this.Result.TimedOut) {
+        return "Submission failed the pre-check (the pre-check timed out).";
+    }
+
+    return "Submission failed the pre-check, see the pre-check output for details.";
+}
+
+// Run the pre-check for an assignment (if it has one).
+// Returns nil if there is no pre-check or the submission passed.
+func runPreCheck(runner Runner, assignment *model.Assignment, submissionPath string, options GradeOptions, fullSubmissionID string) (
+        RejectReason, error) {
+    imageInfo := assignment.GetImageInfo();
+    if ((imageInfo == nil) || !imageInfo.HasPreCheck()) {
+        return nil, nil;
+    }
+
+    sendStageEvent(options.Listener, STAGE_PRE_CHECK);
+
+    result, err := runner.PreCheck(assignment, submissionPath, options, fullSubmissionID);
+    if (err != nil) {
+        return nil, err;
+    }
+
+    if (!result.Passed) {
+        return &RejectPreCheckFailed{result}, nil;
+    }
+
+    return nil, nil;
+}
+
+func runDockerPreCheck(assignment *model.Assignment, submissionPath string, options GradeOptions, fullSubmissionID string) (
+        *PreCheckResult, error) {
+    imageInfo := assignment.GetImageInfo();
+
+    tempDir, inputDir, outputDir, _, err := common.PrepTempGradingDir("docker-pre-check");
+    if (err != nil) {
+        return nil, err;
+    }
+    defer cleanupTempDir(tempDir, options);
+
+    err = copySubmission(imageInfo, submissionPath, inputDir, tempDir);
+    if (err != nil) {
+        return nil, err;
+    }
+
+    stdoutEvents := newEventWriter(options.Listener, EVENT_STDOUT, assignment);
+    stderrEvents := newEventWriter(options.Listener, EVENT_STDERR, assignment);
+
+    timeout := time.Duration(imageInfo.PreCheckTimeoutSecs) * time.Second;
+    stdout, stderr, exitCode, err := docker.RunContainerCommand(assignment, imageInfo, inputDir, outputDir, fullSubmissionID,
+            imageInfo.PreCheckInvocation, timeout, stdoutEvents.asWriter(), stderrEvents.asWriter());
+
+    stdoutEvents.Close();
+    stderrEvents.Close();
+
+    if (errors.Is(err, context.DeadlineExceeded)) {
+        return &PreCheckResult{Passed: false, TimedOut: true, Stdout: stdout, Stderr: stderr}, nil;
+    }
+
+    if (err != nil) {
+        return nil, err;
+    }
+
+    return &PreCheckResult{Passed: (exitCode == 0), Stdout: stdout, Stderr: stderr}, nil;
+}
+
+// Convert the result of running a host pre-check into a result.
+// A non-zero exit or a timeout is a failed check, any other error is a real error.
+func toPreCheckResult(stdout string, stderr string, err error) (*PreCheckResult, error) {
+    if (err == nil) {
+        return &PreCheckResult{Passed: true, Stdout: stdout, Stderr: stderr}, nil;
+    }
+
+    var limitErr *ResourceLimitError;
+    if (errors.As(err, &limitErr) && (limitErr.Limit == LIMIT_TIMEOUT)) {
+        return &PreCheckResult{Passed: false, TimedOut: true, Stdout: stdout, Stderr: stderr}, nil;
+    }
+
+    var exitErr *exec.ExitError;
+    if (errors.As(err, &exitErr)) {
+        return &PreCheckResult{Passed: false, Stdout: stdout, Stderr: stderr}, nil;
+    }
+
+    return nil, err;
+}
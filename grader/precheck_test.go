@@ -0,0 +1,78 @@
+package grader
+
+import (
+    "path/filepath"
+    "reflect"
+    "testing"
+
+    "github.com/edulinq/autograder/db"
+)
+
+func TestPreCheck(test *testing.T) {
+    db.ResetForTesting();
+    defer db.ResetForTesting();
+
+    testCases := []struct{invocation []string; timeoutSecs int; expected *PreCheckResult}{
+        {[]string{"bash", "-c", "echo 'ok'"}, 10, &PreCheckResult{Passed: true, Stdout: "ok\n"}},
+        {[]string{"bash", "-c", "echo 'fail' 1>&2 ; exit 1"}, 10, &PreCheckResult{Passed: false, Stderr: "fail\n"}},
+        {[]string{"ls", "<inputdir>"}, 10, &PreCheckResult{Passed: true, Stdout: "submission.py\ntest-submission.json\n"}},
+        {[]string{"sleep", "10"}, 1, &PreCheckResult{Passed: false, TimedOut: true}},
+    };
+
+    assignment := db.MustGetTestAssignment();
+    submissionPath := filepath.Join(assignment.GetSourceDir(), SUBMISSION_RELPATH);
+    options := GetDefaultGradeOptions();
+
+    for i, testCase := range testCases {
+        assignment.GetImageInfo().PreCheckInvocation = testCase.invocation;
+        assignment.GetImageInfo().PreCheckTimeoutSecs = testCase.timeoutSecs;
+
+        result, err := runLocalPreCheck(assignment, submissionPath, options, "test-pre-check", nil);
+        if (err != nil) {
+            test.Errorf("Case %d: Failed to run pre-check: '%v'.", i, err);
+            continue;
+        }
+
+        if (!reflect.DeepEqual(testCase.expected, result)) {
+            test.Errorf("Case %d: Unexpected result. Expected: '%+v', Actual: '%+v'.", i, testCase.expected, result);
+            continue;
+        }
+    }
+}
+
+func TestPreCheckRejectNoAttempt(test *testing.T) {
+    db.ResetForTesting();
+    defer db.ResetForTesting();
+
+    assignment := db.MustGetTestAssignment();
+    submissionPath := filepath.Join(assignment.GetSourceDir(), SUBMISSION_RELPATH);
+    user := "other@test.com";
+
+    assignment.GetImageInfo().PreCheckInvocation = []string{"bash", "-c", "echo 'Does not compile.' ; exit 1"};
+
+    options := GetDefaultGradeOptions();
+    options.NoDocker = true;
+
+    result, reject, err := Grade(assignment, submissionPath, user, TEST_MESSAGE, true, options);
+    if (err != nil) {
+        test.Fatalf("Failed to grade: '%v'.", err);
+    }
+
+    if (result != nil) {
+        test.Fatalf("Got a result from a submission that failed the pre-check.");
+    }
+
+    expected := &RejectPreCheckFailed{&PreCheckResult{Passed: false, Stdout: "Does not compile.\n"}};
+    if (!reflect.DeepEqual(expected, reject)) {
+        test.Fatalf("Unexpected rejection. Expected: '%+v', Actual: '%+v'.", expected, reject);
+    }
+
+    history, err := db.GetSubmissionHistory(assignment, user);
+    if (err != nil) {
+        test.Fatalf("Failed to get submission history: '%v'.", err);
+    }
+
+    if (len(history) != 0) {
+        test.Fatalf("A submission that failed the pre-check was saved: '%v'.", history);
+    }
+}
@@ -0,0 +1,66 @@
+package grader
+
+import (
+    "testing"
+
+    "github.com/edulinq/autograder/db"
+)
+
+func TestGetReusableResult(test *testing.T) {
+    defer db.ResetForTesting();
+    db.ResetForTesting();
+
+    assignment := db.MustGetTestAssignment();
+    email := "student@test.com";
+
+    if (getReusableResult(assignment, email, "abc123") != nil) {
+        test.Fatalf("Found a reusable result before any submission had a hash.");
+    }
+
+    previous, err := db.GetSubmissionContents(assignment, email, "1697406265");
+    if (err != nil) {
+        test.Fatalf("Failed to get submission contents: '%v'.", err);
+    }
+
+    previous.Info.SubmissionHash = "abc123";
+
+    err = db.SaveSubmission(assignment, previous);
+    if (err != nil) {
+        test.Fatalf("Failed to save submission: '%v'.", err);
+    }
+
+    result := getReusableResult(assignment, email, "abc123");
+    if (result == nil) {
+        test.Fatalf("Did not find a reusable result.");
+    }
+
+    if (result.Info.ReusedFrom != previous.Info.ID) {
+        test.Fatalf("Unexpected reused from. Expected: '%s', Actual: '%s'.", previous.Info.ID, result.Info.ReusedFrom);
+    }
+
+    if ((result.Info.Score != 0.0) || (result.Info.MaxPoints != 0.0)) {
+        test.Fatalf("Points were not cleared: %f / %f.", result.Info.Score, result.Info.MaxPoints);
+    }
+
+    if (len(result.Info.Questions) != len(previous.Info.Questions)) {
+        test.Fatalf("Unexpected number of questions. Expected: %d, Actual: %d.", len(previous.Info.Questions), len(result.Info.Questions));
+    }
+
+    // The result should be a copy.
+    result.Info.Questions[0].Score = -1.0;
+    if (previous.Info.Questions[0].Score == -1.0) {
+        test.Fatalf("Reused result shares questions with the previous result.");
+    }
+
+    if (len(result.OutputFilesGZip) != len(previous.OutputFilesGZip)) {
+        test.Fatalf("Unexpected number of output files. Expected: %d, Actual: %d.", len(previous.OutputFilesGZip), len(result.OutputFilesGZip));
+    }
+
+    if (getReusableResult(assignment, email, "ZZZ") != nil) {
+        test.Fatalf("Found a reusable result for an unknown hash.");
+    }
+
+    if (getReusableResult(assignment, "grader@test.com", "abc123") != nil) {
+        test.Fatalf("Found a reusable result from another user.");
+    }
+}
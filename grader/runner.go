@@ -0,0 +1,145 @@
+package grader
+
+import (
+    "fmt"
+    "slices"
+    "strings"
+
+    "github.com/edulinq/autograder/common"
+    "github.com/edulinq/autograder/config"
+    "github.com/edulinq/autograder/docker"
+    "github.com/edulinq/autograder/model"
+)
+
+// A runner is responsible for running a grader on a submission in some environment.
+type Runner interface {
+    // Ensure that anything the runner needs for an assignment (e.g. images) is ready.
+    Prep(assignment *model.Assignment) error
+
+    // Returns a partial result (grading info, output files, stdout, stderr, and sidecar logs),
+    // the remaining fields are filled in by the caller.
+    // The result should be non-nil (to hold any output) even when there is an error.
+    Run(assignment *model.Assignment, submissionPath string, options GradeOptions, fullSubmissionID string) (
+            *model.GradingResult, error)
+
+    // Get the ID of the image used to grade (empty for runners that do not use images).
+    GetImageID(assignment *model.Assignment) (string, error)
+
+    // Run the assignment's pre-check (see docker.ImageInfo.PreCheckInvocation).
+    // A failed check is not an error.
+    PreCheck(assignment *model.Assignment, submissionPath string, options GradeOptions, fullSubmissionID string) (*PreCheckResult, error)
+}
+
+// Runs graders inside of a container.
+// Both docker and podman use this runner, just with a different host (see docker.ImageInfo.Host).
+type containerRunner struct{}
+
+// Runs graders directly on the host without any isolation.
+type noDockerRunner struct{}
+
+// Runs graders on the host inside of a bubblewrap namespace sandbox.
+type bubblewrapRunner struct{}
+
+// Check the server's default runner (the grader.runner option).
+// This should be called on startup, so a bad config is caught before anything is graded.
+func ValidateRunnerConfig() error {
+    runnerName := config.GRADER_RUNNER.Get();
+    if (!slices.Contains(common.RUNNERS, runnerName)) {
+        return fmt.Errorf("Unknown runner '%s' in the grader.runner option, must be one of: %s.", runnerName, strings.Join(common.RUNNERS, ", "));
+    }
+
+    return nil;
+}
+
+// Get the runner that should be used for this assignment.
+// The NoDocker option will always force the non-docker runner.
+func GetRunner(assignment *model.Assignment, options GradeOptions) (Runner, error) {
+    if (options.NoDocker) {
+        return &noDockerRunner{}, nil;
+    }
+
+    runnerName := assignment.GetCourse().GetRunner();
+
+    switch (runnerName) {
+        case common.RUNNER_DOCKER, common.RUNNER_PODMAN:
+            return &containerRunner{}, nil;
+        case common.RUNNER_BUBBLEWRAP:
+            return &bubblewrapRunner{}, nil;
+        case common.RUNNER_NODOCKER:
+            return &noDockerRunner{}, nil;
+        default:
+            return nil, fmt.Errorf("Unknown runner '%s' for assignment '%s'.", runnerName, assignment.FullID());
+    }
+}
+
+func (this *containerRunner) Prep(assignment *model.Assignment) error {
+    err := docker.BuildImageFromSourceQuick(assignment);
+    if (err != nil) {
+        return fmt.Errorf("Failed to build assignment assignment '%s' docker image: '%w'.", assignment.FullID(), err);
+    }
+
+    return nil;
+}
+
+func (this *containerRunner) GetImageID(assignment *model.Assignment) (string, error) {
+    return docker.GetImageID(assignment.GetImageInfo());
+}
+
+func (this *containerRunner) Run(assignment *model.Assignment, submissionPath string, options GradeOptions, fullSubmissionID string) (
+        *model.GradingResult, error) {
+    return runDockerGrader(assignment, submissionPath, options, fullSubmissionID);
+}
+
+func (this *containerRunner) PreCheck(assignment *model.Assignment, submissionPath string, options GradeOptions, fullSubmissionID string) (
+        *PreCheckResult, error) {
+    return runDockerPreCheck(assignment, submissionPath, options, fullSubmissionID);
+}
+
+func (this *noDockerRunner) Prep(assignment *model.Assignment) error {
+    return nil;
+}
+
+func (this *noDockerRunner) GetImageID(assignment *model.Assignment) (string, error) {
+    return "", nil;
+}
+
+func (this *noDockerRunner) Run(assignment *model.Assignment, submissionPath string, options GradeOptions, fullSubmissionID string) (
+        *model.GradingResult, error) {
+    return newRunResult(runNoDockerGrader(assignment, submissionPath, options, fullSubmissionID));
+}
+
+func (this *noDockerRunner) PreCheck(assignment *model.Assignment, submissionPath string, options GradeOptions, fullSubmissionID string) (
+        *PreCheckResult, error) {
+    return runLocalPreCheck(assignment, submissionPath, options, common.RUNNER_NODOCKER, nil);
+}
+
+func (this *bubblewrapRunner) Prep(assignment *model.Assignment) error {
+    return checkBubblewrap();
+}
+
+func (this *bubblewrapRunner) GetImageID(assignment *model.Assignment) (string, error) {
+    return "", nil;
+}
+
+func (this *bubblewrapRunner) Run(assignment *model.Assignment, submissionPath string, options GradeOptions, fullSubmissionID string) (
+        *model.GradingResult, error) {
+    return newRunResult(runBubblewrapGrader(assignment, submissionPath, options, fullSubmissionID));
+}
+
+func (this *bubblewrapRunner) PreCheck(assignment *model.Assignment, submissionPath string, options GradeOptions, fullSubmissionID string) (
+        *PreCheckResult, error) {
+    return runLocalPreCheck(assignment, submissionPath, options, common.RUNNER_BUBBLEWRAP, wrapBubblewrapCommand);
+}
+
+// Build a runner's result for graders that do not produce sidecar logs.
+func newRunResult(gradingInfo *model.GradingInfo, outputFileContents map[string][]byte, stdout string, stderr string, err error) (
+        *model.GradingResult, error) {
+    result := &model.GradingResult{
+        Info: gradingInfo,
+        OutputFilesGZip: outputFileContents,
+        Stdout: stdout,
+        Stderr: stderr,
+    };
+
+    return result, err;
+}
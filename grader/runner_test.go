@@ -0,0 +1,107 @@
+package grader
+
+import (
+    "os/exec"
+    "reflect"
+    "slices"
+    "testing"
+
+    "github.com/edulinq/autograder/common"
+    "github.com/edulinq/autograder/config"
+    "github.com/edulinq/autograder/db"
+)
+
+func TestGetRunner(test *testing.T) {
+    db.ResetForTesting();
+    defer db.ResetForTesting();
+
+    assignment := db.MustGetTestAssignment();
+    course := assignment.GetCourse();
+
+    oldRunner := course.Runner;
+    defer func() {
+        course.Runner = oldRunner;
+    }();
+
+    testCases := []struct{ runner string; noDocker bool; expected Runner }{
+        {common.RUNNER_DOCKER, false, &containerRunner{}},
+        {common.RUNNER_PODMAN, false, &containerRunner{}},
+        {common.RUNNER_BUBBLEWRAP, false, &bubblewrapRunner{}},
+        {common.RUNNER_NODOCKER, false, &noDockerRunner{}},
+        {common.RUNNER_DOCKER, true, &noDockerRunner{}},
+        {common.RUNNER_BUBBLEWRAP, true, &noDockerRunner{}},
+    };
+
+    for i, testCase := range testCases {
+        course.Runner = testCase.runner;
+
+        runner, err := GetRunner(assignment, GradeOptions{NoDocker: testCase.noDocker});
+        if (err != nil) {
+            test.Errorf("Case %d: Failed to get runner: '%v'.", i, err);
+            continue;
+        }
+
+        if (reflect.TypeOf(runner) != reflect.TypeOf(testCase.expected)) {
+            test.Errorf("Case %d: Unexpected runner. Expected: '%T', Actual: '%T'.", i, testCase.expected, runner);
+            continue;
+        }
+    }
+
+    course.Runner = "ZZZ";
+    _, err := GetRunner(assignment, GradeOptions{});
+    if (err == nil) {
+        test.Fatalf("Did not get an error on an unknown runner.");
+    }
+}
+
+func TestValidateRunnerConfig(test *testing.T) {
+    defer config.GRADER_RUNNER.Set(config.GRADER_RUNNER.Get());
+
+    for _, runner := range common.RUNNERS {
+        config.GRADER_RUNNER.Set(runner);
+
+        err := ValidateRunnerConfig();
+        if (err != nil) {
+            test.Errorf("Runner '%s': Unexpected error: '%v'.", runner, err);
+        }
+    }
+
+    config.GRADER_RUNNER.Set("ZZZ");
+    err := ValidateRunnerConfig();
+    if (err == nil) {
+        test.Fatalf("Did not get an error on an unknown runner.");
+    }
+}
+
+func TestWrapBubblewrapCommand(test *testing.T) {
+    oldBinds := config.BUBBLEWRAP_RO_BINDS.Get();
+    config.BUBBLEWRAP_RO_BINDS.Set("/usr, ,/lib");
+    defer config.BUBBLEWRAP_RO_BINDS.Set(oldBinds);
+
+    cmd := exec.Command("python3", "-m", "grader");
+    cmd.Dir = "/tmp/grading/work";
+
+    wrappedCmd, err := wrapBubblewrapCommand(cmd, "/tmp/grading", "/tmp/grading/input");
+    if (err != nil) {
+        test.Fatalf("Failed to wrap command: '%v'.", err);
+    }
+
+    expectedTail := []string{
+        "--ro-bind-try", "/usr", "/usr",
+        "--ro-bind-try", "/lib", "/lib",
+        "--bind", "/tmp/grading", "/tmp/grading",
+        "--ro-bind", "/tmp/grading/input", "/tmp/grading/input",
+        "--chdir", "/tmp/grading/work",
+        "--",
+        "python3", "-m", "grader",
+    };
+
+    actualTail := wrappedCmd.Args[len(wrappedCmd.Args) - len(expectedTail):];
+    if (!slices.Equal(expectedTail, actualTail)) {
+        test.Fatalf("Unexpected command arguments. Expected (suffix): '%v', Actual: '%v'.", expectedTail, wrappedCmd.Args);
+    }
+
+    if (wrappedCmd.Dir != cmd.Dir) {
+        test.Fatalf("Unexpected working dir. Expected: '%s', Actual: '%s'.", cmd.Dir, wrappedCmd.Dir);
+    }
+}
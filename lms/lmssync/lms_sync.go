@@ -86,6 +86,11 @@ func syncLMSUsers(course *model.Course, dryRun bool, sendEmails bool, lmsUsers m
         return nil, fmt.Errorf("Failed to fetch local users: '%w'.", err);
     }
 
+    serverUsers, err := db.GetServerUsers();
+    if (err != nil) {
+        return nil, fmt.Errorf("Failed to fetch server users: '%w'.", err);
+    }
+
     if (len(syncEmails) == 0) {
         syncEmails = getAllEmails(localUsers, lmsUsers);
     }
@@ -93,7 +98,7 @@ func syncLMSUsers(course *model.Course, dryRun bool, sendEmails bool, lmsUsers m
     syncResult := model.NewUserSyncResult();
 
     for _, email := range syncEmails {
-        resolveResult, err := resolveUserSync(course, localUsers, lmsUsers, email);
+        resolveResult, err := resolveUserSync(course, localUsers, lmsUsers, serverUsers, email);
         if (err != nil) {
             return nil, err;
         }
@@ -107,16 +112,34 @@ func syncLMSUsers(course *model.Course, dryRun bool, sendEmails bool, lmsUsers m
         return syncResult, nil;
     }
 
+    // Added users that already have an account are only enrolled (their account is left alone).
+    enrollUsers := make(map[string]*model.User);
+    for _, newUser := range syncResult.Add {
+        if (serverUsers[newUser.Email] != nil) {
+            enrollUsers[newUser.Email] = newUser;
+            delete(localUsers, newUser.Email);
+        }
+    }
+
     err = db.SaveUsers(course, localUsers);
     if (err != nil) {
         return nil, fmt.Errorf("Failed to save users file: '%w'.", err);
     }
 
+    err = db.EnrollUsers(course, enrollUsers);
+    if (err != nil) {
+        return nil, fmt.Errorf("Failed to save user enrollments: '%w'.", err);
+    }
+
     if (sendEmails) {
         err = nil;
 
         for _, newUser := range syncResult.Add {
             pass := syncResult.ClearTextPasswords[newUser.Email];
+            if (pass == "") {
+                // An existing account, there are no new credentials to send.
+                continue;
+            }
             err = errors.Join(err, model.SendUserAddEmail(course, newUser, pass, true, false, dryRun, true));
         }
 
@@ -155,9 +178,10 @@ func mergeUsers(localUser *model.User, lmsUser *lmstypes.User, mergeAttributes b
 
 // Resolve differences between a local user and LMS user (linked using the provided email).
 // The passed in local user map will be modified to reflect any resolution.
+// Users that are new to the course but already have an account are added without a password.
 // The taken action will depend on the options set in the course's LMS adapter.
 func resolveUserSync(course *model.Course, localUsers map[string]*model.User,
-        lmsUsers map[string]*lmstypes.User, email string) (*model.UserResolveResult, error) {
+        lmsUsers map[string]*lmstypes.User, serverUsers map[string]*model.ServerUser, email string) (*model.UserResolveResult, error) {
     adapter := course.GetLMSAdapter();
 
     localUser := localUsers[email];
@@ -173,6 +197,20 @@ func resolveUserSync(course *model.Course, localUsers map[string]*model.User,
             return nil, nil;
         }
 
+        serverUser := serverUsers[email];
+        if (serverUser != nil) {
+            localUser = &model.User{
+                Email: email,
+                Name: serverUser.Name,
+                Role: lmsUser.Role,
+                LMSID: lmsUser.ID,
+            };
+
+            localUsers[email] = localUser;
+
+            return &model.UserResolveResult{Add: localUser}, nil;
+        }
+
         pass, err := util.RandHex(model.DEFAULT_PASSWORD_LEN);
         if (err != nil) {
             return nil, fmt.Errorf("Failed to generate a default password: '%w'.", err);
@@ -3,8 +3,10 @@ package model
 import (
     "fmt"
     "path/filepath"
+    "slices"
     "strings"
     "sync"
+    "time"
 
     "github.com/edulinq/autograder/common"
     "github.com/edulinq/autograder/docker"
@@ -16,6 +18,7 @@ const DEFAULT_SUBMISSIONS_DIR = "_submissions"
 
 const FILE_CACHE_FILENAME = "filecache.json"
 const CACHE_FILENAME = "cache.json"
+const BUILD_LOG_FILENAME = "build-log.json"
 
 type Assignment struct {
     ID string `json:"id"`
@@ -30,6 +33,21 @@ type Assignment struct {
 
     SubmissionLimit *SubmissionLimitInfo `json:"submission-limit,omitempty"`
 
+    // Questions (by name) that are hidden from students until the release time.
+    // Graders may also mark questions as hidden in their output.
+    HiddenQuestions []string `json:"hidden-questions,omitempty"`
+    // When hidden questions become visible to students.
+    // Defaults to the due date.
+    HiddenReleaseTime common.Timestamp `json:"hidden-release-time,omitempty"`
+
+    // Glob patterns (see filepath.Match()) for the grader output files that students can see (e.g. "report/*.html").
+    // Patterns are matched against paths relative to the output dir.
+    StudentArtifacts []string `json:"student-artifacts,omitempty"`
+
+    // If true, a submission identical to one of the user's earlier submissions (same files and grader version)
+    // will reuse the earlier result instead of being graded again.
+    ReuseResults bool `json:"reuse-results,omitempty"`
+
     docker.ImageInfo
 
     // Ignore these fields in JSON.
@@ -82,6 +100,21 @@ func (this *Assignment) ImageName() string {
     return strings.ToLower(fmt.Sprintf("autograder.%s.%s", this.Course.GetID(), this.ID));
 }
 
+// Get a hash of the parts of this assignment's config that affect grading.
+// Only the image info is hashed (image, docker commands, files, file ops, invocations, and network),
+// so changes to e.g. the name, due date, or container pool do not change the hash.
+func (this *Assignment) GetConfigHash() (string, error) {
+    imageInfo := this.ImageInfo;
+    imageInfo.ContainerPool = nil;
+
+    text, err := util.ToJSON(imageInfo);
+    if (err != nil) {
+        return "", fmt.Errorf("Failed to serialize assignment '%s': '%w'.", this.FullID(), err);
+    }
+
+    return util.MD5StringHex(text);
+}
+
 func (this *Assignment) GetImageInfo() *docker.ImageInfo {
     return &this.ImageInfo;
 }
@@ -112,6 +145,26 @@ func (this *Assignment) Validate() error {
         return fmt.Errorf("Max points cannot be negative: %f.", this.MaxPoints);
     }
 
+    err = this.HiddenReleaseTime.Validate();
+    if (err != nil) {
+        return fmt.Errorf("Hidden release time is not a valid timestamp: '%w'.", err);
+    }
+
+    if (this.StudentArtifacts == nil) {
+        this.StudentArtifacts = make([]string, 0);
+    }
+
+    for i, pattern := range this.StudentArtifacts {
+        pattern = filepath.Clean(strings.TrimSpace(pattern));
+
+        _, err = filepath.Match(pattern, "");
+        if ((err != nil) || filepath.IsAbs(pattern) || strings.HasPrefix(pattern, "..")) {
+            return fmt.Errorf("Student artifact pattern '%s' is not a valid relative glob pattern.", this.StudentArtifacts[i]);
+        }
+
+        this.StudentArtifacts[i] = pattern;
+    }
+
     this.imageLock = &sync.Mutex{};
 
     // Inherit submission limit from course or leave nil.
@@ -147,6 +200,10 @@ func (this *Assignment) Validate() error {
     this.ImageInfo.Name = this.ImageName();
     this.ImageInfo.BaseDir = this.GetSourceDir();
 
+    if (this.Course.GetRunner() == common.RUNNER_PODMAN) {
+        this.ImageInfo.Host = docker.GetPodmanHost();
+    }
+
     err = this.ImageInfo.Validate();
     if (err != nil) {
         return fmt.Errorf("Failed to validate docker information: '%w'.", err);
@@ -155,6 +212,77 @@ func (this *Assignment) Validate() error {
     return nil;
 }
 
+// Mark any questions that this assignment's config declares as hidden.
+func (this *Assignment) MarkHiddenQuestions(gradingInfo *GradingInfo) {
+    for _, question := range gradingInfo.Questions {
+        if (slices.Contains(this.HiddenQuestions, question.Name)) {
+            question.Hidden = true;
+        }
+    }
+}
+
+// Check if the results for hidden questions have been released to students.
+// If neither a release time nor a due date is set, then hidden questions are never released.
+func (this *Assignment) HiddenQuestionsReleased() bool {
+    releaseTime := this.HiddenReleaseTime;
+    if (releaseTime.IsZero()) {
+        releaseTime = this.DueDate;
+    }
+
+    if (releaseTime.IsZero()) {
+        return false;
+    }
+
+    instance, err := releaseTime.Time();
+    if (err != nil) {
+        log.Warn("Failed to parse hidden question release time.", err, this);
+        return false;
+    }
+
+    return !time.Now().Before(instance);
+}
+
+// Check if a user with the given role can see the results for hidden questions.
+// Graders (and above) can always see hidden questions.
+func (this *Assignment) CanSeeHiddenQuestions(role UserRole) bool {
+    if (role >= RoleGrader) {
+        return true;
+    }
+
+    return this.HiddenQuestionsReleased();
+}
+
+// Check if raw grader output (stdout, stderr, and sidecar logs) should be kept from a user with the given role.
+// Raw output may reveal information about hidden questions,
+// so it is kept from users that cannot see them (on assignments that have any).
+func (this *Assignment) ShouldHideGraderOutput(role UserRole) bool {
+    return ((len(this.HiddenQuestions) > 0) && !this.CanSeeHiddenQuestions(role));
+}
+
+// Check if an output file (path relative to the output dir) is declared as visible to students.
+func (this *Assignment) IsStudentArtifact(relPath string) bool {
+    relPath = filepath.Clean(relPath);
+
+    for _, pattern := range this.StudentArtifacts {
+        match, _ := filepath.Match(pattern, relPath);
+        if (match) {
+            return true;
+        }
+    }
+
+    return false;
+}
+
+// Check if a user with the given role can see an output file (path relative to the output dir).
+// Graders (and above) can see all output files.
+func (this *Assignment) CanSeeArtifact(role UserRole, relPath string) bool {
+    if (role >= RoleGrader) {
+        return true;
+    }
+
+    return this.IsStudentArtifact(relPath);
+}
+
 func (this *Assignment) GetCacheDir() string {
     dir := filepath.Join(this.Course.GetCacheDir(), "assignment_" + this.ID);
     util.MkDir(dir);
@@ -169,6 +297,10 @@ func (this *Assignment) GetFileCachePath() string {
     return filepath.Join(this.GetCacheDir(), FILE_CACHE_FILENAME);
 }
 
+func (this *Assignment) GetBuildLogPath() string {
+    return filepath.Join(this.GetCacheDir(), BUILD_LOG_FILENAME);
+}
+
 func (this *Assignment) GetImageLock() *sync.Mutex {
     return this.imageLock;
 }
@@ -0,0 +1,103 @@
+package model
+
+import (
+    "path/filepath"
+    "testing"
+
+    "github.com/edulinq/autograder/common"
+    "github.com/edulinq/autograder/config"
+    "github.com/edulinq/autograder/docker"
+    "github.com/edulinq/autograder/util"
+)
+
+func TestAssignmentGetConfigHash(test *testing.T) {
+    testCases := []struct{modify func(*Assignment); changed bool}{
+        {func(assignment *Assignment) {}, false},
+
+        // Not related to grading.
+        {func(assignment *Assignment) { assignment.Name = "Other Name" }, false},
+        {func(assignment *Assignment) { assignment.DueDate = common.NowTimestamp() }, false},
+        {func(assignment *Assignment) { assignment.HiddenQuestions = []string{"Q1"} }, false},
+        {func(assignment *Assignment) { assignment.StudentArtifacts = []string{"*.png"} }, false},
+        {func(assignment *Assignment) { assignment.ContainerPool = &docker.ContainerPoolInfo{Size: 2} }, false},
+
+        // Related to grading.
+        {func(assignment *Assignment) { assignment.Image = "other-image" }, true},
+        {func(assignment *Assignment) { assignment.Invocation = []string{"bash", "other.sh"} }, true},
+        {func(assignment *Assignment) { assignment.StaticFiles = []*common.FileSpec{common.GetPathFileSpec("other.py")} }, true},
+        {func(assignment *Assignment) { assignment.PostSubmissionFileOperations = []common.FileOperation{common.FileOperation{"cp", "input/a", "work/a"}} }, true},
+        {func(assignment *Assignment) { assignment.PostStaticDockerCommands = []string{"RUN true"} }, true},
+    };
+
+    baseHash, err := getTestConfigHashAssignment().GetConfigHash();
+    if (err != nil) {
+        test.Fatalf("Failed to get base hash: '%v'.", err);
+    }
+
+    for i, testCase := range testCases {
+        assignment := getTestConfigHashAssignment();
+        testCase.modify(assignment);
+
+        hash, err := assignment.GetConfigHash();
+        if (err != nil) {
+            test.Errorf("Case %d: Failed to get hash: '%v'.", i, err);
+            continue;
+        }
+
+        if (testCase.changed != (hash != baseHash)) {
+            test.Errorf("Case %d: Unexpected hash change. Expected change: %v, Base: '%s', Actual: '%s'.", i, testCase.changed, baseHash, hash);
+        }
+    }
+}
+
+// An assignment without an image must load with the default config (where pinned images are required).
+func TestAssignmentLoadDefaultImage(test *testing.T) {
+    defer config.DOCKER_REQUIRE_PINNED.Set(config.DOCKER_REQUIRE_PINNED.Get());
+    config.DOCKER_REQUIRE_PINNED.Set(config.DOCKER_REQUIRE_PINNED.DefaultValue);
+
+    tempDir, err := util.MkDirTemp("autograder-test-default-image-");
+    if (err != nil) {
+        test.Fatalf("Failed to make temp dir: '%v'.", err);
+    }
+
+    files := map[string]string{
+        COURSE_CONFIG_FILENAME: `{"id": "course-default-image"}`,
+        filepath.Join("hw0", ASSIGNMENT_CONFIG_FILENAME): `{"id": "hw0", "invocation": ["bash", "grader.sh"]}`,
+    };
+
+    for path, contents := range files {
+        path = filepath.Join(tempDir, path);
+        util.MkDir(filepath.Dir(path));
+
+        err = util.WriteFile(contents, path);
+        if (err != nil) {
+            test.Fatalf("Failed to write '%s': '%v'.", path, err);
+        }
+    }
+
+    course, err := LoadCourseFromPath(filepath.Join(tempDir, COURSE_CONFIG_FILENAME));
+    if (err != nil) {
+        test.Fatalf("Failed to load course: '%v'.", err);
+    }
+
+    assignment := course.GetAssignment("hw0");
+    if (assignment == nil) {
+        test.Fatalf("Could not find assignment.");
+    }
+
+    if (assignment.Image != docker.DEFAULT_IMAGE) {
+        test.Fatalf("Unexpected image. Expected: '%s', Actual: '%s'.", docker.DEFAULT_IMAGE, assignment.Image);
+    }
+}
+
+func getTestConfigHashAssignment() *Assignment {
+    return &Assignment{
+        ID: "hw0",
+        Name: "HW0",
+        ImageInfo: docker.ImageInfo{
+            Image: "edulinq/autograder.base",
+            Invocation: []string{"bash", "grader.sh"},
+            StaticFiles: []*common.FileSpec{common.GetPathFileSpec("grader.sh")},
+        },
+    };
+}
@@ -4,6 +4,7 @@ import (
     "fmt"
     "path/filepath"
     "slices"
+    "strings"
 
     "github.com/edulinq/autograder/common"
     "github.com/edulinq/autograder/config"
@@ -29,11 +30,22 @@ type Course struct {
     // A common submission limit that assignments can inherit.
     SubmissionLimit *SubmissionLimitInfo `json:"submission-limit,omitempty"`
 
+    // The environment to run graders in (see common.RUNNERS).
+    // Defaults to the server's grader.runner option.
+    Runner string `json:"runner,omitempty"`
+
+    // Only allow users to log in with single sign-on (or sessions/tokens they got from it), not passwords.
+    DisablePasswordLogin bool `json:"disable-password-login,omitempty"`
+
+    // Require staff (graders and above) to use two-factor authentication for privileged (grader and above) endpoints.
+    RequireTOTP bool `json:"require-totp,omitempty"`
+
     Backup []*tasks.BackupTask `json:"backup,omitempty"`
     CourseUpdate []*tasks.CourseUpdateTask `json:"course-update,omitempty"`
     Report []*tasks.ReportTask `json:"report,omitempty"`
     ScoringUpload []*tasks.ScoringUploadTask `json:"scoring-upload,omitempty"`
     EmailLogs []*tasks.EmailLogsTask `json:"email-logs,omitempty"`
+    ImagePrune []*tasks.ImagePruneTask `json:"image-prune,omitempty"`
 
     // Internal fields the autograder will set.
     Assignments map[string]*Assignment `json:"-"`
@@ -84,6 +96,14 @@ func (this *Course) GetAssignmentLMSIDs() ([]string, []string) {
     return lmsIDs, assignmentIDs;
 }
 
+func (this *Course) GetRunner() string {
+    if (this.Runner != "") {
+        return this.Runner;
+    }
+
+    return config.GRADER_RUNNER.Get();
+}
+
 func (this *Course) GetTasks() []tasks.ScheduledTask {
     return this.scheduledTasks;
 }
@@ -121,6 +141,17 @@ func (this *Course) Validate() error {
         }
     }
 
+    this.Runner = strings.ToLower(this.Runner);
+    if ((this.Runner != "") && !slices.Contains(common.RUNNERS, this.Runner)) {
+        return fmt.Errorf("Unknown runner '%s', must be one of: %s.", this.Runner, strings.Join(common.RUNNERS, ", "));
+    }
+
+    // Running graders without any isolation is a server-level choice, a course cannot opt into it.
+    if ((this.Runner == common.RUNNER_NODOCKER) && (config.GRADER_RUNNER.Get() != common.RUNNER_NODOCKER)) {
+        return fmt.Errorf("A course can only use the '%s' runner if it is also the server's runner (see the grader.runner option).",
+                common.RUNNER_NODOCKER);
+    }
+
     // Register tasks.
     this.scheduledTasks = make([]tasks.ScheduledTask, 0);
 
@@ -144,6 +175,10 @@ func (this *Course) Validate() error {
         this.scheduledTasks = append(this.scheduledTasks, task);
     }
 
+    for _, task := range this.ImagePrune {
+        this.scheduledTasks = append(this.scheduledTasks, task);
+    }
+
     // Validate tasks.
     for _, task := range this.scheduledTasks {
         err = task.Validate(this);
@@ -0,0 +1,40 @@
+package model
+
+import (
+    "testing"
+
+    "github.com/edulinq/autograder/common"
+    "github.com/edulinq/autograder/config"
+)
+
+func TestCourseValidateRunner(test *testing.T) {
+    defer config.GRADER_RUNNER.Set(config.GRADER_RUNNER.Get());
+
+    testCases := []struct{runner string; serverRunner string; valid bool}{
+        {"", common.RUNNER_DOCKER, true},
+        {common.RUNNER_PODMAN, common.RUNNER_DOCKER, true},
+        {" BubbleWrap", common.RUNNER_DOCKER, false},
+        {"BubbleWrap", common.RUNNER_DOCKER, true},
+        {common.RUNNER_NODOCKER, common.RUNNER_NODOCKER, true},
+        {common.RUNNER_DOCKER, common.RUNNER_NODOCKER, true},
+
+        // Courses cannot opt into running graders without isolation.
+        {common.RUNNER_NODOCKER, common.RUNNER_DOCKER, false},
+        {"NoDocker", common.RUNNER_BUBBLEWRAP, false},
+
+        {"ZZZ", common.RUNNER_DOCKER, false},
+    };
+
+    for i, testCase := range testCases {
+        config.GRADER_RUNNER.Set(testCase.serverRunner);
+
+        course := &Course{ID: "course101", Runner: testCase.runner};
+        err := course.Validate();
+
+        if (testCase.valid && (err != nil)) {
+            test.Errorf("Case %d: Unexpected validation error: '%v'.", i, err);
+        } else if (!testCase.valid && (err == nil)) {
+            test.Errorf("Case %d: Did not get an expected validation error.", i);
+        }
+    }
+}
@@ -0,0 +1,57 @@
+package model
+
+import (
+    "github.com/edulinq/autograder/util"
+)
+
+// Identify exactly which version of a grader graded a submission.
+// Any field may be empty if it could not be determined (e.g. the course source is not a git repo).
+type GraderVersion struct {
+    // The ID (content digest) of the image that ran the grader (empty for non-container runners).
+    ImageID string `json:"image-id,omitempty"`
+    // The commit of the course source (if the source is a git repo).
+    CommitHash string `json:"commit-hash,omitempty"`
+    // A hash of the assignment's config (see Assignment.GetConfigHash()).
+    ConfigHash string `json:"config-hash,omitempty"`
+}
+
+func (this *GraderVersion) IsEmpty() bool {
+    return ((this == nil) || ((this.ImageID == "") && (this.CommitHash == "") && (this.ConfigHash == "")));
+}
+
+// Check if this version matches a query version.
+// Empty fields in the query match anything.
+func (this *GraderVersion) Matches(query *GraderVersion) bool {
+    if (query == nil) {
+        return true;
+    }
+
+    version := this;
+    if (version == nil) {
+        version = &GraderVersion{};
+    }
+
+    if ((query.ImageID != "") && (query.ImageID != version.ImageID)) {
+        return false;
+    }
+
+    if ((query.CommitHash != "") && (query.CommitHash != version.CommitHash)) {
+        return false;
+    }
+
+    if ((query.ConfigHash != "") && (query.ConfigHash != version.ConfigHash)) {
+        return false;
+    }
+
+    return true;
+}
+
+// Get a hash that identifies a submission's input files (see util.Sha256HexFromDirectory()) graded by a specific grader version.
+// Two submissions with the same hash should get the same grading result.
+func ComputeSubmissionHash(inputHash string, version *GraderVersion) string {
+    if (version == nil) {
+        version = &GraderVersion{};
+    }
+
+    return util.Sha256HexFromString(util.JoinStrings("::", inputHash, version.ImageID, version.CommitHash, version.ConfigHash));
+}
@@ -14,6 +14,8 @@ type GradingResult struct {
     OutputFilesGZip map[string][]byte `json:"output-files-gzip"`
     Stdout string `json:"stdout"`
     Stderr string `json:"stderr"`
+    // The output of any sidecar containers (keyed by sidecar name).
+    SidecarLogs map[string]string `json:"sidecar-logs,omitempty"`
 }
 
 type GradingInfo struct {
@@ -27,6 +29,10 @@ type GradingInfo struct {
     MaxPoints float64 `json:"max_points"`
     Score float64 `json:"score"`
 
+    // The portion of MaxPoints/Score that comes from hidden questions.
+    HiddenMaxPoints float64 `json:"hidden_max_points,omitempty"`
+    HiddenScore float64 `json:"hidden_score,omitempty"`
+
     // Information generally filled out by the grader.
     Name string `json:"name"`
     Questions []*GradedQuestion `json:"questions"`
@@ -37,6 +43,15 @@ type GradingInfo struct {
 
     // Additional pass-through information that the grader can use.
     AdditionalInfo map[string]any `json:"additional-info"`
+
+    // Which version of the grader graded this submission (set by the autograder).
+    GraderVersion *GraderVersion `json:"grader-version,omitempty"`
+
+    // Hashes of the input files, and of the input files plus the grader version (see ComputeSubmissionHash()).
+    InputHash string `json:"input-hash,omitempty"`
+    SubmissionHash string `json:"submission-hash,omitempty"`
+    // If this result was copied from an identical earlier submission (instead of being graded), the full ID of that submission.
+    ReusedFrom string `json:"reused-from,omitempty"`
 }
 
 type GradedQuestion struct {
@@ -46,6 +61,12 @@ type GradedQuestion struct {
     Message string `json:"message"`
     GradingStartTime common.Timestamp `json:"grading_start_time"`
     GradingEndTime common.Timestamp `json:"grading_end_time"`
+
+    // Hidden questions are only shown to students after they are released (see Assignment.HiddenQuestionsReleased()).
+    Hidden bool `json:"hidden,omitempty"`
+
+    // Optional structured results for the individual test cases that make up this question.
+    TestCases []*TestCaseResult `json:"test_cases,omitempty"`
 }
 
 func (this *GradingResult) HasTextOutput() bool {
@@ -118,11 +139,23 @@ func (this GradingInfo) Report() string {
 }
 
 // Fill in the MaxPoints, Score, and (if empty) time fields.
+// Test case results also have their status and diff filled in (see TestCaseResult.Compute()).
 func (this *GradingInfo) ComputePoints() {
     for _, question := range this.Questions {
+        for _, testCase := range question.TestCases {
+            if (testCase != nil) {
+                testCase.Compute();
+            }
+        }
+
         this.Score += question.Score;
         this.MaxPoints += question.MaxPoints;
 
+        if (question.Hidden) {
+            this.HiddenScore += question.Score;
+            this.HiddenMaxPoints += question.MaxPoints;
+        }
+
         if (question.GradingStartTime.IsZero()) {
             question.GradingStartTime = this.GradingStartTime;
         }
@@ -133,6 +166,39 @@ func (this *GradingInfo) ComputePoints() {
     }
 }
 
+func (this GradingInfo) HasHiddenQuestions() bool {
+    for _, question := range this.Questions {
+        if (question.Hidden) {
+            return true;
+        }
+    }
+
+    return false;
+}
+
+// Get a copy of this grading info with all hidden questions removed
+// and the points from hidden questions removed from the totals.
+func (this GradingInfo) ToVisible() *GradingInfo {
+    visible := this;
+    if (!this.HasHiddenQuestions()) {
+        return &visible;
+    }
+
+    visible.Questions = make([]*GradedQuestion, 0, len(this.Questions));
+    for _, question := range this.Questions {
+        if (!question.Hidden) {
+            visible.Questions = append(visible.Questions, question);
+        }
+    }
+
+    visible.MaxPoints -= this.HiddenMaxPoints;
+    visible.Score -= this.HiddenScore;
+    visible.HiddenMaxPoints = 0.0;
+    visible.HiddenScore = 0.0;
+
+    return &visible;
+}
+
 func (this GradedQuestion) Report() string {
     var builder strings.Builder;
 
@@ -144,6 +210,16 @@ func (this GradedQuestion) Report() string {
         }
     }
 
+    for _, testCase := range this.TestCases {
+        if (testCase == nil) {
+            continue;
+        }
+
+        for _, line := range strings.Split(strings.TrimSuffix(testCase.Report(), "\n"), "\n") {
+            builder.WriteString(fmt.Sprintf("    %s\n", line));
+        }
+    }
+
     return builder.String();
 }
 
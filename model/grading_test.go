@@ -5,6 +5,7 @@ import (
     "testing"
     "time"
 
+    "github.com/edulinq/autograder/common"
     "github.com/edulinq/autograder/util"
 )
 
@@ -30,6 +31,72 @@ func TestDates(test *testing.T) {
     }
 }
 
+func TestGradingInfoToVisible(test *testing.T) {
+    gradingInfo := GradingInfo{
+        Questions: []*GradedQuestion{
+            &GradedQuestion{Name: "Q1", MaxPoints: 2.0, Score: 1.0},
+            &GradedQuestion{Name: "Q2", MaxPoints: 3.0, Score: 3.0},
+            &GradedQuestion{Name: "Q3", MaxPoints: 1.0, Score: 0.0, Hidden: true},
+        },
+    };
+
+    assignment := &Assignment{HiddenQuestions: []string{"Q2"}};
+    assignment.MarkHiddenQuestions(&gradingInfo);
+    gradingInfo.ComputePoints();
+
+    if (!util.IsClose(6.0, gradingInfo.MaxPoints) || !util.IsClose(4.0, gradingInfo.Score)) {
+        test.Fatalf("Unexpected full points. Expected: 4 / 6, Actual: %s / %s.",
+                util.FloatToStr(gradingInfo.Score), util.FloatToStr(gradingInfo.MaxPoints));
+    }
+
+    visible := gradingInfo.ToVisible();
+
+    if ((len(visible.Questions) != 1) || (visible.Questions[0].Name != "Q1")) {
+        test.Fatalf("Unexpected visible questions: '%s'.", util.MustToJSON(visible.Questions));
+    }
+
+    if (!util.IsClose(2.0, visible.MaxPoints) || !util.IsClose(1.0, visible.Score)) {
+        test.Fatalf("Unexpected visible points. Expected: 1 / 2, Actual: %s / %s.",
+                util.FloatToStr(visible.Score), util.FloatToStr(visible.MaxPoints));
+    }
+
+    historyItem := gradingInfo.ToHistoryItem().ToVisible();
+    if (!util.IsClose(2.0, historyItem.MaxPoints) || !util.IsClose(1.0, historyItem.Score)) {
+        test.Fatalf("Unexpected visible history points. Expected: 1 / 2, Actual: %s / %s.",
+                util.FloatToStr(historyItem.Score), util.FloatToStr(historyItem.MaxPoints));
+    }
+
+    // The original should not be modified.
+    if (len(gradingInfo.Questions) != 3) {
+        test.Fatalf("Original grading info was modified: '%s'.", util.MustToJSON(gradingInfo));
+    }
+}
+
+func TestAssignmentCanSeeHiddenQuestions(test *testing.T) {
+    past := common.TimestampFromTime(time.Now().Add(-time.Hour));
+    future := common.TimestampFromTime(time.Now().Add(time.Hour));
+
+    testCases := []struct{ dueDate common.Timestamp; releaseTime common.Timestamp; role UserRole; expected bool }{
+        {"", "", RoleStudent, false},
+        {"", "", RoleGrader, true},
+        {past, "", RoleStudent, true},
+        {future, "", RoleStudent, false},
+        {future, "", RoleAdmin, true},
+        {past, future, RoleStudent, false},
+        {future, past, RoleStudent, true},
+        {"", past, RoleOther, true},
+    };
+
+    for i, testCase := range testCases {
+        assignment := &Assignment{DueDate: testCase.dueDate, HiddenReleaseTime: testCase.releaseTime};
+
+        actual := assignment.CanSeeHiddenQuestions(testCase.role);
+        if (testCase.expected != actual) {
+            test.Errorf("Case %d: Unexpected result. Expected: '%v', Actual: '%v'.", i, testCase.expected, actual);
+        }
+    }
+}
+
 type dateTestCase struct {
     Input string
     Expected time.Time
@@ -0,0 +1,74 @@
+package model
+
+// Password hashes are stored along with the algorithm and parameters used to make them.
+// This lets the target parameters (see config.PASSWORD_HASH_*) be raised without invalidating existing passwords,
+// a password with old parameters is rehashed the next time the user logs in with it (see User.PasswordNeedsRehash()).
+
+import (
+    "fmt"
+
+    "golang.org/x/crypto/argon2"
+
+    "github.com/edulinq/autograder/config"
+)
+
+const PASSWORD_HASH_ALGORITHM_ARGON2ID = "argon2id";
+
+type PasswordHashParams struct {
+    Algorithm string `json:"algorithm"`
+    Time uint32 `json:"time"`
+    MemoryKB uint32 `json:"memory-kb"`
+    Threads uint8 `json:"threads"`
+    KeyLen uint32 `json:"key-len"`
+}
+
+// The parameters for hashes from before parameters were stored.
+func GetLegacyPasswordHashParams() *PasswordHashParams {
+    return &PasswordHashParams{
+        Algorithm: PASSWORD_HASH_ALGORITHM_ARGON2ID,
+        Time: ARGON2_TIME,
+        MemoryKB: ARGON2_MEM_KB,
+        Threads: ARGON2_THREADS,
+        KeyLen: ARGON2_KEY_LEN_BYTES,
+    };
+}
+
+// The parameters that new hashes should use.
+func GetTargetPasswordHashParams() *PasswordHashParams {
+    return &PasswordHashParams{
+        Algorithm: PASSWORD_HASH_ALGORITHM_ARGON2ID,
+        Time: uint32(config.PASSWORD_HASH_TIME.Get()),
+        MemoryKB: uint32(config.PASSWORD_HASH_MEMORY_KB.Get()),
+        Threads: uint8(config.PASSWORD_HASH_THREADS.Get()),
+        KeyLen: ARGON2_KEY_LEN_BYTES,
+    };
+}
+
+func (this *PasswordHashParams) Validate() error {
+    if (this.Algorithm != PASSWORD_HASH_ALGORITHM_ARGON2ID) {
+        return fmt.Errorf("Unknown password hash algorithm: '%s'.", this.Algorithm);
+    }
+
+    if ((this.Time == 0) || (this.MemoryKB == 0) || (this.Threads == 0) || (this.KeyLen == 0)) {
+        return fmt.Errorf("Password hash parameters must be positive: '%+v'.", *this);
+    }
+
+    return nil;
+}
+
+func (this *PasswordHashParams) Equals(other *PasswordHashParams) bool {
+    if ((this == nil) || (other == nil)) {
+        return (this == other);
+    }
+
+    return (*this == *other);
+}
+
+func (this *PasswordHashParams) hash(hashPass string, salt []byte) ([]byte, error) {
+    err := this.Validate();
+    if (err != nil) {
+        return nil, err;
+    }
+
+    return argon2.IDKey([]byte(hashPass), salt, this.Time, this.MemoryKB, this.Threads, this.KeyLen), nil;
+}
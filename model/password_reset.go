@@ -0,0 +1,154 @@
+package model
+
+// Password resets let a user who forgot their password set a new one
+// using a single-use token that is emailed to them.
+// Like API tokens, only a hash of the reset token is stored.
+
+import (
+    "crypto/subtle"
+    "fmt"
+    "net/url"
+    "time"
+
+    "github.com/edulinq/autograder/common"
+    "github.com/edulinq/autograder/config"
+    "github.com/edulinq/autograder/email"
+    "github.com/edulinq/autograder/log"
+    "github.com/edulinq/autograder/util"
+)
+
+const PASSWORD_RESET_TOKEN_LEN = 32;
+
+// After this many bad tokens, the outstanding reset is cancelled (and a new one must be requested).
+const PASSWORD_RESET_MAX_FAILED_ATTEMPTS = 5;
+
+type PasswordReset struct {
+    // A hex encoding of a sha256 hash of the cleartext token.
+    // Empty when there is no outstanding reset.
+    Hash string `json:"hash,omitempty"`
+    ExpirationTime common.Timestamp `json:"expiration-time,omitempty"`
+    FailedAttempts int `json:"failed-attempts,omitempty"`
+
+    // When resets were recently requested (used for rate limiting).
+    RequestTimes []common.Timestamp `json:"request-times,omitempty"`
+}
+
+// Start a new password reset (replacing any outstanding one) and return the cleartext token.
+// If the user has already requested |maxRequests| resets within |window|, then no reset is started and an empty token is returned.
+func (this *ServerUser) StartPasswordReset(now time.Time, ttl time.Duration, window time.Duration, maxRequests int) (string, error) {
+    if (this.PasswordReset == nil) {
+        this.PasswordReset = &PasswordReset{};
+    }
+
+    reset := this.PasswordReset;
+
+    // Forget requests that are outside the window.
+    requestTimes := make([]common.Timestamp, 0, len(reset.RequestTimes) + 1);
+    for _, requestTime := range reset.RequestTimes {
+        instance, err := requestTime.Time();
+        if (err != nil) {
+            log.Warn("Bad password reset request time, ignoring.", err, log.NewUserAttr(this.Email));
+            continue;
+        }
+
+        if (now.Sub(instance) < window) {
+            requestTimes = append(requestTimes, requestTime);
+        }
+    }
+
+    reset.RequestTimes = requestTimes;
+
+    if (len(reset.RequestTimes) >= maxRequests) {
+        return "", nil;
+    }
+
+    cleartext, err := util.RandHex(PASSWORD_RESET_TOKEN_LEN);
+    if (err != nil) {
+        return "", fmt.Errorf("Failed to generate password reset token: '%w'.", err);
+    }
+
+    reset.Hash = util.Sha256HexFromString(cleartext);
+    reset.ExpirationTime = common.TimestampFromTime(now.Add(ttl));
+    reset.FailedAttempts = 0;
+    reset.RequestTimes = append(reset.RequestTimes, common.TimestampFromTime(now));
+
+    return cleartext, nil;
+}
+
+// Check (and use up) a reset token.
+// Returns true if the token matches the outstanding (unexpired) reset.
+// A matching token can only be used once,
+// and too many bad tokens will cancel the outstanding reset.
+func (this *ServerUser) UsePasswordReset(cleartext string, now time.Time) bool {
+    reset := this.PasswordReset;
+    if ((reset == nil) || (reset.Hash == "")) {
+        return false;
+    }
+
+    hash := util.Sha256HexFromString(cleartext);
+    matches := (subtle.ConstantTimeCompare([]byte(reset.Hash), []byte(hash)) == 1);
+
+    if (matches && !reset.IsExpired(now)) {
+        reset.clear();
+        return true;
+    }
+
+    reset.FailedAttempts++;
+    if (reset.FailedAttempts >= PASSWORD_RESET_MAX_FAILED_ATTEMPTS) {
+        log.Warn("Too many bad password reset tokens, cancelling reset.", log.NewUserAttr(this.Email));
+        reset.clear();
+    }
+
+    return false;
+}
+
+func (this *PasswordReset) IsExpired(now time.Time) bool {
+    expiration, err := this.ExpirationTime.Time();
+    if (err != nil) {
+        log.Warn("Bad password reset expiration time, treating reset as expired.", err);
+        return true;
+    }
+
+    return !now.Before(expiration);
+}
+
+// Cancel the outstanding reset (recent request times are kept).
+func (this *PasswordReset) clear() {
+    this.Hash = "";
+    this.ExpirationTime = "";
+    this.FailedAttempts = 0;
+}
+
+func SendPasswordResetEmail(address string, cleartext string, expirationTime common.Timestamp) error {
+    subject, body := composePasswordResetEmail(address, cleartext, expirationTime);
+
+    err := email.Send([]string{address}, subject, body, false);
+    if (err != nil) {
+        log.Error("Failed to send password reset email.", err, log.NewUserAttr(address));
+        return err;
+    }
+
+    log.Info("Password reset email sent.", log.NewUserAttr(address));
+
+    return nil;
+}
+
+func composePasswordResetEmail(address string, cleartext string, expirationTime common.Timestamp) (string, string) {
+    subject := "Autograder -- Password Reset";
+
+    body :=
+        "Hello,\n" +
+        fmt.Sprintf("\nA password reset was requested for the autograder account '%s'.\n", address) +
+        "If you did not request a reset, you can ignore this email.\n";
+
+    resetURL := config.PASSWORD_RESET_URL.Get();
+    if (resetURL != "") {
+        body += fmt.Sprintf("\nTo set a new password, visit: %s?email=%s&reset-token=%s\n",
+                resetURL, url.QueryEscape(address), cleartext);
+    }
+
+    body += fmt.Sprintf("\nYour reset token is '%s' (no quotes).\n", cleartext);
+    body += fmt.Sprintf("This token can only be used once, and expires at %s.\n", expirationTime.ShouldPrettyString());
+
+    return subject, body;
+}
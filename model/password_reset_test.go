@@ -0,0 +1,122 @@
+package model
+
+import (
+    "testing"
+    "time"
+)
+
+func TestPasswordResetSingleUse(test *testing.T) {
+    user := &ServerUser{Email: "a@test.com"};
+    now := time.Now();
+
+    cleartext, err := user.StartPasswordReset(now, time.Hour, time.Hour, 3);
+    if (err != nil) {
+        test.Fatalf("Failed to start reset: '%v'.", err);
+    }
+
+    if (cleartext == "") {
+        test.Fatalf("Reset was not started.");
+    }
+
+    if (user.UsePasswordReset("ZZZ", now)) {
+        test.Fatalf("Bad token was accepted.");
+    }
+
+    if (!user.UsePasswordReset(cleartext, now)) {
+        test.Fatalf("Good token was not accepted.");
+    }
+
+    if (user.UsePasswordReset(cleartext, now)) {
+        test.Fatalf("Token was accepted twice.");
+    }
+}
+
+func TestPasswordResetExpired(test *testing.T) {
+    user := &ServerUser{Email: "a@test.com"};
+    now := time.Now();
+
+    cleartext, err := user.StartPasswordReset(now, time.Hour, time.Hour, 3);
+    if (err != nil) {
+        test.Fatalf("Failed to start reset: '%v'.", err);
+    }
+
+    if (user.UsePasswordReset(cleartext, now.Add(2 * time.Hour))) {
+        test.Fatalf("Expired token was accepted.");
+    }
+}
+
+func TestPasswordResetReplaced(test *testing.T) {
+    user := &ServerUser{Email: "a@test.com"};
+    now := time.Now();
+
+    first, err := user.StartPasswordReset(now, time.Hour, time.Hour, 3);
+    if (err != nil) {
+        test.Fatalf("Failed to start reset: '%v'.", err);
+    }
+
+    second, err := user.StartPasswordReset(now, time.Hour, time.Hour, 3);
+    if (err != nil) {
+        test.Fatalf("Failed to start reset: '%v'.", err);
+    }
+
+    if (user.UsePasswordReset(first, now)) {
+        test.Fatalf("Replaced token was accepted.");
+    }
+
+    if (!user.UsePasswordReset(second, now)) {
+        test.Fatalf("Newest token was not accepted.");
+    }
+}
+
+func TestPasswordResetMaxFailedAttempts(test *testing.T) {
+    user := &ServerUser{Email: "a@test.com"};
+    now := time.Now();
+
+    cleartext, err := user.StartPasswordReset(now, time.Hour, time.Hour, 3);
+    if (err != nil) {
+        test.Fatalf("Failed to start reset: '%v'.", err);
+    }
+
+    for i := 0; i < PASSWORD_RESET_MAX_FAILED_ATTEMPTS; i++ {
+        user.UsePasswordReset("ZZZ", now);
+    }
+
+    if (user.UsePasswordReset(cleartext, now)) {
+        test.Fatalf("Token was accepted after too many failed attempts.");
+    }
+}
+
+func TestPasswordResetRateLimit(test *testing.T) {
+    user := &ServerUser{Email: "a@test.com"};
+    now := time.Now();
+
+    for i := 0; i < 3; i++ {
+        cleartext, err := user.StartPasswordReset(now, time.Hour, time.Hour, 3);
+        if (err != nil) {
+            test.Fatalf("Request %d: Failed to start reset: '%v'.", i, err);
+        }
+
+        if (cleartext == "") {
+            test.Fatalf("Request %d: Reset was rate limited too early.", i);
+        }
+    }
+
+    cleartext, err := user.StartPasswordReset(now, time.Hour, time.Hour, 3);
+    if (err != nil) {
+        test.Fatalf("Failed to start reset: '%v'.", err);
+    }
+
+    if (cleartext != "") {
+        test.Fatalf("Reset was not rate limited.");
+    }
+
+    // Once the window passes, requests are allowed again.
+    cleartext, err = user.StartPasswordReset(now.Add(2 * time.Hour), time.Hour, time.Hour, 3);
+    if (err != nil) {
+        test.Fatalf("Failed to start reset: '%v'.", err);
+    }
+
+    if (cleartext == "") {
+        test.Fatalf("Reset was rate limited after the window passed.");
+    }
+}
@@ -0,0 +1,205 @@
+package model
+
+// Users have a single server-wide account (credentials, name, tokens)
+// and an enrollment (role, LMS ID) for each course they are in.
+// Most of the system works with course users (model.User),
+// which are just a view of an account in the context of one course.
+
+import (
+    "slices"
+
+    "github.com/edulinq/autograder/log"
+)
+
+// Roles for the server as a whole (as opposed to roles in a course, see UserRole).
+type ServerRole string;
+
+const (
+    ServerRoleUser ServerRole = "user"
+    // Can manage all courses (and acts as an owner in every course).
+    ServerRoleAdmin ServerRole = "admin"
+)
+
+type ServerUser struct {
+    Email string `json:"email"`
+    Name string `json:"name"`
+    Pass string `json:"pass"`
+    Salt string `json:"salt"`
+    PassParams *PasswordHashParams `json:"pass-params,omitempty"`
+    PassVersion string `json:"pass-version,omitempty"`
+
+    // An empty role is a normal user.
+    Role ServerRole `json:"role,omitempty"`
+
+    Tokens []*APIToken `json:"tokens,omitempty"`
+
+    // Only set if the user has (started to) set up two-factor authentication.
+    TOTP *TOTPInfo `json:"totp,omitempty"`
+
+    // Only set if the user has requested a password reset.
+    PasswordReset *PasswordReset `json:"password-reset,omitempty"`
+
+    // Keyed by course ID.
+    Enrollments map[string]*Enrollment `json:"enrollments"`
+}
+
+type Enrollment struct {
+    Role UserRole `json:"role"`
+    LMSID string `json:"lms-id"`
+}
+
+// Create a new account from a course user (enrolled in that course).
+func NewServerUserFromCourseUser(courseID string, user *User) *ServerUser {
+    serverUser := &ServerUser{
+        Email: user.Email,
+        Enrollments: make(map[string]*Enrollment),
+    };
+
+    serverUser.SetCourseUser(courseID, user);
+
+    return serverUser;
+}
+
+func (this ServerRole) IsValid() bool {
+    return ((this == ServerRoleUser) || (this == ServerRoleAdmin));
+}
+
+func (this *ServerUser) LogValue() []*log.Attr {
+    return []*log.Attr{log.NewUserAttr(this.Email)};
+}
+
+func (this *ServerUser) IsAdmin() bool {
+    return (this.Role == ServerRoleAdmin);
+}
+
+func (this *ServerUser) IsEnrolled(courseID string) bool {
+    _, ok := this.Enrollments[courseID];
+    return ok;
+}
+
+// Get the sorted IDs of the courses this user is enrolled in.
+func (this *ServerUser) GetCourseIDs() []string {
+    courseIDs := make([]string, 0, len(this.Enrollments));
+    for courseID, _ := range this.Enrollments {
+        courseIDs = append(courseIDs, courseID);
+    }
+
+    slices.Sort(courseIDs);
+
+    return courseIDs;
+}
+
+// Get the view of this account in a course.
+// Returns nil if the user is not enrolled in the course.
+func (this *ServerUser) ToCourseUser(courseID string) *User {
+    enrollment, ok := this.Enrollments[courseID];
+    if (!ok) {
+        return nil;
+    }
+
+    user := this.ToUser();
+    user.Role = enrollment.Role;
+    user.LMSID = enrollment.LMSID;
+
+    return user;
+}
+
+// Get the view of this account outside of any course (the user will not have a role).
+// This is mostly useful for checking credentials.
+func (this *ServerUser) ToUser() *User {
+    return &User{
+        Email: this.Email,
+        Name: this.Name,
+        Role: RoleUnknown,
+        Pass: this.Pass,
+        Salt: this.Salt,
+        PassParams: this.PassParams,
+        PassVersion: this.PassVersion,
+        Tokens: this.Tokens,
+        TOTPEnabled: this.HasTOTP(),
+    };
+}
+
+// Set the account's password (see User.SetPassword()).
+func (this *ServerUser) SetPassword(hashPass string) error {
+    user := this.ToUser();
+
+    err := user.SetPassword(hashPass);
+    if (err != nil) {
+        return err;
+    }
+
+    this.setPasswordFields(user);
+
+    return nil;
+}
+
+// Rehash the account's (unchanged) password (see User.RehashPassword()).
+func (this *ServerUser) RehashPassword(hashPass string) error {
+    user := this.ToUser();
+
+    err := user.RehashPassword(hashPass);
+    if (err != nil) {
+        return err;
+    }
+
+    this.setPasswordFields(user);
+
+    return nil;
+}
+
+func (this *ServerUser) setPasswordFields(user *User) {
+    this.Pass = user.Pass;
+    this.Salt = user.Salt;
+    this.PassParams = user.PassParams;
+    this.PassVersion = user.PassVersion;
+}
+
+// Overwrite this account (and its enrollment in the given course) with a course user.
+// A server admin's elevated role (see User.ServerAdmin) is never saved as an enrollment.
+func (this *ServerUser) SetCourseUser(courseID string, user *User) {
+    this.Name = user.Name;
+    this.Pass = user.Pass;
+    this.Salt = user.Salt;
+    this.PassParams = user.PassParams;
+    this.PassVersion = user.PassVersion;
+    this.Tokens = user.Tokens;
+
+    if (user.ServerAdmin) {
+        return;
+    }
+
+    this.Enroll(courseID, user);
+}
+
+// Set only the enrollment information (role and LMS ID) from a course user.
+// The account itself (name, credentials) is left alone, unless it is missing.
+func (this *ServerUser) Enroll(courseID string, user *User) {
+    if (this.Enrollments == nil) {
+        this.Enrollments = make(map[string]*Enrollment);
+    }
+
+    this.Enrollments[courseID] = &Enrollment{
+        Role: user.Role,
+        LMSID: user.LMSID,
+    };
+
+    if (this.Name == "") {
+        this.Name = user.Name;
+    }
+
+    if (this.Pass == "") {
+        this.Pass = user.Pass;
+        this.Salt = user.Salt;
+        this.PassParams = user.PassParams;
+        this.PassVersion = user.PassVersion;
+    }
+}
+
+// Remove an enrollment.
+// Returns true if the user was enrolled in the course.
+func (this *ServerUser) Unenroll(courseID string) bool {
+    _, ok := this.Enrollments[courseID];
+    delete(this.Enrollments, courseID);
+    return ok;
+}
@@ -0,0 +1,196 @@
+package model
+
+// Session tokens are short-lived signed tokens that a user gets by logging in (with their password or single sign-on).
+// A session token can be used instead of a password, and a (longer-lived) refresh token can be exchanged for new tokens.
+// Tokens are signed (HMAC-SHA256) with a server key, and carry the ID of the key that signed them.
+// This allows keys to be rotated while tokens signed by older keys are still valid.
+
+import (
+    "crypto/hmac"
+    "crypto/sha256"
+    "encoding/base64"
+    "encoding/hex"
+    "encoding/json"
+    "fmt"
+    "strings"
+    "time"
+
+    "github.com/edulinq/autograder/common"
+    "github.com/edulinq/autograder/util"
+)
+
+const (
+    SESSION_TOKEN_TYPE_SESSION = "session"
+    SESSION_TOKEN_TYPE_REFRESH = "refresh"
+    // Not a session, but the (signed) state for a single sign-on login that is in progress.
+    SESSION_TOKEN_TYPE_OIDC_STATE = "oidc-state"
+)
+
+const (
+    SESSION_TOKEN_PREFIX = "s1";
+    SESSION_KEY_LEN = 32;
+)
+
+// A key used to sign session tokens.
+type SessionKey struct {
+    ID string `json:"id"`
+    // Hex encoded.
+    Secret string `json:"secret"`
+    CreationTime common.Timestamp `json:"creation-time"`
+}
+
+// The contents of a session token.
+type SessionClaims struct {
+    Type string `json:"type"`
+    KeyID string `json:"key-id"`
+    CourseID string `json:"course-id"`
+    Email string `json:"email"`
+    // Ties the token to the user's current password (see GetSessionPassCheck()).
+    PassCheck string `json:"pass-check"`
+    IssuedTime common.Timestamp `json:"issued-time"`
+    ExpirationTime common.Timestamp `json:"expiration-time"`
+    // The login used a second factor (see ServerUser.CheckSecondFactor()).
+    SecondFactor bool `json:"second-factor,omitempty"`
+    // Only used for single sign-on state.
+    Nonce string `json:"nonce,omitempty"`
+}
+
+func NewSessionKey() (*SessionKey, error) {
+    secret, err := util.RandHex(SESSION_KEY_LEN);
+    if (err != nil) {
+        return nil, fmt.Errorf("Failed to generate session key: '%w'.", err);
+    }
+
+    return &SessionKey{
+        ID: util.UUID(),
+        Secret: secret,
+        CreationTime: common.NowTimestamp(),
+    }, nil;
+}
+
+func NewSessionClaims(tokenType string, courseID string, user *User, duration time.Duration) *SessionClaims {
+    now := time.Now();
+
+    return &SessionClaims{
+        Type: tokenType,
+        CourseID: courseID,
+        Email: user.Email,
+        PassCheck: GetSessionPassCheck(user),
+        IssuedTime: common.TimestampFromTime(now),
+        ExpirationTime: common.TimestampFromTime(now.Add(duration)),
+    };
+}
+
+// Get a short value that changes whenever the user's password changes
+// (so that changing a password ends all existing sessions).
+// Rehashing a password (see User.RehashPassword()) does not change this value.
+func GetSessionPassCheck(user *User) string {
+    if (user.PassVersion != "") {
+        return user.PassVersion;
+    }
+
+    // Passwords set before versions were stored.
+    return util.Sha256HexFromString(user.Salt + user.Pass)[0:16];
+}
+
+// Sign the claims with the given key and return the token.
+func SignSessionClaims(key *SessionKey, claims *SessionClaims) (string, error) {
+    claims.KeyID = key.ID;
+
+    payload, err := util.ToJSON(claims);
+    if (err != nil) {
+        return "", fmt.Errorf("Failed to serialize session claims: '%w'.", err);
+    }
+
+    encodedPayload := base64.RawURLEncoding.EncodeToString([]byte(payload));
+
+    signature, err := computeSessionSignature(key, encodedPayload);
+    if (err != nil) {
+        return "", err;
+    }
+
+    return strings.Join([]string{SESSION_TOKEN_PREFIX, encodedPayload, base64.RawURLEncoding.EncodeToString(signature)}, "."), nil;
+}
+
+// Verify a token (using any of the given keys) and return its claims.
+// An error is returned if the token is malformed, has a bad signature, is of the wrong type, or is expired.
+func ParseSessionToken(token string, tokenType string, keys []*SessionKey) (*SessionClaims, error) {
+    parts := strings.Split(token, ".");
+    if ((len(parts) != 3) || (parts[0] != SESSION_TOKEN_PREFIX)) {
+        return nil, fmt.Errorf("Malformed session token.");
+    }
+
+    payload, err := base64.RawURLEncoding.DecodeString(parts[1]);
+    if (err != nil) {
+        return nil, fmt.Errorf("Malformed session token payload: '%w'.", err);
+    }
+
+    signature, err := base64.RawURLEncoding.DecodeString(parts[2]);
+    if (err != nil) {
+        return nil, fmt.Errorf("Malformed session token signature: '%w'.", err);
+    }
+
+    var claims SessionClaims;
+    err = json.Unmarshal(payload, &claims);
+    if (err != nil) {
+        return nil, fmt.Errorf("Malformed session token claims: '%w'.", err);
+    }
+
+    var key *SessionKey = nil;
+    for _, candidate := range keys {
+        if (candidate.ID == claims.KeyID) {
+            key = candidate;
+            break;
+        }
+    }
+
+    if (key == nil) {
+        return nil, fmt.Errorf("Session token was signed with an unknown (or retired) key: '%s'.", claims.KeyID);
+    }
+
+    expectedSignature, err := computeSessionSignature(key, parts[1]);
+    if (err != nil) {
+        return nil, err;
+    }
+
+    if (!hmac.Equal(signature, expectedSignature)) {
+        return nil, fmt.Errorf("Session token has a bad signature.");
+    }
+
+    if (claims.Type != tokenType) {
+        return nil, fmt.Errorf("Wrong type of session token. Expected: '%s', Actual: '%s'.", tokenType, claims.Type);
+    }
+
+    expiration, err := claims.ExpirationTime.Time();
+    if (err != nil) {
+        return nil, fmt.Errorf("Session token has a bad expiration time: '%w'.", err);
+    }
+
+    if (!time.Now().Before(expiration)) {
+        return nil, fmt.Errorf("Session token is expired.");
+    }
+
+    return &claims, nil;
+}
+
+// Check that the claims are for this course and user (and the user's current password).
+func (this *SessionClaims) Matches(courseID string, user *User) bool {
+    return ((this.CourseID == courseID) && this.MatchesUser(user));
+}
+
+// Check that the claims are for this user (and the user's current password), in any course.
+func (this *SessionClaims) MatchesUser(user *User) bool {
+    return ((this.Email == user.Email) && hmac.Equal([]byte(this.PassCheck), []byte(GetSessionPassCheck(user))));
+}
+
+func computeSessionSignature(key *SessionKey, encodedPayload string) ([]byte, error) {
+    secret, err := hex.DecodeString(key.Secret);
+    if (err != nil) {
+        return nil, fmt.Errorf("Bad session key secret for key '%s': '%w'.", key.ID, err);
+    }
+
+    mac := hmac.New(sha256.New, secret);
+    mac.Write([]byte(encodedPayload));
+
+    return mac.Sum(nil), nil;
+}
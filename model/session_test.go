@@ -0,0 +1,139 @@
+package model
+
+import (
+    "strings"
+    "testing"
+    "time"
+)
+
+func TestSessionTokenRoundTrip(test *testing.T) {
+    oldKey := mustNewSessionKey(test);
+    key := mustNewSessionKey(test);
+    retiredKey := mustNewSessionKey(test);
+
+    keys := []*SessionKey{key, oldKey};
+
+    user := &User{Email: "student@test.com"};
+    user.SetPassword("abc");
+
+    validToken := mustSignSession(test, key, NewSessionClaims(SESSION_TOKEN_TYPE_SESSION, "course101", user, time.Hour));
+    oldKeyToken := mustSignSession(test, oldKey, NewSessionClaims(SESSION_TOKEN_TYPE_SESSION, "course101", user, time.Hour));
+    retiredKeyToken := mustSignSession(test, retiredKey, NewSessionClaims(SESSION_TOKEN_TYPE_SESSION, "course101", user, time.Hour));
+    refreshToken := mustSignSession(test, key, NewSessionClaims(SESSION_TOKEN_TYPE_REFRESH, "course101", user, time.Hour));
+    expiredToken := mustSignSession(test, key, NewSessionClaims(SESSION_TOKEN_TYPE_SESSION, "course101", user, -time.Minute));
+
+    // Swap in a payload from another token (keeping the original signature).
+    validParts := strings.Split(validToken, ".");
+    refreshParts := strings.Split(refreshToken, ".");
+    tamperedToken := strings.Join([]string{validParts[0], refreshParts[1], validParts[2]}, ".");
+
+    testCases := []struct{token string; tokenType string; valid bool}{
+        {validToken, SESSION_TOKEN_TYPE_SESSION, true},
+        {oldKeyToken, SESSION_TOKEN_TYPE_SESSION, true},
+        {refreshToken, SESSION_TOKEN_TYPE_REFRESH, true},
+
+        {retiredKeyToken, SESSION_TOKEN_TYPE_SESSION, false},
+        {refreshToken, SESSION_TOKEN_TYPE_SESSION, false},
+        {validToken, SESSION_TOKEN_TYPE_REFRESH, false},
+        {expiredToken, SESSION_TOKEN_TYPE_SESSION, false},
+        {tamperedToken, SESSION_TOKEN_TYPE_REFRESH, false},
+        {validToken + "Z", SESSION_TOKEN_TYPE_SESSION, false},
+        {"", SESSION_TOKEN_TYPE_SESSION, false},
+        {"ZZZ", SESSION_TOKEN_TYPE_SESSION, false},
+        {"s1.ZZZ.ZZZ", SESSION_TOKEN_TYPE_SESSION, false},
+    };
+
+    for i, testCase := range testCases {
+        claims, err := ParseSessionToken(testCase.token, testCase.tokenType, keys);
+        if (testCase.valid && (err != nil)) {
+            test.Errorf("Case %d: Failed to parse valid token: '%v'.", i, err);
+            continue;
+        }
+
+        if (!testCase.valid) {
+            if (err == nil) {
+                test.Errorf("Case %d: Parsed an invalid token.", i);
+            }
+
+            continue;
+        }
+
+        if (!claims.Matches("course101", user)) {
+            test.Errorf("Case %d: Claims do not match the user: '%+v'.", i, claims);
+        }
+    }
+}
+
+func TestSessionClaimsMatches(test *testing.T) {
+    user := &User{Email: "student@test.com"};
+    user.SetPassword("abc");
+
+    claims := NewSessionClaims(SESSION_TOKEN_TYPE_SESSION, "course101", user, time.Hour);
+
+    if (!claims.Matches("course101", user)) {
+        test.Fatalf("Claims do not match their own user.");
+    }
+
+    if (claims.Matches("course102", user)) {
+        test.Fatalf("Claims match another course.");
+    }
+
+    otherUser := &User{Email: "other@test.com", Pass: user.Pass, Salt: user.Salt};
+    if (claims.Matches("course101", otherUser)) {
+        test.Fatalf("Claims match another user.");
+    }
+
+    // Rehashing a password keeps sessions.
+    user.RehashPassword("abc");
+    if (!claims.Matches("course101", user)) {
+        test.Fatalf("Claims do not match after a password rehash.");
+    }
+
+    // Changing a password ends sessions.
+    user.SetPassword("abc");
+    if (claims.Matches("course101", user)) {
+        test.Fatalf("Claims match after a password change.");
+    }
+}
+
+func TestSessionClaimsMatchesLegacyRehash(test *testing.T) {
+    user := &User{Email: "student@test.com"};
+    user.SetPassword("abc");
+
+    // Passwords from before versions were stored.
+    user.PassVersion = "";
+
+    claims := NewSessionClaims(SESSION_TOKEN_TYPE_SESSION, "course101", user, time.Hour);
+
+    user.RehashPassword("abc");
+    if (user.PassVersion == "") {
+        test.Fatalf("Rehashing did not set a password version.");
+    }
+
+    if (!claims.Matches("course101", user)) {
+        test.Fatalf("Claims do not match after a password rehash.");
+    }
+
+    user.SetPassword("abc");
+    if (claims.Matches("course101", user)) {
+        test.Fatalf("Claims match after a password change.");
+    }
+}
+
+func mustNewSessionKey(test *testing.T) *SessionKey {
+    key, err := NewSessionKey();
+    if (err != nil) {
+        test.Fatalf("Failed to create session key: '%v'.", err);
+    }
+
+    return key;
+}
+
+func mustSignSession(test *testing.T, key *SessionKey, claims *SessionClaims) string {
+    token, err := SignSessionClaims(key, claims);
+    if (err != nil) {
+        test.Fatalf("Failed to sign session claims: '%v'.", err);
+    }
+
+    return token;
+}
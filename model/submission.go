@@ -18,7 +18,10 @@ type SubmissionHistoryItem struct {
     Message string `json:"message"`
     MaxPoints float64 `json:"max_points"`
     Score float64 `json:"score"`
+    HiddenMaxPoints float64 `json:"hidden_max_points,omitempty"`
+    HiddenScore float64 `json:"hidden_score,omitempty"`
     GradingStartTime common.Timestamp `json:"grading_start_time"`
+    GraderVersion *GraderVersion `json:"grader-version,omitempty"`
 }
 
 func (this GradingInfo) ToHistoryItem() *SubmissionHistoryItem {
@@ -31,6 +34,21 @@ func (this GradingInfo) ToHistoryItem() *SubmissionHistoryItem {
         Message: this.Message,
         MaxPoints: this.MaxPoints,
         Score: this.Score,
+        HiddenMaxPoints: this.HiddenMaxPoints,
+        HiddenScore: this.HiddenScore,
         GradingStartTime: this.GradingStartTime,
+        GraderVersion: this.GraderVersion,
     };
 }
+
+// Get a copy of this item with the points from hidden questions removed.
+func (this SubmissionHistoryItem) ToVisible() *SubmissionHistoryItem {
+    visible := this;
+
+    visible.MaxPoints -= this.HiddenMaxPoints;
+    visible.Score -= this.HiddenScore;
+    visible.HiddenMaxPoints = 0.0;
+    visible.HiddenScore = 0.0;
+
+    return &visible;
+}
@@ -70,10 +70,21 @@ func LoadGradingResult(resultPath string) (*GradingResult, error) {
         return nil, fmt.Errorf("Unable to gzip files in submission output dir '%s': '%w'.", submissionOutputDir, err);
     }
 
+    // Only submissions that used sidecars will have sidecar logs.
+    var sidecarLogs map[string]string = nil;
+    sidecarLogsPath := filepath.Join(baseSubmissionDir, common.SUBMISSION_SIDECAR_LOGS_FILENAME);
+    if (util.PathExists(sidecarLogsPath)) {
+        err = util.JSONFromFile(sidecarLogsPath, &sidecarLogs);
+        if (err != nil) {
+            return nil, fmt.Errorf("Failed to load sidecar logs '%s': '%w'.", sidecarLogsPath, err);
+        }
+    }
+
     return &GradingResult{
         Info: &gradingInfo,
         InputFilesGZip: inputFileContents,
         OutputFilesGZip: outputFileContents,
+        SidecarLogs: sidecarLogs,
     }, nil;
 }
 
@@ -0,0 +1,13 @@
+package tasks
+
+type ImagePruneTask struct {
+    *BaseTask
+
+    DryRun bool `json:"dry-run,omitempty"`
+}
+
+func (this *ImagePruneTask) Validate(course TaskCourse) error {
+    this.BaseTask.Name = "image-prune";
+
+    return this.BaseTask.Validate(course);
+}
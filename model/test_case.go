@@ -0,0 +1,125 @@
+package model
+
+// Structured test-case results that graders can attach to a graded question
+// (instead of formatting expected/actual output into the question's message).
+
+import (
+    "fmt"
+    "strings"
+
+    "github.com/edulinq/autograder/util"
+)
+
+const (
+    TEST_CASE_STATUS_PASS = "pass"
+    TEST_CASE_STATUS_FAIL = "fail"
+    TEST_CASE_STATUS_ERROR = "error"
+    TEST_CASE_STATUS_SKIP = "skip"
+)
+
+// Long fields are truncated to this many lines in reports (the full text is still available in the grading info).
+const MAX_REPORT_TEST_CASE_LINES = 20;
+const MAX_REPORT_TEST_CASE_LINE_LENGTH = 200;
+
+type TestCaseResult struct {
+    Name string `json:"name"`
+    // One of the TEST_CASE_STATUS_* values.
+    // If empty, this will be inferred from the expected and actual output.
+    Status string `json:"status,omitempty"`
+    Message string `json:"message,omitempty"`
+
+    Input string `json:"input,omitempty"`
+    Expected string `json:"expected,omitempty"`
+    Actual string `json:"actual,omitempty"`
+
+    // A line diff between the expected and actual output (see util.LineDiff()).
+    // Computed by the autograder if the grader does not provide one.
+    Diff string `json:"diff,omitempty"`
+}
+
+type reportField struct {
+    label string
+    text string
+}
+
+// Fill in the status and diff (if they are empty).
+func (this *TestCaseResult) Compute() {
+    this.Status = strings.ToLower(strings.TrimSpace(this.Status));
+
+    if (this.Diff == "") {
+        this.Diff = util.LineDiff(this.Expected, this.Actual);
+    }
+
+    if (this.Status == "") {
+        if (this.Expected == this.Actual) {
+            this.Status = TEST_CASE_STATUS_PASS;
+        } else {
+            this.Status = TEST_CASE_STATUS_FAIL;
+        }
+    }
+}
+
+func (this *TestCaseResult) Passed() bool {
+    return (this.Status == TEST_CASE_STATUS_PASS);
+}
+
+func (this *TestCaseResult) Report() string {
+    var builder strings.Builder;
+
+    name := this.Name;
+    if (name == "") {
+        name = "<unnamed test case>";
+    }
+
+    builder.WriteString(fmt.Sprintf("[%s] %s\n", this.Status, name));
+
+    fields := []reportField{
+        {"Message", this.Message},
+        {"Input", this.Input},
+    };
+
+    // Passing cases do not need to show the output.
+    if (!this.Passed()) {
+        if (this.Diff != "") {
+            fields = append(fields, reportField{"Diff (- expected, + actual)", this.Diff});
+        } else {
+            fields = append(fields, reportField{"Expected", this.Expected}, reportField{"Actual", this.Actual});
+        }
+    }
+
+    for _, field := range fields {
+        if (field.text == "") {
+            continue;
+        }
+
+        builder.WriteString(fmt.Sprintf("    %s:\n", field.label));
+        for _, line := range truncateReportLines(field.text) {
+            builder.WriteString(fmt.Sprintf("        %s\n", line));
+        }
+    }
+
+    return builder.String();
+}
+
+func truncateReportLines(text string) []string {
+    lines := strings.Split(strings.TrimSuffix(text, "\n"), "\n");
+
+    extraLines := 0;
+    if (len(lines) > MAX_REPORT_TEST_CASE_LINES) {
+        extraLines = len(lines) - MAX_REPORT_TEST_CASE_LINES;
+        lines = lines[0:MAX_REPORT_TEST_CASE_LINES];
+    }
+
+    for i, line := range lines {
+        runes := []rune(line);
+        if (len(runes) > MAX_REPORT_TEST_CASE_LINE_LENGTH) {
+            lines[i] = string(runes[0:MAX_REPORT_TEST_CASE_LINE_LENGTH]) + " ...";
+        }
+    }
+
+    if (extraLines > 0) {
+        lines = append(lines, fmt.Sprintf("... (%d more lines)", extraLines));
+    }
+
+    return lines;
+}
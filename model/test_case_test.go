@@ -0,0 +1,84 @@
+package model
+
+import (
+    "strings"
+    "testing"
+)
+
+func TestTestCaseResultCompute(test *testing.T) {
+    testCases := []struct{testCase *TestCaseResult; status string; diff string}{
+        {&TestCaseResult{Expected: "a\n", Actual: "a\n"}, TEST_CASE_STATUS_PASS, ""},
+        {&TestCaseResult{Expected: "a", Actual: "b"}, TEST_CASE_STATUS_FAIL, "- a\n+ b"},
+        {&TestCaseResult{Status: " Error ", Expected: "a", Actual: "a"}, TEST_CASE_STATUS_ERROR, ""},
+        {&TestCaseResult{Status: "pass", Expected: "a", Actual: "b", Diff: "custom"}, TEST_CASE_STATUS_PASS, "custom"},
+    };
+
+    for i, testCase := range testCases {
+        testCase.testCase.Compute();
+
+        if (testCase.status != testCase.testCase.Status) {
+            test.Errorf("Case %d: Unexpected status. Expected: '%s', Actual: '%s'.", i, testCase.status, testCase.testCase.Status);
+        }
+
+        if (testCase.diff != testCase.testCase.Diff) {
+            test.Errorf("Case %d: Unexpected diff. Expected: '%s', Actual: '%s'.", i, testCase.diff, testCase.testCase.Diff);
+        }
+    }
+}
+
+func TestGradedQuestionReportTestCases(test *testing.T) {
+    gradingInfo := GradingInfo{
+        Questions: []*GradedQuestion{
+            &GradedQuestion{
+                Name: "Q1",
+                MaxPoints: 2.0,
+                Score: 1.0,
+                TestCases: []*TestCaseResult{
+                    &TestCaseResult{Name: "add", Input: "1 2", Expected: "3", Actual: "3"},
+                    &TestCaseResult{Name: "sub", Input: "1 2", Expected: "-1\n", Actual: "1\n"},
+                },
+            },
+        },
+    };
+
+    gradingInfo.ComputePoints();
+
+    expected := strings.Join([]string{
+        "Q1: 1 / 2",
+        "    [pass] add",
+        "        Input:",
+        "            1 2",
+        "    [fail] sub",
+        "        Input:",
+        "            1 2",
+        "        Diff (- expected, + actual):",
+        "            - -1",
+        "            + 1",
+        "",
+    }, "\n");
+
+    actual := gradingInfo.Questions[0].Report();
+    if (expected != actual) {
+        test.Fatalf("Unexpected report. Expected:\n%s\nActual:\n%s", expected, actual);
+    }
+}
+
+func TestTestCaseResultReportTruncation(test *testing.T) {
+    longLine := strings.Repeat("x", MAX_REPORT_TEST_CASE_LINE_LENGTH + 10);
+    manyLines := strings.Repeat("y\n", MAX_REPORT_TEST_CASE_LINES + 5);
+
+    testCase := &TestCaseResult{Name: "long", Status: TEST_CASE_STATUS_ERROR, Message: longLine, Input: manyLines};
+    report := testCase.Report();
+
+    if (strings.Contains(report, longLine)) {
+        test.Fatalf("Long line was not truncated: '%s'.", report);
+    }
+
+    if (!strings.Contains(report, strings.Repeat("x", MAX_REPORT_TEST_CASE_LINE_LENGTH) + " ...")) {
+        test.Fatalf("Long line was not truncated with an ellipsis: '%s'.", report);
+    }
+
+    if (!strings.Contains(report, "... (5 more lines)")) {
+        test.Fatalf("Many lines were not truncated: '%s'.", report);
+    }
+}
@@ -0,0 +1,158 @@
+package model
+
+// API tokens let a user authenticate without sending their password.
+// Only a hash of each token is stored, the cleartext token is only available when it is created.
+// Unlike passwords, tokens are long random strings, so a fast hash (sha256) is sufficient.
+
+import (
+    "crypto/subtle"
+    "fmt"
+    "strings"
+    "time"
+
+    "github.com/edulinq/autograder/common"
+    "github.com/edulinq/autograder/log"
+    "github.com/edulinq/autograder/util"
+)
+
+const API_TOKEN_LEN = 32;
+
+// Scopes are ordered, a scope allows everything that the scopes below it allow.
+type TokenScope string;
+
+const (
+    // Only endpoints that do not change anything.
+    TokenScopeRead TokenScope = "read"
+    // Read endpoints plus making submissions.
+    TokenScopeSubmit TokenScope = "submit"
+    // Everything the user can do.
+    TokenScopeAdmin TokenScope = "admin"
+)
+
+var tokenScopeLevels = map[TokenScope]int{
+    TokenScopeRead: 1,
+    TokenScopeSubmit: 2,
+    TokenScopeAdmin: 3,
+};
+
+type APIToken struct {
+    ID string `json:"id"`
+    Name string `json:"name"`
+    Scope TokenScope `json:"scope"`
+    // A hex encoding of a sha256 hash of the cleartext token.
+    Hash string `json:"hash"`
+
+    CreationTime common.Timestamp `json:"creation-time"`
+    // A zero time means that the token never expires.
+    ExpirationTime common.Timestamp `json:"expiration-time,omitempty"`
+
+    // The token was created by a request that used a second factor,
+    // so it can be used for endpoints that require two-factor authentication.
+    SecondFactor bool `json:"second-factor,omitempty"`
+}
+
+// Create a new token and return it along with the cleartext token.
+// A zero expiration time means the token will never expire.
+func NewAPIToken(name string, scope TokenScope, expirationTime common.Timestamp) (*APIToken, string, error) {
+    name = strings.TrimSpace(name);
+    if (name == "") {
+        return nil, "", fmt.Errorf("API tokens must have a name.");
+    }
+
+    if (!scope.IsValid()) {
+        return nil, "", fmt.Errorf("Unknown API token scope: '%s'.", scope);
+    }
+
+    if (!expirationTime.IsZero()) {
+        err := expirationTime.Validate();
+        if (err != nil) {
+            return nil, "", fmt.Errorf("Invalid expiration time: '%w'.", err);
+        }
+    }
+
+    cleartext, err := util.RandHex(API_TOKEN_LEN);
+    if (err != nil) {
+        return nil, "", fmt.Errorf("Failed to generate API token: '%w'.", err);
+    }
+
+    token := &APIToken{
+        ID: util.UUID(),
+        Name: name,
+        Scope: scope,
+        Hash: util.Sha256HexFromString(cleartext),
+        CreationTime: common.NowTimestamp(),
+        ExpirationTime: expirationTime,
+    };
+
+    return token, cleartext, nil;
+}
+
+func (this TokenScope) IsValid() bool {
+    _, ok := tokenScopeLevels[this];
+    return ok;
+}
+
+// Does this scope allow access to something that requires the other scope.
+func (this TokenScope) Allows(required TokenScope) bool {
+    level, ok := tokenScopeLevels[this];
+    if (!ok) {
+        return false;
+    }
+
+    return (level >= tokenScopeLevels[required]);
+}
+
+func (this *APIToken) IsExpired(now time.Time) bool {
+    if (this.ExpirationTime.IsZero()) {
+        return false;
+    }
+
+    expiration, err := this.ExpirationTime.Time();
+    if (err != nil) {
+        log.Warn("Bad API token expiration time, treating token as expired.", err, log.NewAttr("token-id", this.ID));
+        return true;
+    }
+
+    return !now.Before(expiration);
+}
+
+// Return true if the cleartext token matches this token.
+func (this *APIToken) Matches(cleartext string) bool {
+    hash := util.Sha256HexFromString(cleartext);
+    return (subtle.ConstantTimeCompare([]byte(this.Hash), []byte(hash)) == 1);
+}
+
+// Get the (unexpired) token that matches the given cleartext token.
+// Returns nil if no token matches.
+func (this *User) CheckToken(cleartext string) *APIToken {
+    if (cleartext == "") {
+        return nil;
+    }
+
+    now := time.Now();
+
+    for _, token := range this.Tokens {
+        if (token.Matches(cleartext) && !token.IsExpired(now)) {
+            return token;
+        }
+    }
+
+    return nil;
+}
+
+func (this *User) AddToken(token *APIToken) {
+    this.Tokens = append(this.Tokens, token);
+}
+
+// Remove a token.
+// Returns true if the token existed.
+func (this *User) RemoveToken(id string) bool {
+    for i, token := range this.Tokens {
+        if (token.ID == id) {
+            this.Tokens = append(this.Tokens[0:i], this.Tokens[i + 1:]...);
+            return true;
+        }
+    }
+
+    return false;
+}
@@ -0,0 +1,115 @@
+package model
+
+import (
+    "testing"
+    "time"
+
+    "github.com/edulinq/autograder/common"
+)
+
+func TestTokenScopeAllows(test *testing.T) {
+    testCases := []struct{scope TokenScope; required TokenScope; expected bool}{
+        {TokenScopeRead, TokenScopeRead, true},
+        {TokenScopeRead, TokenScopeSubmit, false},
+        {TokenScopeRead, TokenScopeAdmin, false},
+
+        {TokenScopeSubmit, TokenScopeRead, true},
+        {TokenScopeSubmit, TokenScopeSubmit, true},
+        {TokenScopeSubmit, TokenScopeAdmin, false},
+
+        {TokenScopeAdmin, TokenScopeRead, true},
+        {TokenScopeAdmin, TokenScopeSubmit, true},
+        {TokenScopeAdmin, TokenScopeAdmin, true},
+
+        {TokenScope(""), TokenScopeRead, false},
+        {TokenScope("ZZZ"), TokenScopeRead, false},
+    };
+
+    for i, testCase := range testCases {
+        actual := testCase.scope.Allows(testCase.required);
+        if (testCase.expected != actual) {
+            test.Errorf("Case %d: Unexpected result for '%s' allowing '%s'. Expected: %v, Actual: %v.",
+                    i, testCase.scope, testCase.required, testCase.expected, actual);
+        }
+    }
+}
+
+func TestUserCheckToken(test *testing.T) {
+    user := &User{};
+
+    past := common.TimestampFromTime(time.Now().Add(-time.Hour));
+    future := common.TimestampFromTime(time.Now().Add(time.Hour));
+
+    validToken, validCleartext, err := NewAPIToken("valid", TokenScopeRead, "");
+    if (err != nil) {
+        test.Fatalf("Failed to create token: '%v'.", err);
+    }
+
+    futureToken, futureCleartext, err := NewAPIToken("future", TokenScopeAdmin, future);
+    if (err != nil) {
+        test.Fatalf("Failed to create token: '%v'.", err);
+    }
+
+    expiredToken, expiredCleartext, err := NewAPIToken("expired", TokenScopeAdmin, past);
+    if (err != nil) {
+        test.Fatalf("Failed to create token: '%v'.", err);
+    }
+
+    user.AddToken(validToken);
+    user.AddToken(futureToken);
+    user.AddToken(expiredToken);
+
+    if (validToken.Hash == validCleartext) {
+        test.Fatalf("Token is stored in cleartext.");
+    }
+
+    testCases := []struct{cleartext string; expected *APIToken}{
+        {validCleartext, validToken},
+        {futureCleartext, futureToken},
+        {expiredCleartext, nil},
+        {"", nil},
+        {"ZZZ", nil},
+        {validCleartext + "Z", nil},
+        {validToken.Hash, nil},
+    };
+
+    for i, testCase := range testCases {
+        actual := user.CheckToken(testCase.cleartext);
+        if (testCase.expected != actual) {
+            test.Errorf("Case %d: Unexpected token. Expected: '%v', Actual: '%v'.", i, testCase.expected, actual);
+        }
+    }
+
+    if (!user.RemoveToken(validToken.ID)) {
+        test.Fatalf("Failed to remove token.");
+    }
+
+    if (user.RemoveToken(validToken.ID)) {
+        test.Fatalf("Removed a token twice.");
+    }
+
+    if (user.CheckToken(validCleartext) != nil) {
+        test.Fatalf("Removed token still works.");
+    }
+
+    if (user.CheckToken(futureCleartext) != futureToken) {
+        test.Fatalf("Remaining token no longer works.");
+    }
+}
+
+func TestNewAPITokenErrors(test *testing.T) {
+    testCases := []struct{name string; scope TokenScope; expiration common.Timestamp}{
+        {"", TokenScopeRead, ""},
+        {"  ", TokenScopeRead, ""},
+        {"a", TokenScope(""), ""},
+        {"a", TokenScope("ZZZ"), ""},
+        {"a", TokenScopeRead, "ZZZ"},
+    };
+
+    for i, testCase := range testCases {
+        _, _, err := NewAPIToken(testCase.name, testCase.scope, testCase.expiration);
+        if (err == nil) {
+            test.Errorf("Case %d: Did not get an error on a bad token.", i);
+        }
+    }
+}
@@ -0,0 +1,211 @@
+package model
+
+// Two-factor authentication with time-based one-time passwords (TOTP, RFC 6238).
+// A user enrolls by adding the secret to an authenticator app (usually by scanning a QR code of the otpauth URI),
+// and then confirming a code from the app.
+// Recovery codes can be used (once each) in place of a code if the authenticator is lost.
+// Like API tokens, only hashes of recovery codes are stored.
+
+import (
+    "crypto/hmac"
+    "crypto/rand"
+    "crypto/sha1"
+    "crypto/subtle"
+    "encoding/base32"
+    "encoding/binary"
+    "fmt"
+    "net/url"
+    "strings"
+    "time"
+
+    "github.com/edulinq/autograder/util"
+)
+
+const (
+    TOTP_ISSUER = "Autograder";
+    TOTP_SECRET_LEN_BYTES = 20;
+    TOTP_DIGITS = 6;
+    TOTP_PERIOD_SECS = 30;
+    // Codes from this many periods before/after the current one are accepted (to allow for clock drift).
+    TOTP_SKEW_PERIODS = 1;
+
+    TOTP_NUM_RECOVERY_CODES = 10;
+    // In hex characters (64 bits), so recovery codes cannot be guessed.
+    TOTP_RECOVERY_CODE_LEN = 16;
+)
+
+var totpEncoding *base32.Encoding = base32.StdEncoding.WithPadding(base32.NoPadding);
+
+type TOTPInfo struct {
+    // Base32 encoded (without padding).
+    Secret string `json:"secret"`
+    // A secret is not used until the user confirms it with a code (see ServerUser.ConfirmTOTP()).
+    Enabled bool `json:"enabled"`
+    // Hex encodings of sha256 hashes of the unused recovery codes.
+    RecoveryCodes []string `json:"recovery-codes,omitempty"`
+    // The last time step that a code was accepted for (codes cannot be reused).
+    LastStep int64 `json:"last-step,omitempty"`
+}
+
+func NewTOTPSecret() (string, error) {
+    secret := make([]byte, TOTP_SECRET_LEN_BYTES);
+
+    _, err := rand.Read(secret);
+    if (err != nil) {
+        return "", fmt.Errorf("Failed to generate TOTP secret: '%w'.", err);
+    }
+
+    return totpEncoding.EncodeToString(secret), nil;
+}
+
+// Get the code for a secret at a time step (see GetTOTPStep()).
+func GetTOTPCode(secret string, step int64) (string, error) {
+    key, err := totpEncoding.DecodeString(strings.ToUpper(secret));
+    if (err != nil) {
+        return "", fmt.Errorf("Failed to decode TOTP secret: '%w'.", err);
+    }
+
+    counter := make([]byte, 8);
+    binary.BigEndian.PutUint64(counter, uint64(step));
+
+    mac := hmac.New(sha1.New, key);
+    mac.Write(counter);
+    sum := mac.Sum(nil);
+
+    // Dynamic truncation (RFC 4226).
+    offset := sum[len(sum) - 1] & 0x0f;
+    value := binary.BigEndian.Uint32(sum[offset:offset + 4]) & 0x7fffffff;
+
+    modulus := uint32(1);
+    for i := 0; i < TOTP_DIGITS; i++ {
+        modulus *= 10;
+    }
+
+    return fmt.Sprintf("%0*d", TOTP_DIGITS, value % modulus), nil;
+}
+
+func GetTOTPStep(now time.Time) int64 {
+    return now.Unix() / TOTP_PERIOD_SECS;
+}
+
+// Get the URI that authenticator apps use to add a secret (usually shown as a QR code).
+func GetTOTPURI(secret string, email string) string {
+    label := url.PathEscape(TOTP_ISSUER + ":" + email);
+
+    query := url.Values{};
+    query.Set("secret", secret);
+    query.Set("issuer", TOTP_ISSUER);
+    query.Set("algorithm", "SHA1");
+    query.Set("digits", fmt.Sprintf("%d", TOTP_DIGITS));
+    query.Set("period", fmt.Sprintf("%d", TOTP_PERIOD_SECS));
+
+    return fmt.Sprintf("otpauth://totp/%s?%s", label, query.Encode());
+}
+
+func (this *ServerUser) HasTOTP() bool {
+    return ((this.TOTP != nil) && this.TOTP.Enabled);
+}
+
+// Start enrolling in TOTP (replacing any unconfirmed secret) and return the new secret.
+func (this *ServerUser) StartTOTPEnrollment() (string, error) {
+    if (this.HasTOTP()) {
+        return "", fmt.Errorf("Two-factor authentication is already enabled.");
+    }
+
+    secret, err := NewTOTPSecret();
+    if (err != nil) {
+        return "", err;
+    }
+
+    this.TOTP = &TOTPInfo{
+        Secret: secret,
+    };
+
+    return secret, nil;
+}
+
+// Finish enrolling in TOTP with a code from the user's authenticator.
+// On success, the cleartext recovery codes are returned (this is the only time they are available).
+// Returns nil if the code does not match.
+func (this *ServerUser) ConfirmTOTP(code string, now time.Time) ([]string, error) {
+    if ((this.TOTP == nil) || this.TOTP.Enabled) {
+        return nil, fmt.Errorf("There is no two-factor authentication enrollment in progress.");
+    }
+
+    if (!this.TOTP.checkCode(code, now)) {
+        return nil, nil;
+    }
+
+    codes := make([]string, 0, TOTP_NUM_RECOVERY_CODES);
+    hashes := make([]string, 0, TOTP_NUM_RECOVERY_CODES);
+
+    for i := 0; i < TOTP_NUM_RECOVERY_CODES; i++ {
+        recoveryCode, err := util.RandHex(TOTP_RECOVERY_CODE_LEN);
+        if (err != nil) {
+            return nil, fmt.Errorf("Failed to generate recovery code: '%w'.", err);
+        }
+
+        codes = append(codes, recoveryCode);
+        hashes = append(hashes, util.Sha256HexFromString(recoveryCode));
+    }
+
+    this.TOTP.Enabled = true;
+    this.TOTP.RecoveryCodes = hashes;
+
+    return codes, nil;
+}
+
+// Check (and use up) a second factor, either a code from the user's authenticator or a recovery code.
+// Returns false if the user does not have TOTP enabled.
+func (this *ServerUser) CheckSecondFactor(code string, now time.Time) bool {
+    if (!this.HasTOTP()) {
+        return false;
+    }
+
+    code = strings.TrimSpace(code);
+
+    if (this.TOTP.checkCode(code, now)) {
+        return true;
+    }
+
+    hash := util.Sha256HexFromString(strings.ToLower(code));
+    for i, recoveryCode := range this.TOTP.RecoveryCodes {
+        if (subtle.ConstantTimeCompare([]byte(recoveryCode), []byte(hash)) == 1) {
+            this.TOTP.RecoveryCodes = append(this.TOTP.RecoveryCodes[0:i], this.TOTP.RecoveryCodes[i + 1:]...);
+            return true;
+        }
+    }
+
+    return false;
+}
+
+func (this *ServerUser) DisableTOTP() {
+    this.TOTP = nil;
+}
+
+// Check a code against the steps around now.
+// An accepted code's step is recorded, so the code (and any earlier ones) cannot be used again.
+func (this *TOTPInfo) checkCode(code string, now time.Time) bool {
+    if (len(code) != TOTP_DIGITS) {
+        return false;
+    }
+
+    currentStep := GetTOTPStep(now);
+    for step := (currentStep - TOTP_SKEW_PERIODS); step <= (currentStep + TOTP_SKEW_PERIODS); step++ {
+        if (step <= this.LastStep) {
+            continue;
+        }
+
+        expected, err := GetTOTPCode(this.Secret, step);
+        if (err != nil) {
+            return false;
+        }
+
+        if (subtle.ConstantTimeCompare([]byte(expected), []byte(code)) == 1) {
+            this.LastStep = step;
+            return true;
+        }
+    }
+
+    return false;
+}
@@ -0,0 +1,136 @@
+package model
+
+import (
+    "testing"
+    "time"
+)
+
+// The SHA1 test vectors from RFC 6238 (truncated to 6 digits).
+func TestGetTOTPCode(test *testing.T) {
+    // Base32 of "12345678901234567890".
+    secret := "GEZDGNBVGY3TQOJQGEZDGNBVGY3TQOJQ";
+
+    testCases := []struct{unixTime int64; expected string}{
+        {59, "287082"},
+        {1111111109, "081804"},
+        {1111111111, "050471"},
+        {1234567890, "005924"},
+        {2000000000, "279037"},
+    };
+
+    for i, testCase := range testCases {
+        actual, err := GetTOTPCode(secret, GetTOTPStep(time.Unix(testCase.unixTime, 0)));
+        if (err != nil) {
+            test.Errorf("Case %d: Failed to get code: '%v'.", i, err);
+            continue;
+        }
+
+        if (testCase.expected != actual) {
+            test.Errorf("Case %d: Unexpected code. Expected: '%s', Actual: '%s'.", i, testCase.expected, actual);
+        }
+    }
+}
+
+func TestTOTPEnrollment(test *testing.T) {
+    user := &ServerUser{Email: "a@test.com"};
+    now := time.Now();
+
+    secret, err := user.StartTOTPEnrollment();
+    if (err != nil) {
+        test.Fatalf("Failed to start enrollment: '%v'.", err);
+    }
+
+    // Not usable until confirmed.
+    if (user.HasTOTP() || user.CheckSecondFactor(mustGetTOTPCode(test, secret, now, 0), now)) {
+        test.Fatalf("TOTP is usable before it was confirmed.");
+    }
+
+    recoveryCodes, err := user.ConfirmTOTP("000000", now);
+    if ((err != nil) || (recoveryCodes != nil)) {
+        test.Fatalf("Bad code confirmed enrollment: '%v', '%v'.", recoveryCodes, err);
+    }
+
+    recoveryCodes, err = user.ConfirmTOTP(mustGetTOTPCode(test, secret, now, 0), now);
+    if (err != nil) {
+        test.Fatalf("Failed to confirm enrollment: '%v'.", err);
+    }
+
+    if (len(recoveryCodes) != TOTP_NUM_RECOVERY_CODES) {
+        test.Fatalf("Unexpected number of recovery codes. Expected: %d, Actual: %d.", TOTP_NUM_RECOVERY_CODES, len(recoveryCodes));
+    }
+
+    for _, recoveryCode := range recoveryCodes {
+        if (len(recoveryCode) != TOTP_RECOVERY_CODE_LEN) {
+            test.Fatalf("Unexpected recovery code length. Expected: %d, Actual: %d.", TOTP_RECOVERY_CODE_LEN, len(recoveryCode));
+        }
+    }
+
+    if (!user.HasTOTP()) {
+        test.Fatalf("TOTP is not enabled after confirming.");
+    }
+
+    _, err = user.StartTOTPEnrollment();
+    if (err == nil) {
+        test.Fatalf("Enrollment was restarted while TOTP is enabled.");
+    }
+}
+
+func TestTOTPCheckSecondFactor(test *testing.T) {
+    user := &ServerUser{Email: "a@test.com"};
+    now := time.Now();
+
+    secret, err := user.StartTOTPEnrollment();
+    if (err != nil) {
+        test.Fatalf("Failed to start enrollment: '%v'.", err);
+    }
+
+    recoveryCodes, err := user.ConfirmTOTP(mustGetTOTPCode(test, secret, now, -1), now);
+    if (err != nil) {
+        test.Fatalf("Failed to confirm enrollment: '%v'.", err);
+    }
+
+    // The code used to confirm cannot be reused.
+    if (user.CheckSecondFactor(mustGetTOTPCode(test, secret, now, -1), now)) {
+        test.Fatalf("Confirmation code was reused.");
+    }
+
+    code := mustGetTOTPCode(test, secret, now, 0);
+    if (!user.CheckSecondFactor(code, now)) {
+        test.Fatalf("Current code was not accepted.");
+    }
+
+    if (user.CheckSecondFactor(code, now)) {
+        test.Fatalf("Code was reused.");
+    }
+
+    // Too far in the future.
+    if (user.CheckSecondFactor(mustGetTOTPCode(test, secret, now, TOTP_SKEW_PERIODS + 1), now)) {
+        test.Fatalf("Code outside of the allowed skew was accepted.");
+    }
+
+    if (!user.CheckSecondFactor(recoveryCodes[0], now)) {
+        test.Fatalf("Recovery code was not accepted.");
+    }
+
+    if (user.CheckSecondFactor(recoveryCodes[0], now)) {
+        test.Fatalf("Recovery code was reused.");
+    }
+
+    if (len(user.TOTP.RecoveryCodes) != (TOTP_NUM_RECOVERY_CODES - 1)) {
+        test.Fatalf("Recovery code was not removed.");
+    }
+
+    user.DisableTOTP();
+    if (user.CheckSecondFactor(recoveryCodes[1], now)) {
+        test.Fatalf("Recovery code was accepted after disabling TOTP.");
+    }
+}
+
+func mustGetTOTPCode(test *testing.T, secret string, now time.Time, offset int64) string {
+    code, err := GetTOTPCode(secret, GetTOTPStep(now) + offset);
+    if (err != nil) {
+        test.Fatalf("Failed to get code: '%v'.", err);
+    }
+
+    return code;
+}
@@ -12,8 +12,6 @@ import (
     "strings"
     "time"
 
-    "golang.org/x/crypto/argon2"
-
     "github.com/edulinq/autograder/config"
     "github.com/edulinq/autograder/email"
     "github.com/edulinq/autograder/log"
@@ -23,7 +21,10 @@ import (
 const (
     DEFAULT_PASSWORD_LEN = 32;
     SALT_LENGTH_BYTES = 16;
+    // In hex characters.
+    PASS_VERSION_LEN = 16;
 
+    // The parameters for hashes from before parameters were stored (see PasswordHashParams).
     ARGON2_KEY_LEN_BYTES = 32;
     ARGON2_MEM_KB = 64 * 1024;
     ARGON2_THREADS = 4;
@@ -38,8 +39,23 @@ type User struct {
     Role UserRole `json:"role"`
     Pass string `json:"pass"`
     Salt string `json:"salt"`
+    // How Pass was hashed, nil for hashes from before parameters were stored.
+    PassParams *PasswordHashParams `json:"pass-params,omitempty"`
+    // Changes only when the password itself changes (not when it is rehashed), see GetSessionPassCheck().
+    // Empty for passwords set before versions were stored.
+    PassVersion string `json:"pass-version,omitempty"`
 
     LMSID string `json:"lms-id"`
+
+    // API tokens that can be used instead of a password (see APIToken).
+    Tokens []*APIToken `json:"tokens,omitempty"`
+
+    // Set (only) when a server admin is authenticated in a course.
+    // The user's role will be RoleOwner, which may not be their actual role in the course.
+    ServerAdmin bool `json:"-"`
+
+    // Set from the user's account, two-factor authentication is managed on accounts (see ServerUser.TOTP).
+    TOTPEnabled bool `json:"-"`
 }
 
 func NewUser(email string, name string, role UserRole) *User {
@@ -54,18 +70,51 @@ func (this *User) LogValue() []*log.Attr {
     return []*log.Attr{log.NewUserAttr(this.Email)};
 }
 
-// Sets the password and generates a new salt.
+// Sets the password and generates a new salt (and password version).
 // The passed in passowrd should actually be a hash of the cleartext password.
 func (this *User) SetPassword(hashPass string) error {
+    version, err := util.RandHex(PASS_VERSION_LEN);
+    if (err != nil) {
+        return fmt.Errorf("Could not generate password version: '%w'.", err);
+    }
+
+    err = this.hashPassword(hashPass);
+    if (err != nil) {
+        return err;
+    }
+
+    this.PassVersion = version;
+
+    return nil;
+}
+
+// Hash the same password again with the current parameters (see PasswordNeedsRehash()).
+// Since the password did not change, the password version is kept (so existing sessions stay valid).
+func (this *User) RehashPassword(hashPass string) error {
+    if (this.PassVersion == "") {
+        // Keep the check that was based on the old hash.
+        this.PassVersion = GetSessionPassCheck(this);
+    }
+
+    return this.hashPassword(hashPass);
+}
+
+func (this *User) hashPassword(hashPass string) error {
     salt, err := util.RandBytes(SALT_LENGTH_BYTES);
     if (err != nil) {
         return fmt.Errorf("Could not generate salt: '%w'.", err);
     }
 
-    pass := generateHash(hashPass, salt);
+    params := GetTargetPasswordHashParams();
+
+    pass, err := params.hash(hashPass, salt);
+    if (err != nil) {
+        return fmt.Errorf("Could not hash password: '%w'.", err);
+    }
 
     this.Salt = hex.EncodeToString(salt);
     this.Pass = hex.EncodeToString(pass);
+    this.PassParams = params;
 
     return nil;
 }
@@ -101,11 +150,29 @@ func (this *User) CheckPassword(hashPass string) bool {
         return false;
     }
 
-    otherHash := generateHash(hashPass, salt);
+    otherHash, err := this.getPassParams().hash(hashPass, salt);
+    if (err != nil) {
+        log.Warn("Bad password hash parameters for user.", err, this);
+        return false;
+    }
 
     return (subtle.ConstantTimeCompare(thisHash, otherHash) == 1);
 }
 
+// Return true if the password was hashed with different parameters than new passwords are.
+// The password should be set again (see SetPassword()) the next time the cleartext is available (e.g., on login).
+func (this *User) PasswordNeedsRehash() bool {
+    return !this.getPassParams().Equals(GetTargetPasswordHashParams());
+}
+
+func (this *User) getPassParams() *PasswordHashParams {
+    if (this.PassParams == nil) {
+        return GetLegacyPasswordHashParams();
+    }
+
+    return this.PassParams;
+}
+
 // Merge another user's information into this user (email will not be merged).
 // Empty values will not be merged.
 // Returns true if any changes were made.
@@ -120,6 +187,8 @@ func (this *User) Merge(other *User) bool {
     if ((other.Pass != "") && (this.Pass != other.Pass)) {
         this.Pass = other.Pass;
         this.Salt = other.Salt;
+        this.PassParams = other.PassParams;
+        this.PassVersion = other.PassVersion;
         changed = true;
     }
 
@@ -136,8 +205,22 @@ func (this *User) Merge(other *User) bool {
     return changed;
 }
 
-func generateHash(hashPass string, salt []byte) []byte {
-    return argon2.IDKey([]byte(hashPass), salt, ARGON2_TIME, ARGON2_MEM_KB, ARGON2_THREADS, ARGON2_KEY_LEN_BYTES);
+// Merge only the enrollment fields (role and LMS ID) of another user into this one.
+// Returns true if anything changed.
+func (this *User) MergeEnrollment(other *User) bool {
+    changed := false;
+
+    if ((other.Role != RoleUnknown) && (this.Role != other.Role)) {
+        this.Role = other.Role;
+        changed = true;
+    }
+
+    if ((other.LMSID != "") && (this.LMSID != other.LMSID)) {
+        this.LMSID = other.LMSID;
+        changed = true;
+    }
+
+    return changed;
 }
 
 func SendUserAddEmail(course *Course, user *User, pass string, generatedPass bool, userExists bool, dryRun bool, sleep bool) error {
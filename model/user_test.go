@@ -2,6 +2,8 @@ package model
 
 import (
     "testing"
+
+    "github.com/edulinq/autograder/config"
 )
 
 var testPasswords []string = []string{
@@ -29,3 +31,67 @@ func TestUserPasswordTable(test *testing.T) {
         }
     }
 }
+
+func TestUserPasswordRehash(test *testing.T) {
+    oldTime := config.PASSWORD_HASH_TIME.Get();
+    defer config.PASSWORD_HASH_TIME.Set(oldTime);
+
+    user := &User{};
+    err := user.SetPassword("a");
+    if (err != nil) {
+        test.Fatalf("Failed to set password: '%v'.", err);
+    }
+
+    if (!user.PassParams.Equals(GetTargetPasswordHashParams())) {
+        test.Fatalf("Password was not hashed with the target parameters: '%+v'.", user.PassParams);
+    }
+
+    // Hashes without parameters use the legacy ones (which match the default target).
+    user.PassParams = nil;
+    if (!user.CheckPassword("a") || user.PasswordNeedsRehash()) {
+        test.Fatalf("Legacy hash does not work.");
+    }
+
+    // Raise the target.
+    config.PASSWORD_HASH_TIME.Set(oldTime + 1);
+
+    if (!user.CheckPassword("a")) {
+        test.Fatalf("Old hash does not work after raising the target parameters.");
+    }
+
+    if (!user.PasswordNeedsRehash()) {
+        test.Fatalf("Old hash does not need a rehash after raising the target parameters.");
+    }
+
+    version := user.PassVersion;
+
+    err = user.RehashPassword("a");
+    if (err != nil) {
+        test.Fatalf("Failed to rehash password: '%v'.", err);
+    }
+
+    if (!user.CheckPassword("a") || user.PasswordNeedsRehash()) {
+        test.Fatalf("Rehashed password does not work.");
+    }
+
+    if (user.PassVersion != version) {
+        test.Fatalf("Rehashing changed the password version. Expected: '%s', Actual: '%s'.", version, user.PassVersion);
+    }
+
+    if (user.PassParams.Time != uint32(oldTime + 1)) {
+        test.Fatalf("Rehashed password does not have the new parameters: '%+v'.", user.PassParams);
+    }
+}
+
+func TestUserPasswordBadParams(test *testing.T) {
+    user := &User{};
+    err := user.SetPassword("a");
+    if (err != nil) {
+        test.Fatalf("Failed to set password: '%v'.", err);
+    }
+
+    user.PassParams = &PasswordHashParams{Algorithm: "ZZZ"};
+    if (user.CheckPassword("a")) {
+        test.Fatalf("Password with an unknown algorithm was accepted.");
+    }
+}
@@ -0,0 +1,202 @@
+package oidc
+
+// A local identity provider for testing.
+// Instead of showing a login page, a test "logs in" a user by calling MockIdP.Authorize()
+// with the authorization URL the autograder generated.
+
+import (
+    "crypto"
+    "crypto/rand"
+    "crypto/rsa"
+    "crypto/sha256"
+    "encoding/base64"
+    "fmt"
+    "math/big"
+    "net/http"
+    "net/http/httptest"
+    "net/url"
+    "sync"
+    "time"
+
+    "github.com/edulinq/autograder/util"
+)
+
+const MOCK_KEY_ID = "mock-key";
+
+type MockIdP struct {
+    ClientID string
+    ClientSecret string
+
+    server *httptest.Server
+    key *rsa.PrivateKey
+
+    lock sync.Mutex
+    // Codes that have not been exchanged yet.
+    codes map[string]*mockCode
+}
+
+type mockCode struct {
+    redirectURL string
+    claims map[string]any
+}
+
+func NewMockIdP(clientID string, clientSecret string) (*MockIdP, error) {
+    key, err := rsa.GenerateKey(rand.Reader, 2048);
+    if (err != nil) {
+        return nil, fmt.Errorf("Failed to generate mock IdP key: '%w'.", err);
+    }
+
+    idp := &MockIdP{
+        ClientID: clientID,
+        ClientSecret: clientSecret,
+        key: key,
+        codes: make(map[string]*mockCode),
+    };
+
+    mux := http.NewServeMux();
+    mux.HandleFunc(DISCOVERY_SUFFIX, idp.handleDiscovery);
+    mux.HandleFunc("/token", idp.handleToken);
+    mux.HandleFunc("/jwks", idp.handleJWKS);
+
+    idp.server = httptest.NewServer(mux);
+
+    return idp, nil;
+}
+
+func (this *MockIdP) Issuer() string {
+    return this.server.URL;
+}
+
+func (this *MockIdP) Close() {
+    this.server.Close();
+}
+
+// Simulate a user logging in at the IdP.
+// The extra claims (e.g. "email") will be included in the ID token.
+// Returns the code and state that the IdP would send to the redirect URL.
+func (this *MockIdP) Authorize(authURL string, extraClaims map[string]any) (string, string, error) {
+    parsedURL, err := url.Parse(authURL);
+    if (err != nil) {
+        return "", "", fmt.Errorf("Failed to parse authorization URL: '%w'.", err);
+    }
+
+    query := parsedURL.Query();
+    if (query.Get("client_id") != this.ClientID) {
+        return "", "", fmt.Errorf("Unknown client: '%s'.", query.Get("client_id"));
+    }
+
+    if (query.Get("response_type") != "code") {
+        return "", "", fmt.Errorf("Unsupported response type: '%s'.", query.Get("response_type"));
+    }
+
+    now := time.Now();
+
+    claims := map[string]any{
+        "iss": this.Issuer(),
+        "aud": this.ClientID,
+        "sub": util.UUID(),
+        "iat": now.Unix(),
+        "exp": now.Add(5 * time.Minute).Unix(),
+        "nonce": query.Get("nonce"),
+    };
+
+    for key, value := range extraClaims {
+        claims[key] = value;
+    }
+
+    code := util.UUID();
+
+    this.lock.Lock();
+    defer this.lock.Unlock();
+
+    this.codes[code] = &mockCode{
+        redirectURL: query.Get("redirect_uri"),
+        claims: claims,
+    };
+
+    return code, query.Get("state"), nil;
+}
+
+// Sign arbitrary claims with the IdP's key (e.g. to test bad tokens).
+func (this *MockIdP) SignIDToken(claims map[string]any) (string, error) {
+    header := util.MustToJSON(map[string]any{"alg": "RS256", "typ": "JWT", "kid": MOCK_KEY_ID});
+
+    payload, err := util.ToJSON(claims);
+    if (err != nil) {
+        return "", err;
+    }
+
+    signingInput := base64.RawURLEncoding.EncodeToString([]byte(header)) + "." + base64.RawURLEncoding.EncodeToString([]byte(payload));
+
+    digest := sha256.Sum256([]byte(signingInput));
+    signature, err := rsa.SignPKCS1v15(rand.Reader, this.key, crypto.SHA256, digest[:]);
+    if (err != nil) {
+        return "", fmt.Errorf("Failed to sign mock ID token: '%w'.", err);
+    }
+
+    return signingInput + "." + base64.RawURLEncoding.EncodeToString(signature), nil;
+}
+
+func (this *MockIdP) handleDiscovery(response http.ResponseWriter, request *http.Request) {
+    writeMockJSON(response, http.StatusOK, map[string]any{
+        "issuer": this.Issuer(),
+        "authorization_endpoint": this.Issuer() + "/authorize",
+        "token_endpoint": this.Issuer() + "/token",
+        "jwks_uri": this.Issuer() + "/jwks",
+    });
+}
+
+func (this *MockIdP) handleToken(response http.ResponseWriter, request *http.Request) {
+    err := request.ParseForm();
+    if (err != nil) {
+        writeMockJSON(response, http.StatusBadRequest, map[string]any{"error": "invalid_request"});
+        return;
+    }
+
+    if ((request.Form.Get("client_id") != this.ClientID) || (request.Form.Get("client_secret") != this.ClientSecret)) {
+        writeMockJSON(response, http.StatusUnauthorized, map[string]any{"error": "invalid_client"});
+        return;
+    }
+
+    this.lock.Lock();
+    code, ok := this.codes[request.Form.Get("code")];
+    // Codes can only be used once.
+    delete(this.codes, request.Form.Get("code"));
+    this.lock.Unlock();
+
+    if (!ok || (code.redirectURL != request.Form.Get("redirect_uri"))) {
+        writeMockJSON(response, http.StatusBadRequest, map[string]any{"error": "invalid_grant"});
+        return;
+    }
+
+    idToken, err := this.SignIDToken(code.claims);
+    if (err != nil) {
+        writeMockJSON(response, http.StatusInternalServerError, map[string]any{"error": "server_error", "error_description": err.Error()});
+        return;
+    }
+
+    writeMockJSON(response, http.StatusOK, map[string]any{
+        "access_token": util.UUID(),
+        "token_type": "Bearer",
+        "id_token": idToken,
+    });
+}
+
+func (this *MockIdP) handleJWKS(response http.ResponseWriter, request *http.Request) {
+    key := map[string]any{
+        "kty": "RSA",
+        "use": "sig",
+        "alg": "RS256",
+        "kid": MOCK_KEY_ID,
+        "n": base64.RawURLEncoding.EncodeToString(this.key.PublicKey.N.Bytes()),
+        "e": base64.RawURLEncoding.EncodeToString(big.NewInt(int64(this.key.PublicKey.E)).Bytes()),
+    };
+
+    writeMockJSON(response, http.StatusOK, map[string]any{"keys": []any{key}});
+}
+
+func writeMockJSON(response http.ResponseWriter, status int, data any) {
+    response.Header().Set("Content-Type", "application/json");
+    response.WriteHeader(status);
+    response.Write([]byte(util.MustToJSON(data)));
+}
@@ -0,0 +1,144 @@
+package oidc
+
+// A minimal OpenID Connect relying party (authorization code flow).
+// Only the parts of the spec needed for logging users in are implemented:
+// discovery, exchanging a code for an ID token, and verifying RS256 ID tokens against the provider's keys.
+
+import (
+    "fmt"
+    "net/url"
+    "strings"
+
+    "github.com/edulinq/autograder/common"
+    "github.com/edulinq/autograder/config"
+    "github.com/edulinq/autograder/util"
+)
+
+const DISCOVERY_SUFFIX = "/.well-known/openid-configuration";
+
+type Provider struct {
+    Issuer string `json:"issuer"`
+    AuthorizationEndpoint string `json:"authorization_endpoint"`
+    TokenEndpoint string `json:"token_endpoint"`
+    JWKSURI string `json:"jwks_uri"`
+
+    clientID string
+    clientSecret string
+    redirectURL string
+    emailClaim string
+}
+
+type tokenResponse struct {
+    IDToken string `json:"id_token"`
+    Error string `json:"error"`
+    ErrorDescription string `json:"error_description"`
+}
+
+func IsEnabled() bool {
+    return (config.OIDC_ISSUER.Get() != "");
+}
+
+// Get the provider from the server's config (see config.OIDC_*).
+func GetProvider() (*Provider, error) {
+    if (!IsEnabled()) {
+        return nil, fmt.Errorf("Single sign-on is not configured (see the oidc.issuer option).");
+    }
+
+    if ((config.OIDC_CLIENT_ID.Get() == "") || (config.OIDC_REDIRECT_URL.Get() == "")) {
+        return nil, fmt.Errorf("Single sign-on requires the oidc.clientid and oidc.redirecturl options.");
+    }
+
+    return Discover(config.OIDC_ISSUER.Get(), config.OIDC_CLIENT_ID.Get(), config.OIDC_CLIENT_SECRET.Get(),
+            config.OIDC_REDIRECT_URL.Get(), config.OIDC_EMAIL_CLAIM.Get());
+}
+
+// Fetch the provider's configuration from its discovery document.
+func Discover(issuer string, clientID string, clientSecret string, redirectURL string, emailClaim string) (*Provider, error) {
+    issuer = strings.TrimSuffix(issuer, "/");
+
+    body, err := common.Get(issuer + DISCOVERY_SUFFIX);
+    if (err != nil) {
+        return nil, fmt.Errorf("Failed to fetch OIDC discovery document for '%s': '%w'.", issuer, err);
+    }
+
+    var provider Provider;
+    err = util.JSONFromString(body, &provider);
+    if (err != nil) {
+        return nil, fmt.Errorf("Failed to parse OIDC discovery document for '%s': '%w'.", issuer, err);
+    }
+
+    if (strings.TrimSuffix(provider.Issuer, "/") != issuer) {
+        return nil, fmt.Errorf("OIDC discovery document has a different issuer. Expected: '%s', Actual: '%s'.", issuer, provider.Issuer);
+    }
+
+    if ((provider.AuthorizationEndpoint == "") || (provider.TokenEndpoint == "") || (provider.JWKSURI == "")) {
+        return nil, fmt.Errorf("OIDC discovery document for '%s' is missing required endpoints.", issuer);
+    }
+
+    if (emailClaim == "") {
+        emailClaim = "email";
+    }
+
+    provider.clientID = clientID;
+    provider.clientSecret = clientSecret;
+    provider.redirectURL = redirectURL;
+    provider.emailClaim = emailClaim;
+
+    return &provider, nil;
+}
+
+// Get the URL to send a user to in order to log in at the provider.
+func (this *Provider) AuthorizationURL(state string, nonce string) string {
+    values := url.Values{};
+    values.Set("response_type", "code");
+    values.Set("client_id", this.clientID);
+    values.Set("redirect_uri", this.redirectURL);
+    values.Set("scope", "openid email");
+    values.Set("state", state);
+    values.Set("nonce", nonce);
+
+    separator := "?";
+    if (strings.Contains(this.AuthorizationEndpoint, "?")) {
+        separator = "&";
+    }
+
+    return this.AuthorizationEndpoint + separator + values.Encode();
+}
+
+// Exchange an authorization code for an ID token,
+// verify the ID token, and return the user's email.
+func (this *Provider) Login(code string, nonce string) (string, error) {
+    form := map[string]string{
+        "grant_type": "authorization_code",
+        "code": code,
+        "redirect_uri": this.redirectURL,
+        "client_id": this.clientID,
+        "client_secret": this.clientSecret,
+    };
+
+    body, _, err := common.PostWithHeadersNoCheck(this.TokenEndpoint, form, map[string][]string{"Accept": []string{"application/json"}});
+    if (err != nil) {
+        return "", fmt.Errorf("Failed to exchange OIDC code: '%w'.", err);
+    }
+
+    var response tokenResponse;
+    err = util.JSONFromString(body, &response);
+    if (err != nil) {
+        return "", fmt.Errorf("Failed to parse OIDC token response: '%w'.", err);
+    }
+
+    if (response.Error != "") {
+        return "", fmt.Errorf("OIDC provider rejected the code: '%s' ('%s').", response.Error, response.ErrorDescription);
+    }
+
+    if (response.IDToken == "") {
+        return "", fmt.Errorf("OIDC token response does not have an ID token.");
+    }
+
+    claims, err := this.VerifyIDToken(response.IDToken, nonce);
+    if (err != nil) {
+        return "", err;
+    }
+
+    return claims.GetEmail(this.emailClaim);
+}
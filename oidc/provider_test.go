@@ -0,0 +1,175 @@
+package oidc
+
+import (
+    "net/url"
+    "strings"
+    "testing"
+    "time"
+)
+
+const (
+    TEST_CLIENT_ID = "autograder"
+    TEST_CLIENT_SECRET = "secret"
+    TEST_REDIRECT_URL = "http://localhost/sso"
+)
+
+func TestProviderLogin(test *testing.T) {
+    idp, provider := startTestIdP(test);
+    defer idp.Close();
+
+    authURL := provider.AuthorizationURL("some-state", "some-nonce");
+    if (!strings.HasPrefix(authURL, idp.Issuer() + "/authorize?")) {
+        test.Fatalf("Unexpected authorization URL: '%s'.", authURL);
+    }
+
+    parsedURL, err := url.Parse(authURL);
+    if (err != nil) {
+        test.Fatalf("Failed to parse authorization URL: '%v'.", err);
+    }
+
+    if (parsedURL.Query().Get("redirect_uri") != TEST_REDIRECT_URL) {
+        test.Fatalf("Unexpected redirect URL: '%s'.", parsedURL.Query().Get("redirect_uri"));
+    }
+
+    code, state, err := idp.Authorize(authURL, map[string]any{"email": "Student@test.com", "email_verified": true});
+    if (err != nil) {
+        test.Fatalf("Failed to authorize: '%v'.", err);
+    }
+
+    if (state != "some-state") {
+        test.Fatalf("Unexpected state. Expected: 'some-state', Actual: '%s'.", state);
+    }
+
+    // The nonce must match the one in the authorization URL.
+    _, err = provider.Login(code, "other-nonce");
+    if (err == nil) {
+        test.Fatalf("Logged in with the wrong nonce.");
+    }
+
+    code, _, err = idp.Authorize(authURL, map[string]any{"email": "Student@test.com", "email_verified": true});
+    if (err != nil) {
+        test.Fatalf("Failed to authorize: '%v'.", err);
+    }
+
+    email, err := provider.Login(code, "some-nonce");
+    if (err != nil) {
+        test.Fatalf("Failed to log in: '%v'.", err);
+    }
+
+    if (email != "student@test.com") {
+        test.Fatalf("Unexpected email. Expected: 'student@test.com', Actual: '%s'.", email);
+    }
+
+    // Codes can only be used once.
+    _, err = provider.Login(code, "some-nonce");
+    if (err == nil) {
+        test.Fatalf("Logged in with a used code.");
+    }
+}
+
+func TestVerifyIDToken(test *testing.T) {
+    idp, provider := startTestIdP(test);
+    defer idp.Close();
+
+    now := time.Now();
+
+    baseClaims := func() map[string]any {
+        return map[string]any{
+            "iss": idp.Issuer(),
+            "aud": TEST_CLIENT_ID,
+            "exp": now.Add(time.Minute).Unix(),
+            "nonce": "nonce",
+            "email": "student@test.com",
+        };
+    };
+
+    testCases := []struct{key string; value any; valid bool}{
+        {"", nil, true},
+        {"aud", []any{"other", TEST_CLIENT_ID}, true},
+        {"email_verified", true, true},
+
+        {"iss", "http://evil.test", false},
+        {"aud", "other", false},
+        {"aud", []any{"other"}, false},
+        {"exp", now.Add(-time.Hour).Unix(), false},
+        {"exp", nil, false},
+        {"nonce", "other", false},
+        {"email", nil, false},
+        {"email", "", false},
+        {"email_verified", false, false},
+    };
+
+    for i, testCase := range testCases {
+        claims := baseClaims();
+        if (testCase.key != "") {
+            if (testCase.value == nil) {
+                delete(claims, testCase.key);
+            } else {
+                claims[testCase.key] = testCase.value;
+            }
+        }
+
+        token, err := idp.SignIDToken(claims);
+        if (err != nil) {
+            test.Fatalf("Case %d: Failed to sign token: '%v'.", i, err);
+        }
+
+        verifiedClaims, err := provider.VerifyIDToken(token, "nonce");
+        if (err == nil) {
+            _, err = verifiedClaims.GetEmail("email");
+        }
+
+        if (testCase.valid && (err != nil)) {
+            test.Errorf("Case %d: Valid token failed verification: '%v'.", i, err);
+        } else if (!testCase.valid && (err == nil)) {
+            test.Errorf("Case %d: Invalid token passed verification.", i);
+        }
+    }
+
+    // Unsigned and tampered tokens.
+    token, err := idp.SignIDToken(baseClaims());
+    if (err != nil) {
+        test.Fatalf("Failed to sign token: '%v'.", err);
+    }
+
+    parts := strings.Split(token, ".");
+
+    otherClaims := baseClaims();
+    otherClaims["email"] = "admin@test.com";
+    otherToken, err := idp.SignIDToken(otherClaims);
+    if (err != nil) {
+        test.Fatalf("Failed to sign token: '%v'.", err);
+    }
+
+    otherParts := strings.Split(otherToken, ".");
+
+    badTokens := []string{
+        "",
+        "ZZZ",
+        strings.Join([]string{"eyJhbGciOiJub25lIn0", parts[1], ""}, "."),
+        strings.Join([]string{parts[0], otherParts[1], parts[2]}, "."),
+        token + "Z",
+    };
+
+    for i, badToken := range badTokens {
+        _, err = provider.VerifyIDToken(badToken, "nonce");
+        if (err == nil) {
+            test.Errorf("Case %d: Bad token passed verification.", i);
+        }
+    }
+}
+
+func startTestIdP(test *testing.T) (*MockIdP, *Provider) {
+    idp, err := NewMockIdP(TEST_CLIENT_ID, TEST_CLIENT_SECRET);
+    if (err != nil) {
+        test.Fatalf("Failed to start mock IdP: '%v'.", err);
+    }
+
+    provider, err := Discover(idp.Issuer(), TEST_CLIENT_ID, TEST_CLIENT_SECRET, TEST_REDIRECT_URL, "");
+    if (err != nil) {
+        idp.Close();
+        test.Fatalf("Failed to discover provider: '%v'.", err);
+    }
+
+    return idp, provider;
+}
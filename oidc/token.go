@@ -0,0 +1,202 @@
+package oidc
+
+import (
+    "crypto"
+    "crypto/rsa"
+    "crypto/sha256"
+    "encoding/base64"
+    "encoding/json"
+    "fmt"
+    "math/big"
+    "strings"
+    "time"
+
+    "github.com/edulinq/autograder/common"
+    "github.com/edulinq/autograder/util"
+)
+
+// Allowed clock difference between the autograder and the provider.
+const CLOCK_SKEW_SECS = 60;
+
+// The claims from a verified ID token.
+type Claims map[string]any;
+
+type tokenHeader struct {
+    Alg string `json:"alg"`
+    KeyID string `json:"kid"`
+}
+
+type jsonWebKey struct {
+    KeyType string `json:"kty"`
+    KeyID string `json:"kid"`
+    Use string `json:"use"`
+    N string `json:"n"`
+    E string `json:"e"`
+}
+
+type jsonWebKeySet struct {
+    Keys []*jsonWebKey `json:"keys"`
+}
+
+// Verify an ID token's signature (using the provider's published keys) and its standard claims.
+func (this *Provider) VerifyIDToken(idToken string, nonce string) (Claims, error) {
+    parts := strings.Split(idToken, ".");
+    if (len(parts) != 3) {
+        return nil, fmt.Errorf("Malformed ID token.");
+    }
+
+    var header tokenHeader;
+    err := decodeTokenPart(parts[0], &header);
+    if (err != nil) {
+        return nil, fmt.Errorf("Malformed ID token header: '%w'.", err);
+    }
+
+    // Only asymmetric signatures are accepted (never "none" or a symmetric algorithm).
+    if (header.Alg != "RS256") {
+        return nil, fmt.Errorf("Unsupported ID token algorithm: '%s'.", header.Alg);
+    }
+
+    signature, err := base64.RawURLEncoding.DecodeString(parts[2]);
+    if (err != nil) {
+        return nil, fmt.Errorf("Malformed ID token signature: '%w'.", err);
+    }
+
+    key, err := this.getKey(header.KeyID);
+    if (err != nil) {
+        return nil, err;
+    }
+
+    digest := sha256.Sum256([]byte(parts[0] + "." + parts[1]));
+    err = rsa.VerifyPKCS1v15(key, crypto.SHA256, digest[:], signature);
+    if (err != nil) {
+        return nil, fmt.Errorf("ID token has a bad signature: '%w'.", err);
+    }
+
+    var claims Claims;
+    err = decodeTokenPart(parts[1], &claims);
+    if (err != nil) {
+        return nil, fmt.Errorf("Malformed ID token claims: '%w'.", err);
+    }
+
+    err = claims.validate(this.Issuer, this.clientID, nonce, time.Now());
+    if (err != nil) {
+        return nil, err;
+    }
+
+    return claims, nil;
+}
+
+// Get the user's email from the claims.
+// If the provider says whether the email is verified, then it must be verified.
+func (this Claims) GetEmail(emailClaim string) (string, error) {
+    email, ok := this[emailClaim].(string);
+    if (!ok || (strings.TrimSpace(email) == "")) {
+        return "", fmt.Errorf("ID token does not have an email claim ('%s').", emailClaim);
+    }
+
+    verified, ok := this["email_verified"];
+    if (ok && (verified != true)) {
+        return "", fmt.Errorf("ID token email ('%s') is not verified.", email);
+    }
+
+    return strings.ToLower(strings.TrimSpace(email)), nil;
+}
+
+func (this Claims) validate(issuer string, clientID string, nonce string, now time.Time) error {
+    if (this["iss"] != issuer) {
+        return fmt.Errorf("ID token has the wrong issuer. Expected: '%s', Actual: '%v'.", issuer, this["iss"]);
+    }
+
+    if (!this.hasAudience(clientID)) {
+        return fmt.Errorf("ID token is not for this client ('%s'): '%v'.", clientID, this["aud"]);
+    }
+
+    expiration, ok := this["exp"].(float64);
+    if (!ok) {
+        return fmt.Errorf("ID token does not have an expiration.");
+    }
+
+    if (now.Unix() > (int64(expiration) + CLOCK_SKEW_SECS)) {
+        return fmt.Errorf("ID token is expired.");
+    }
+
+    if (this["nonce"] != nonce) {
+        return fmt.Errorf("ID token has the wrong nonce.");
+    }
+
+    return nil;
+}
+
+func (this Claims) hasAudience(clientID string) bool {
+    switch audience := this["aud"].(type) {
+        case string:
+            return (audience == clientID);
+        case []any:
+            for _, value := range audience {
+                if (value == clientID) {
+                    return true;
+                }
+            }
+    }
+
+    return false;
+}
+
+func (this *Provider) getKey(keyID string) (*rsa.PublicKey, error) {
+    body, err := common.Get(this.JWKSURI);
+    if (err != nil) {
+        return nil, fmt.Errorf("Failed to fetch OIDC provider keys: '%w'.", err);
+    }
+
+    var keySet jsonWebKeySet;
+    err = util.JSONFromString(body, &keySet);
+    if (err != nil) {
+        return nil, fmt.Errorf("Failed to parse OIDC provider keys: '%w'.", err);
+    }
+
+    for _, key := range keySet.Keys {
+        if ((key.KeyType != "RSA") || ((key.Use != "") && (key.Use != "sig"))) {
+            continue;
+        }
+
+        // Tokens without a key ID can only be matched if there is a single key.
+        if ((key.KeyID != keyID) && !((keyID == "") && (len(keySet.Keys) == 1))) {
+            continue;
+        }
+
+        return key.toPublicKey();
+    }
+
+    return nil, fmt.Errorf("Could not find OIDC provider key: '%s'.", keyID);
+}
+
+func (this *jsonWebKey) toPublicKey() (*rsa.PublicKey, error) {
+    n, err := base64.RawURLEncoding.DecodeString(this.N);
+    if (err != nil) {
+        return nil, fmt.Errorf("Bad OIDC key modulus: '%w'.", err);
+    }
+
+    e, err := base64.RawURLEncoding.DecodeString(this.E);
+    if (err != nil) {
+        return nil, fmt.Errorf("Bad OIDC key exponent: '%w'.", err);
+    }
+
+    exponent := new(big.Int).SetBytes(e);
+    if (!exponent.IsInt64() || (exponent.Int64() > (1 << 31))) {
+        return nil, fmt.Errorf("OIDC key exponent is too large.");
+    }
+
+    return &rsa.PublicKey{
+        N: new(big.Int).SetBytes(n),
+        E: int(exponent.Int64()),
+    }, nil;
+}
+
+func decodeTokenPart(part string, target any) error {
+    data, err := base64.RawURLEncoding.DecodeString(part);
+    if (err != nil) {
+        return err;
+    }
+
+    return json.Unmarshal(data, target);
+}
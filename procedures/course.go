@@ -3,13 +3,17 @@ package procedures
 
 import (
     "errors"
+    "fmt"
 
+    "github.com/edulinq/autograder/common"
+    "github.com/edulinq/autograder/config"
     "github.com/edulinq/autograder/db"
     "github.com/edulinq/autograder/docker"
     "github.com/edulinq/autograder/lms/lmssync"
     "github.com/edulinq/autograder/log"
     "github.com/edulinq/autograder/model"
     "github.com/edulinq/autograder/task"
+    "github.com/edulinq/autograder/util"
 )
 
 // Update a live course.
@@ -31,8 +35,109 @@ func UpdateCourse(course *model.Course, startTasks bool) (bool, error) {
         course = newCourse;
     }
 
+    err = startCourse(course, startTasks);
+    if (err != nil) {
+        errs = errors.Join(errs, err);
+    }
+
+    return updated, errs;
+}
+
+// Add new courses from a source.
+// Courses that already exist will not be added (an error will be returned instead).
+// Any courses that were added will be returned (even on error).
+func AddCourses(source *common.FileSpec, startTasks bool) ([]*model.Course, error) {
+    tempDir, err := util.MkDirTemp("autograder-add-course-source-");
+    if (err != nil) {
+        return nil, fmt.Errorf("Failed to make temp source dir: '%w'.", err);
+    }
+    defer util.RemoveDirent(tempDir);
+
+    err = source.CopyTarget(common.ShouldGetCWD(), tempDir, false);
+    if (err != nil) {
+        return nil, fmt.Errorf("Failed to copy course source: '%w'.", err);
+    }
+
+    configPaths, err := util.FindFiles(model.COURSE_CONFIG_FILENAME, tempDir);
+    if (err != nil) {
+        return nil, fmt.Errorf("Failed to search for course configs in source: '%w'.", err);
+    }
+
+    if (len(configPaths) == 0) {
+        return nil, fmt.Errorf("Did not find any course configs in course source ('%s').", source);
+    }
+
+    // Check all the courses before adding any.
+    for _, configPath := range configPaths {
+        courseConfig, err := model.ReadCourseConfig(configPath);
+        if (err != nil) {
+            return nil, err;
+        }
+
+        existingCourse, err := db.GetCourse(courseConfig.GetID());
+        if (err != nil) {
+            return nil, fmt.Errorf("Failed to check for existing course '%s': '%w'.", courseConfig.GetID(), err);
+        }
+
+        if (existingCourse != nil) {
+            return nil, fmt.Errorf("Course already exists: '%s'.", courseConfig.GetID());
+        }
+    }
+
+    courseIDs, err := db.AddCoursesFromDir(tempDir, source);
+    if (err != nil) {
+        return nil, err;
+    }
+
+    var errs error;
+    courses := make([]*model.Course, 0, len(courseIDs));
+
+    for _, courseID := range courseIDs {
+        course, err := db.GetCourse(courseID);
+        if (err != nil) {
+            errs = errors.Join(errs, err);
+            continue;
+        }
+
+        courses = append(courses, course);
+
+        err = startCourse(course, startTasks);
+        if (err != nil) {
+            errs = errors.Join(errs, err);
+        }
+    }
+
+    return courses, errs;
+}
+
+// Remove a course and all its data (enrolled users keep their accounts).
+func RemoveCourse(course *model.Course) error {
+    task.StopCourse(course.GetID());
+
+    err := db.ClearCourse(course);
+    if (err != nil) {
+        return fmt.Errorf("Failed to clear course '%s': '%w'.", course.GetID(), err);
+    }
+
+    // The course's images are now orphaned (and will not be pruned by the course's own task).
+    if (!config.DOCKER_DISABLE.Get()) {
+        removed, err := task.PruneImages(course.GetID(), false);
+        if (err != nil) {
+            log.Warn("Failed to prune images of removed course.", err, course);
+        } else {
+            log.Info("Pruned images of removed course.", course, log.NewAttr("count", len(removed)));
+        }
+    }
+
+    return nil;
+}
+
+// Sync, build, and (optionally) schedule tasks for a course that was just added or updated.
+func startCourse(course *model.Course, startTasks bool) error {
+    var errs error;
+
     // Sync the course.
-    _, err = lmssync.SyncLMS(course, false, true);
+    _, err := lmssync.SyncLMS(course, false, true);
     if (err != nil) {
         log.Error("Failed to sync course with LMS.", err, course);
         errs = errors.Join(errs, err);
@@ -56,5 +161,5 @@ func UpdateCourse(course *model.Course, startTasks bool) (bool, error) {
         }
     }
 
-    return updated, errs;
+    return errs;
 }
@@ -54,6 +54,8 @@ func Schedule(course *model.Course, target tasks.ScheduledTask) error {
             runFunc = RunCourseUpdateTask;
         case *tasks.EmailLogsTask:
             runFunc = RunEmailLogsTask;
+        case *tasks.ImagePruneTask:
+            runFunc = RunImagePruneTask;
         case *tasks.ReportTask:
             runFunc = RunReportTask;
         case *tasks.ScoringUploadTask:
@@ -0,0 +1,196 @@
+package task
+
+// Inventory and pruning of the docker images built for assignments.
+// Images are shared by the whole server, so pruning only ever removes images that no known assignment uses.
+// A course's prune task only removes the orphaned images that belong to (are named for) that course,
+// or to a course that no longer exists (e.g. a removed course or an old semester).
+
+import (
+    "fmt"
+    "sort"
+    "strings"
+
+    "github.com/edulinq/autograder/config"
+    "github.com/edulinq/autograder/db"
+    "github.com/edulinq/autograder/docker"
+    "github.com/edulinq/autograder/log"
+    "github.com/edulinq/autograder/model"
+    "github.com/edulinq/autograder/model/tasks"
+)
+
+type ImageInventoryEntry struct {
+    *docker.ImageSummary
+
+    // The owning course/assignment.
+    // For orphaned images, these are a best guess (based on the image name).
+    CourseID string `json:"course-id"`
+    AssignmentID string `json:"assignment-id"`
+
+    // The image does not belong to any known assignment.
+    Orphaned bool `json:"orphaned"`
+
+    // The image is orphaned and its (guessed) course does not exist.
+    UnknownCourse bool `json:"unknown-course"`
+}
+
+func RunImagePruneTask(course *model.Course, rawTask tasks.ScheduledTask) (bool, error) {
+    task, ok := rawTask.(*tasks.ImagePruneTask);
+    if (!ok) {
+        return false, fmt.Errorf("Task is not an ImagePruneTask: %t (%v).", rawTask, rawTask);
+    }
+
+    if (task.Disable) {
+        return true, nil;
+    }
+
+    removed, err := PruneImages(course.GetID(), task.DryRun);
+    if (err != nil) {
+        return true, err;
+    }
+
+    log.Info("Pruned images.", course, log.NewAttr("count", len(removed)), log.NewAttr("dry-run", task.DryRun));
+
+    return true, nil;
+}
+
+// Get all the autograder images on all known hosts (sorted by name).
+func GetImageInventory() ([]*ImageInventoryEntry, error) {
+    if (config.DOCKER_DISABLE.Get()) {
+        return nil, fmt.Errorf("Docker is disabled.");
+    }
+
+    courses, err := db.GetCourses();
+    if (err != nil) {
+        return nil, fmt.Errorf("Failed to get courses: '%w'.", err);
+    }
+
+    images := make([]*docker.ImageSummary, 0);
+    for _, host := range getImageHosts(courses) {
+        hostImages, err := docker.ListImages(host);
+        if (err != nil) {
+            return nil, fmt.Errorf("Failed to list images on host '%s': '%w'.", host, err);
+        }
+
+        images = append(images, hostImages...);
+    }
+
+    return buildImageInventory(images, courses), nil;
+}
+
+// Remove all orphaned images (images that do not belong to any known assignment).
+// If a course ID is given, then only orphaned images that belong to that course (or to an unknown course) are removed.
+// Returns the orphaned images (which were not removed on a dry run).
+// Failures to remove an image are logged and skipped.
+func PruneImages(courseID string, dryRun bool) ([]*ImageInventoryEntry, error) {
+    inventory, err := GetImageInventory();
+    if (err != nil) {
+        return nil, err;
+    }
+
+    removed := make([]*ImageInventoryEntry, 0);
+    for _, entry := range getPrunableImages(inventory, courseID) {
+        if (!dryRun) {
+            err = docker.RemoveImage(entry.Host, entry.Name);
+            if (err != nil) {
+                log.Warn("Failed to prune image.", err, log.NewAttr("image", entry.Name), log.NewAttr("host", entry.Host));
+                continue;
+            }
+
+            log.Debug("Pruned image.", log.NewAttr("image", entry.Name), log.NewAttr("host", entry.Host));
+        }
+
+        removed = append(removed, entry);
+    }
+
+    return removed, nil;
+}
+
+// Get the orphaned images (optionally, only for a single course and unknown courses).
+func getPrunableImages(inventory []*ImageInventoryEntry, courseID string) []*ImageInventoryEntry {
+    prunable := make([]*ImageInventoryEntry, 0);
+    for _, entry := range inventory {
+        if (!entry.Orphaned) {
+            continue;
+        }
+
+        if ((courseID != "") && (entry.CourseID != courseID) && !entry.UnknownCourse) {
+            continue;
+        }
+
+        prunable = append(prunable, entry);
+    }
+
+    return prunable;
+}
+
+func buildImageInventory(images []*docker.ImageSummary, courses map[string]*model.Course) []*ImageInventoryEntry {
+    // {imageName: assignment}
+    owners := make(map[string]*model.Assignment);
+    for _, course := range courses {
+        for _, assignment := range course.GetAssignments() {
+            owners[assignment.ImageName()] = assignment;
+        }
+    }
+
+    inventory := make([]*ImageInventoryEntry, 0, len(images));
+    for _, image := range images {
+        entry := &ImageInventoryEntry{ImageSummary: image};
+
+        assignment, ok := owners[image.Name];
+        if (ok && (assignment.GetImageInfo().Host == image.Host)) {
+            entry.CourseID = assignment.GetCourse().GetID();
+            entry.AssignmentID = assignment.GetID();
+        } else {
+            entry.Orphaned = true;
+            entry.CourseID, entry.AssignmentID = guessImageOwner(image.Name, courses);
+
+            _, ok = courses[entry.CourseID];
+            entry.UnknownCourse = !ok;
+        }
+
+        inventory = append(inventory, entry);
+    }
+
+    sort.Slice(inventory, func(i int, j int) bool {
+        if (inventory[i].Name != inventory[j].Name) {
+            return inventory[i].Name < inventory[j].Name;
+        }
+
+        return inventory[i].Host < inventory[j].Host;
+    });
+
+    return inventory;
+}
+
+// IDs may contain periods, so an image name cannot always be split into a course and assignment.
+// Prefer a known course, and otherwise split on the first period.
+func guessImageOwner(imageName string, courses map[string]*model.Course) (string, string) {
+    text := strings.TrimPrefix(imageName, docker.IMAGE_NAME_PREFIX);
+
+    for courseID, _ := range courses {
+        if (strings.HasPrefix(text, courseID + ".")) {
+            return courseID, strings.TrimPrefix(text, courseID + ".");
+        }
+    }
+
+    courseID, assignmentID, _ := strings.Cut(text, ".");
+    return courseID, assignmentID;
+}
+
+// Get all the container hosts used by known assignments (the default host is always included).
+func getImageHosts(courses map[string]*model.Course) []string {
+    hosts := map[string]bool{"": true};
+    for _, course := range courses {
+        for _, assignment := range course.GetAssignments() {
+            hosts[assignment.GetImageInfo().Host] = true;
+        }
+    }
+
+    result := make([]string, 0, len(hosts));
+    for host, _ := range hosts {
+        result = append(result, host);
+    }
+
+    sort.Strings(result);
+    return result;
+}
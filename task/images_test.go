@@ -0,0 +1,54 @@
+package task
+
+import (
+    "reflect"
+    "testing"
+
+    "github.com/edulinq/autograder/db"
+    "github.com/edulinq/autograder/docker"
+    "github.com/edulinq/autograder/util"
+)
+
+func TestBuildImageInventory(test *testing.T) {
+    db.ResetForTesting();
+
+    courses := db.MustGetCourses();
+    assignment := db.MustGetTestAssignment();
+
+    images := []*docker.ImageSummary{
+        &docker.ImageSummary{Name: "autograder.zzz.hw0"},
+        &docker.ImageSummary{Name: assignment.ImageName()},
+        &docker.ImageSummary{Name: "autograder.course101.removed.hw"},
+        &docker.ImageSummary{Name: assignment.ImageName(), Host: "unix:///other.sock"},
+    };
+
+    expected := []*ImageInventoryEntry{
+        &ImageInventoryEntry{ImageSummary: images[1], CourseID: "course101", AssignmentID: "hw0", Orphaned: false},
+        &ImageInventoryEntry{ImageSummary: images[3], CourseID: "course101", AssignmentID: "hw0", Orphaned: true},
+        &ImageInventoryEntry{ImageSummary: images[2], CourseID: "course101", AssignmentID: "removed.hw", Orphaned: true},
+        &ImageInventoryEntry{ImageSummary: images[0], CourseID: "zzz", AssignmentID: "hw0", Orphaned: true, UnknownCourse: true},
+    };
+
+    actual := buildImageInventory(images, courses);
+
+    if (!reflect.DeepEqual(expected, actual)) {
+        test.Fatalf("Unexpected inventory. Expected: '%s', Actual: '%s'.",
+            util.MustToJSONIndent(expected), util.MustToJSONIndent(actual));
+    }
+
+    // Only orphaned images are pruned, and course prunes only touch that course's images (and images of unknown courses).
+    testCases := []struct{courseID string; expected []*ImageInventoryEntry}{
+        {"", expected[1:]},
+        {"course101", expected[1:]},
+        {"zzz", expected[3:]},
+        {"course-languages", expected[3:]},
+    };
+
+    for i, testCase := range testCases {
+        prunable := getPrunableImages(actual, testCase.courseID);
+        if (!reflect.DeepEqual(testCase.expected, prunable)) {
+            test.Errorf("Case %d: Unexpected prunable images. Expected: '%s', Actual: '%s'.", i,
+                util.MustToJSONIndent(testCase.expected), util.MustToJSONIndent(prunable));
+        }
+    }
+}
@@ -0,0 +1,78 @@
+package util
+
+import (
+    "strings"
+)
+
+// Above this many (expected * actual) lines, a full diff is too expensive
+// and all the lines are just shown as removed/added.
+const MAX_DIFF_CELLS = 1000 * 1000;
+
+// Compute a line-based diff between two texts.
+// Each line of the result starts with "  " (unchanged), "- " (only in expected), or "+ " (only in actual).
+// Returns an empty string if the texts are the same.
+func LineDiff(expected string, actual string) string {
+    if (expected == actual) {
+        return "";
+    }
+
+    expectedLines := splitDiffLines(expected);
+    actualLines := splitDiffLines(actual);
+
+    var builder strings.Builder;
+
+    if ((len(expectedLines) * len(actualLines)) > MAX_DIFF_CELLS) {
+        for _, line := range expectedLines {
+            builder.WriteString("- " + line + "\n");
+        }
+
+        for _, line := range actualLines {
+            builder.WriteString("+ " + line + "\n");
+        }
+
+        return strings.TrimSuffix(builder.String(), "\n");
+    }
+
+    // lengths[i][j] is the length of the longest common subsequence of expectedLines[i:] and actualLines[j:].
+    lengths := make([][]int, len(expectedLines) + 1);
+    for i := range lengths {
+        lengths[i] = make([]int, len(actualLines) + 1);
+    }
+
+    for i := len(expectedLines) - 1; i >= 0; i-- {
+        for j := len(actualLines) - 1; j >= 0; j-- {
+            if (expectedLines[i] == actualLines[j]) {
+                lengths[i][j] = lengths[i + 1][j + 1] + 1;
+            } else {
+                lengths[i][j] = max(lengths[i + 1][j], lengths[i][j + 1]);
+            }
+        }
+    }
+
+    i := 0;
+    j := 0;
+
+    for ((i < len(expectedLines)) || (j < len(actualLines))) {
+        if ((i < len(expectedLines)) && (j < len(actualLines)) && (expectedLines[i] == actualLines[j])) {
+            builder.WriteString("  " + expectedLines[i] + "\n");
+            i++;
+            j++;
+        } else if ((i < len(expectedLines)) && ((j == len(actualLines)) || (lengths[i + 1][j] >= lengths[i][j + 1]))) {
+            builder.WriteString("- " + expectedLines[i] + "\n");
+            i++;
+        } else {
+            builder.WriteString("+ " + actualLines[j] + "\n");
+            j++;
+        }
+    }
+
+    return strings.TrimSuffix(builder.String(), "\n");
+}
+
+func splitDiffLines(text string) []string {
+    if (text == "") {
+        return []string{};
+    }
+
+    return strings.Split(strings.TrimSuffix(text, "\n"), "\n");
+}
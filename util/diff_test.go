@@ -0,0 +1,42 @@
+package util
+
+import (
+    "strings"
+    "testing"
+)
+
+func TestLineDiff(test *testing.T) {
+    testCases := []struct{expected string; actual string; diff string}{
+        {"", "", ""},
+        {"a\nb\n", "a\nb\n", ""},
+        {"a", "b", "- a\n+ b"},
+        {"", "a\nb", "+ a\n+ b"},
+        {"a\nb", "", "- a\n- b"},
+        {"a\nb\nc\n", "a\nc\n", "  a\n- b\n  c"},
+        {"a\nc", "a\nb\nc", "  a\n+ b\n  c"},
+        {"1\n2\n3\n4", "1\n5\n3\n4\n6", "  1\n- 2\n+ 5\n  3\n  4\n+ 6"},
+    };
+
+    for i, testCase := range testCases {
+        diff := LineDiff(testCase.expected, testCase.actual);
+        if (testCase.diff != diff) {
+            test.Errorf("Case %d: Unexpected diff. Expected: '%s', Actual: '%s'.", i, testCase.diff, diff);
+        }
+    }
+}
+
+func TestLineDiffLarge(test *testing.T) {
+    expected := strings.Repeat("a\n", 2000);
+    actual := strings.Repeat("b\n", 2000);
+
+    diff := LineDiff(expected, actual);
+    lines := strings.Split(diff, "\n");
+
+    if (len(lines) != 4000) {
+        test.Fatalf("Unexpected number of diff lines. Expected: %d, Actual: %d.", 4000, len(lines));
+    }
+
+    if ((lines[0] != "- a") || (lines[len(lines) - 1] != "+ b")) {
+        test.Fatalf("Unexpected diff lines: '%s' ... '%s'.", lines[0], lines[len(lines) - 1]);
+    }
+}
@@ -0,0 +1,17 @@
+package util
+
+import (
+    "fmt"
+    "os"
+)
+
+// Get the number of hard links to a file.
+// Returns -1 if link counts are not supported on this platform.
+func GetLinkCount(path string) (int, error) {
+    info, err := os.Stat(path);
+    if (err != nil) {
+        return -1, fmt.Errorf("Failed to stat file '%s': '%w'.", path, err);
+    }
+
+    return getLinkCount(info), nil;
+}
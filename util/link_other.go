@@ -0,0 +1,11 @@
+//go:build !unix
+
+package util
+
+import (
+    "os"
+)
+
+func getLinkCount(info os.FileInfo) int {
+    return -1;
+}
@@ -0,0 +1,17 @@
+//go:build unix
+
+package util
+
+import (
+    "os"
+    "syscall"
+)
+
+func getLinkCount(info os.FileInfo) int {
+    stat, ok := info.Sys().(*syscall.Stat_t);
+    if (!ok) {
+        return -1;
+    }
+
+    return int(stat.Nlink);
+}
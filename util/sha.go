@@ -3,6 +3,9 @@ package util
 import (
     "crypto/sha256"
     "encoding/hex"
+    "fmt"
+    "os"
+    "sort"
 )
 
 func Sha256Hex(data []byte) string {
@@ -23,3 +26,37 @@ func Sha256HashFromJSONObject(object any) (string, error) {
 
     return Sha256HexFromString(json), nil;
 }
+
+// Hash all the files in a directory (their relative paths and contents).
+// Two directories with the same files (regardless of timestamps/permissions) will have the same hash.
+func Sha256HexFromDirectory(dir string) (string, error) {
+    paths, err := FindFiles("", dir);
+    if (err != nil) {
+        return "", fmt.Errorf("Unable to find files in dir '%s': '%w'.", dir, err);
+    }
+
+    relPaths := make(map[string]string, len(paths));
+    for _, path := range paths {
+        relPaths[RelPath(path, dir)] = path;
+    }
+
+    keys := make([]string, 0, len(relPaths));
+    for relPath := range relPaths {
+        keys = append(keys, relPath);
+    }
+    sort.Strings(keys);
+
+    hash := sha256.New();
+    for _, relPath := range keys {
+        data, err := os.ReadFile(relPaths[relPath]);
+        if (err != nil) {
+            return "", fmt.Errorf("Failed to read file '%s' for hashing: '%w'.", relPaths[relPath], err);
+        }
+
+        // Include the lengths so that different splits of paths and contents cannot collide.
+        fmt.Fprintf(hash, "%d:%s:%d:", len(relPath), relPath, len(data));
+        hash.Write(data);
+    }
+
+    return hex.EncodeToString(hash.Sum(nil)), nil;
+}